@@ -0,0 +1,205 @@
+// Command packcli packs a file of items and boxes without the HTTP service.
+//
+//	packcli [-out result.json] [-html result.html] order.json
+//
+// The input is a pack request body, or a CSV file of items and boxes; see
+// packing.ReadCSV. The result JSON goes to -out, or stdout, and -html
+// writes a standalone visualization that opens offline.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"binpacker/pkg/packing"
+	"binpacker/pkg/visualize"
+)
+
+// input is the subset of a pack request the CLI understands.
+type input struct {
+	Items []packing.InputItem `json:"items"`
+	Boxes []packing.InputBox  `json:"boxes"`
+	packing.Options
+}
+
+// result mirrors the packing fields of the API response.
+type result struct {
+	PackedBoxes   []packing.PackedBox      `json:"packed_boxes"`
+	UnpackedItems []packing.InputItem      `json:"unpacked_items"`
+	TotalVolume   int                      `json:"total_volume"`
+	Utilization   float64                  `json:"utilization_percent"`
+	Splits        []packing.MultipackSplit `json:"splits,omitempty"`
+	Warnings      []packing.Warning        `json:"warnings,omitempty"`
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("packcli: ")
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("packcli", flag.ContinueOnError)
+	out := fs.String("out", "", "write the result JSON to `file` instead of stdout")
+	html := fs.String("html", "", "write a standalone visualization to `file`")
+	format := fs.String("format", "", "input format, json or csv; by default from the file extension")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: packcli [flags] input.json|input.csv|-")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected one input file")
+	}
+
+	name := fs.Arg(0)
+	r := stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(name)), ".")
+	}
+
+	var in input
+	switch *format {
+	case "json":
+		if err := json.NewDecoder(r).Decode(&in); err != nil {
+			return fmt.Errorf("parse %s: %w", name, err)
+		}
+	case "csv":
+		var err error
+		if in.Items, in.Boxes, err = packing.ReadCSV(r); err != nil {
+			return fmt.Errorf("parse %s: %w", name, err)
+		}
+	default:
+		return fmt.Errorf("unknown input format %q: use -format json or csv", *format)
+	}
+	if err := validate(in); err != nil {
+		return err
+	}
+
+	res := pack(in)
+
+	if *html != "" {
+		page, err := visualize.HTML(visualize.Data{
+			PackedBoxes: res.PackedBoxes,
+			Boxes:       in.Boxes,
+			RequestID:   filepath.Base(name),
+			Standalone:  true,
+		})
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*html, []byte(page), 0o644); err != nil {
+			return err
+		}
+	}
+
+	w := stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+// validate applies the checks of the API that do not depend on server
+// limits.
+func validate(in input) error {
+	if len(in.Items) == 0 || len(in.Boxes) == 0 {
+		return errors.New("items and boxes are required")
+	}
+	var errs []error
+	for i, it := range in.Items {
+		if it.ID == "" {
+			errs = append(errs, fmt.Errorf("items[%d]: id must not be empty", i))
+		}
+		if it.Size != nil || min(it.W, it.H, it.D) < 1 {
+			errs = append(errs, fmt.Errorf("items[%d]: sides must be whole numbers of at least 1", i))
+		}
+		if it.Quantity < 1 {
+			errs = append(errs, fmt.Errorf("items[%d]: quantity must be at least 1", i))
+		}
+		if it.Compressibility < 0 || it.Compressibility >= 100 {
+			errs = append(errs, fmt.Errorf("items[%d]: compressibility must be from 0 up to 100", i))
+		}
+	}
+	for i, b := range in.Boxes {
+		if b.ID == "" {
+			errs = append(errs, fmt.Errorf("boxes[%d]: id must not be empty", i))
+		}
+		if b.Size != nil || min(b.W, b.H, b.D) < 1 {
+			errs = append(errs, fmt.Errorf("boxes[%d]: sides must be whole numbers of at least 1", i))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	for _, check := range []func() error{
+		in.Options.Validate,
+		func() error { return packing.ValidateWeights(in.Items, in.Boxes) },
+		func() error { return packing.ValidateOrientations(in.Items) },
+		func() error { return packing.ValidateMultipacks(in.Items) },
+		func() error { return packing.CheckAisle(in.Aisle, in.Boxes) },
+	} {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pack packs a validated input and summarizes it like the API does.
+func pack(in input) result {
+	items, merged := packing.MergeDuplicates(in.Items)
+	items, splits := packing.SplitMultipacks(items, in.Boxes)
+	packed, unpacked := packing.PackWithOptions(items, in.Boxes, in.Options)
+	packing.Measure(packed, in.Boxes)
+
+	res := result{PackedBoxes: packed, UnpackedItems: unpacked, Splits: splits}
+	var used int
+	for _, pb := range packed {
+		res.TotalVolume += pb.W * pb.H * pb.D
+		used += pb.UsedVolume
+	}
+	if res.TotalVolume > 0 {
+		res.Utilization = float64(used) / float64(res.TotalVolume) * 100
+	}
+
+	res.Warnings = append(packing.InputWarnings(in.Items, in.Boxes), merged...)
+	res.Warnings = append(res.Warnings, packing.StockWarnings(packed, unpacked, in.Boxes)...)
+	res.Warnings = append(res.Warnings, packing.MaxBoxesWarnings(packed, unpacked, in.Boxes, in.Options)...)
+	res.Warnings = append(res.Warnings, packing.CubeOutWarnings(packed, unpacked, in.Boxes)...)
+	if in.KeepGroupsTogether {
+		res.Warnings = append(res.Warnings, packing.GroupSplitWarnings(packed, items)...)
+	}
+	if err := packing.CheckLayout(packed, in.Boxes); err != nil {
+		res.Warnings = append(res.Warnings, packing.Warning{Code: packing.WarnLayoutInconsistent, Message: err.Error()})
+	}
+	return res
+}
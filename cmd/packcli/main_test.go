@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPackJSONFile(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "order.json")
+	body := `{"items": [{"id": "cube", "w": 5, "h": 5, "d": 5, "quantity": 9}],
+		"boxes": [{"id": "box", "w": 10, "h": 10, "d": 10}]}`
+	if err := os.WriteFile(in, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	html := filepath.Join(dir, "order.html")
+
+	var out bytes.Buffer
+	if err := run([]string{"-html", html, in}, nil, &out); err != nil {
+		t.Fatal(err)
+	}
+	var res result
+	if err := json.Unmarshal(out.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.PackedBoxes) != 2 || len(res.UnpackedItems) != 0 {
+		t.Errorf("Expected 9 cubes in 2 boxes, got %d boxes and %d unpacked", len(res.PackedBoxes), len(res.UnpackedItems))
+	}
+	if res.TotalVolume != 2000 || res.PackedBoxes[0].Utilization != 100 {
+		t.Errorf("Expected a full first box of 2000 total, got %d and %v%%", res.TotalVolume, res.PackedBoxes[0].Utilization)
+	}
+
+	page, err := os.ReadFile(html)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(page), "order.json") || strings.Contains(string(page), "<script src=") {
+		t.Error("Expected a standalone visualization named after the input")
+	}
+}
+
+func TestPackCSVFromStdin(t *testing.T) {
+	csv := "type,id,w,h,d,quantity,weight,max_weight\n" +
+		"item,mug,8,10,8,4,0.5,\n" +
+		"box,small,20,20,20,,,1\n"
+	var out bytes.Buffer
+	if err := run([]string{"-format", "csv", "-"}, strings.NewReader(csv), &out); err != nil {
+		t.Fatal(err)
+	}
+	var res result
+	if err := json.Unmarshal(out.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	// The weight limit allows two mugs per box.
+	if len(res.PackedBoxes) != 2 || len(res.PackedBoxes[0].Contents) != 2 {
+		t.Errorf("Expected 2 boxes of 2 mugs, got %+v", res.PackedBoxes)
+	}
+}
+
+func TestRunRejectsInvalidInput(t *testing.T) {
+	body := `{"items": [{"id": "a", "w": 0, "h": 1, "d": 1, "quantity": 1}], "boxes": [{"id": "b", "w": 1, "h": 1, "d": 1}]}`
+	err := run([]string{"-format", "json", "-"}, strings.NewReader(body), new(bytes.Buffer))
+	if err == nil || !strings.Contains(err.Error(), "items[0]") {
+		t.Errorf("Expected an error for items[0], got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// AdminMiddleware guards /admin endpoints with the ADMIN_TOKEN bearer token.
+// Without a configured token the admin API is disabled entirely.
+func AdminMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := os.Getenv("ADMIN_TOKEN")
+		if token == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		got, ok := bearerToken(r)
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			http.Error(w, "Unauthorized: invalid admin token", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// Admin is the HTTP handler for operator endpoints under /admin/.
+func Admin(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/admin/mode":
+		handleAdminMode(w, r)
+	case strings.HasPrefix(r.URL.Path, "/admin/data/"):
+		handleAdminData(w, r)
+	case r.URL.Path == "/admin/backup":
+		handleAdminBackup(w, r)
+	case r.URL.Path == "/admin/restore":
+		handleAdminRestore(w, r)
+	case r.URL.Path == "/admin/reload":
+		handleAdminReload(w, r)
+	case strings.HasPrefix(r.URL.Path, "/admin/grafana/"):
+		handleAdminGrafana(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func handleAdminMode(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var m ServiceMode
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if err := setServiceMode(m); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(getServiceMode())
+}
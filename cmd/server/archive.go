@@ -0,0 +1,368 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	archiveQueueSize     = 256
+	archivePutTimeout    = 30 * time.Second
+	defaultArchiveStrip  = "email,phone,name,address,customer"
+	archiveRedactedValue = "[redacted]"
+)
+
+var (
+	archivedPayloads = newCounter("archived_payloads_total", "Request/response pairs written to the archive.")
+	archiveErrors    = newCounter("archive_errors_total", "Archive uploads that failed.")
+	archiveDropped   = newCounter("archive_dropped_total", "Archive records dropped because the upload queue was full.")
+)
+
+// archive is the process-wide payload archiver; nil when archiving is off.
+var archive *Archiver
+
+// ArchiveRecord is one archived /pack call, stored as gzipped JSON.
+type ArchiveRecord struct {
+	ArchivedAt time.Time    `json:"archived_at"`
+	Principal  string       `json:"principal,omitempty"`
+	Request    PackRequest  `json:"request"`
+	Response   PackResponse `json:"response"`
+}
+
+// objectSink stores archive objects under a key.
+type objectSink interface {
+	Put(ctx context.Context, key string, body []byte) error
+}
+
+// Archiver samples /pack calls and uploads them in the background so a slow
+// bucket never delays a response. Records are dropped when the queue is full.
+type Archiver struct {
+	sink       objectSink
+	prefix     string
+	sampleRate float64
+	stripMeta  []string
+	queue      chan ArchiveRecord
+}
+
+func newArchiver(sink objectSink, prefix string, sampleRate float64, stripMeta []string) *Archiver {
+	return &Archiver{
+		sink:       sink,
+		prefix:     strings.Trim(prefix, "/"),
+		sampleRate: sampleRate,
+		stripMeta:  stripMeta,
+		queue:      make(chan ArchiveRecord, archiveQueueSize),
+	}
+}
+
+// archiverFromEnv configures archiving from ARCHIVE_URL (s3://bucket/prefix,
+// gs://bucket/prefix or file:///dir). It returns nil when ARCHIVE_URL is unset.
+func archiverFromEnv() (*Archiver, error) {
+	raw := os.Getenv("ARCHIVE_URL")
+	if raw == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ARCHIVE_URL: %w", err)
+	}
+
+	rate := 1.0
+	if v := os.Getenv("ARCHIVE_SAMPLE_RATE"); v != "" {
+		if rate, err = strconv.ParseFloat(v, 64); err != nil || rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("invalid ARCHIVE_SAMPLE_RATE %q: must be between 0 and 1", v)
+		}
+	}
+	strip := defaultArchiveStrip
+	if v, ok := os.LookupEnv("ARCHIVE_STRIP_META"); ok {
+		strip = v
+	}
+
+	var sink objectSink
+	prefix := u.Path
+	switch u.Scheme {
+	case "file":
+		sink, prefix = dirSink(u.Path), ""
+	case "s3", "gs":
+		if sink, err = s3SinkFromEnv(u.Scheme, u.Host, "ARCHIVE"); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported ARCHIVE_URL scheme %q", u.Scheme)
+	}
+	return newArchiver(sink, prefix, rate, splitList(strings.ToLower(strip))), nil
+}
+
+// Archive queues a request/response pair if it is sampled. Meta fields that
+// may hold personal data and the inline visualization are removed first.
+func (a *Archiver) Archive(ctx context.Context, req PackRequest, resp PackResponse) {
+	if a == nil || rand.Float64() >= a.sampleRate {
+		return
+	}
+
+	req.Meta = a.redact(req.Meta)
+	resp.VisualizationHTML = ""
+	resp.VisualizationDataURI = ""
+
+	rec := ArchiveRecord{
+		ArchivedAt: time.Now().UTC(),
+		Principal:  principalFrom(ctx),
+		Request:    req,
+		Response:   resp,
+	}
+	select {
+	case a.queue <- rec:
+	default:
+		archiveDropped.Add(1)
+	}
+}
+
+func (a *Archiver) redact(meta map[string]string) map[string]string {
+	if len(meta) == 0 {
+		return meta
+	}
+	out := make(map[string]string, len(meta))
+	for k, v := range meta {
+		lk := strings.ToLower(k)
+		for _, s := range a.stripMeta {
+			if strings.Contains(lk, s) {
+				v = archiveRedactedValue
+				break
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// key partitions records by date and hour so analytics tools can prune by
+// path, e.g. "packs/dt=2026-10-15/hour=09/pk_....json.gz".
+func (a *Archiver) key(rec ArchiveRecord) string {
+	name := rec.Response.PackID
+	if name == "" {
+		name = strconv.FormatInt(rec.ArchivedAt.UnixNano(), 10)
+	}
+	return path.Join(a.prefix, "dt="+rec.ArchivedAt.Format("2006-01-02"), "hour="+rec.ArchivedAt.Format("15"), name+".json.gz")
+}
+
+// Run uploads queued records until ctx is cancelled.
+func (a *Archiver) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case rec := <-a.queue:
+			if err := a.upload(ctx, rec); err != nil {
+				archiveErrors.Add(1)
+				slog.Error("archive failed", "pack_id", rec.Response.PackID, "error", err)
+				continue
+			}
+			archivedPayloads.Add(1)
+		}
+	}
+}
+
+func (a *Archiver) upload(ctx context.Context, rec ArchiveRecord) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(rec); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, archivePutTimeout)
+	defer cancel()
+	return a.sink.Put(ctx, a.key(rec), buf.Bytes())
+}
+
+// dirSink writes objects below a local directory, for development and for
+// volumes that are synced to a bucket by other means.
+type dirSink string
+
+func (d dirSink) Put(_ context.Context, key string, body []byte) error {
+	p := filepath.Join(string(d), filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, body, 0o644)
+}
+
+// s3Sink uploads with S3 PUT Object requests signed with AWS Signature V4.
+// Google Cloud Storage accepts the same requests with HMAC keys, so one
+// client covers both without pulling in either SDK.
+type s3Sink struct {
+	client       *http.Client
+	endpoint     string // scheme://host, objects are addressed path-style
+	bucket       string
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	now          func() time.Time
+}
+
+// s3SinkFromEnv reads <prefix>_ACCESS_KEY_ID, <prefix>_SECRET_ACCESS_KEY and
+// <prefix>_REGION, falling back to the standard AWS_* variables.
+// <prefix>_ENDPOINT overrides the service endpoint, e.g. for MinIO.
+func s3SinkFromEnv(scheme, bucket, prefix string) (*s3Sink, error) {
+	env := func(names ...string) string {
+		for _, n := range names {
+			if v := os.Getenv(n); v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	s := &s3Sink{
+		client:       &http.Client{},
+		bucket:       bucket,
+		region:       env(prefix+"_REGION", "AWS_REGION"),
+		accessKey:    env(prefix+"_ACCESS_KEY_ID", "AWS_ACCESS_KEY_ID"),
+		secretKey:    env(prefix+"_SECRET_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY"),
+		sessionToken: env(prefix+"_SESSION_TOKEN", "AWS_SESSION_TOKEN"),
+		endpoint:     os.Getenv(prefix + "_ENDPOINT"),
+		now:          time.Now,
+	}
+	if bucket == "" {
+		return nil, fmt.Errorf("%s_URL must name a bucket", prefix)
+	}
+	if s.accessKey == "" || s.secretKey == "" {
+		return nil, fmt.Errorf("bucket credentials are required: set %[1]s_ACCESS_KEY_ID and %[1]s_SECRET_ACCESS_KEY", prefix)
+	}
+
+	switch scheme {
+	case "gs":
+		if s.region == "" {
+			s.region = "auto"
+		}
+		if s.endpoint == "" {
+			s.endpoint = "https://storage.googleapis.com"
+		}
+	default:
+		if s.region == "" {
+			s.region = "us-east-1"
+		}
+		if s.endpoint == "" {
+			s.endpoint = "https://s3." + s.region + ".amazonaws.com"
+		}
+	}
+	s.endpoint = strings.TrimRight(s.endpoint, "/")
+	return s, nil
+}
+
+func (s *s3Sink) Put(ctx context.Context, key string, body []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, nil, body, "Content-Encoding", "gzip")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("put /%s/%s: %s", s.bucket, key, resp.Status)
+	}
+	return nil
+}
+
+// do sends a signed request for key in the bucket, or for the bucket itself
+// when key is empty. headers are extra name/value pairs to set and sign.
+func (s *s3Sink) do(ctx context.Context, method, key string, query url.Values, body []byte, headers ...string) (*http.Response, error) {
+	target := s.endpoint + "/" + s.bucket
+	if key != "" {
+		target += "/" + key
+	}
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	s.sign(req, body)
+	return s.client.Do(req)
+}
+
+// sign adds an AWS Signature V4 Authorization header covering the host, the
+// content headers that are set and the x-amz-* headers.
+func (s *s3Sink) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+	var signed []string
+	for _, h := range []string{"content-encoding", "content-type", "host", "x-amz-content-sha256", "x-amz-date", "x-amz-security-token"} {
+		if h == "host" || req.Header.Get(h) != "" {
+			signed = append(signed, h)
+		}
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, h := range signed {
+		v := req.Header.Get(h)
+		if h == "host" {
+			v = req.URL.Host
+		}
+		canonicalHeaders.WriteString(h + ":" + strings.TrimSpace(v) + "\n")
+	}
+	signedHeaders := strings.Join(signed, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
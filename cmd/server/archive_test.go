@@ -0,0 +1,82 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestArchiverRedactsMetaAndPartitionsByDate(t *testing.T) {
+	a := newArchiver(dirSink(t.TempDir()), "/packs/", 1, []string{"email", "phone"})
+	a.Archive(context.Background(),
+		PackRequest{Meta: map[string]string{"order": "1001", "Customer_Email": "a@example.com"}},
+		PackResponse{PackID: "pk_test", VisualizationHTML: "<html>"})
+
+	rec := <-a.queue
+	if rec.Request.Meta["order"] != "1001" || rec.Request.Meta["Customer_Email"] != archiveRedactedValue {
+		t.Errorf("Expected only the email to be redacted, got %v", rec.Request.Meta)
+	}
+	if rec.Response.VisualizationHTML != "" {
+		t.Error("Expected the inline visualization to be dropped")
+	}
+
+	rec.ArchivedAt = time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC)
+	if got, want := a.key(rec), "packs/dt=2026-10-15/hour=09/pk_test.json.gz"; got != want {
+		t.Errorf("Expected key %s, got %s", want, got)
+	}
+}
+
+func TestArchiverSampling(t *testing.T) {
+	a := newArchiver(dirSink(t.TempDir()), "", 0, nil)
+	a.Archive(context.Background(), PackRequest{}, PackResponse{PackID: "pk_test"})
+	if len(a.queue) != 0 {
+		t.Error("Expected nothing queued with a sample rate of 0")
+	}
+}
+
+func TestS3SinkPut(t *testing.T) {
+	var gotPath, gotAuth string
+	var gotRec ArchiveRecord
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.Path, r.Header.Get("Authorization")
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Errorf("Expected a gzipped body, got %v", err)
+			return
+		}
+		body, _ := io.ReadAll(zr)
+		_ = json.Unmarshal(body, &gotRec)
+	}))
+	defer srv.Close()
+
+	t.Setenv("ARCHIVE_ENDPOINT", srv.URL)
+	t.Setenv("ARCHIVE_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("ARCHIVE_SECRET_ACCESS_KEY", "secret")
+	sink, err := s3SinkFromEnv("gs", "analytics", "ARCHIVE")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	sink.now = func() time.Time { return time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC) }
+
+	a := newArchiver(sink, "packs", 1, nil)
+	rec := ArchiveRecord{ArchivedAt: sink.now(), Response: PackResponse{PackID: "pk_test"}}
+	if err := a.upload(context.Background(), rec); err != nil {
+		t.Fatalf("Expected upload to succeed, got %v", err)
+	}
+
+	if gotPath != "/analytics/packs/dt=2026-10-15/hour=09/pk_test.json.gz" {
+		t.Errorf("Expected a path-style object path, got %s", gotPath)
+	}
+	if !strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20261015/auto/s3/aws4_request, ") {
+		t.Errorf("Expected a SigV4 authorization header, got %s", gotAuth)
+	}
+	if gotRec.Response.PackID != "pk_test" {
+		t.Errorf("Expected the record to round-trip, got %+v", gotRec)
+	}
+}
@@ -0,0 +1,477 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Authenticator checks a single authentication scheme. It returns
+// errNoCredentials when the request carries nothing for this scheme so the
+// chain can try the next provider.
+type Authenticator interface {
+	Name() string
+	Authenticate(r *http.Request) (principal string, err error)
+}
+
+var errNoCredentials = errors.New("no credentials")
+
+// authenticatorsFromEnv builds the provider chain from environment variables.
+// Providers whose variables are unset are left out. AUTH_MODE, when set,
+// narrows the chain to the named providers and requires each to be configured.
+func authenticatorsFromEnv() ([]Authenticator, error) {
+	var chain []Authenticator
+
+	if secret := os.Getenv("RAPIDAPI_PROXY_SECRET"); secret != "" {
+		chain = append(chain, rapidAPIAuth{secret: secret})
+	}
+	keys := splitList(os.Getenv("AUTH_API_KEYS"))
+	if path := os.Getenv("AUTH_API_KEYS_FILE"); path != "" {
+		fileKeys, err := readKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, fileKeys...)
+	}
+	if len(keys) > 0 {
+		chain = append(chain, newAPIKeyAuth(keys))
+	}
+	if secret := os.Getenv("AUTH_HMAC_SECRET"); secret != "" {
+		skew := 5 * time.Minute
+		if v, err := time.ParseDuration(os.Getenv("AUTH_HMAC_MAX_SKEW")); err == nil && v > 0 {
+			skew = v
+		}
+		chain = append(chain, hmacAuth{secret: []byte(secret), maxSkew: skew})
+	}
+	if issuer := os.Getenv("AUTH_OIDC_ISSUER"); issuer != "" {
+		chain = append(chain, newOIDCAuth(issuer, os.Getenv("AUTH_OIDC_AUDIENCE")))
+	}
+
+	return restrictAuthMode(chain, os.Getenv("AUTH_MODE"))
+}
+
+// authModeNone disables authentication explicitly.
+const authModeNone = "none"
+
+var authProviders = []string{"rapidapi", "api_key", "hmac", "oidc"}
+
+// restrictAuthMode keeps the providers named in mode, a comma-separated list,
+// in that order. An empty mode keeps every configured provider.
+func restrictAuthMode(chain []Authenticator, mode string) ([]Authenticator, error) {
+	names := splitList(strings.ToLower(mode))
+	if len(names) == 0 {
+		return chain, nil
+	}
+	if slices.Contains(names, authModeNone) {
+		if len(names) > 1 {
+			return nil, fmt.Errorf("AUTH_MODE %q cannot be combined with other providers", authModeNone)
+		}
+		return nil, nil
+	}
+
+	var out []Authenticator
+	for _, name := range names {
+		if !slices.Contains(authProviders, name) {
+			return nil, fmt.Errorf("unknown AUTH_MODE provider %q (want %s or %s)", name, strings.Join(authProviders, ", "), authModeNone)
+		}
+		i := slices.IndexFunc(chain, func(a Authenticator) bool { return a.Name() == name })
+		if i < 0 {
+			return nil, fmt.Errorf("AUTH_MODE provider %q is not configured", name)
+		}
+		out = append(out, chain[i])
+	}
+	return out, nil
+}
+
+// readKeyFile reads API keys one per line. Blank lines and lines starting
+// with # are ignored.
+func readKeyFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read API key file: %w", err)
+	}
+	var keys []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keys = append(keys, line)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("API key file %s contains no keys", path)
+	}
+	return keys, nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// rapidAPIAuth checks the X-RapidAPI-Proxy-Secret header set by the RapidAPI gateway.
+type rapidAPIAuth struct {
+	secret string
+}
+
+func (rapidAPIAuth) Name() string { return "rapidapi" }
+
+func (a rapidAPIAuth) Authenticate(r *http.Request) (string, error) {
+	proxySecret := r.Header.Get("X-RapidAPI-Proxy-Secret")
+	if proxySecret == "" {
+		return "", errNoCredentials
+	}
+	if subtle.ConstantTimeCompare([]byte(proxySecret), []byte(a.secret)) != 1 {
+		return "", errors.New("invalid RapidAPI proxy secret")
+	}
+
+	if user := r.Header.Get("X-RapidAPI-User"); user != "" {
+		return "rapidapi:" + user, nil
+	}
+	return "rapidapi", nil
+}
+
+// apiKeyAuth accepts static keys sent as "Authorization: Bearer <key>" or X-API-Key.
+type apiKeyAuth struct {
+	keys map[string]bool
+}
+
+func newAPIKeyAuth(keys []string) apiKeyAuth {
+	m := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		m[k] = true
+	}
+	return apiKeyAuth{keys: m}
+}
+
+func (apiKeyAuth) Name() string { return "api_key" }
+
+func (a apiKeyAuth) Authenticate(r *http.Request) (string, error) {
+	key := r.Header.Get("X-API-Key")
+	if key == "" {
+		// A bearer value containing dots is a JWT and belongs to the OIDC provider.
+		if token, ok := bearerToken(r); ok && strings.Count(token, ".") != 2 {
+			key = token
+		}
+	}
+	if key == "" {
+		return "", errNoCredentials
+	}
+	if !a.keys[key] {
+		return "", errors.New("unknown API key")
+	}
+	return "key:" + keyFingerprint(key), nil
+}
+
+// keyFingerprint identifies a key in logs and storage without revealing it.
+func keyFingerprint(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:6])
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	h := r.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(h, "Bearer ")
+	return strings.TrimSpace(token), ok
+}
+
+// maxSignedBody bounds the body hmacAuth reads to check a signature.
+const maxSignedBody = 10 << 20
+
+// hmacAuth verifies requests signed with a shared secret. The signature is the
+// hex HMAC-SHA256 of "timestamp\nMETHOD\npath\nquery\nbody", where query is
+// the raw query string without "?", sent in X-Signature along with the unix
+// timestamp in X-Signature-Timestamp.
+type hmacAuth struct {
+	secret  []byte
+	maxSkew time.Duration
+}
+
+func (hmacAuth) Name() string { return "hmac" }
+
+func (a hmacAuth) Authenticate(r *http.Request) (string, error) {
+	sig := r.Header.Get("X-Signature")
+	ts := r.Header.Get("X-Signature-Timestamp")
+	if sig == "" {
+		return "", errNoCredentials
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return "", errors.New("invalid signature timestamp")
+	}
+	if d := time.Since(time.Unix(unix, 0)); d > a.maxSkew || d < -a.maxSkew {
+		return "", errors.New("signature timestamp outside allowed window")
+	}
+
+	var body []byte
+	if r.Body != nil {
+		body, err = io.ReadAll(http.MaxBytesReader(nil, r.Body, maxSignedBody))
+		if err != nil {
+			return "", fmt.Errorf("read body: %w", err)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	mac := hmac.New(sha256.New, a.secret)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s\n", ts, r.Method, r.URL.Path, r.URL.RawQuery)
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(strings.ToLower(sig)), []byte(expected)) {
+		return "", errors.New("invalid request signature")
+	}
+	return "hmac", nil
+}
+
+// oidcAuth validates RS256 bearer tokens against the issuer's published JWKS.
+type oidcAuth struct {
+	issuer   string
+	audience string
+	client   *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+	// triedAt is the start of the last fetch, successful or not, and
+	// fetching is closed when the fetch in flight ends.
+	triedAt  time.Time
+	fetching chan struct{}
+}
+
+const (
+	jwksRefreshInterval = 10 * time.Minute
+	// jwksMinRefetch spaces out fetches for unknown key IDs, so tokens
+	// with made-up IDs cannot make every request wait on the issuer.
+	jwksMinRefetch = 30 * time.Second
+)
+
+func newOIDCAuth(issuer, audience string) *oidcAuth {
+	return &oidcAuth{
+		issuer:   strings.TrimSuffix(issuer, "/"),
+		audience: audience,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (*oidcAuth) Name() string { return "oidc" }
+
+func (a *oidcAuth) Authenticate(r *http.Request) (string, error) {
+	token, ok := bearerToken(r)
+	if !ok || strings.Count(token, ".") != 2 {
+		return "", errNoCredentials
+	}
+
+	parts := strings.Split(token, ".")
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return "", fmt.Errorf("decode token header: %w", err)
+	}
+	if header.Alg != "RS256" {
+		return "", fmt.Errorf("unsupported token algorithm %q", header.Alg)
+	}
+
+	key, err := a.key(r.Context(), header.Kid)
+	if err != nil {
+		return "", err
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("decode token signature: %w", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+		return "", errors.New("invalid token signature")
+	}
+
+	var claims struct {
+		Iss string          `json:"iss"`
+		Sub string          `json:"sub"`
+		Aud json.RawMessage `json:"aud"`
+		Exp int64           `json:"exp"`
+		Nbf int64           `json:"nbf"`
+	}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", fmt.Errorf("decode token claims: %w", err)
+	}
+
+	now := time.Now().Unix()
+	if claims.Iss != a.issuer {
+		return "", errors.New("token issuer mismatch")
+	}
+	if claims.Exp == 0 || now >= claims.Exp {
+		return "", errors.New("token expired")
+	}
+	if claims.Nbf != 0 && now < claims.Nbf {
+		return "", errors.New("token not yet valid")
+	}
+	if a.audience != "" && !audienceContains(claims.Aud, a.audience) {
+		return "", errors.New("token audience mismatch")
+	}
+
+	return "oidc:" + claims.Sub, nil
+}
+
+func decodeSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func audienceContains(raw json.RawMessage, want string) bool {
+	var single string
+	if json.Unmarshal(raw, &single) == nil {
+		return single == want
+	}
+	var many []string
+	if json.Unmarshal(raw, &many) == nil {
+		for _, aud := range many {
+			if aud == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// key returns the signing key for kid, refreshing the JWKS when the key is
+// unknown or the cache is stale. One fetch runs at a time, outside the lock,
+// and at most one every jwksMinRefetch; a stale key is used meanwhile.
+func (a *oidcAuth) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	for {
+		a.mu.Lock()
+		k, ok := a.keys[kid]
+		if ok && time.Since(a.fetchedAt) < jwksRefreshInterval {
+			a.mu.Unlock()
+			return k, nil
+		}
+		if wait := a.fetching; wait != nil {
+			a.mu.Unlock()
+			select {
+			case <-wait:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		if time.Since(a.triedAt) < jwksMinRefetch {
+			a.mu.Unlock()
+			if ok {
+				return k, nil
+			}
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		done := make(chan struct{})
+		a.fetching, a.triedAt = done, time.Now()
+		a.mu.Unlock()
+
+		// The fetch serves every waiting request, so it must not end
+		// with the one that started it.
+		keys, err := a.fetchJWKS(context.WithoutCancel(ctx))
+
+		a.mu.Lock()
+		if err == nil {
+			a.keys, a.fetchedAt = keys, time.Now()
+		}
+		a.fetching = nil
+		close(done)
+		k, ok = a.keys[kid]
+		a.mu.Unlock()
+
+		switch {
+		case ok:
+			return k, nil
+		case err != nil:
+			return nil, fmt.Errorf("fetch JWKS: %w", err)
+		default:
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+	}
+}
+
+func (a *oidcAuth) fetchJWKS(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	var discovery struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := a.getJSON(ctx, a.issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, err
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := a.getJSON(ctx, discovery.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+func (a *oidcAuth) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	a := newAPIKeyAuth([]string{"secret"})
+	tests := []struct {
+		header, value string
+		wantErr       error
+		ok            bool
+	}{
+		{"X-API-Key", "secret", nil, true},
+		{"Authorization", "Bearer secret", nil, true},
+		{"X-API-Key", "guess", nil, false},
+		{"Authorization", "Bearer a.b.c", errNoCredentials, false},
+		{"", "", errNoCredentials, false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/pack", nil)
+		if tt.header != "" {
+			r.Header.Set(tt.header, tt.value)
+		}
+		principal, err := a.Authenticate(r)
+		switch {
+		case tt.ok && (err != nil || principal != "key:"+keyFingerprint("secret")):
+			t.Errorf("%s %q: expected the key's principal, got %q, %v", tt.header, tt.value, principal, err)
+		case !tt.ok && err == nil:
+			t.Errorf("%s %q: expected an error", tt.header, tt.value)
+		case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+			t.Errorf("%s %q: expected %v, got %v", tt.header, tt.value, tt.wantErr, err)
+		}
+	}
+}
+
+func TestRapidAPIAuth(t *testing.T) {
+	a := rapidAPIAuth{secret: "proxy"}
+	r := httptest.NewRequest(http.MethodPost, "/pack", nil)
+	if _, err := a.Authenticate(r); !errors.Is(err, errNoCredentials) {
+		t.Errorf("Expected errNoCredentials without the header, got %v", err)
+	}
+	r.Header.Set("X-RapidAPI-Proxy-Secret", "proxy")
+	r.Header.Set("X-RapidAPI-User", "alice")
+	if p, err := a.Authenticate(r); err != nil || p != "rapidapi:alice" {
+		t.Errorf("Expected rapidapi:alice, got %q, %v", p, err)
+	}
+	r.Header.Set("X-RapidAPI-Proxy-Secret", "wrong")
+	if _, err := a.Authenticate(r); err == nil {
+		t.Error("Expected a wrong proxy secret to be rejected")
+	}
+}
+
+// signRequest signs r for hmacAuth with secret at ts.
+func signRequest(r *http.Request, secret, body string, ts time.Time) {
+	unix := strconv.FormatInt(ts.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s\n%s", unix, r.Method, r.URL.Path, r.URL.RawQuery, body)
+	r.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+	r.Header.Set("X-Signature-Timestamp", unix)
+}
+
+func TestHMACAuth(t *testing.T) {
+	a := hmacAuth{secret: []byte("shared"), maxSkew: time.Minute}
+	body := `{"items": []}`
+
+	r := httptest.NewRequest(http.MethodPost, "/pack?format=csv", strings.NewReader(body))
+	signRequest(r, "shared", body, time.Now())
+	if p, err := a.Authenticate(r); err != nil || p != "hmac" {
+		t.Fatalf("Expected a valid signature to pass, got %q, %v", p, err)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/pack?format=csv", strings.NewReader(body))
+	signRequest(r, "shared", body, time.Now())
+	r.URL.RawQuery = "format=json"
+	if _, err := a.Authenticate(r); err == nil {
+		t.Error("Expected a changed query string to break the signature")
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/pack", strings.NewReader(body))
+	signRequest(r, "shared", body, time.Now().Add(-time.Hour))
+	if _, err := a.Authenticate(r); err == nil {
+		t.Error("Expected an old timestamp to be rejected")
+	}
+
+	big := strings.Repeat("x", maxSignedBody+1)
+	r = httptest.NewRequest(http.MethodPost, "/pack", strings.NewReader(big))
+	signRequest(r, "shared", big, time.Now())
+	if _, err := a.Authenticate(r); err == nil {
+		t.Error("Expected a body over maxSignedBody to be rejected")
+	}
+}
+
+// testIssuer is an OIDC issuer serving one RSA key and counting JWKS fetches.
+type testIssuer struct {
+	*httptest.Server
+	key     *rsa.PrivateKey
+	fetches atomic.Int32
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iss := &testIssuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"jwks_uri": iss.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		iss.fetches.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	iss.Server = httptest.NewServer(mux)
+	t.Cleanup(iss.Close)
+	return iss
+}
+
+func (iss *testIssuer) token(t *testing.T, kid string, claims map[string]any) string {
+	enc := func(v any) string {
+		b, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	signed := enc(map[string]string{"alg": "RS256", "kid": kid}) + "." + enc(claims)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, iss.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestOIDCAuth(t *testing.T) {
+	iss := newTestIssuer(t)
+	a := newOIDCAuth(iss.URL, "binpacker")
+	exp := time.Now().Add(time.Hour).Unix()
+
+	authenticate := func(token string) (string, error) {
+		r := httptest.NewRequest(http.MethodPost, "/pack", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		return a.Authenticate(r)
+	}
+
+	if p, err := authenticate(iss.token(t, "k1", map[string]any{"iss": iss.URL, "sub": "u1", "aud": "binpacker", "exp": exp})); err != nil || p != "oidc:u1" {
+		t.Fatalf("Expected oidc:u1, got %q, %v", p, err)
+	}
+	for name, claims := range map[string]map[string]any{
+		"expired":  {"iss": iss.URL, "sub": "u1", "aud": "binpacker", "exp": time.Now().Add(-time.Minute).Unix()},
+		"audience": {"iss": iss.URL, "sub": "u1", "aud": "other", "exp": exp},
+		"issuer":   {"iss": "https://evil.example", "sub": "u1", "aud": "binpacker", "exp": exp},
+	} {
+		if _, err := authenticate(iss.token(t, "k1", claims)); err == nil {
+			t.Errorf("%s: expected the token to be rejected", name)
+		}
+	}
+
+	// Unknown key IDs trigger at most one fetch per jwksMinRefetch.
+	a.mu.Lock()
+	a.fetchedAt, a.triedAt = time.Time{}, time.Time{}
+	a.mu.Unlock()
+	fetches := iss.fetches.Load()
+	for i := range 5 {
+		if _, err := authenticate(iss.token(t, fmt.Sprint("made-up-", i), map[string]any{"iss": iss.URL, "exp": exp})); err == nil {
+			t.Error("Expected an unknown key ID to be rejected")
+		}
+	}
+	if n := iss.fetches.Load() - fetches; n != 1 {
+		t.Errorf("Expected one JWKS fetch for 5 unknown key IDs, got %d", n)
+	}
+	// The stale key still verifies meanwhile.
+	if _, err := authenticate(iss.token(t, "k1", map[string]any{"iss": iss.URL, "sub": "u1", "aud": "binpacker", "exp": exp})); err != nil {
+		t.Errorf("Expected the cached key to be used between fetches, got %v", err)
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	chain := []Authenticator{rapidAPIAuth{secret: "proxy"}, newAPIKeyAuth([]string{"secret"})}
+	var principal string
+	handler := AuthMiddleware(chain, func(w http.ResponseWriter, r *http.Request) {
+		principal = principalFrom(r.Context())
+	})
+
+	tests := []struct {
+		header, value string
+		want          int
+		principal     string
+	}{
+		{"X-API-Key", "secret", http.StatusOK, "key:" + keyFingerprint("secret")},
+		{"X-API-Key", "guess", http.StatusUnauthorized, ""},
+		{"", "", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		principal = ""
+		r := httptest.NewRequest(http.MethodPost, "/pack", nil)
+		if tt.header != "" {
+			r.Header.Set(tt.header, tt.value)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, r)
+		if rec.Code != tt.want || principal != tt.principal {
+			t.Errorf("%s %q: expected %d as %q, got %d as %q", tt.header, tt.value, tt.want, tt.principal, rec.Code, principal)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodOptions, "/pack", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected a preflight to pass without credentials, got %d", rec.Code)
+	}
+}
+
+func TestAuthModeAPIKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	if err := os.WriteFile(path, []byte("# clients\nalpha\n\n  beta  \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AUTH_MODE", "api_key")
+	t.Setenv("AUTH_API_KEYS_FILE", path)
+	t.Setenv("RAPIDAPI_PROXY_SECRET", "proxy")
+
+	chain, err := authenticatorsFromEnv()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(chain) != 1 || chain[0].Name() != "api_key" {
+		t.Fatalf("Expected only the api_key provider, got %v", chain)
+	}
+
+	r := httptest.NewRequest("POST", "/", nil)
+	r.Header.Set("X-API-Key", "beta")
+	if _, err := chain[0].Authenticate(r); err != nil {
+		t.Errorf("Expected key from file to be accepted, got %v", err)
+	}
+	r.Header.Set("X-API-Key", "# clients")
+	if _, err := chain[0].Authenticate(r); err == nil {
+		t.Error("Expected comment line not to be a key")
+	}
+}
+
+func TestAuthModeErrors(t *testing.T) {
+	tests := []struct {
+		mode string
+		ok   bool
+	}{
+		{"", true},
+		{"none", true},
+		{"rapidapi", true},
+		{"api_key", false},
+		{"oidc", false},
+		{"jwt", false},
+		{"none,rapidapi", false},
+	}
+	t.Setenv("RAPIDAPI_PROXY_SECRET", "proxy")
+	for _, tt := range tests {
+		t.Setenv("AUTH_MODE", tt.mode)
+		_, err := authenticatorsFromEnv()
+		if (err == nil) != tt.ok {
+			t.Errorf("AUTH_MODE=%q: expected ok=%v, got error %v", tt.mode, tt.ok, err)
+		}
+	}
+
+	t.Setenv("AUTH_MODE", "none")
+	if chain, _ := authenticatorsFromEnv(); len(chain) != 0 {
+		t.Errorf("Expected AUTH_MODE=none to disable authentication, got %d providers", len(chain))
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A backup is every stored pack and visualization, with the profiles file,
+// as newline-delimited JSON: a header line, then one line per entry. Records
+// are written as plain JSON whatever the backend and encryption key, so a
+// backup taken from one storage backend can be restored into another:
+//
+//	binpacker backup > state.ndjson    # with STORAGE_URL of the old backend
+//	binpacker restore < state.ndjson   # with STORAGE_URL of the new one
+//
+// GET /admin/backup and POST /admin/restore do the same over HTTP.
+
+const backupVersion = 1
+
+// backupEntry is one line of a backup.
+type backupEntry struct {
+	Kind string `json:"kind"`
+	// Header
+	Version   int       `json:"version,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+	// Record
+	Key   string          `json:"key,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Kinds of backup entries.
+const (
+	backupHeader   = "header"
+	backupRecord   = "record"
+	backupProfiles = "profiles"
+)
+
+// RestoreResult reports what a restore wrote.
+type RestoreResult struct {
+	Records  int  `json:"records"`
+	Profiles bool `json:"profiles"`
+}
+
+// backupPrefixes are the key prefixes of the records a backup holds.
+var backupPrefixes = []string{packKeyPrefix, visualizationKeyPrefix, scenarioKeyPrefix}
+
+// Backup writes every record of s, and profilesData if any, to w.
+func (s *Store) Backup(w io.Writer, profilesData []byte) error {
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(backupEntry{Kind: backupHeader, Version: backupVersion, CreatedAt: time.Now().UTC()}); err != nil {
+		return err
+	}
+	if len(profilesData) > 0 {
+		if err := enc.Encode(backupEntry{Kind: backupProfiles, Value: profilesData}); err != nil {
+			return err
+		}
+	}
+
+	for _, prefix := range backupPrefixes {
+		ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+		keys, err := s.backend.Keys(ctx, prefix)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("list %s: %w", prefix, err)
+		}
+		for _, key := range keys {
+			ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+			value, err := s.backend.Get(ctx, key)
+			cancel()
+			if errors.Is(err, errNotStored) {
+				// Purged since it was listed.
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("get %s: %w", key, err)
+			}
+			if err := enc.Encode(backupEntry{Kind: backupRecord, Key: key, Value: value}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Restore writes the records of a backup read from r into s, replacing
+// records under the same keys. Profiles in the backup are checked and
+// passed to restoreProfiles, which reports whether it kept them.
+func (s *Store) Restore(r io.Reader, restoreProfiles func([]byte) (bool, error)) (RestoreResult, error) {
+	var res RestoreResult
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 64<<20)
+	header := false
+	for line := 1; sc.Scan(); line++ {
+		if len(strings.TrimSpace(sc.Text())) == 0 {
+			continue
+		}
+		var e backupEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return res, fmt.Errorf("line %d: %w", line, err)
+		}
+		if !header {
+			if e.Kind != backupHeader || e.Version != backupVersion {
+				return res, fmt.Errorf("not a version %d backup", backupVersion)
+			}
+			header = true
+			continue
+		}
+
+		switch e.Kind {
+		case backupRecord:
+			if !hasBackupPrefix(e.Key) || !json.Valid(e.Value) {
+				return res, fmt.Errorf("line %d: invalid record %q", line, e.Key)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+			err := s.backend.Put(ctx, e.Key, e.Value)
+			cancel()
+			if err != nil {
+				return res, fmt.Errorf("line %d: put %s: %w", line, e.Key, err)
+			}
+			res.Records++
+		case backupProfiles:
+			if _, err := parseProfiles(e.Value); err != nil {
+				return res, fmt.Errorf("line %d: %w", line, err)
+			}
+			kept, err := restoreProfiles(e.Value)
+			if err != nil {
+				return res, err
+			}
+			res.Profiles = kept
+		default:
+			return res, fmt.Errorf("line %d: unknown entry kind %q", line, e.Kind)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return res, err
+	}
+	if !header {
+		return res, errors.New("empty backup")
+	}
+	return res, nil
+}
+
+func hasBackupPrefix(key string) bool {
+	for _, p := range backupPrefixes {
+		if strings.HasPrefix(key, p) && len(key) > len(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// profilesFile returns the contents of PROFILES_FILE, if set.
+func profilesFile() ([]byte, error) {
+	path := os.Getenv("PROFILES_FILE")
+	if path == "" {
+		return nil, nil
+	}
+	return os.ReadFile(path)
+}
+
+// writeProfilesFile replaces PROFILES_FILE with data and the profiles in
+// force with those in it. Without PROFILES_FILE there is nowhere to keep
+// them, and they are skipped.
+func writeProfilesFile(data []byte) (bool, error) {
+	path := os.Getenv("PROFILES_FILE")
+	if path == "" {
+		return false, nil
+	}
+	set, err := parseProfiles(data)
+	if err != nil {
+		return false, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".profiles-*")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return false, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return false, err
+	}
+	updateSettings(func(s *Settings) { s.Profiles = set })
+	return true, nil
+}
+
+// handleAdminBackup streams a backup of the store.
+func handleAdminBackup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	data, err := profilesFile()
+	if err != nil {
+		http.Error(w, "Cannot read profiles: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="binpacker-backup.ndjson"`)
+	if err := store.Backup(w, data); err != nil {
+		// The status line is sent; a truncated body is all that can signal it.
+		slog.ErrorContext(r.Context(), "backup failed", "error", err)
+	}
+}
+
+// handleAdminRestore restores a backup sent as the request body.
+func handleAdminRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	res, err := store.Restore(r.Body, writeProfilesFile)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Restore stopped after %d records: %v", res.Records, err), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// runCommand runs the command line form of the server: "backup [file]"
+// writes a backup of the configured storage to file or stdout, and
+// "restore [file]" restores one from file or stdin.
+func runCommand(args []string) error {
+	if len(args) > 2 || (args[0] != "backup" && args[0] != "restore") {
+		return errors.New("usage: binpacker [backup|restore] [file]")
+	}
+	backend, err := storageFromEnv()
+	if err != nil {
+		return fmt.Errorf("invalid storage configuration: %w", err)
+	}
+	if backend, err = encryptedStorageFromEnv(backend); err != nil {
+		return fmt.Errorf("invalid storage encryption: %w", err)
+	}
+	s := newStoreOn(backend)
+
+	if args[0] == "backup" {
+		out := os.Stdout
+		if len(args) == 2 {
+			if out, err = os.Create(args[1]); err != nil {
+				return err
+			}
+		}
+		data, err := profilesFile()
+		if err != nil {
+			return err
+		}
+		if err := s.Backup(out, data); err != nil {
+			return err
+		}
+		return out.Close()
+	}
+
+	in := os.Stdin
+	if len(args) == 2 {
+		if in, err = os.Open(args[1]); err != nil {
+			return err
+		}
+		defer in.Close()
+	}
+	res, err := s.Restore(in, writeProfilesFile)
+	fmt.Fprintf(os.Stderr, "restored %d records (profiles: %t)\n", res.Records, res.Profiles)
+	return err
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"binpacker/pkg/packing"
+)
+
+func TestBackupRestore(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32))
+	encrypted, err := newEncryptedStorage(newMemoryStorage(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := newStoreOn(encrypted)
+	src.SaveVisualization("vz_a", "pk_a", "key:a", "<html>")
+	src.SavePack("key:a", "vz_a", PackResponse{PackID: "pk_a", Utilization: 42}, []packing.InputBox{{ID: "box", W: 1, H: 1, D: 1}})
+
+	var buf bytes.Buffer
+	if err := src.Backup(&buf, []byte(testProfiles)); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(buf.String(), "\n") != 4 {
+		t.Errorf("Expected a header, the profiles and two records, got %s", buf.String())
+	}
+
+	// The backup is plain JSON, so it restores into an unencrypted backend.
+	dst := newStoreOn(newMemoryStorage())
+	var restoredProfiles []byte
+	res, err := dst.Restore(&buf, func(data []byte) (bool, error) {
+		restoredProfiles = data
+		return true, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Records != 2 || !res.Profiles || len(restoredProfiles) == 0 {
+		t.Errorf("Expected 2 records and the profiles restored, got %+v", res)
+	}
+	if p, ok := dst.Pack("key:a", "pk_a"); !ok || p.Response.Utilization != 42 || len(p.Boxes) != 1 {
+		t.Errorf("Expected the pack restored for its owner, got %+v", p)
+	}
+	if v, ok := dst.Visualization("key:a", "vz_a"); !ok || v.HTML != "<html>" {
+		t.Errorf("Expected the visualization restored, got %+v", v)
+	}
+
+	noProfiles := func([]byte) (bool, error) { return false, nil }
+	for _, bad := range []string{
+		"",
+		`{"kind":"record","key":"packs/x","value":{}}`,
+		`{"kind":"header","version":1}` + "\n" + `{"kind":"record","key":"jobs/x","value":{}}`,
+		`{"kind":"header","version":1}` + "\n" + `{"kind":"profiles","value":{"p":{"boxes":[]}}}`,
+	} {
+		if _, err := dst.Restore(strings.NewReader(bad), noProfiles); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+
+	"binpacker/pkg/packing"
+)
+
+// errOverBudget is a request projected to need more compute than the
+// limits allow. Like errTooLarge it is answered with 413; the message says
+// how far to split the request.
+type errOverBudget struct {
+	msg string
+}
+
+func (e errOverBudget) Error() string { return e.msg }
+
+// checkComputeBudget rejects a request whose estimate exceeds the compute
+// budget of l. The limits on counts cannot catch every such request: a few
+// thousand units offered a hundred box types are within them but would
+// pack for hours.
+func checkComputeBudget(e packing.Estimate, l InputLimits) error {
+	if e.Evaluations > int64(l.MaxPackEvaluations) {
+		// Evaluations grow with the square of the units.
+		units := int(float64(e.Units) * math.Sqrt(float64(l.MaxPackEvaluations)/float64(e.Evaluations)))
+		return errOverBudget{fmt.Sprintf("request too expensive: packing is projected to try about %d placements, more than the budget of %d; split it into requests of at most %d units, or offer fewer box types",
+			e.Evaluations, l.MaxPackEvaluations, max(units, 1))}
+	}
+	if mb := e.MemoryBytes >> 20; mb > int64(l.MaxPackMemoryMB) {
+		units := int(float64(e.Units) * float64(l.MaxPackMemoryMB) / float64(mb))
+		return errOverBudget{fmt.Sprintf("request too expensive: packing is projected to need about %d MB, more than the budget of %d MB; split it into requests of at most %d units",
+			mb, l.MaxPackMemoryMB, max(units, 1))}
+	}
+	return nil
+}
+
+// DryRunResponse answers a /pack request with dry_run set: the projected
+// cost of packing it, checked against the budget, without packing.
+type DryRunResponse struct {
+	Estimate     packing.Estimate `json:"estimate"`
+	WithinBudget bool             `json:"within_budget"`
+	// Guidance says how to split a request over budget.
+	Guidance           string `json:"guidance,omitempty"`
+	MaxPackEvaluations int    `json:"max_pack_evaluations"`
+	MaxPackMemoryMB    int    `json:"max_pack_memory_mb"`
+}
+
+// writeDryRun answers a dry run of req, which validatePackRequest has
+// checked with the result err.
+func writeDryRun(w http.ResponseWriter, req PackRequest, err error) {
+	var over errOverBudget
+	if err != nil && !errors.As(err, &over) {
+		writeRequestError(w, err)
+		return
+	}
+	limits := getSettings().Limits
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(DryRunResponse{
+		Estimate:           req.estimate,
+		WithinBudget:       err == nil,
+		Guidance:           over.msg,
+		MaxPackEvaluations: limits.MaxPackEvaluations,
+		MaxPackMemoryMB:    limits.MaxPackMemoryMB,
+	})
+}
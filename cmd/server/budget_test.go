@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"binpacker/pkg/packing"
+)
+
+func TestCheckComputeBudget(t *testing.T) {
+	limits := InputLimits{MaxPackEvaluations: 1000, MaxPackMemoryMB: 1}
+
+	if err := checkComputeBudget(packing.Estimate{Units: 10, Evaluations: 1000, MemoryBytes: 1 << 20}, limits); err != nil {
+		t.Errorf("Expected a request on the budget to pass, got %v", err)
+	}
+	err := checkComputeBudget(packing.Estimate{Units: 100, Evaluations: 4000}, limits)
+	if _, ok := err.(errOverBudget); !ok || !strings.Contains(err.Error(), "at most 50 units") {
+		t.Errorf("Expected an over budget error suggesting 50 units, got %v", err)
+	}
+	if err := checkComputeBudget(packing.Estimate{Units: 100, MemoryBytes: 4 << 20}, limits); err == nil {
+		t.Error("Expected a request over the memory budget to be rejected")
+	}
+}
+
+func TestPackDryRun(t *testing.T) {
+	old := getSettings()
+	defer setSettings(old)
+	updateSettings(func(s *Settings) { s.Limits.MaxPackEvaluations = 10 })
+
+	body := `{"items": [{"id": "mug", "w": 5, "h": 5, "d": 5, "quantity": 20}], "boxes": [{"id": "box", "w": 10, "h": 10, "d": 10}]}`
+	rec := httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodPost, "/pack", strings.NewReader(body)))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 over budget, got %d: %s", rec.Code, rec.Body)
+	}
+
+	body = strings.Replace(body, `"boxes"`, `"dry_run": true, "boxes"`, 1)
+	rec = httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodPost, "/pack", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for a dry run, got %d: %s", rec.Code, rec.Body)
+	}
+	var resp DryRunResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.WithinBudget || resp.Guidance == "" || resp.Estimate.Units != 20 {
+		t.Errorf("Expected an over budget estimate of 20 units with guidance, got %+v", resp)
+	}
+}
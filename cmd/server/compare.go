@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"maps"
+	"net/http"
+
+	"binpacker/pkg/packing"
+	"binpacker/pkg/visualize"
+)
+
+// PackStats are the headline numbers shown for each side of a comparison.
+type PackStats struct {
+	PackID        string
+	Boxes         int
+	PackedItems   int
+	UnpackedItems int
+	TotalVolume   int
+	Utilization   float64
+}
+
+func packStats(resp PackResponse) PackStats {
+	s := PackStats{
+		PackID:        resp.PackID,
+		Boxes:         len(resp.PackedBoxes),
+		UnpackedItems: len(resp.UnpackedItems),
+		TotalVolume:   resp.TotalVolume,
+		Utilization:   resp.Utilization,
+	}
+	for _, pb := range resp.PackedBoxes {
+		s.PackedItems += len(pb.Contents)
+	}
+	return s
+}
+
+// itemCounts counts item instances by ID, packed or not.
+func itemCounts(resp PackResponse) map[string]int {
+	counts := make(map[string]int)
+	for _, pb := range resp.PackedBoxes {
+		for _, p := range pb.Contents {
+			counts[p.ItemID]++
+		}
+	}
+	for _, it := range resp.UnpackedItems {
+		counts[it.ID]++
+	}
+	return counts
+}
+
+// diffRow is one line of the stats diff table.
+type diffRow struct {
+	Label   string
+	A, B    string
+	Delta   string
+	Better  bool
+	Changed bool
+}
+
+func statsDiff(a, b PackStats) []diffRow {
+	row := func(label string, va, vb float64, format string, lowerIsBetter bool) diffRow {
+		d := vb - va
+		return diffRow{
+			Label:   label,
+			A:       fmt.Sprintf(format, va),
+			B:       fmt.Sprintf(format, vb),
+			Delta:   fmt.Sprintf("%+"+format[1:], d),
+			Better:  (d < 0) == lowerIsBetter,
+			Changed: d != 0,
+		}
+	}
+	return []diffRow{
+		row("Boxes", float64(a.Boxes), float64(b.Boxes), "%.0f", true),
+		row("Packed items", float64(a.PackedItems), float64(b.PackedItems), "%.0f", false),
+		row("Unpacked items", float64(a.UnpackedItems), float64(b.UnpackedItems), "%.0f", true),
+		row("Box volume", float64(a.TotalVolume), float64(b.TotalVolume), "%.0f", true),
+		row("Utilization %", a.Utilization, b.Utilization, "%.1f", false),
+	}
+}
+
+// compareSide is one half of the compare view.
+type compareSide struct {
+	PackID      string
+	PackedBoxes []packing.PackedBox
+	Boxes       []packing.InputBox
+}
+
+type compareData struct {
+	A, B compareSide
+	Diff []diffRow
+}
+
+// GenerateCompareHTML renders two packs of the same items side by side with
+// synchronized cameras and a stats diff.
+func GenerateCompareHTML(a, b storedPack) (string, error) {
+	t, err := template.New("compare").Funcs(template.FuncMap{
+		"jsonMarshal": visualize.MarshalJS,
+	}).Parse(compareTemplate)
+	if err != nil {
+		return "", fmt.Errorf("parse template: %w", err)
+	}
+
+	data := compareData{
+		A:    compareSide{PackID: a.Response.PackID, PackedBoxes: a.Response.PackedBoxes, Boxes: a.Boxes},
+		B:    compareSide{PackID: b.Response.PackID, PackedBoxes: b.Response.PackedBoxes, Boxes: b.Boxes},
+		Diff: statsDiff(packStats(a.Response), packStats(b.Response)),
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("execute template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// handleCompare serves /visualize/compare?a={pack_id}&b={pack_id}.
+func handleCompare(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	a, okA := visiblePack(r, q.Get("a"))
+	b, okB := visiblePack(r, q.Get("b"))
+	if !okA || !okB {
+		http.Error(w, "Pack not found", http.StatusNotFound)
+		return
+	}
+
+	if !maps.Equal(itemCounts(a.Response), itemCounts(b.Response)) {
+		http.Error(w, "Packs must contain the same items to be compared", http.StatusBadRequest)
+		return
+	}
+
+	html, err := GenerateCompareHTML(a, b)
+	if err != nil {
+		http.Error(w, "Failed to render comparison", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(html))
+}
+
+const compareTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Packing Comparison - {{.A.PackID}} vs {{.B.PackID}}</title>
+    <style>
+        * { margin: 0; padding: 0; box-sizing: border-box; }
+        body {
+            font-family: 'Inter', 'Segoe UI', system-ui, sans-serif;
+            background: #0f0f1a;
+            color: #e8e8f0;
+            overflow: hidden;
+        }
+        #views { display: flex; width: 100vw; height: 100vh; }
+        .view { flex: 1; position: relative; border-right: 1px solid #3a3a5c; }
+        .view:last-child { border-right: none; }
+        .view h2 {
+            position: absolute;
+            top: 16px;
+            left: 16px;
+            font-size: 14px;
+            color: #818cf8;
+            word-break: break-all;
+        }
+        #diff {
+            position: absolute;
+            bottom: 20px;
+            left: 50%;
+            transform: translateX(-50%);
+            background: #1a1a2e;
+            border: 1px solid #3a3a5c;
+            border-radius: 12px;
+            padding: 12px 16px;
+            font-size: 12px;
+            z-index: 100;
+        }
+        #diff th, #diff td { padding: 4px 10px; text-align: right; }
+        #diff th:first-child, #diff td:first-child { text-align: left; color: #a0a0b8; }
+        #diff caption { color: #818cf8; font-weight: 600; margin-bottom: 6px; }
+        #diff .better { color: #22c55e; }
+        #diff .worse { color: #f43f5e; }
+    </style>
+</head>
+<body>
+    <div id="views">
+        <div class="view" id="viewA"><h2>A: {{.A.PackID}}</h2></div>
+        <div class="view" id="viewB"><h2>B: {{.B.PackID}}</h2></div>
+    </div>
+
+    <table id="diff">
+        <caption>A vs B</caption>
+        <thead><tr><th scope="col">Metric</th><th scope="col">A</th><th scope="col">B</th><th scope="col">B − A</th></tr></thead>
+        <tbody>
+        {{- range .Diff}}
+            <tr><td>{{.Label}}</td><td>{{.A}}</td><td>{{.B}}</td><td{{if .Changed}} class="{{if .Better}}better{{else}}worse{{end}}"{{end}}>{{.Delta}}</td></tr>
+        {{- end}}
+        </tbody>
+    </table>
+
+    <script src="https://cdnjs.cloudflare.com/ajax/libs/three.js/r128/three.min.js"></script>
+    <script src="https://cdn.jsdelivr.net/npm/three@0.128.0/examples/js/controls/OrbitControls.js"></script>
+
+    <script>
+        const colorPalette = [
+            0x6366f1, 0xec4899, 0x14b8a6, 0xf59e0b,
+            0x8b5cf6, 0x06b6d4, 0xf43f5e, 0x22c55e
+        ];
+
+        function buildView(container, packedBoxes, boxes) {
+            const scene = new THREE.Scene();
+            scene.background = new THREE.Color(0x0f0f1a);
+            scene.add(new THREE.AmbientLight(0xffffff, 0.5));
+            const light = new THREE.DirectionalLight(0xffffff, 0.9);
+            light.position.set(50, 100, 50);
+            scene.add(light);
+            scene.add(new THREE.GridHelper(200, 40, 0x2a2a4a, 0x1a1a2e));
+
+            const camera = new THREE.PerspectiveCamera(50, container.clientWidth / container.clientHeight, 0.1, 10000);
+            const renderer = new THREE.WebGLRenderer({ antialias: true });
+            renderer.setSize(container.clientWidth, container.clientHeight);
+            container.appendChild(renderer.domElement);
+
+            const boxMap = {};
+            boxes.forEach(box => { boxMap[box.id] = box; });
+
+            let maxDimension = 0;
+            packedBoxes.forEach((packedBox, boxIndex) => {
+                const boxDef = boxMap[packedBox.box_id];
+                if (!boxDef) return;
+                maxDimension = Math.max(maxDimension, boxDef.w, boxDef.h, boxDef.d);
+                const offsetX = boxIndex * (boxDef.w + 30);
+
+                const boxGeometry = new THREE.BoxGeometry(boxDef.w, boxDef.h, boxDef.d);
+                const boxLine = new THREE.LineSegments(
+                    new THREE.EdgesGeometry(boxGeometry),
+                    new THREE.LineBasicMaterial({ color: 0x6366f1 })
+                );
+                boxLine.position.set(offsetX + boxDef.w / 2, boxDef.h / 2, boxDef.d / 2);
+                scene.add(boxLine);
+
+                packedBox.contents.forEach((item, itemIndex) => {
+                    const itemMesh = new THREE.Mesh(
+                        new THREE.BoxGeometry(item.w * 0.98, item.h * 0.98, item.d * 0.98),
+                        new THREE.MeshStandardMaterial({ color: colorPalette[itemIndex % colorPalette.length], roughness: 0.3 })
+                    );
+                    itemMesh.position.set(offsetX + item.x + item.w / 2, item.y + item.h / 2, item.z + item.d / 2);
+                    scene.add(itemMesh);
+                });
+            });
+
+            return { scene, camera, renderer, container, maxDimension };
+        }
+
+        const views = [
+            buildView(document.getElementById('viewA'), {{.A.PackedBoxes | jsonMarshal}}, {{.A.Boxes | jsonMarshal}}),
+            buildView(document.getElementById('viewB'), {{.B.PackedBoxes | jsonMarshal}}, {{.B.Boxes | jsonMarshal}})
+        ];
+
+        // Both cameras start from the same spot; whichever view is dragged
+        // drives the other.
+        const maxDimension = Math.max(views[0].maxDimension, views[1].maxDimension);
+        const cameraDistance = maxDimension * 2.5;
+        views.forEach(v => {
+            v.camera.position.set(cameraDistance, cameraDistance * 0.8, cameraDistance);
+            v.controls = new THREE.OrbitControls(v.camera, v.renderer.domElement);
+            v.controls.target.set(maxDimension / 2, 0, maxDimension / 2);
+        });
+        let syncing = false;
+        views.forEach((v, i) => {
+            const other = views[1 - i];
+            v.controls.addEventListener('change', () => {
+                if (syncing) return;
+                syncing = true;
+                other.camera.position.copy(v.camera.position);
+                other.camera.quaternion.copy(v.camera.quaternion);
+                other.controls.target.copy(v.controls.target);
+                other.controls.update();
+                syncing = false;
+            });
+        });
+        views.forEach(v => v.controls.update());
+
+        function animate() {
+            requestAnimationFrame(animate);
+            views.forEach(v => v.renderer.render(v.scene, v.camera));
+        }
+        animate();
+
+        window.addEventListener('resize', () => {
+            views.forEach(v => {
+                v.camera.aspect = v.container.clientWidth / v.container.clientHeight;
+                v.camera.updateProjectionMatrix();
+                v.renderer.setSize(v.container.clientWidth, v.container.clientHeight);
+            });
+        });
+    </script>
+</body>
+</html>`
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"binpacker/pkg/packing"
+)
+
+func TestCompareViewRequiresSameItems(t *testing.T) {
+	boxes := []packing.InputBox{{ID: "box", W: 10, H: 10, D: 10}}
+	pack := func(itemIDs ...string) string {
+		resp := PackResponse{PackID: newID(IDPrefixPack), PackedBoxes: []packing.PackedBox{{BoxID: "box"}}}
+		for _, id := range itemIDs {
+			resp.PackedBoxes[0].Contents = append(resp.PackedBoxes[0].Contents, packing.Placement{ItemID: id, W: 1, H: 1, D: 1})
+		}
+		store.SavePack("", "", resp, boxes)
+		return resp.PackID
+	}
+	a, b, other := pack("x", "y"), pack("y", "x"), pack("x", "z")
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		Packer(rec, httptest.NewRequest(http.MethodGet, "/visualize/compare?"+query, nil))
+		return rec
+	}
+
+	rec := get("a=" + a + "&b=" + b)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, a) || !strings.Contains(body, b) {
+		t.Error("Expected both pack IDs in the compare view")
+	}
+
+	if rec := get("a=" + a + "&b=" + other); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for different items, got %d", rec.Code)
+	}
+	if rec := get("a=" + a + "&b=pk_missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown pack, got %d", rec.Code)
+	}
+}
+
+func TestStatsDiffMarksImprovements(t *testing.T) {
+	rows := statsDiff(PackStats{Boxes: 3, Utilization: 50}, PackStats{Boxes: 2, Utilization: 75})
+
+	if rows[0].Delta != "-1" || !rows[0].Better {
+		t.Errorf("Expected one box fewer to be better, got %+v", rows[0])
+	}
+	if rows[4].Delta != "+25.0" || !rows[4].Better {
+		t.Errorf("Expected higher utilization to be better, got %+v", rows[4])
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"binpacker/pkg/packing"
+)
+
+// maxConsolidateOrders bounds the pairwise merge search, which repacks every
+// pair of groups on each round.
+const maxConsolidateOrders = 50
+
+// ConsolidateRequest holds pending orders going to the same address.
+type ConsolidateRequest struct {
+	Orders []Order            `json:"orders"`
+	Boxes  []packing.InputBox `json:"boxes"`
+	packing.Options
+}
+
+// Order is a set of items that would normally ship on its own.
+type Order struct {
+	ID    string              `json:"id"`
+	Items []packing.InputItem `json:"items"`
+}
+
+// ConsolidateResponse compares shipping orders separately with the suggested
+// merged shipments.
+type ConsolidateResponse struct {
+	SeparateBoxes     int                `json:"separate_boxes"`
+	ConsolidatedBoxes int                `json:"consolidated_boxes"`
+	BoxesSaved        int                `json:"boxes_saved"`
+	Shipments         []ShipmentProposal `json:"shipments"`
+}
+
+// ShipmentProposal is a group of orders packed together.
+type ShipmentProposal struct {
+	OrderIDs      []string            `json:"order_ids"`
+	SeparateBoxes int                 `json:"separate_boxes"`
+	PackedBoxes   []packing.PackedBox `json:"packed_boxes"`
+	UnpackedItems []packing.InputItem `json:"unpacked_items"`
+}
+
+type orderGroup struct {
+	orderIDs      []string
+	items         []packing.InputItem
+	separateBoxes int
+	packed        []packing.PackedBox
+	unpacked      []packing.InputItem
+}
+
+// consolidate greedily merges the pair of groups that saves the most boxes
+// until no merge saves anything. Merges that would leave more items unpacked
+// are never taken.
+func consolidate(orders []Order, boxes []packing.InputBox, opts packing.Options) ConsolidateResponse {
+	groups := make([]orderGroup, 0, len(orders))
+	separate := 0
+	for _, o := range orders {
+		packed, unpacked := packing.PackWithOptions(o.Items, boxes, opts)
+		groups = append(groups, orderGroup{
+			orderIDs:      []string{o.ID},
+			items:         o.Items,
+			separateBoxes: len(packed),
+			packed:        packed,
+			unpacked:      unpacked,
+		})
+		separate += len(packed)
+	}
+
+	for {
+		bestI, bestJ, bestSaving := -1, -1, 0
+		var best orderGroup
+
+		for i := range groups {
+			for j := i + 1; j < len(groups); j++ {
+				merged := mergeGroups(groups[i], groups[j], boxes, opts)
+				if len(merged.unpacked) > len(groups[i].unpacked)+len(groups[j].unpacked) {
+					continue
+				}
+				saving := len(groups[i].packed) + len(groups[j].packed) - len(merged.packed)
+				if saving > bestSaving {
+					bestI, bestJ, bestSaving, best = i, j, saving, merged
+				}
+			}
+		}
+
+		if bestI == -1 {
+			break
+		}
+		groups[bestI] = best
+		groups = slices.Delete(groups, bestJ, bestJ+1)
+	}
+
+	resp := ConsolidateResponse{SeparateBoxes: separate}
+	for _, g := range groups {
+		resp.ConsolidatedBoxes += len(g.packed)
+		resp.Shipments = append(resp.Shipments, ShipmentProposal{
+			OrderIDs:      g.orderIDs,
+			SeparateBoxes: g.separateBoxes,
+			PackedBoxes:   g.packed,
+			UnpackedItems: g.unpacked,
+		})
+	}
+	resp.BoxesSaved = resp.SeparateBoxes - resp.ConsolidatedBoxes
+	return resp
+}
+
+func mergeGroups(a, b orderGroup, boxes []packing.InputBox, opts packing.Options) orderGroup {
+	items := slices.Concat(a.items, b.items)
+	packed, unpacked := packing.PackWithOptions(items, boxes, opts)
+	return orderGroup{
+		orderIDs:      slices.Concat(a.orderIDs, b.orderIDs),
+		items:         items,
+		separateBoxes: a.separateBoxes + b.separateBoxes,
+		packed:        packed,
+		unpacked:      unpacked,
+	}
+}
+
+func handleConsolidate(w http.ResponseWriter, r *http.Request) {
+	var req ConsolidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Orders) == 0 || len(req.Boxes) == 0 {
+		http.Error(w, "Orders and Boxes are required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Orders) > maxConsolidateOrders {
+		http.Error(w, "Too many orders to consolidate in one request", http.StatusBadRequest)
+		return
+	}
+	limits := getSettings().Limits
+	if err := validateOrders(req.Orders, limits); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	if err := validateBoxes("boxes", req.Boxes, limits); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	if err := req.Options.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	release, ok := acquirePackSlot(w, r, LaneBatch)
+	if !ok {
+		return
+	}
+	defer release()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(consolidate(req.Orders, req.Boxes, req.Options))
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"binpacker/pkg/packing"
+)
+
+func TestConsolidateSavesBoxes(t *testing.T) {
+	orders := []Order{
+		{ID: "order-1", Items: []packing.InputItem{{ID: "a", W: 10, H: 10, D: 10, Quantity: 2}}},
+		{ID: "order-2", Items: []packing.InputItem{{ID: "b", W: 10, H: 10, D: 10, Quantity: 2}}},
+		{ID: "order-3", Items: []packing.InputItem{{ID: "c", W: 20, H: 20, D: 20, Quantity: 1}}},
+	}
+	boxes := []packing.InputBox{
+		{ID: "box", W: 20, H: 20, D: 20},
+	}
+
+	resp := consolidate(orders, boxes, packing.Options{})
+
+	if resp.SeparateBoxes != 3 {
+		t.Errorf("Expected 3 boxes when shipped separately, got %d", resp.SeparateBoxes)
+	}
+	if resp.ConsolidatedBoxes != 2 || resp.BoxesSaved != 1 {
+		t.Errorf("Expected 2 consolidated boxes saving 1, got %d saving %d", resp.ConsolidatedBoxes, resp.BoxesSaved)
+	}
+	if len(resp.Shipments) != 2 {
+		t.Fatalf("Expected 2 shipments, got %d", len(resp.Shipments))
+	}
+	if ids := resp.Shipments[0].OrderIDs; len(ids) != 2 || ids[0] != "order-1" || ids[1] != "order-2" {
+		t.Errorf("Expected order-1 and order-2 to be merged, got %v", ids)
+	}
+}
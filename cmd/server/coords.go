@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"binpacker/pkg/packing"
+	"binpacker/pkg/visualize"
+)
+
+// Placements are computed and stored in one canonical frame, shared with the
+// 3D visualization (three.js is Y-up too):
+//
+//   - the origin is the back-left-bottom corner of the box,
+//   - X runs along the box width (W) to the right,
+//   - Y runs along the box height (H) upwards,
+//   - Z runs along the box depth (D) towards the front,
+//
+// which is right-handed. Other frames are produced only at the API boundary
+// by converting the canonical placements, so the visualization and the JSON
+// always describe the same layout.
+const (
+	FrameYUp = "y_up"
+	FrameZUp = "z_up"
+
+	HandRight = "right"
+	HandLeft  = "left"
+)
+
+// CoordinateFrame describes the frame placements are returned in. Every axis
+// starts at the Origin corner and points into the box, so coordinates are
+// never negative; W, H and D stay the extents along X, Y and Z. In JSON it
+// may also be given as just the up axis, e.g. "z_up".
+type CoordinateFrame struct {
+	// Up is the vertical axis: "y_up" or "z_up".
+	Up string `json:"up,omitempty"`
+	// Origin is the box corner at (0, 0, 0), written
+	// "{back|front}_{left|right}_{bottom|top}". Left and right are seen
+	// from the front. The default depends on Up and Handedness, see
+	// resolve.
+	Origin string `json:"origin,omitempty"`
+	// Handedness is "right" (default) or "left".
+	Handedness string `json:"handedness,omitempty"`
+}
+
+func (f *CoordinateFrame) UnmarshalJSON(b []byte) error {
+	var up string
+	if err := json.Unmarshal(b, &up); err == nil {
+		*f = CoordinateFrame{Up: up}
+		return nil
+	}
+	type plain CoordinateFrame
+	return json.Unmarshal(b, (*plain)(f))
+}
+
+// frameAxis maps one output axis onto a canonical axis (0 = X, 1 = Y, 2 = Z).
+// A reversed axis runs against the canonical one, from the far side.
+type frameAxis struct {
+	canonical int
+	reversed  bool
+}
+
+// resolve fills in defaults and works out the axis mapping. Without an
+// Origin, the corner is chosen so X runs along the width to the right:
+// back-left-bottom for right-handed Y-up, front-left-bottom for right-handed
+// Z-up and left-handed Y-up (as in Unity), back-left-bottom for left-handed
+// Z-up. When an explicit Origin cannot give the requested handedness with X
+// along the width, the two horizontal axes are swapped.
+func (f CoordinateFrame) resolve() (CoordinateFrame, [3]frameAxis, error) {
+	if f.Up == "" {
+		f.Up = FrameYUp
+	}
+	if f.Handedness == "" {
+		f.Handedness = HandRight
+	}
+	if f.Up != FrameYUp && f.Up != FrameZUp {
+		return f, [3]frameAxis{}, fmt.Errorf("unknown coordinate_frame up axis %q: use %q or %q", f.Up, FrameYUp, FrameZUp)
+	}
+	if f.Handedness != HandRight && f.Handedness != HandLeft {
+		return f, [3]frameAxis{}, fmt.Errorf("unknown coordinate_frame handedness %q: use %q or %q", f.Handedness, HandRight, HandLeft)
+	}
+	if f.Origin == "" {
+		f.Origin = "back_left_bottom"
+		if (f.Up == FrameZUp) == (f.Handedness == HandRight) {
+			f.Origin = "front_left_bottom"
+		}
+	}
+
+	parts := strings.Split(f.Origin, "_")
+	if len(parts) != 3 ||
+		(parts[0] != "back" && parts[0] != "front") ||
+		(parts[1] != "left" && parts[1] != "right") ||
+		(parts[2] != "bottom" && parts[2] != "top") {
+		return f, [3]frameAxis{}, fmt.Errorf("invalid coordinate_frame origin %q: use e.g. %q", f.Origin, "back_left_bottom")
+	}
+	width := frameAxis{canonical: 0, reversed: parts[1] == "right"}
+	height := frameAxis{canonical: 1, reversed: parts[2] == "top"}
+	depth := frameAxis{canonical: 2, reversed: parts[0] == "front"}
+
+	axes := [3]frameAxis{width, height, depth}
+	if f.Up == FrameZUp {
+		axes = [3]frameAxis{width, depth, height}
+	}
+	if axesRightHanded(axes) != (f.Handedness == HandRight) {
+		horizontal := 2
+		if f.Up == FrameZUp {
+			horizontal = 1
+		}
+		axes[0], axes[horizontal] = axes[horizontal], axes[0]
+	}
+	return f, axes, nil
+}
+
+// axesRightHanded reports whether the mapped axes form a right-handed frame,
+// from the sign of the permutation and the number of reversed axes.
+func axesRightHanded(axes [3]frameAxis) bool {
+	right := true
+	for i := range axes {
+		for j := i + 1; j < 3; j++ {
+			if axes[i].canonical > axes[j].canonical {
+				right = !right
+			}
+		}
+		if axes[i].reversed {
+			right = !right
+		}
+	}
+	return right
+}
+
+func validateFrame(f CoordinateFrame) error {
+	_, _, err := f.resolve()
+	return err
+}
+
+// toFrame converts a canonical placement in box into the frame given by axes.
+func toFrame(p packing.Placement, box packing.InputBox, axes [3]frameAxis) packing.Placement {
+	pos := [3]int{p.X, p.Y, p.Z}
+	ext := [3]int{p.W, p.H, p.D}
+	size := [3]int{box.W, box.H, box.D}
+
+	var outPos, outExt [3]int
+	for i, a := range axes {
+		outExt[i] = ext[a.canonical]
+		outPos[i] = pos[a.canonical]
+		if a.reversed {
+			outPos[i] = size[a.canonical] - pos[a.canonical] - ext[a.canonical]
+		}
+	}
+	return packing.Placement{ItemID: p.ItemID, X: outPos[0], Y: outPos[1], Z: outPos[2], W: outExt[0], H: outExt[1], D: outExt[2], Weight: p.Weight}
+}
+
+// fromFrame is the inverse of toFrame.
+func fromFrame(p packing.Placement, box packing.InputBox, axes [3]frameAxis) packing.Placement {
+	pos := [3]int{p.X, p.Y, p.Z}
+	ext := [3]int{p.W, p.H, p.D}
+	size := [3]int{box.W, box.H, box.D}
+
+	var cPos, cExt [3]int
+	for i, a := range axes {
+		cExt[a.canonical] = ext[i]
+		cPos[a.canonical] = pos[i]
+		if a.reversed {
+			cPos[a.canonical] = size[a.canonical] - pos[i] - ext[i]
+		}
+	}
+	return packing.Placement{ItemID: p.ItemID, X: cPos[0], Y: cPos[1], Z: cPos[2], W: cExt[0], H: cExt[1], D: cExt[2], Weight: p.Weight}
+}
+
+// inFrame returns a copy of resp with its placements converted into its
+// CoordinateFrame. resp itself is left in the canonical frame.
+func (resp PackResponse) inFrame(boxes []packing.InputBox) PackResponse {
+	if resp.CoordinateFrame == nil {
+		return resp
+	}
+	_, axes, err := resp.CoordinateFrame.resolve()
+	if err != nil || axes == canonicalAxes {
+		return resp
+	}
+
+	byID := packing.BoxesByID(boxes)
+	packed := make([]packing.PackedBox, len(resp.PackedBoxes))
+	for i, pb := range resp.PackedBoxes {
+		contents := make([]packing.Placement, len(pb.Contents))
+		for j, p := range pb.Contents {
+			contents[j] = toFrame(p, byID[pb.BoxID], axes)
+		}
+		box := toFrame(packing.Placement{W: pb.W, H: pb.H, D: pb.D}, byID[pb.BoxID], axes)
+		pb.Contents = contents
+		pb.W, pb.H, pb.D = box.W, box.H, box.D
+		packed[i] = pb
+	}
+	resp.PackedBoxes = packed
+	return resp
+}
+
+var canonicalAxes = [3]frameAxis{{canonical: 0}, {canonical: 1}, {canonical: 2}}
+
+// newFrameGizmo tells the visualization where to draw the axes of f.
+func newFrameGizmo(f CoordinateFrame) (visualize.Frame, error) {
+	f, axes, err := f.resolve()
+	if err != nil {
+		return visualize.Frame{}, err
+	}
+	g := visualize.Frame{
+		Label: fmt.Sprintf("%s, origin %s, %s-handed",
+			strings.ToUpper(f.Up[:1])+"-up", strings.ReplaceAll(f.Origin, "_", "-"), f.Handedness),
+	}
+	for i, a := range axes {
+		dir := 1
+		if a.reversed {
+			dir = -1
+			g.Origin[a.canonical] = 1
+		}
+		g.Axes[i][a.canonical] = dir
+	}
+	return g, nil
+}
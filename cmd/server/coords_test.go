@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"binpacker/pkg/packing"
+	"binpacker/pkg/visualize"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	box := packing.InputBox{ID: "box", W: 30, H: 20, D: 10}
+	p := packing.Placement{ItemID: "a", X: 1, Y: 2, Z: 3, W: 4, H: 5, D: 6}
+
+	_, axes, err := CoordinateFrame{Up: FrameZUp}.resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := toFrame(p, box, axes)
+	// Height becomes Z, and depth is measured from the front.
+	want := packing.Placement{ItemID: "a", X: 1, Y: 10 - 3 - 6, Z: 2, W: 4, H: 6, D: 5}
+	if z != want {
+		t.Errorf("Expected %+v, got %+v", want, z)
+	}
+	if back := fromFrame(z, box, axes); back != p {
+		t.Errorf("Expected the round trip to return %+v, got %+v", p, back)
+	}
+
+	if _, axes, _ := (CoordinateFrame{}).resolve(); axes != canonicalAxes {
+		t.Errorf("Expected the default frame to be canonical, got %+v", axes)
+	}
+}
+
+func TestFrameHandedness(t *testing.T) {
+	cases := []struct {
+		frame  CoordinateFrame
+		origin string
+	}{
+		{CoordinateFrame{Up: FrameYUp, Handedness: HandRight}, "back_left_bottom"},
+		{CoordinateFrame{Up: FrameYUp, Handedness: HandLeft}, "front_left_bottom"},
+		{CoordinateFrame{Up: FrameZUp, Handedness: HandRight}, "front_left_bottom"},
+		{CoordinateFrame{Up: FrameZUp, Handedness: HandLeft}, "back_left_bottom"},
+		// Explicit origins that need the horizontal axes swapped.
+		{CoordinateFrame{Up: FrameYUp, Handedness: HandRight, Origin: "front_left_top"}, "front_left_top"},
+		{CoordinateFrame{Up: FrameZUp, Handedness: HandLeft, Origin: "front_right_bottom"}, "front_right_bottom"},
+	}
+	box := packing.InputBox{W: 30, H: 20, D: 10}
+	p := packing.Placement{X: 1, Y: 2, Z: 3, W: 4, H: 5, D: 6}
+	for _, c := range cases {
+		f, axes, err := c.frame.resolve()
+		if err != nil {
+			t.Fatalf("%+v: expected no error, got %v", c.frame, err)
+		}
+		if f.Origin != c.origin {
+			t.Errorf("%+v: expected origin %s, got %s", c.frame, c.origin, f.Origin)
+		}
+		if axesRightHanded(axes) != (c.frame.Handedness == HandRight) {
+			t.Errorf("%+v: expected a %s-handed frame", c.frame, c.frame.Handedness)
+		}
+		out := toFrame(p, box, axes)
+		if out.X < 0 || out.Y < 0 || out.Z < 0 {
+			t.Errorf("%+v: expected non-negative coordinates, got %+v", c.frame, out)
+		}
+		if back := fromFrame(out, box, axes); back != p {
+			t.Errorf("%+v: expected the round trip to return %+v, got %+v", c.frame, p, back)
+		}
+	}
+
+	if err := validateFrame(CoordinateFrame{Origin: "middle"}); err == nil {
+		t.Error("Expected an invalid origin to be rejected")
+	}
+}
+
+func TestCoordinateFrameJSON(t *testing.T) {
+	var req PackRequest
+	if err := json.Unmarshal([]byte(`{"coordinate_frame": "z_up"}`), &req); err != nil || req.CoordinateFrame.Up != FrameZUp {
+		t.Errorf("Expected the string shorthand to set the up axis, got %+v (%v)", req.CoordinateFrame, err)
+	}
+	if err := json.Unmarshal([]byte(`{"coordinate_frame": {"up": "y_up", "handedness": "left"}}`), &req); err != nil || req.CoordinateFrame.Handedness != HandLeft {
+		t.Errorf("Expected the object form to be accepted, got %+v (%v)", req.CoordinateFrame, err)
+	}
+}
+
+func TestPackResponseInFrameLeavesCanonicalCopy(t *testing.T) {
+	boxes := []packing.InputBox{{ID: "box", W: 10, H: 10, D: 10}}
+	packed, _ := packing.Pack([]packing.InputItem{{ID: "a", W: 10, H: 5, D: 4, Quantity: 1}}, boxes)
+	resp := newPackResponse(packed, nil, boxes)
+	resp.CoordinateFrame = &CoordinateFrame{Up: FrameZUp}
+
+	out := resp.inFrame(boxes)
+	if out.PackedBoxes[0].Contents[0] == resp.PackedBoxes[0].Contents[0] {
+		t.Error("Expected the z_up placement to differ from the canonical one")
+	}
+	if err := packing.CheckLayout(resp.PackedBoxes, boxes); err != nil {
+		t.Errorf("Expected the canonical layout to pass the check, got %v", err)
+	}
+}
+
+func TestVisualizationShowsFrameAxes(t *testing.T) {
+	g, err := newFrameGizmo(CoordinateFrame{Up: FrameZUp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Origin != [3]int{0, 0, 1} || g.Axes[1] != [3]int{0, 0, -1} || g.Axes[2] != [3]int{0, 1, 0} {
+		t.Errorf("Expected Z-up axes from the front-left-bottom corner, got %+v", g)
+	}
+
+	html, err := visualize.HTML(visualize.Data{Frame: &g})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html, "Z-up, origin front-left-bottom, right-handed") {
+		t.Error("Expected the frame label in the info panel")
+	}
+}
+
+func TestCheckLayout(t *testing.T) {
+	boxes := []packing.InputBox{{ID: "box", W: 10, H: 10, D: 10}}
+	overlap := []packing.PackedBox{{BoxID: "box", Contents: []packing.Placement{
+		{ItemID: "a", W: 5, H: 5, D: 5},
+		{ItemID: "b", X: 4, W: 5, H: 5, D: 5},
+	}}}
+	if err := packing.CheckLayout(overlap, boxes); err == nil {
+		t.Error("Expected overlapping items to fail the check")
+	}
+	outside := []packing.PackedBox{{BoxID: "box", Contents: []packing.Placement{{ItemID: "a", Y: 6, W: 5, H: 5, D: 5}}}}
+	if err := packing.CheckLayout(outside, boxes); err == nil {
+		t.Error("Expected an item sticking out of the box to fail the check")
+	}
+}
+
+func TestPackedBoxSizeInFrame(t *testing.T) {
+	boxes := []packing.InputBox{{ID: "box", W: 30, H: 20, D: 10}}
+	packed, _ := packing.Pack([]packing.InputItem{{ID: "a", W: 10, H: 10, D: 10, Quantity: 3}}, boxes)
+	resp := newPackResponse(packed, nil, boxes)
+
+	pb := resp.PackedBoxes[0]
+	if pb.UsedVolume != 3000 || pb.FreeVolume != 3000 || pb.Utilization != 50 {
+		t.Errorf("Expected 3000 used, 3000 free and 50%% utilization, got %+v", pb)
+	}
+
+	resp.CoordinateFrame = &CoordinateFrame{Up: FrameZUp}
+	if out := resp.inFrame(boxes).PackedBoxes[0]; out.W != 30 || out.H != 10 || out.D != 20 {
+		t.Errorf("Expected the z_up box size to be 30x10x20, got %dx%dx%d", out.W, out.H, out.D)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"binpacker/pkg/packing"
+)
+
+// decodePackRequest reads a /pack body. Besides JSON it takes CSV, so
+// spreadsheets exported from a WMS can be uploaded as they are; see
+// packing.ReadItemsCSV for the columns.
+//
+//   - text/csv: the items, with the boxes from the profile query parameter
+//     and the units from units.
+//   - multipart/form-data: "items" and "boxes" CSV files, and optionally a
+//     "request" field holding the other fields as JSON.
+func decodePackRequest(r *http.Request) (PackRequest, error) {
+	var req PackRequest
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		req.Profile = r.URL.Query().Get("profile")
+		req.Units = r.URL.Query().Get("units")
+		if req.Profile == "" {
+			return req, errors.New("a text/csv body holds the items only: name a box profile with ?profile=, or upload items and boxes as multipart/form-data")
+		}
+		var err error
+		req.Items, err = packing.ReadItemsCSV(r.Body)
+		return req, err
+	case "multipart/form-data":
+		return decodeMultipartPackRequest(r)
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, errors.New("Invalid JSON")
+	}
+	return req, nil
+}
+
+func decodeMultipartPackRequest(r *http.Request) (PackRequest, error) {
+	var req PackRequest
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return req, err
+	}
+	var items []packing.InputItem
+	var boxes []packing.InputBox
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return req, err
+		}
+		switch part.FormName() {
+		case "items":
+			items, err = packing.ReadItemsCSV(part)
+		case "boxes":
+			boxes, err = packing.ReadBoxesCSV(part)
+		case "request":
+			if json.NewDecoder(part).Decode(&req) != nil {
+				err = errors.New("invalid JSON")
+			}
+		default:
+			err = errors.New("unknown field: use items, boxes and request")
+		}
+		if err != nil {
+			return req, fmt.Errorf("%s: %w", part.FormName(), err)
+		}
+	}
+	// The CSV files come in addition to any items and boxes in request.
+	req.Items = append(req.Items, items...)
+	req.Boxes = append(req.Boxes, boxes...)
+	return req, nil
+}
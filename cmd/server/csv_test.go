@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPackMultipartCSV(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	items, _ := mw.CreateFormFile("items", "items.csv")
+	items.Write([]byte("id,w,h,d,quantity,weight\nmug,5,5,5,9,0.5\n"))
+	boxes, _ := mw.CreateFormFile("boxes", "boxes.csv")
+	boxes.Write([]byte("id,w,h,d\nsmall,10,10,10\n"))
+	mw.WriteField("request", `{"visualization": "none"}`)
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/pack", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	Packer(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var resp PackResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.PackedBoxes) != 2 || resp.PackedBoxes[0].TotalWeight != 4 || resp.VisualizationURL != "" {
+		t.Errorf("Expected 9 mugs in 2 boxes without a visualization, got %+v", resp)
+	}
+}
+
+func TestDecodeCSVPackRequest(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/pack?profile=warehouse-a&units=cm", strings.NewReader("id,w,h,d,quantity\nmug,8.5,10,8,4\n"))
+	r.Header.Set("Content-Type", "text/csv; charset=utf-8")
+	req, err := decodePackRequest(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Profile != "warehouse-a" || req.Units != "cm" || len(req.Items) != 1 || req.Items[0].Size == nil {
+		t.Errorf("Expected one item with decimal sides for the warehouse-a profile, got %+v", req)
+	}
+
+	for name, r := range map[string]*http.Request{
+		"no profile": httptest.NewRequest(http.MethodPost, "/pack", strings.NewReader("id,w,h,d,quantity\nmug,8,10,8,4\n")),
+		"bad column": httptest.NewRequest(http.MethodPost, "/pack?profile=a", strings.NewReader("id,w,h,d,colour\nmug,8,10,8,red\n")),
+	} {
+		r.Header.Set("Content-Type", "text/csv")
+		rec := httptest.NewRecorder()
+		Packer(rec, r)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", name, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBaseCurrency = "USD"
+	defaultRatesTTL     = time.Hour
+)
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// RateProvider returns how many units of to one unit of from is worth.
+type RateProvider interface {
+	Rate(ctx context.Context, from, to string) (float64, error)
+}
+
+// rates converts cost reports; main replaces it from the environment.
+var rates RateProvider = staticRates{base: defaultBaseCurrency}
+
+// baseCurrency is the currency costs are given in when a request does not
+// say otherwise.
+var baseCurrency = defaultBaseCurrency
+
+// staticRates is a fixed table of units per one unit of base.
+type staticRates struct {
+	base  string
+	table map[string]float64
+}
+
+func (s staticRates) perBase(code string) (float64, bool) {
+	if code == s.base {
+		return 1, true
+	}
+	r, ok := s.table[code]
+	return r, ok && r > 0
+}
+
+func (s staticRates) Rate(_ context.Context, from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	f, ok := s.perBase(from)
+	if !ok {
+		return 0, fmt.Errorf("no exchange rate for %s", from)
+	}
+	t, ok := s.perBase(to)
+	if !ok {
+		return 0, fmt.Errorf("no exchange rate for %s", to)
+	}
+	return t / f, nil
+}
+
+// parseRates reads "EUR=0.92,GBP=0.79": units of each currency per one unit
+// of base.
+func parseRates(base, list string) (staticRates, error) {
+	s := staticRates{base: base, table: make(map[string]float64)}
+	for _, entry := range splitList(list) {
+		code, v, ok := strings.Cut(entry, "=")
+		r, err := strconv.ParseFloat(v, 64)
+		if !ok || err != nil || r <= 0 || !currencyPattern.MatchString(code) {
+			return staticRates{}, fmt.Errorf("invalid rate %q: use e.g. EUR=0.92", entry)
+		}
+		s.table[code] = r
+	}
+	return s, nil
+}
+
+// httpRates fetches a table of the form {"base": "USD", "rates": {"EUR":
+// 0.92}} and keeps it for ttl. When a refresh fails, the last table is used.
+type httpRates struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	mu      sync.Mutex
+	table   staticRates
+	fetched time.Time
+}
+
+func (h *httpRates) Rate(ctx context.Context, from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if time.Since(h.fetched) >= h.ttl {
+		if err := h.refresh(ctx); err != nil && h.fetched.IsZero() {
+			return 0, fmt.Errorf("exchange rates unavailable: %w", err)
+		}
+	}
+	return h.table.Rate(ctx, from, to)
+}
+
+func (h *httpRates) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("rates request: %s", resp.Status)
+	}
+	var body struct {
+		Base  string             `json:"base"`
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("decode rates: %w", err)
+	}
+	if !currencyPattern.MatchString(body.Base) {
+		return fmt.Errorf("rates have no valid base currency")
+	}
+	h.table = staticRates{base: body.Base, table: body.Rates}
+	h.fetched = time.Now()
+	return nil
+}
+
+// ratesFromEnv configures currency conversion: CURRENCY_RATES_URL for a rates
+// service, or a static CURRENCY_RATES table relative to CURRENCY_BASE.
+func ratesFromEnv() (RateProvider, string, error) {
+	base := defaultBaseCurrency
+	if v := os.Getenv("CURRENCY_BASE"); v != "" {
+		if !currencyPattern.MatchString(v) {
+			return nil, "", fmt.Errorf("invalid CURRENCY_BASE %q", v)
+		}
+		base = v
+	}
+	if u := os.Getenv("CURRENCY_RATES_URL"); u != "" {
+		ttl := defaultRatesTTL
+		if v := os.Getenv("CURRENCY_RATES_TTL"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				return nil, "", fmt.Errorf("invalid CURRENCY_RATES_TTL %q", v)
+			}
+			ttl = d
+		}
+		return &httpRates{url: u, ttl: ttl, client: &http.Client{Timeout: 10 * time.Second}}, base, nil
+	}
+	s, err := parseRates(base, os.Getenv("CURRENCY_RATES"))
+	if err != nil {
+		return nil, "", err
+	}
+	return s, base, nil
+}
+
+// CurrencyOptions select the currency of cost reports. Costs are given in
+// CostCurrency (default: the server's base currency) and reported in
+// Currency (default: CostCurrency).
+type CurrencyOptions struct {
+	CostCurrency string `json:"cost_currency,omitempty"`
+	Currency     string `json:"currency,omitempty"`
+}
+
+// conversion returns the rate to multiply costs by and the currency they are
+// then in.
+func (o CurrencyOptions) conversion(ctx context.Context) (float64, string, error) {
+	from := o.CostCurrency
+	if from == "" {
+		from = baseCurrency
+	}
+	to := o.Currency
+	if to == "" {
+		to = from
+	}
+	for _, c := range []string{from, to} {
+		if !currencyPattern.MatchString(c) {
+			return 0, "", fmt.Errorf("invalid currency %q: use an ISO 4217 code such as EUR", c)
+		}
+	}
+	rate, err := rates.Rate(ctx, from, to)
+	if err != nil {
+		return 0, "", err
+	}
+	return rate, to, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"binpacker/pkg/packing"
+)
+
+func TestStaticRates(t *testing.T) {
+	r, err := parseRates("USD", "EUR=0.8, GBP=0.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := r.Rate(context.Background(), "EUR", "GBP"); got != 0.625 {
+		t.Errorf("Expected EUR->GBP 0.625, got %g", got)
+	}
+	if _, err := r.Rate(context.Background(), "USD", "JPY"); err == nil {
+		t.Error("Expected an error for a currency without a rate")
+	}
+	if _, err := parseRates("USD", "EUR=abc"); err == nil {
+		t.Error("Expected an invalid rate to be rejected")
+	}
+}
+
+func TestHTTPRatesKeepLastTable(t *testing.T) {
+	fail := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"base": "EUR", "rates": {"USD": 1.25}}`))
+	}))
+	defer srv.Close()
+
+	h := &httpRates{url: srv.URL, ttl: time.Nanosecond, client: srv.Client()}
+	if got, err := h.Rate(context.Background(), "USD", "EUR"); err != nil || got != 0.8 {
+		t.Errorf("Expected USD->EUR 0.8, got %g (%v)", got, err)
+	}
+	fail = true
+	if got, err := h.Rate(context.Background(), "USD", "EUR"); err != nil || got != 0.8 {
+		t.Errorf("Expected the last rates after a failed refresh, got %g (%v)", got, err)
+	}
+}
+
+func TestSimulateCatalogCurrency(t *testing.T) {
+	defer func(p RateProvider) { rates = p }(rates)
+	rates, _ = parseRates("USD", "EUR=0.5")
+
+	req := SimulateCatalogRequest{
+		Orders:          []Order{{ID: "o1", Items: []packing.InputItem{{ID: "a", W: 5, H: 5, D: 5, Quantity: 1}}}},
+		CurrentBoxes:    []packing.InputBox{{ID: "box", W: 10, H: 10, D: 10, Cost: 3}},
+		ProposedBoxes:   []packing.InputBox{{ID: "box", W: 10, H: 10, D: 10, Cost: 2}},
+		CurrencyOptions: CurrencyOptions{Currency: "EUR"},
+	}
+	post := func() *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		rec := httptest.NewRecorder()
+		Packer(rec, httptest.NewRequest(http.MethodPost, "/simulate-catalog", bytes.NewReader(body)))
+		return rec
+	}
+
+	rec := post()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var resp SimulateCatalogResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.Currency != "EUR" || math.Abs(resp.Current.Cost-1.5) > 1e-9 || math.Abs(resp.Delta.Cost+0.5) > 1e-9 {
+		t.Errorf("Expected costs in EUR (1.5, delta -0.5), got %+v", resp)
+	}
+
+	req.Currency = "JPY"
+	if rec := post(); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown currency, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Stored packs and visualizations describe what customers ship, so they can
+// be encrypted before they reach the storage backend. Each value is sealed
+// with AES-256-GCM under its storage key as additional data, which stops a
+// record from being read back under another key, and so from being moved
+// into another tenant's namespace.
+//
+// STORAGE_ENCRYPTION_KEY holds the base64 encoded 32-byte key, or
+// STORAGE_ENCRYPTION_KEY_FILE names a file holding it, e.g. one written by a
+// KMS or secrets manager agent. Keys listed in STORAGE_ENCRYPTION_OLD_KEYS
+// still decrypt, so the key can be rotated without losing stored records.
+// Records written before encryption was enabled are read as they are.
+
+// encryptedMagic starts every sealed value. JSON records never start with it.
+var encryptedMagic = []byte("enc1")
+
+const encryptionKeyIDLen = 4
+
+// encryptedStorage seals values on their way into a Storage backend and
+// opens them on their way out. Keys are stored in the clear.
+type encryptedStorage struct {
+	Storage
+	keyID string
+	keys  map[string]cipher.AEAD
+}
+
+// encryptedStorageFromEnv wraps backend in encryption if a key is set, and
+// returns it unchanged otherwise.
+func encryptedStorageFromEnv(backend Storage) (Storage, error) {
+	key := os.Getenv("STORAGE_ENCRYPTION_KEY")
+	if path := os.Getenv("STORAGE_ENCRYPTION_KEY_FILE"); path != "" {
+		if key != "" {
+			return nil, errors.New("set STORAGE_ENCRYPTION_KEY or STORAGE_ENCRYPTION_KEY_FILE, not both")
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key = strings.TrimSpace(string(data))
+	}
+	if key == "" {
+		if os.Getenv("STORAGE_ENCRYPTION_OLD_KEYS") != "" {
+			return nil, errors.New("STORAGE_ENCRYPTION_OLD_KEYS needs a current key")
+		}
+		return backend, nil
+	}
+	return newEncryptedStorage(backend, key, splitList(os.Getenv("STORAGE_ENCRYPTION_OLD_KEYS"))...)
+}
+
+// newEncryptedStorage encrypts with key and decrypts with key or any of old.
+// Keys are base64 encoded.
+func newEncryptedStorage(backend Storage, key string, old ...string) (*encryptedStorage, error) {
+	s := &encryptedStorage{Storage: backend, keys: make(map[string]cipher.AEAD)}
+	for i, k := range append([]string{key}, old...) {
+		raw, err := base64.StdEncoding.DecodeString(k)
+		if err != nil || len(raw) != 32 {
+			return nil, errors.New("encryption keys must be 32 bytes, base64 encoded")
+		}
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(raw)
+		id := string(sum[:encryptionKeyIDLen])
+		if i == 0 {
+			s.keyID = id
+		}
+		s.keys[id] = aead
+	}
+	return s, nil
+}
+
+// Put stores value as magic, key ID, nonce and ciphertext.
+func (s *encryptedStorage) Put(ctx context.Context, key string, value []byte) error {
+	aead := s.keys[s.keyID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	out := append(bytes.Clone(encryptedMagic), s.keyID...)
+	out = append(out, nonce...)
+	return s.Storage.Put(ctx, key, aead.Seal(out, nonce, value, []byte(key)))
+}
+
+func (s *encryptedStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := s.Storage.Get(ctx, key)
+	if err != nil || !bytes.HasPrefix(data, encryptedMagic) {
+		return data, err
+	}
+	data = data[len(encryptedMagic):]
+	if len(data) < encryptionKeyIDLen {
+		return nil, errors.New("encrypted record is truncated")
+	}
+	aead, ok := s.keys[string(data[:encryptionKeyIDLen])]
+	if !ok {
+		return nil, fmt.Errorf("record is encrypted with an unknown key %x", data[:encryptionKeyIDLen])
+	}
+	data = data[encryptionKeyIDLen:]
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("encrypted record is truncated")
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(key))
+	if err != nil {
+		return nil, errors.New("cannot decrypt record: wrong key or tampered data")
+	}
+	return plain, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"binpacker/pkg/packing"
+)
+
+// BoxExplanation answers /packs/{id}/boxes/{n}/explanation: why box n of a
+// pack, counting from 1, got its type.
+type BoxExplanation struct {
+	PackID   string              `json:"pack_id"`
+	Box      int                 `json:"box"`
+	BoxID    string              `json:"box_id"`
+	Summary  string              `json:"summary"`
+	Decision packing.BoxDecision `json:"decision"`
+}
+
+// explanationBox parses the box number out of a pack subpath of the form
+// boxes/{n}/explanation.
+func explanationBox(sub string) (int, bool) {
+	rest, ok := strings.CutPrefix(sub, "boxes/")
+	if !ok {
+		return 0, false
+	}
+	n, ok := strings.CutSuffix(rest, "/explanation")
+	if !ok {
+		return 0, false
+	}
+	box, err := strconv.Atoi(n)
+	return box, err == nil
+}
+
+// takeDecisions moves the decisions off packed, so responses round-trip
+// through JSON, and returns them by box, or nil if there are none.
+func takeDecisions(packed []packing.PackedBox) []*packing.BoxDecision {
+	var decisions []*packing.BoxDecision
+	for i := range packed {
+		if d := packed[i].Decision; d != nil {
+			if decisions == nil {
+				decisions = make([]*packing.BoxDecision, len(packed))
+			}
+			decisions[i], packed[i].Decision = d, nil
+		}
+	}
+	return decisions
+}
+
+func handleBoxExplanation(w http.ResponseWriter, r *http.Request, p storedPack, box int) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	boxes := p.Response.PackedBoxes
+	if box < 1 || box > len(boxes) {
+		http.Error(w, fmt.Sprintf("Box %d not found: the pack has %d boxes", box, len(boxes)), http.StatusNotFound)
+		return
+	}
+	var d *packing.BoxDecision
+	if box <= len(p.Decisions) {
+		d = p.Decisions[box-1]
+	}
+	if d == nil {
+		// Only the default algorithm compares box types one box at a
+		// time; balanced packing and the open-box algorithms do not.
+		http.Error(w, fmt.Sprintf("No explanation recorded for box %d", box), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(BoxExplanation{
+		PackID:   p.Response.PackID,
+		Box:      box,
+		BoxID:    boxes[box-1].BoxID,
+		Summary:  d.Summary(),
+		Decision: *d,
+	})
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"binpacker/pkg/packing"
+)
+
+func TestBoxExplanation(t *testing.T) {
+	body, _ := json.Marshal(PackRequest{
+		Items:         []packing.InputItem{{ID: "cube", W: 5, H: 5, D: 5, Quantity: 9}},
+		Boxes:         []packing.InputBox{{ID: "large", W: 20, H: 20, D: 20}, {ID: "small", W: 10, H: 10, D: 10}},
+		Visualization: VizModeNone,
+	})
+	rec := httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodPost, "/pack", bytes.NewReader(body)))
+	var resp PackResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	rec = httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodGet, "/packs/"+resp.PackID+"/boxes/1/explanation", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var ex BoxExplanation
+	if err := json.NewDecoder(rec.Body).Decode(&ex); err != nil {
+		t.Fatal(err)
+	}
+	// All nine cubes only fit the large box.
+	if ex.BoxID != "large" || ex.Decision.Rule != packing.RulePackedVolume || len(ex.Decision.Candidates) != 2 {
+		t.Errorf("Expected large chosen by packed volume out of 2 candidates, got %+v", ex)
+	}
+	if !strings.HasPrefix(ex.Summary, "large was chosen over small because it packs more volume") {
+		t.Errorf("Expected a summary of the comparison, got %q", ex.Summary)
+	}
+
+	for _, path := range []string{"/boxes/2/explanation", "/boxes/0/explanation", "/boxes/one/explanation", "/boxes/1"} {
+		rec = httptest.NewRecorder()
+		Packer(rec, httptest.NewRequest(http.MethodGet, "/packs/"+resp.PackID+path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status 404, got %d", path, rec.Code)
+		}
+	}
+}
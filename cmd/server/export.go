@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"binpacker/pkg/packing"
+)
+
+// topViewSize is the longest side of the top view image in pixels.
+const topViewSize = 480
+
+// topViewPalette matches the item colors of the 3D visualization.
+var topViewPalette = []color.RGBA{
+	{0x63, 0x66, 0xf1, 0xff}, {0xec, 0x48, 0x99, 0xff}, {0x14, 0xb8, 0xa6, 0xff}, {0xf5, 0x9e, 0x0b, 0xff},
+	{0x8b, 0x5c, 0xf6, 0xff}, {0x06, 0xb6, 0xd4, 0xff}, {0xf4, 0x3f, 0x5e, 0xff}, {0x22, 0xc5, 0x5e, 0xff},
+}
+
+// renderTopView draws a box seen from above as a PNG: width left to right,
+// back at the top and front at the bottom. Items are painted from the floor
+// up so the topmost item in each spot is the one visible.
+func renderTopView(box packing.InputBox, contents []packing.Placement) ([]byte, int, int, error) {
+	scale := float64(topViewSize) / float64(max(box.W, box.D, 1))
+	w := max(int(float64(box.W)*scale), 1)
+	h := max(int(float64(box.D)*scale), 1)
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{0xf3, 0xf4, 0xf6, 0xff}), image.Point{}, draw.Src)
+
+	order := make([]int, len(contents))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(contents[a].Y+contents[a].H, contents[b].Y+contents[b].H)
+	})
+
+	outline := color.RGBA{0x1f, 0x29, 0x37, 0xff}
+	for _, i := range order {
+		p := contents[i]
+		r := image.Rect(
+			int(float64(p.X)*scale), int(float64(p.Z)*scale),
+			int(float64(p.X+p.W)*scale), int(float64(p.Z+p.D)*scale),
+		)
+		draw.Draw(img, r, image.NewUniform(topViewPalette[i%len(topViewPalette)]), image.Point{}, draw.Src)
+		strokeRect(img, r, outline)
+	}
+	strokeRect(img, img.Bounds(), outline)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, 0, 0, err
+	}
+	return buf.Bytes(), w, h, nil
+}
+
+func strokeRect(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	if r.Empty() {
+		return
+	}
+	for x := r.Min.X; x < r.Max.X; x++ {
+		img.SetRGBA(x, r.Min.Y, c)
+		img.SetRGBA(x, r.Max.Y-1, c)
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		img.SetRGBA(r.Min.X, y, c)
+		img.SetRGBA(r.Max.X-1, y, c)
+	}
+}
+
+// loadPlanWorkbook builds the Excel load plan of a stored pack: a summary
+// sheet and one sheet per box with its placements and a top view. Tables use
+// the coordinate frame the pack was requested in; the top view is always
+// drawn from above.
+func loadPlanWorkbook(p storedPack) ([]xlsxSheet, error) {
+	resp := p.Response.inFrame(p.Boxes)
+	stats := packStats(p.Response)
+	byID := packing.BoxesByID(p.Boxes)
+
+	frame := "y_up"
+	if resp.CoordinateFrame != nil {
+		f, _, _ := resp.CoordinateFrame.resolve()
+		frame = fmt.Sprintf("%s, origin %s, %s-handed", f.Up, f.Origin, f.Handedness)
+	}
+
+	summary := xlsxSheet{
+		Name:      "Summary",
+		ColWidths: []float64{18, 24, 10, 10, 10, 10, 14},
+		Rows: [][]any{
+			{xlsxBold("Load plan"), resp.PackID},
+			{"Created", p.CreatedAt.UTC().Format(time.RFC3339)},
+			{"Boxes", stats.Boxes},
+			{"Packed items", stats.PackedItems},
+			{"Unpacked items", stats.UnpackedItems},
+			{"Box volume", resp.volume(stats.TotalVolume)},
+			{"Utilization %", round1(stats.Utilization)},
+			{"Coordinates", frame},
+			nil,
+			{xlsxBold("#"), xlsxBold("Box"), xlsxBold("W"), xlsxBold("H"), xlsxBold("D"), xlsxBold("Items"), xlsxBold("Utilization %")},
+		},
+	}
+
+	sheets := []xlsxSheet{summary}
+	for i, pb := range p.Response.PackedBoxes {
+		box := byID[pb.BoxID]
+		sheets[0].Rows = append(sheets[0].Rows, []any{i + 1, pb.BoxID, resp.length(box.W), resp.length(box.H), resp.length(box.D), len(pb.Contents), round1(pb.Utilization)})
+
+		img, w, h, err := renderTopView(box, pb.Contents)
+		if err != nil {
+			return nil, err
+		}
+		sheet := xlsxSheet{
+			Name:      fmt.Sprintf("Box %d", i+1),
+			ColWidths: []float64{6, 24, 8, 8, 8, 8, 8, 8},
+			Image:     img, ImageW: w, ImageH: h, ImageCol: 9,
+			Rows: [][]any{
+				{xlsxBold(fmt.Sprintf("Box %d: %s (%s × %s × %s)", i+1, pb.BoxID, resp.lengthText(box.W), resp.lengthText(box.H), resp.lengthText(box.D)))},
+				{"Top view: width left to right, back at the top, front at the bottom."},
+				nil,
+				{xlsxBold("#"), xlsxBold("Item"), xlsxBold("X"), xlsxBold("Y"), xlsxBold("Z"), xlsxBold("W"), xlsxBold("H"), xlsxBold("D")},
+			},
+		}
+		for j, c := range resp.PackedBoxes[i].Contents {
+			sheet.Rows = append(sheet.Rows, []any{j + 1, c.ItemID, resp.length(c.X), resp.length(c.Y), resp.length(c.Z), resp.length(c.W), resp.length(c.H), resp.length(c.D)})
+		}
+		sheets = append(sheets, sheet)
+	}
+
+	if len(resp.UnpackedItems) > 0 {
+		sheets[0].Rows = append(sheets[0].Rows, nil, []any{xlsxBold("Not packed"), xlsxBold("Item"), xlsxBold("W"), xlsxBold("H"), xlsxBold("D")})
+		for _, it := range resp.UnpackedItems {
+			sheets[0].Rows = append(sheets[0].Rows, []any{nil, it.ID, resp.length(it.W), resp.length(it.H), resp.length(it.D)})
+		}
+	}
+	return sheets, nil
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// handlePackExport serves /packs/{id}/export?format=xlsx.
+func handlePackExport(w http.ResponseWriter, r *http.Request, p storedPack) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if format := r.URL.Query().Get("format"); format != "" && format != "xlsx" {
+		http.Error(w, fmt.Sprintf("unsupported export format %q: use %q", format, "xlsx"), http.StatusBadRequest)
+		return
+	}
+
+	sheets, err := loadPlanWorkbook(p)
+	if err != nil {
+		http.Error(w, "Failed to build export", http.StatusInternalServerError)
+		return
+	}
+	var buf bytes.Buffer
+	if err := writeXLSX(&buf, sheets); err != nil {
+		http.Error(w, "Failed to build export", http.StatusInternalServerError)
+		return
+	}
+
+	name := strings.ReplaceAll(p.Response.PackID, `"`, "") + ".xlsx"
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	_, _ = w.Write(buf.Bytes())
+}
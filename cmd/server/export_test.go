@@ -0,0 +1,96 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"binpacker/pkg/packing"
+)
+
+func TestXLSXColumn(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 701: "ZZ", 702: "AAA"} {
+		if got := xlsxColumn(i); got != want {
+			t.Errorf("xlsxColumn(%d): expected %s, got %s", i, want, got)
+		}
+	}
+}
+
+func TestPackExportWorkbook(t *testing.T) {
+	boxes := []packing.InputBox{{ID: "box", W: 10, H: 10, D: 10}}
+	packed, unpacked := packing.Pack([]packing.InputItem{
+		{ID: "a & b", W: 5, H: 5, D: 5, Quantity: 3},
+		{ID: "huge", W: 50, H: 50, D: 50, Quantity: 1},
+	}, boxes)
+	resp := newPackResponse(packed, unpacked, boxes)
+	resp.PackID = newID(IDPrefixPack)
+	store.SavePack("", "", resp, boxes)
+
+	rec := httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodGet, "/packs/"+resp.PackID+"/export?format=xlsx", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatalf("Expected a zip archive, got %v", err)
+	}
+	parts := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		parts[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "xl/workbook.xml", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml", "xl/media/image2.png"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("Expected part %s in the workbook", name)
+		}
+	}
+
+	// Every XML part is well formed and every relationship target exists.
+	for name, data := range parts {
+		if !strings.HasSuffix(name, ".xml") && !strings.HasSuffix(name, ".rels") {
+			continue
+		}
+		if err := xml.Unmarshal(data, new(struct{})); err != nil {
+			t.Errorf("%s is not well-formed XML: %v", name, err)
+		}
+		if !strings.HasSuffix(name, ".rels") {
+			continue
+		}
+		var rels struct {
+			Rel []struct {
+				Target string `xml:"Target,attr"`
+			} `xml:"Relationship"`
+		}
+		_ = xml.Unmarshal(data, &rels)
+		base := path.Dir(path.Dir(name))
+		for _, r := range rels.Rel {
+			if _, ok := parts[path.Join(base, r.Target)]; !ok {
+				t.Errorf("%s points to missing part %s", name, path.Join(base, r.Target))
+			}
+		}
+	}
+
+	summary := string(parts["xl/worksheets/sheet1.xml"])
+	if !strings.Contains(summary, "huge") {
+		t.Error("Expected the unpacked item on the summary sheet")
+	}
+	if !strings.Contains(string(parts["xl/worksheets/sheet2.xml"]), "a &amp; b") {
+		t.Error("Expected item IDs to be XML-escaped on the box sheet")
+	}
+
+	rec = httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodGet, "/packs/"+resp.PackID+"/export?format=pdf", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unsupported format, got %d", rec.Code)
+	}
+}
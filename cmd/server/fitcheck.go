@@ -0,0 +1,141 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"binpacker/pkg/packing"
+)
+
+// FitCheckRequest asks which boxes of a catalog can hold a single item.
+type FitCheckRequest struct {
+	Item  packing.InputItem  `json:"item"`
+	Boxes []packing.InputBox `json:"boxes"`
+}
+
+// FitCheckResponse lists the boxes the item fits in, smallest first.
+type FitCheckResponse struct {
+	ItemID string   `json:"item_id"`
+	Fits   []BoxFit `json:"fits"`
+	NoFit  []string `json:"no_fit"`
+}
+
+// BoxFit describes how an item sits in one box.
+type BoxFit struct {
+	BoxID string `json:"box_id"`
+	// W, H and D are the item dimensions in the orientation that fits.
+	W       int  `json:"w"`
+	H       int  `json:"h"`
+	D       int  `json:"d"`
+	Rotated bool `json:"rotated"`
+	// Clearance is the smallest gap left between the item and a wall.
+	Clearance int `json:"clearance"`
+}
+
+// fitCheck finds every box that can hold item. For each box it prefers the
+// original orientation and otherwise the rotation leaving the most clearance.
+func fitCheck(item packing.InputItem, boxes []packing.InputBox) FitCheckResponse {
+	sorted := slices.Clone(boxes)
+	slices.SortStableFunc(sorted, func(a, b packing.InputBox) int {
+		return cmp.Compare(a.Volume(), b.Volume())
+	})
+
+	resp := FitCheckResponse{ItemID: item.ID, Fits: []BoxFit{}, NoFit: []string{}}
+	for _, box := range sorted {
+		best := BoxFit{Clearance: -1}
+		for _, rot := range packing.Rotations(item) {
+			if !packing.FitsInBox(box, 0, 0, 0, rot[0], rot[1], rot[2]) {
+				continue
+			}
+			clearance := min(box.W-rot[0], box.H-rot[1], box.D-rot[2])
+			if best.Clearance == -1 || (best.Rotated && clearance > best.Clearance) {
+				best = BoxFit{
+					BoxID: box.ID,
+					W:     rot[0], H: rot[1], D: rot[2],
+					Rotated:   rot != [3]int{item.W, item.H, item.D},
+					Clearance: clearance,
+				}
+			}
+		}
+
+		if best.Clearance == -1 {
+			resp.NoFit = append(resp.NoFit, box.ID)
+			continue
+		}
+		resp.Fits = append(resp.Fits, best)
+	}
+	return resp
+}
+
+func handleFitCheck(w http.ResponseWriter, r *http.Request) {
+	var req FitCheckRequest
+
+	switch r.Method {
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	case http.MethodGet:
+		var err error
+		req, err = parseFitCheckQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if req.Item.W <= 0 || req.Item.H <= 0 || req.Item.D <= 0 || len(req.Boxes) == 0 {
+		http.Error(w, "Item dimensions and Boxes are required", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(fitCheck(req.Item, req.Boxes))
+}
+
+// parseFitCheckQuery reads a fit check from query parameters so the result
+// can be cached by CDNs, e.g. ?id=mug&w=10&h=12&d=10&box=small:20x15x15&box=large:40x30x30
+func parseFitCheckQuery(r *http.Request) (FitCheckRequest, error) {
+	q := r.URL.Query()
+
+	var req FitCheckRequest
+	req.Item.ID = q.Get("id")
+	for _, dim := range []struct {
+		name string
+		dst  *int
+	}{{"w", &req.Item.W}, {"h", &req.Item.H}, {"d", &req.Item.D}} {
+		v, err := strconv.Atoi(q.Get(dim.name))
+		if err != nil {
+			return req, fmt.Errorf("invalid %s parameter", dim.name)
+		}
+		*dim.dst = v
+	}
+
+	for _, spec := range q["box"] {
+		id, dims, ok := strings.Cut(spec, ":")
+		parts := strings.Split(dims, "x")
+		if !ok || len(parts) != 3 {
+			return req, fmt.Errorf("invalid box %q, expected id:WxHxD", spec)
+		}
+		box := packing.InputBox{ID: id}
+		for i, dst := range []*int{&box.W, &box.H, &box.D} {
+			v, err := strconv.Atoi(parts[i])
+			if err != nil {
+				return req, fmt.Errorf("invalid box %q, expected id:WxHxD", spec)
+			}
+			*dst = v
+		}
+		req.Boxes = append(req.Boxes, box)
+	}
+
+	return req, nil
+}
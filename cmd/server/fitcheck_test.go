@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"binpacker/pkg/packing"
+)
+
+func TestFitCheck(t *testing.T) {
+	item := packing.InputItem{ID: "poster-tube", W: 5, H: 5, D: 50}
+	boxes := []packing.InputBox{
+		{ID: "large", W: 60, H: 40, D: 60},
+		{ID: "long", W: 55, H: 10, D: 10},
+		{ID: "small", W: 20, H: 20, D: 20},
+	}
+
+	resp := fitCheck(item, boxes)
+
+	if len(resp.Fits) != 2 {
+		t.Fatalf("Expected 2 fitting boxes, got %+v", resp.Fits)
+	}
+	// Smallest box first, and the tube must be turned to lie along its width.
+	long := resp.Fits[0]
+	if long.BoxID != "long" || !long.Rotated || long.W != 50 {
+		t.Errorf("Expected rotated fit in long box, got %+v", long)
+	}
+	if resp.Fits[1].BoxID != "large" || resp.Fits[1].Rotated {
+		t.Errorf("Expected upright fit in large box, got %+v", resp.Fits[1])
+	}
+	if len(resp.NoFit) != 1 || resp.NoFit[0] != "small" {
+		t.Errorf("Expected small box in no_fit, got %v", resp.NoFit)
+	}
+}
+
+func TestParseFitCheckQuery(t *testing.T) {
+	r := httptest.NewRequest("GET", "/fit-check?id=mug&w=10&h=12&d=10&box=small:20x15x15&box=large:40x30x30", nil)
+
+	req, err := parseFitCheckQuery(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Item.ID != "mug" || req.Item.H != 12 {
+		t.Errorf("Unexpected item %+v", req.Item)
+	}
+	if len(req.Boxes) != 2 || req.Boxes[1] != (packing.InputBox{ID: "large", W: 40, H: 30, D: 30}) {
+		t.Errorf("Unexpected boxes %+v", req.Boxes)
+	}
+}
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"strings"
+	"time"
+
+	"binpacker/pkg/packing"
+)
+
+//go:embed static/*
+var staticFiles embed.FS
+
+// PackRequest defines the input structure for the packing API.
+type PackRequest struct {
+	Items []packing.InputItem `json:"items"`
+	Boxes []packing.InputBox  `json:"boxes"`
+
+	// DegenerateItems selects how items with extreme proportions are handled:
+	// "warn" (default), "reject" or "clamp".
+	DegenerateItems string  `json:"degenerate_items,omitempty"`
+	MaxAspectRatio  float64 `json:"max_aspect_ratio,omitempty"`
+	MinDimension    int     `json:"min_dimension,omitempty"`
+
+	// Visualization selects how the visualization is returned: "cdn"
+	// (default), "data_uri" for standalone HTML with three.js inlined, or
+	// "none".
+	Visualization string `json:"visualization,omitempty"`
+
+	// CoordinateFrame selects the frame of the returned placements. See
+	// coords.go.
+	CoordinateFrame CoordinateFrame `json:"coordinate_frame,omitempty"`
+
+	// Meta is free-form caller data such as an order number. It does not
+	// affect packing but is passed to hooks and archived.
+	Meta map[string]string `json:"meta,omitempty"`
+
+	// ShippingClasses is the caller's carrier tier table, tried in order
+	// for every packed box.
+	ShippingClasses []packing.ShippingClass `json:"shipping_classes,omitempty"`
+
+	// OverhangTolerance enables overhang warnings for stacked items that
+	// stick out further than this past the items below them.
+	OverhangTolerance *int `json:"overhang_tolerance,omitempty"`
+
+	// SuggestBoxes adds box suggestions for items too large for every box.
+	SuggestBoxes bool `json:"suggest_boxes,omitempty"`
+
+	// Optimize searches for OptimizeMS milliseconds, or OptimizeSteps steps,
+	// for a better packing than the greedy one. Seed makes the search
+	// repeatable; see optimizeRun.
+	Optimize      bool    `json:"optimize,omitempty"`
+	OptimizeMS    int     `json:"optimize_ms,omitempty"`
+	OptimizeSteps int     `json:"optimize_steps,omitempty"`
+	Seed          *uint64 `json:"seed,omitempty"`
+
+	// Units is the unit of every length in the request and response: "mm",
+	// "cm", "m", "in" or "ft". Without it lengths are whole numbers in any
+	// unit. UnitGrid is set by validation; see units.go.
+	Units    string `json:"units,omitempty"`
+	UnitGrid int    `json:"unit_grid_um,omitempty"`
+
+	// Profile names a server-side box catalog to pack with instead of
+	// Boxes; see profiles.go. activeProfile is the profile and schedule
+	// applied.
+	Profile       string `json:"profile,omitempty"`
+	activeProfile string
+
+	// merged reports the duplicate item entries validation merged.
+	merged []packing.Warning
+
+	// ExpectedBoxes is the number of boxes the caller expects the items to
+	// need. A packing that needs more is searched for a better one; see
+	// hint.go.
+	ExpectedBoxes int `json:"expected_boxes,omitempty"`
+	// Lane is the lane the request is packed in, interactive by default;
+	// see pool.go.
+	Lane string `json:"lane,omitempty"`
+
+	// DryRun answers /pack with the projected cost of the request instead
+	// of packing it; see budget.go. estimate is set by validation.
+	DryRun   bool `json:"dry_run,omitempty"`
+	estimate packing.Estimate
+
+	packing.Options
+}
+
+// PackResponse defines the output structure for the packing API.
+type PackResponse struct {
+	PackID               string                   `json:"pack_id"`
+	PackedBoxes          []packing.PackedBox      `json:"packed_boxes"`
+	UnpackedItems        []packing.InputItem      `json:"unpacked_items"`
+	TotalVolume          int                      `json:"total_volume"`
+	Utilization          float64                  `json:"utilization_percent"`
+	CoordinateFrame      *CoordinateFrame         `json:"coordinate_frame,omitempty"`
+	Customs              *packing.CustomsSummary  `json:"customs,omitempty"`
+	Suggestions          []packing.BoxSuggestion  `json:"suggestions,omitempty"`
+	Splits               []packing.MultipackSplit `json:"splits,omitempty"`
+	VisualizationURL     string                   `json:"visualization_url,omitempty"`
+	VisualizationDataURI string                   `json:"visualization_data_uri"`
+	VisualizationHTML    string                   `json:"visualization_html"`
+	Warnings             []packing.Warning        `json:"warnings,omitempty"`
+	// TimedOut marks a best-effort result: packing hit the time limit and
+	// the items not placed by then are unpacked.
+	TimedOut bool `json:"timed_out,omitempty"`
+	// Units and UnitGrid are copied from the request. Lengths are kept on
+	// the grid and converted to Units in JSON; see units.go.
+	Units    string `json:"units,omitempty"`
+	UnitGrid int    `json:"unit_grid_um,omitempty"`
+	// Profile is the profile the boxes came from, with the active schedule
+	// after a slash, e.g. "warehouse-a/weekend".
+	Profile string `json:"profile,omitempty"`
+	// OptimizeIterations is the number of orders an optimize search tried.
+	OptimizeIterations int `json:"optimize_iterations,omitempty"`
+	// BoxHint reports whether expected_boxes was met.
+	BoxHint *BoxHint `json:"box_hint,omitempty"`
+	// decisions is taken off PackedBoxes for the explanation endpoint; see
+	// explain.go.
+	decisions []*packing.BoxDecision
+}
+
+// Packer is the HTTP handler entry point.
+func Packer(w http.ResponseWriter, r *http.Request) {
+	setCORSHeaders(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/pack" && r.Method == http.MethodPost:
+		handlePack(w, r)
+	case r.URL.Path == "/pack/async" && r.Method == http.MethodPost:
+		handlePackAsync(w, r)
+	case r.URL.Path == "/pack/stream" && r.Method == http.MethodPost:
+		handlePackStream(w, r)
+	case r.URL.Path == "/pack/live" && r.Method == http.MethodGet:
+		handleLive(w, r)
+	case r.URL.Path == "/consolidate" && r.Method == http.MethodPost:
+		handleConsolidate(w, r)
+	case r.URL.Path == "/simulate-catalog" && r.Method == http.MethodPost:
+		handleSimulateCatalog(w, r)
+	case r.URL.Path == "/recommend-boxes" && r.Method == http.MethodPost:
+		handleRecommendBoxes(w, r)
+	case r.URL.Path == "/optimize" && r.Method == http.MethodPost:
+		handleOptimize(w, r)
+	case strings.HasPrefix(r.URL.Path, "/jobs/") && r.Method == http.MethodGet:
+		handleJob(w, r)
+	case r.URL.Path == "/scenarios" && r.Method == http.MethodPost:
+		handleScenarios(w, r)
+	case strings.HasPrefix(r.URL.Path, "/scenarios/"):
+		handleScenario(w, r)
+	case strings.HasPrefix(r.URL.Path, "/packs/"):
+		handlePackResource(w, r)
+	case strings.HasPrefix(r.URL.Path, "/result/") && r.Method == http.MethodGet:
+		handleResult(w, r)
+	case r.URL.Path == "/visualize/compare" && r.Method == http.MethodGet:
+		handleCompare(w, r)
+	case strings.HasPrefix(r.URL.Path, "/visualize/") && r.Method == http.MethodGet:
+		handleVisualization(w, r)
+	case r.URL.Path == "/stats/summary" && r.Method == http.MethodGet:
+		handleStatsSummary(w, r)
+	case r.URL.Path == "/account/data":
+		handleAccountData(w, r)
+	case r.URL.Path == "/fit-check":
+		handleFitCheck(w, r)
+	default:
+		handleStatic(w, r)
+	}
+}
+
+func setCORSHeaders(w http.ResponseWriter) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "POST, GET, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Signature, X-Signature-Timestamp")
+}
+
+func handlePack(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	req, err := decodePackRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	logPackRequest(r.Context(), req)
+	err = validatePackRequest(r.Context(), &req)
+	if req.DryRun {
+		logOutcome(r.Context(), "dry_run")
+		writeDryRun(w, req, err)
+		return
+	}
+	if err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	release, ok := acquirePackSlot(w, r, req.Lane)
+	if !ok {
+		return
+	}
+	defer release()
+
+	resp := runPack(r.Context(), req, start)
+	logPackResponse(r.Context(), resp)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp.inFrame(req.Boxes))
+}
+
+// validatePackRequest runs the pre-pack hooks, which may edit req, and then
+// checks the request. Errors are the caller's fault; field errors are
+// ValidationErrors.
+func validatePackRequest(ctx context.Context, req *PackRequest) error {
+	if err := applyProfile(req, principalFrom(ctx), time.Now()); err != nil {
+		return err
+	}
+	if len(req.Items) == 0 || len(req.Boxes) == 0 {
+		return errors.New("Items and Boxes are required")
+	}
+	limits := getSettings().Limits
+	if err := applyUnits(req, limits); err != nil {
+		return err
+	}
+	req.Items, req.merged = packing.MergeDuplicates(req.Items)
+	if err := checkRequestSize(req.Items, req.Boxes, limits); err != nil {
+		return err
+	}
+	if err := runPrePackHooks(ctx, req); err != nil {
+		return err
+	}
+
+	if err := validateInput(req.Items, req.Boxes, limits); err != nil {
+		return err
+	}
+	if err := req.Options.Validate(); err != nil {
+		return err
+	}
+	if err := validateLane(req.Lane); err != nil {
+		return err
+	}
+	if err := validateVizMode(req.Visualization); err != nil {
+		return err
+	}
+	if err := validateFrame(req.CoordinateFrame); err != nil {
+		return err
+	}
+	if err := packing.ValidateWeights(req.Items, req.Boxes); err != nil {
+		return err
+	}
+	if err := packing.ValidateCustoms(req.Items); err != nil {
+		return err
+	}
+	if err := packing.ValidateOrientations(req.Items); err != nil {
+		return err
+	}
+	if err := packing.ValidateMultipacks(req.Items); err != nil {
+		return err
+	}
+	if err := packing.CheckAisle(req.Aisle, req.Boxes); err != nil {
+		return err
+	}
+	if err := packing.ValidateShippingClasses(req.ShippingClasses); err != nil {
+		return err
+	}
+	if req.OverhangTolerance != nil && *req.OverhangTolerance < 0 {
+		return errors.New("overhang_tolerance must not be negative")
+	}
+	if err := req.validateOptimize(); err != nil {
+		return err
+	}
+	if err := validateBoxHint(req); err != nil {
+		return err
+	}
+
+	guard, err := newDegenerateGuard(*req)
+	if err != nil {
+		return err
+	}
+	if _, _, err := guard.apply(req.Items); err != nil {
+		return err
+	}
+
+	req.estimate = packing.EstimateCost(req.Items, req.Boxes, req.Options)
+	return checkComputeBudget(req.estimate, limits)
+}
+
+// defaultPackTimeout bounds the packing of one request, so a pathological
+// input cannot hold a connection or worker forever.
+// Batch packs may take longer.
+const (
+	defaultPackTimeout      = time.Minute
+	defaultBatchPackTimeout = 5 * time.Minute
+)
+
+// runPack packs a request that passed validatePackRequest, then stores,
+// archives and records the result. The response is in the canonical frame.
+func runPack(ctx context.Context, req PackRequest, start time.Time) PackResponse {
+	// The guard cannot fail here: validatePackRequest has already built and
+	// applied it.
+	guard, _ := newDegenerateGuard(req)
+	items, guardWarnings, _ := guard.apply(req.Items)
+
+	items, splits := packing.SplitMultipacks(items, req.Boxes)
+
+	packCtx := ctx
+	if packTimeout := getSettings().packTimeout(req.Lane); packTimeout > 0 {
+		var cancel context.CancelFunc
+		packCtx, cancel = context.WithTimeout(ctx, packTimeout)
+		defer cancel()
+	}
+	var packedBoxes []packing.PackedBox
+	var unpackedItems []packing.InputItem
+	var err error
+	var iterations int
+	if req.Optimize {
+		// Every packing the search tries is complete, so running out of
+		// time only ends the search early; only a seeded search reports it.
+		packedBoxes, unpackedItems, iterations, err = packing.OptimizeContext(packCtx, items, req.Boxes, req.Options, req.optimizeRun())
+	} else {
+		packedBoxes, unpackedItems, err = packing.PackContext(packCtx, items, req.Boxes, req.Options)
+	}
+	var hint *BoxHint
+	if req.ExpectedBoxes > 0 {
+		packedBoxes, unpackedItems, iterations, hint = meetBoxHint(packCtx, req, items, packedBoxes, unpackedItems, iterations)
+	}
+
+	resp := newPackResponse(packedBoxes, unpackedItems, req.Boxes)
+	resp.TimedOut = err != nil
+	resp.OptimizeIterations = iterations
+	resp.BoxHint = hint
+	resp.Units, resp.UnitGrid = req.Units, req.UnitGrid
+	resp.Profile = req.activeProfile
+	resp.PackID = newID(IDPrefixPack)
+	resp.Splits = splits
+	resp.Warnings = append(packing.InputWarnings(req.Items, req.Boxes), req.merged...)
+	resp.Warnings = append(resp.Warnings, guardWarnings...)
+	resp.Warnings = append(resp.Warnings, packing.StockWarnings(packedBoxes, unpackedItems, req.Boxes)...)
+	resp.Warnings = append(resp.Warnings, packing.MaxBoxesWarnings(packedBoxes, unpackedItems, req.Boxes, req.Options)...)
+	resp.Warnings = append(resp.Warnings, packing.CubeOutWarnings(packedBoxes, unpackedItems, req.Boxes)...)
+	if req.KeepGroupsTogether {
+		resp.Warnings = append(resp.Warnings, packing.GroupSplitWarnings(packedBoxes, items)...)
+	}
+	if req.OverhangTolerance != nil {
+		resp.Warnings = append(resp.Warnings, packing.OverhangWarnings(packedBoxes, *req.OverhangTolerance)...)
+	}
+	if req.SuggestBoxes {
+		resp.Suggestions = packing.SuggestBoxes(unpackedItems, req.Boxes)
+	}
+
+	runPostPackHooks(ctx, &req, &resp)
+	resp.Customs = packing.CustomsRollup(items, resp.PackedBoxes)
+	resp.Warnings = append(resp.Warnings, packing.AssignShippingClasses(resp.PackedBoxes, req.Boxes, req.ShippingClasses)...)
+
+	if err := packing.CheckLayout(resp.PackedBoxes, req.Boxes); err != nil {
+		resp.Warnings = append(resp.Warnings, packing.Warning{Code: packing.WarnLayoutInconsistent, Message: err.Error()})
+	}
+	frame, _, _ := req.CoordinateFrame.resolve()
+	resp.CoordinateFrame = &frame
+
+	// The packing result is still useful without a visualization, so a
+	// rendering failure is reported as a warning rather than failing the request.
+	owner := principalFrom(ctx)
+	var vizID string
+	if req.Visualization != VizModeNone {
+		vizID = newID(IDPrefixVisualization)
+		if err := attachVisualization(&resp, req.Boxes, req.Visualization, vizID, owner); err != nil {
+			vizID = ""
+			resp.Warnings = append(resp.Warnings, packing.Warning{
+				Code:    packing.WarnVisualizationFailed,
+				Message: err.Error(),
+			})
+		}
+	}
+	store.SavePack(owner, vizID, resp, req.Boxes)
+	archive.Archive(ctx, req, resp)
+	telemetry.Record(newTelemetryRow(ctx, resp, time.Since(start)))
+	return resp
+}
+
+// newPackResponse summarizes a packing result. Visualization fields are left
+// for the caller to fill in.
+func newPackResponse(packedBoxes []packing.PackedBox, unpackedItems []packing.InputItem, boxes []packing.InputBox) PackResponse {
+	packing.Measure(packedBoxes, boxes)
+	var totalBoxVolume, totalItemVolume int
+	for _, pb := range packedBoxes {
+		totalBoxVolume += pb.W * pb.H * pb.D
+		totalItemVolume += pb.UsedVolume
+	}
+
+	var utilization float64
+	if totalBoxVolume > 0 {
+		utilization = float64(totalItemVolume) / float64(totalBoxVolume) * 100
+	}
+
+	return PackResponse{
+		PackedBoxes:   packedBoxes,
+		UnpackedItems: unpackedItems,
+		TotalVolume:   totalBoxVolume,
+		Utilization:   utilization,
+		decisions:     takeDecisions(packedBoxes),
+	}
+}
+
+func handleStatic(w http.ResponseWriter, r *http.Request) {
+	fsys, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	http.FileServer(http.FS(fsys)).ServeHTTP(w, r)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DataExport is everything stored for one principal, as returned by a data
+// subject access request.
+type DataExport struct {
+	Principal  string             `json:"principal"`
+	ExportedAt time.Time          `json:"exported_at"`
+	Packs      []ExportedPack     `json:"packs"`
+	Jobs       []Job              `json:"jobs"`
+	Scenarios  []ExportedScenario `json:"scenarios"`
+}
+
+// ExportedPack is a stored pack result with its storage metadata.
+type ExportedPack struct {
+	CreatedAt       time.Time    `json:"created_at"`
+	DeletedAt       time.Time    `json:"deleted_at,omitzero"`
+	VisualizationID string       `json:"visualization_id,omitempty"`
+	Result          PackResponse `json:"result"`
+}
+
+// ExportedScenario is a stored scenario request.
+type ExportedScenario struct {
+	ScenarioID string          `json:"scenario_id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Request    json.RawMessage `json:"request"`
+}
+
+// DataDeletion reports what a hard delete removed.
+type DataDeletion struct {
+	Principal      string `json:"principal"`
+	Packs          int    `json:"packs"`
+	Visualizations int    `json:"visualizations"`
+	Jobs           int    `json:"jobs"`
+	Scenarios      int    `json:"scenarios"`
+}
+
+// PacksOwnedBy returns every pack held for owner, soft-deleted ones included,
+// oldest first.
+func (s *Store) PacksOwnedBy(owner string) []ExportedPack {
+	var out []ExportedPack
+	s.packsOf(owner, func(_ string, p storedPack) {
+		if p.Owner == owner {
+			out = append(out, ExportedPack{
+				CreatedAt:       p.CreatedAt,
+				DeletedAt:       p.DeletedAt,
+				VisualizationID: p.VisualizationID,
+				Result:          p.Response,
+			})
+		}
+	})
+	slices.SortFunc(out, func(a, b ExportedPack) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return out
+}
+
+// DeleteOwnedBy permanently removes all packs and visualizations of owner.
+func (s *Store) DeleteOwnedBy(owner string) (packs, visualizations int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.packsOf(owner, func(key string, p storedPack) {
+		if p.Owner == owner && s.remove(key) {
+			packs++
+		}
+	})
+	s.visualizationsOf(owner, func(key string, v storedVisualization) {
+		if v.Owner == owner && s.remove(key) {
+			visualizations++
+		}
+	})
+	return packs, visualizations
+}
+
+// ScenariosOwnedBy returns owner's scenarios, oldest first.
+func (s *Store) ScenariosOwnedBy(owner string) []ExportedScenario {
+	var out []ExportedScenario
+	s.scenarios(func(key string, sc storedScenario) {
+		if sc.Owner == owner {
+			out = append(out, ExportedScenario{
+				ScenarioID: strings.TrimPrefix(key, scenarioKeyPrefix),
+				CreatedAt:  sc.CreatedAt,
+				Request:    sc.Request,
+			})
+		}
+	})
+	slices.SortFunc(out, func(a, b ExportedScenario) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return out
+}
+
+// DeleteScenariosOwnedBy permanently removes owner's scenarios.
+func (s *Store) DeleteScenariosOwnedBy(owner string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	s.scenarios(func(key string, sc storedScenario) {
+		if sc.Owner == owner && s.remove(key) {
+			n++
+		}
+	})
+	return n
+}
+
+// JobsOwnedBy returns snapshots of owner's jobs, oldest first.
+func (m *JobManager) JobsOwnedBy(owner string) []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var out []Job
+	for _, rec := range m.jobs {
+		if rec.Owner == owner {
+			out = append(out, rec.Job)
+		}
+	}
+	slices.SortFunc(out, func(a, b Job) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return out
+}
+
+// DeleteOwnedBy removes owner's jobs and checkpoints. Running jobs stop at
+// their next step.
+func (m *JobManager) DeleteOwnedBy(owner string) int {
+	m.mu.Lock()
+	var ids []string
+	for id, rec := range m.jobs {
+		if rec.Owner == owner {
+			delete(m.jobs, id)
+			ids = append(ids, id)
+		}
+	}
+	m.mu.Unlock()
+
+	m.removeCheckpoints(ids)
+	return len(ids)
+}
+
+func exportData(principal string) DataExport {
+	return DataExport{
+		Principal:  principal,
+		ExportedAt: time.Now().UTC(),
+		Packs:      store.PacksOwnedBy(principal),
+		Jobs:       jobs.JobsOwnedBy(principal),
+		Scenarios:  store.ScenariosOwnedBy(principal),
+	}
+}
+
+func deleteData(principal string) DataDeletion {
+	packs, vizs := store.DeleteOwnedBy(principal)
+	d := DataDeletion{
+		Principal:      principal,
+		Packs:          packs,
+		Visualizations: vizs,
+		Jobs:           jobs.DeleteOwnedBy(principal),
+		Scenarios:      store.DeleteScenariosOwnedBy(principal),
+	}
+	slog.Info("data deletion", "principal", principal, "packs", d.Packs, "visualizations", d.Visualizations, "jobs", d.Jobs, "scenarios", d.Scenarios)
+	return d
+}
+
+// serveDataRequest answers an export (GET) or erasure (DELETE) request for
+// the given principal.
+func serveDataRequest(w http.ResponseWriter, r *http.Request, principal string) {
+	var body any
+	switch r.Method {
+	case http.MethodGet:
+		body = exportData(principal)
+		w.Header().Set("Content-Disposition", `attachment; filename="data-export.json"`)
+	case http.MethodDelete:
+		body = deleteData(principal)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// handleAccountData serves /account/data for the calling API key.
+func handleAccountData(w http.ResponseWriter, r *http.Request) {
+	principal := principalFrom(r.Context())
+	if principal == "" {
+		http.Error(w, "Data export requires an authenticated caller", http.StatusForbidden)
+		return
+	}
+	serveDataRequest(w, r, principal)
+}
+
+// handleAdminData serves /admin/data/{principal} so operators can handle
+// requests that arrive out of band, e.g. for a revoked key.
+func handleAdminData(w http.ResponseWriter, r *http.Request) {
+	principal := strings.TrimPrefix(r.URL.Path, "/admin/data/")
+	if principal == "" {
+		http.NotFound(w, r)
+		return
+	}
+	serveDataRequest(w, r, principal)
+}
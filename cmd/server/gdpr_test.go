@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDeleteOwnedByOnlyRemovesOwnersData(t *testing.T) {
+	s := newStore()
+	s.SaveVisualization("vz_a", "pk_a", "key:a", "<html>")
+	s.SavePack("key:a", "vz_a", PackResponse{PackID: "pk_a"}, nil)
+	s.SavePack("key:b", "", PackResponse{PackID: "pk_b"}, nil)
+	s.DeletePack("key:a", "pk_a")
+
+	exported := s.PacksOwnedBy("key:a")
+	if len(exported) != 1 || exported[0].Result.PackID != "pk_a" || exported[0].DeletedAt.IsZero() {
+		t.Errorf("Expected the soft-deleted pack pk_a in the export, got %+v", exported)
+	}
+
+	packs, vizs := s.DeleteOwnedBy("key:a")
+	if packs != 1 || vizs != 1 {
+		t.Errorf("Expected 1 pack and 1 visualization deleted, got %d and %d", packs, vizs)
+	}
+	if len(s.PacksOwnedBy("key:a")) != 0 {
+		t.Error("Expected no packs left for key:a")
+	}
+	if _, ok := s.Pack("key:b", "pk_b"); !ok {
+		t.Error("Expected pk_b of another key to be kept")
+	}
+
+	s.SaveScenario("key:a", "sc_a", []byte(`{}`))
+	s.SaveScenario("key:b", "sc_b", []byte(`{}`))
+	if got := s.ScenariosOwnedBy("key:a"); len(got) != 1 || got[0].ScenarioID != "sc_a" {
+		t.Errorf("Expected scenario sc_a in the export, got %+v", got)
+	}
+	if n := s.DeleteScenariosOwnedBy("key:a"); n != 1 {
+		t.Errorf("Expected 1 scenario deleted, got %d", n)
+	}
+	if _, ok := s.Scenario("sc_b"); !ok {
+		t.Error("Expected sc_b of another key to be kept")
+	}
+}
+
+// getRecorder records the keys read from a Storage.
+type getRecorder struct {
+	Storage
+	got []string
+}
+
+func (g *getRecorder) Get(ctx context.Context, key string) ([]byte, error) {
+	g.got = append(g.got, key)
+	return g.Storage.Get(ctx, key)
+}
+
+func TestOwnerQueriesStayInTenant(t *testing.T) {
+	rec := &getRecorder{Storage: newMemoryStorage()}
+	s := newStoreOn(rec)
+	s.SavePack("key:a", "", PackResponse{PackID: "pk_a"}, nil)
+	s.SavePack("key:b", "", PackResponse{PackID: "pk_b"}, nil)
+	// A record stored before tenants, under the flat key.
+	legacy, _ := json.Marshal(storedPack{Owner: "key:a", CreatedAt: time.Now(), Response: PackResponse{PackID: "pk_old"}})
+	_ = rec.Put(context.Background(), packKeyPrefix+"pk_old", legacy)
+
+	rec.got = nil
+	if got := s.PacksOwnedBy("key:a"); len(got) != 2 {
+		t.Errorf("Expected pk_a and the legacy pk_old, got %+v", got)
+	}
+	s.PacksBetween("key:a", time.Time{}, time.Time{})
+	s.DeleteOwnedBy("key:a")
+	for _, key := range rec.got {
+		if key == packKey("key:b", "pk_b") {
+			t.Errorf("Expected queries for key:a not to read %s", key)
+		}
+	}
+	if _, ok := s.Pack("key:b", "pk_b"); !ok {
+		t.Error("Expected pk_b of another key to be kept")
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// alertRule is an example alert on a counter: it fires when the counter
+// grows by more than Over within Window.
+type alertRule struct {
+	Counter  *Counter
+	Window   string
+	Over     int
+	Severity string
+	Summary  string
+}
+
+// alertRules are the alerts served by /admin/grafana/alerts.json. They
+// refer to the counters themselves, so a renamed metric cannot leave an
+// alert watching nothing.
+var alertRules = []alertRule{
+	{panicsRecovered, "5m", 0, "critical", "Handlers panicked; the stacks are in the server log"},
+	{packsRejected, "5m", 10, "warning", "Interactive packs are being turned away; raise MAX_CONCURRENT_PACKS or scale out"},
+	{batchPacksRejected, "15m", 10, "warning", "Batch packs are being turned away; raise BATCH_CONCURRENT_PACKS"},
+	{archiveErrors, "15m", 0, "warning", "Payload archive uploads are failing"},
+	{archiveDropped, "15m", 0, "warning", "Payload archive records are being dropped"},
+	{telemetryErrors, "15m", 0, "warning", "Telemetry batches are failing to export"},
+	{telemetryDropped, "15m", 0, "warning", "Telemetry rows are being dropped"},
+}
+
+// metricNames returns the names and help of the registered counters,
+// sorted by name.
+func metricNames() [][2]string {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	names := make([][2]string, 0, len(counters))
+	for _, c := range counters {
+		names = append(names, [2]string{c.name, c.help})
+	}
+	slices.SortFunc(names, func(a, b [2]string) int { return strings.Compare(a[0], b[0]) })
+	return names
+}
+
+// grafanaDashboard builds a dashboard with a panel of the rate of each
+// counter on /metrics. The Prometheus data source and the job scraping the
+// server are picked in the dashboard, so it imports as is.
+func grafanaDashboard() map[string]any {
+	ds := map[string]any{"type": "prometheus", "uid": "${datasource}"}
+	names := metricNames()
+	panels := make([]any, 0, len(names))
+	for i, m := range names {
+		panels = append(panels, map[string]any{
+			"id":          i + 1,
+			"type":        "timeseries",
+			"title":       m[0],
+			"description": m[1],
+			"datasource":  ds,
+			"gridPos":     map[string]any{"x": i % 2 * 12, "y": i / 2 * 8, "w": 12, "h": 8},
+			"fieldConfig": map[string]any{"defaults": map[string]any{"unit": "ops"}, "overrides": []any{}},
+			"targets": []any{map[string]any{
+				"refId":        "A",
+				"datasource":   ds,
+				"expr":         fmt.Sprintf(`sum(rate(%s{job=~"$job"}[$__rate_interval]))`, m[0]),
+				"legendFormat": m[0],
+			}},
+		})
+	}
+	first := "up"
+	if len(names) > 0 {
+		first = names[0][0]
+	}
+	return map[string]any{
+		"uid":           "binpacker",
+		"title":         "3D Bin Packing API",
+		"tags":          []string{"binpacker"},
+		"schemaVersion": 39,
+		"time":          map[string]any{"from": "now-6h", "to": "now"},
+		"refresh":       "1m",
+		"templating": map[string]any{"list": []any{
+			map[string]any{"name": "datasource", "label": "Data source", "type": "datasource", "query": "prometheus"},
+			map[string]any{
+				"name": "job", "label": "Job", "type": "query", "datasource": ds,
+				"query": "label_values(" + first + ", job)", "refresh": 2,
+				"includeAll": true, "multi": true, "allValue": ".*",
+				"current": map[string]any{"text": "All", "value": "$__all"},
+			},
+		}},
+		"panels": panels,
+	}
+}
+
+// grafanaAlerts builds alertRules as a Grafana alerting provisioning file,
+// querying the Prometheus data source with the given UID.
+func grafanaAlerts(datasource string) map[string]any {
+	rules := make([]any, 0, len(alertRules))
+	for _, a := range alertRules {
+		name := a.Counter.name
+		window, _ := time.ParseDuration(a.Window)
+		rules = append(rules, map[string]any{
+			"uid":       "binpacker-" + strings.ReplaceAll(strings.TrimSuffix(name, "_total"), "_", "-"),
+			"title":     name + " increasing",
+			"condition": "C",
+			"data": []any{
+				map[string]any{
+					"refId":             "A",
+					"datasourceUid":     datasource,
+					"relativeTimeRange": map[string]any{"from": int(window.Seconds()), "to": 0},
+					"model": map[string]any{
+						"refId":   "A",
+						"expr":    fmt.Sprintf("sum(increase(%s[%s]))", name, a.Window),
+						"instant": true,
+					},
+				},
+				map[string]any{
+					"refId":         "C",
+					"datasourceUid": "__expr__",
+					"model": map[string]any{
+						"refId":      "C",
+						"type":       "threshold",
+						"expression": "A",
+						"conditions": []any{map[string]any{
+							"evaluator": map[string]any{"type": "gt", "params": []int{a.Over}},
+						}},
+					},
+				},
+			},
+			"noDataState":  "OK",
+			"execErrState": "Error",
+			"for":          "0s",
+			"labels":       map[string]string{"severity": a.Severity},
+			"annotations": map[string]string{
+				"summary":     a.Summary,
+				"description": fmt.Sprintf("%s grew by more than %d in %s.", name, a.Over, a.Window),
+			},
+		})
+	}
+	return map[string]any{
+		"apiVersion": 1,
+		"groups": []any{map[string]any{
+			"orgId":    1,
+			"name":     "binpacker",
+			"folder":   "3D Bin Packing API",
+			"interval": "1m",
+			"rules":    rules,
+		}},
+	}
+}
+
+// handleAdminGrafana serves the dashboard at /admin/grafana/dashboard.json
+// and the alert rules at /admin/grafana/alerts.json. The datasource query
+// parameter is the UID of the Prometheus data source the alerts query,
+// "prometheus" by default.
+func handleAdminGrafana(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var doc map[string]any
+	switch strings.TrimPrefix(r.URL.Path, "/admin/grafana/") {
+	case "dashboard.json":
+		doc = grafanaDashboard()
+	case "alerts.json":
+		datasource := r.URL.Query().Get("datasource")
+		if datasource == "" {
+			datasource = "prometheus"
+		}
+		doc = grafanaAlerts(datasource)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(doc)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAdminGrafana(t *testing.T) {
+	get := func(path string) map[string]any {
+		rec := httptest.NewRecorder()
+		handleAdminGrafana(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200 for %s, got %d", path, rec.Code)
+		}
+		var doc map[string]any
+		if err := json.NewDecoder(rec.Body).Decode(&doc); err != nil {
+			t.Fatal(err)
+		}
+		return doc
+	}
+
+	dashboard, _ := json.Marshal(get("/admin/grafana/dashboard.json"))
+	for _, m := range metricNames() {
+		if !strings.Contains(string(dashboard), "rate("+m[0]+"{") {
+			t.Errorf("Expected a panel for %s", m[0])
+		}
+	}
+
+	alerts := get("/admin/grafana/alerts.json?datasource=prom-main")
+	rules := alerts["groups"].([]any)[0].(map[string]any)["rules"].([]any)
+	if len(rules) != len(alertRules) {
+		t.Fatalf("Expected %d rules, got %d", len(alertRules), len(rules))
+	}
+	names := map[string]bool{}
+	for _, m := range metricNames() {
+		names[m[0]] = true
+	}
+	for _, r := range rules {
+		query := r.(map[string]any)["data"].([]any)[0].(map[string]any)
+		expr := query["model"].(map[string]any)["expr"].(string)
+		name := strings.TrimPrefix(expr, "sum(increase(")
+		name, _, _ = strings.Cut(name, "[")
+		if !names[name] || query["datasourceUid"] != "prom-main" {
+			t.Errorf("Expected an alert on a served metric from prom-main, got %s on %v", expr, query["datasourceUid"])
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handleAdminGrafana(rec, httptest.NewRequest(http.MethodGet, "/admin/grafana/other.json", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"binpacker/pkg/packing"
+)
+
+// Policies for handling degenerate items, selected by PackRequest.DegenerateItems.
+const (
+	DegenerateWarn   = "warn"
+	DegenerateReject = "reject"
+	DegenerateClamp  = "clamp"
+)
+
+const (
+	defaultMaxAspectRatio = 100
+	defaultMinDimension   = 1
+)
+
+// degenerateGuard detects items with extreme proportions (for example
+// 1x1x10000). Such items multiply extreme points without filling space and
+// usually come from a unit mix-up in the caller's data.
+type degenerateGuard struct {
+	policy         string
+	maxAspectRatio float64
+	minDimension   int
+}
+
+func newDegenerateGuard(req PackRequest) (degenerateGuard, error) {
+	g := degenerateGuard{
+		policy:         req.DegenerateItems,
+		maxAspectRatio: req.MaxAspectRatio,
+		minDimension:   req.MinDimension,
+	}
+	if g.policy == "" {
+		g.policy = DegenerateWarn
+	}
+	if g.maxAspectRatio <= 0 {
+		g.maxAspectRatio = defaultMaxAspectRatio
+	}
+	if g.minDimension <= 0 {
+		g.minDimension = defaultMinDimension
+	}
+
+	switch g.policy {
+	case DegenerateWarn, DegenerateReject, DegenerateClamp:
+		return g, nil
+	default:
+		return g, fmt.Errorf("unknown degenerate_items policy %q", g.policy)
+	}
+}
+
+// problem describes why item is degenerate, or returns "" when it is fine.
+func (g degenerateGuard) problem(item packing.InputItem) string {
+	lo := min(item.W, item.H, item.D)
+	hi := max(item.W, item.H, item.D)
+
+	if lo < g.minDimension {
+		return fmt.Sprintf("smallest dimension %d is below the minimum of %d", lo, g.minDimension)
+	}
+	if ratio := float64(hi) / float64(lo); ratio > g.maxAspectRatio {
+		return fmt.Sprintf("aspect ratio %.0f:1 exceeds the maximum of %.0f:1", ratio, g.maxAspectRatio)
+	}
+	return ""
+}
+
+// clamp grows the short sides of item until it satisfies the guard. Growing
+// rather than shrinking keeps the packed result physically valid.
+func (g degenerateGuard) clamp(item packing.InputItem) packing.InputItem {
+	hi := max(item.W, item.H, item.D)
+	floor := max(g.minDimension, int(math.Ceil(float64(hi)/g.maxAspectRatio)))
+
+	item.W = max(item.W, floor)
+	item.H = max(item.H, floor)
+	item.D = max(item.D, floor)
+	return item
+}
+
+// apply checks every item against the guard. Under the clamp policy it
+// returns adjusted copies of the items; under reject it returns an error
+// naming the first offending item.
+func (g degenerateGuard) apply(items []packing.InputItem) ([]packing.InputItem, []packing.Warning, error) {
+	var warnings []packing.Warning
+	out := make([]packing.InputItem, len(items))
+
+	for i, item := range items {
+		out[i] = item
+
+		reason := g.problem(item)
+		if reason == "" {
+			continue
+		}
+
+		switch g.policy {
+		case DegenerateReject:
+			return nil, nil, fmt.Errorf("item %q is degenerate: %s", item.ID, reason)
+		case DegenerateClamp:
+			out[i] = g.clamp(item)
+			reason += fmt.Sprintf("; clamped to %dx%dx%d", out[i].W, out[i].H, out[i].D)
+		}
+
+		warnings = append(warnings, packing.Warning{
+			Code:    packing.WarnDegenerateItem,
+			Message: fmt.Sprintf("item %q: %s", item.ID, reason),
+			ItemID:  item.ID,
+		})
+	}
+
+	return out, warnings, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"binpacker/pkg/packing"
+)
+
+func TestDegenerateGuardClamp(t *testing.T) {
+	guard, err := newDegenerateGuard(PackRequest{DegenerateItems: DegenerateClamp})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := []packing.InputItem{
+		{ID: "needle", W: 1, H: 1, D: 10000, Quantity: 1},
+		{ID: "cube", W: 10, H: 10, D: 10, Quantity: 1},
+	}
+	out, warnings, err := guard.apply(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(warnings) != 1 || warnings[0].ItemID != "needle" {
+		t.Fatalf("Expected one warning for needle, got %+v", warnings)
+	}
+	if got := guard.problem(out[0]); got != "" {
+		t.Errorf("Clamped item is still degenerate: %s", got)
+	}
+	if out[0].D != 10000 {
+		t.Errorf("Expected long side to be kept, got %d", out[0].D)
+	}
+	if !reflect.DeepEqual(out[1], items[1]) {
+		t.Errorf("Expected cube to be unchanged, got %+v", out[1])
+	}
+}
+
+func TestDegenerateGuardReject(t *testing.T) {
+	guard, err := newDegenerateGuard(PackRequest{DegenerateItems: DegenerateReject})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = guard.apply([]packing.InputItem{{ID: "needle", W: 1, H: 1, D: 10000, Quantity: 1}})
+	if err == nil {
+		t.Error("Expected degenerate item to be rejected")
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"binpacker/pkg/packing"
+)
+
+// hintSearchBudget bounds the search run when a packing needs more boxes
+// than the caller expected.
+const hintSearchBudget = 5 * time.Second
+
+// BoxHint reports on the expected_boxes of a request.
+type BoxHint struct {
+	Expected int `json:"expected"`
+	Boxes    int `json:"boxes"`
+	// Met is set when every item is packed in at most Expected boxes.
+	Met bool `json:"met"`
+	// Escalated is set when the first packing missed the hint and an
+	// optimize search was run for a better one.
+	Escalated bool `json:"escalated"`
+}
+
+// validateBoxHint rejects an expected_boxes no packing can meet, which
+// would otherwise only waste the search.
+func validateBoxHint(req *PackRequest) error {
+	n := req.ExpectedBoxes
+	if n < 0 {
+		return errors.New("expected_boxes must not be negative")
+	}
+	if n == 0 {
+		return nil
+	}
+	if req.MaxBoxes > 0 && n > req.MaxBoxes {
+		return fmt.Errorf("expected_boxes %d is more than max_boxes %d", n, req.MaxBoxes)
+	}
+	if least := packing.MinBoxes(req.Items, req.Boxes); n < least {
+		return fmt.Errorf("expected_boxes %d cannot be met: the items fill at least %d of the largest box by volume", n, least)
+	}
+	return nil
+}
+
+// meetBoxHint searches for a packing in at most req.ExpectedBoxes boxes
+// when packed, the first packing, needs more. The search starts over from
+// the greedy packing and keeps the best it finds, so the result is never
+// worse. A request that already optimized is only reported on.
+func meetBoxHint(ctx context.Context, req PackRequest, items []packing.InputItem, packed []packing.PackedBox, unpacked []packing.InputItem, iterations int) ([]packing.PackedBox, []packing.InputItem, int, *BoxHint) {
+	hint := &BoxHint{Expected: req.ExpectedBoxes}
+	met := func() bool { return len(unpacked) == 0 && len(packed) <= hint.Expected }
+	if !met() && !req.Optimize && ctx.Err() == nil {
+		hint.Escalated = true
+		run := packing.OptimizeRun{Budget: hintSearchBudget, TargetBoxes: hint.Expected}
+		packed, unpacked, iterations, _ = packing.OptimizeContext(ctx, items, req.Boxes, req.Options, run)
+	}
+	hint.Boxes, hint.Met = len(packed), met()
+	return packed, unpacked, iterations, hint
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPackExpectedBoxes(t *testing.T) {
+	pack := func(body string) (*httptest.ResponseRecorder, PackResponse) {
+		rec := httptest.NewRecorder()
+		Packer(rec, httptest.NewRequest(http.MethodPost, "/pack", strings.NewReader(body)))
+		var resp PackResponse
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return rec, resp
+	}
+
+	_, resp := pack(`{"expected_boxes": 1, "items": [{"id": "cube", "w": 5, "h": 5, "d": 5, "quantity": 8}], "boxes": [{"id": "box", "w": 10, "h": 10, "d": 10}]}`)
+	if h := resp.BoxHint; h == nil || !h.Met || h.Escalated || h.Boxes != 1 {
+		t.Errorf("Expected the hint met without a search, got %+v", h)
+	}
+
+	// Two 6-cubes fill less than one box by volume but never fit together,
+	// so the search runs and misses.
+	_, resp = pack(`{"expected_boxes": 1, "items": [{"id": "cube", "w": 6, "h": 6, "d": 6, "quantity": 2}], "boxes": [{"id": "box", "w": 10, "h": 10, "d": 10}]}`)
+	if h := resp.BoxHint; h == nil || h.Met || !h.Escalated || h.Boxes != 2 {
+		t.Errorf("Expected the hint missed after a search, got %+v", h)
+	}
+
+	rec, _ := pack(`{"expected_boxes": 1, "items": [{"id": "cube", "w": 10, "h": 10, "d": 10, "quantity": 2}], "boxes": [{"id": "box", "w": 10, "h": 10, "d": 10}]}`)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "at least 2") {
+		t.Errorf("Expected 400 for a hint below the volume bound, got %d: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"binpacker/pkg/packing"
+)
+
+// PrePackHook runs before packing and may modify the request, for example to
+// add house-rule boxes or drop items that ship separately. Returning an error
+// rejects the request with 400 and the error message.
+type PrePackHook interface {
+	PrePack(ctx context.Context, req *PackRequest) error
+}
+
+// PostPackHook runs after packing and may annotate or adjust the response.
+// An error does not fail the request; it is reported as a warning.
+type PostPackHook interface {
+	PostPack(ctx context.Context, req *PackRequest, resp *PackResponse) error
+}
+
+// PrePackHookFunc adapts a function to PrePackHook.
+type PrePackHookFunc func(ctx context.Context, req *PackRequest) error
+
+func (f PrePackHookFunc) PrePack(ctx context.Context, req *PackRequest) error { return f(ctx, req) }
+
+// PostPackHookFunc adapts a function to PostPackHook.
+type PostPackHookFunc func(ctx context.Context, req *PackRequest, resp *PackResponse) error
+
+func (f PostPackHookFunc) PostPack(ctx context.Context, req *PackRequest, resp *PackResponse) error {
+	return f(ctx, req, resp)
+}
+
+var (
+	prePackHooks  []PrePackHook
+	postPackHooks []PostPackHook
+)
+
+// RegisterPrePackHook adds a hook run before every pack, in registration
+// order. Private business rules live in their own file in this package and
+// register from init, so the packer core never needs forking. Hooks must be
+// registered before the server starts.
+func RegisterPrePackHook(h PrePackHook) {
+	prePackHooks = append(prePackHooks, h)
+}
+
+// RegisterPostPackHook adds a hook run after every pack, in registration
+// order. Hooks must be registered before the server starts.
+func RegisterPostPackHook(h PostPackHook) {
+	postPackHooks = append(postPackHooks, h)
+}
+
+func runPrePackHooks(ctx context.Context, req *PackRequest) error {
+	for _, h := range prePackHooks {
+		if err := h.PrePack(ctx, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runPostPackHooks(ctx context.Context, req *PackRequest, resp *PackResponse) {
+	for i, h := range postPackHooks {
+		if err := h.PostPack(ctx, req, resp); err != nil {
+			resp.Warnings = append(resp.Warnings, packing.Warning{
+				Code:    packing.WarnPostPackHookFailed,
+				Message: fmt.Sprintf("post-pack hook %d: %v", i, err),
+			})
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"binpacker/pkg/packing"
+)
+
+func TestPackHooks(t *testing.T) {
+	defer func(pre []PrePackHook, post []PostPackHook) {
+		prePackHooks, postPackHooks = pre, post
+	}(prePackHooks, postPackHooks)
+
+	RegisterPrePackHook(PrePackHookFunc(func(ctx context.Context, req *PackRequest) error {
+		if len(req.Items) > 1 {
+			return errors.New("single-item orders only")
+		}
+		req.Boxes = append(req.Boxes, packing.InputBox{ID: "house-box", W: 50, H: 50, D: 50})
+		return nil
+	}))
+	RegisterPostPackHook(PostPackHookFunc(func(ctx context.Context, req *PackRequest, resp *PackResponse) error {
+		return errors.New("annotation service down")
+	}))
+
+	body := `{"items":[{"id":"big","w":40,"h":40,"d":40,"quantity":1}],"boxes":[{"id":"small","w":10,"h":10,"d":10}]}`
+	rec := httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodPost, "/pack", bytes.NewBufferString(body)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var resp PackResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.PackedBoxes) != 1 || resp.PackedBoxes[0].BoxID != "house-box" {
+		t.Errorf("Expected the pre-pack hook's box to be used, got %+v", resp.PackedBoxes)
+	}
+	found := false
+	for _, w := range resp.Warnings {
+		found = found || w.Code == packing.WarnPostPackHookFailed
+	}
+	if !found {
+		t.Errorf("Expected a post-pack hook warning, got %+v", resp.Warnings)
+	}
+
+	body = `{"items":[{"id":"a","w":1,"h":1,"d":1,"quantity":1},{"id":"b","w":1,"h":1,"d":1,"quantity":1}],"boxes":[{"id":"small","w":10,"h":10,"d":10}]}`
+	rec = httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodPost, "/pack", bytes.NewBufferString(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected the pre-pack hook to reject the request, got %d", rec.Code)
+	}
+}
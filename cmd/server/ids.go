@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"hash/crc32"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ID prefixes for stored resources.
+const (
+	IDPrefixPack          = "pk"
+	IDPrefixVisualization = "vz"
+	IDPrefixJob           = "jb"
+	IDPrefixScenario      = "sc"
+	IDPrefixRequest       = "rq"
+)
+
+// IDs have the form <prefix>_<region>_<random><check>, for example
+// pk_euw1_k3m9q2x7c4v8b6n1z5a0d2f4. The region tells a multi-region
+// deployment where the resource lives so a GET can be routed without a
+// lookup, and the trailing checksum rejects mistyped IDs before any storage
+// is touched. The checksum is a CRC anyone can compute, so it is no defence
+// against forged IDs; ownership checks are.
+const (
+	idRandomBytes = 15 // 24 base32 characters
+	idCheckLen    = 4
+	defaultRegion = "local"
+)
+
+var idEncoding = base32.NewEncoding("0123456789abcdefghjkmnpqrstvwxyz").WithPadding(base32.NoPadding)
+
+var (
+	regionOnce sync.Once
+	region     string
+)
+
+// idRegion returns the region hint from REGION, reduced to lowercase letters
+// and digits so it can never contain the separator.
+func idRegion() string {
+	regionOnce.Do(func() {
+		region = strings.Map(func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+				return r
+			case r >= 'A' && r <= 'Z':
+				return r + ('a' - 'A')
+			}
+			return -1
+		}, os.Getenv("REGION"))
+		if region == "" {
+			region = defaultRegion
+		}
+	})
+	return region
+}
+
+// newID returns a fresh ID for a resource of the given kind.
+func newID(prefix string) string {
+	buf := make([]byte, idRandomBytes)
+	_, _ = rand.Read(buf)
+
+	body := prefix + "_" + idRegion() + "_" + idEncoding.EncodeToString(buf)
+	return body + idChecksum(body)
+}
+
+func idChecksum(body string) string {
+	sum := crc32.ChecksumIEEE([]byte(body))
+	return idEncoding.EncodeToString([]byte{byte(sum >> 24), byte(sum >> 16), byte(sum >> 8)})[:idCheckLen]
+}
+
+// ParsedID is a decoded resource ID.
+type ParsedID struct {
+	Prefix string
+	Region string
+}
+
+var errInvalidID = errors.New("invalid id")
+
+// parseID validates the structure and checksum of id and, when prefix is not
+// empty, that it names a resource of that kind.
+func parseID(id, prefix string) (ParsedID, error) {
+	parts := strings.Split(id, "_")
+	if len(parts) != 3 {
+		return ParsedID{}, errInvalidID
+	}
+	if prefix != "" && parts[0] != prefix {
+		return ParsedID{}, errInvalidID
+	}
+
+	tail := parts[2]
+	if len(tail) <= idCheckLen {
+		return ParsedID{}, errInvalidID
+	}
+	body := id[:len(id)-idCheckLen]
+	if idChecksum(body) != tail[len(tail)-idCheckLen:] {
+		return ParsedID{}, errInvalidID
+	}
+
+	return ParsedID{Prefix: parts[0], Region: parts[1]}, nil
+}
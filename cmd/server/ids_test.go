@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIDRoundTrip(t *testing.T) {
+	id := newID(IDPrefixPack)
+
+	if !strings.HasPrefix(id, "pk_"+idRegion()+"_") {
+		t.Errorf("Unexpected ID format %q", id)
+	}
+	parsed, err := parseID(id, IDPrefixPack)
+	if err != nil {
+		t.Fatalf("parseID(%q): %v", id, err)
+	}
+	if parsed.Region != idRegion() {
+		t.Errorf("Expected region %q, got %q", idRegion(), parsed.Region)
+	}
+
+	if _, err := parseID(id, IDPrefixJob); err == nil {
+		t.Error("Expected a pack ID to be rejected as a job ID")
+	}
+
+	// Flip one character of the random part.
+	b := []byte(id)
+	i := len(b) - idCheckLen - 1
+	if b[i] == 'a' {
+		b[i] = 'b'
+	} else {
+		b[i] = 'a'
+	}
+	if _, err := parseID(string(b), IDPrefixPack); err == nil {
+		t.Errorf("Expected tampered ID %q to fail the checksum", b)
+	}
+
+	if newID(IDPrefixPack) == id {
+		t.Error("Expected IDs to be unique")
+	}
+}
@@ -0,0 +1,516 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"binpacker/pkg/packing"
+)
+
+// Job statuses.
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// Job kinds.
+const (
+	JobKindOptimize = "optimize"
+	JobKindBoxSizes = "box_sizes"
+	JobKindPack     = "pack"
+)
+
+const (
+	defaultOptimizeDuration = time.Minute
+	maxOptimizeDuration     = 30 * time.Minute
+	checkpointInterval      = 10 * time.Second
+
+	// maxTrackedJobs bounds the job table. Past it the oldest finished job
+	// is dropped to make room; when every job is still running, new jobs
+	// are turned away with 503.
+	maxTrackedJobs = 10000
+)
+
+var (
+	errSearchesBusy = errors.New("too many optimization jobs running, try again later")
+	errTooManyJobs  = errors.New("too many unfinished jobs, try again later")
+)
+
+// Job is the public view of a background job.
+type Job struct {
+	ID         string        `json:"id"`
+	Kind       string        `json:"kind"`
+	Status     string        `json:"status"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+	Deadline   time.Time     `json:"deadline,omitzero"`
+	Iterations int           `json:"iterations,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Result     *PackResponse `json:"result,omitempty"`
+
+	Recommendation *BoxRecommendation `json:"recommendation,omitempty"`
+}
+
+// OptimizeRequest starts a long-running search for a better packing.
+type OptimizeRequest struct {
+	PackRequest
+	// OptimizeSeconds is the time budget; the best plan found so far is
+	// available from the job API while the search runs.
+	OptimizeSeconds int `json:"optimize_seconds,omitempty"`
+}
+
+// jobRecord is what gets checkpointed: the public job plus everything needed
+// to resume it after a restart.
+type jobRecord struct {
+	Job     Job                  `json:"job"`
+	Owner   string               `json:"owner,omitempty"`
+	Request OptimizeRequest      `json:"request"`
+	Search  *packing.SearchState `json:"search,omitempty"`
+
+	BoxSizes *RecommendBoxesRequest `json:"box_sizes,omitempty"`
+}
+
+// JobManager tracks background jobs. With a checkpoint directory, job state
+// is written to disk periodically and unfinished jobs resume on startup.
+type JobManager struct {
+	dir string
+
+	mu   sync.Mutex
+	jobs map[string]*jobRecord
+
+	// packWorkers bounds how many /pack/async jobs run at once. The
+	// workers start with the first job.
+	packWorkers int
+	packQueue   chan *jobRecord
+	packStart   sync.Once
+
+	// searchWorkers bounds how many optimize and box size searches run at
+	// once; searches counts the running ones.
+	searchWorkers int
+	searches      int
+
+	// stopping ends when the server shuts down: pack workers take no more
+	// jobs, and searches checkpoint and stop. running counts the goroutines
+	// Drain waits for.
+	stopping context.Context
+	stop     context.CancelFunc
+	running  sync.WaitGroup
+}
+
+// jobs is the process-wide job manager; main replaces it once the checkpoint
+// directory is known.
+var jobs = newJobManager("")
+
+func newJobManager(dir string) *JobManager {
+	stopping, stop := context.WithCancel(context.Background())
+	return &JobManager{
+		dir:           dir,
+		jobs:          make(map[string]*jobRecord),
+		packWorkers:   defaultPackWorkers(),
+		packQueue:     make(chan *jobRecord, maxQueuedPackJobs),
+		searchWorkers: defaultPackWorkers(),
+		stopping:      stopping,
+		stop:          stop,
+	}
+}
+
+// searchWorkersFromEnv reads OPTIMIZE_WORKERS, the number of optimize and box
+// size searches run concurrently.
+func searchWorkersFromEnv() (int, error) {
+	v := os.Getenv("OPTIMIZE_WORKERS")
+	if v == "" {
+		return defaultPackWorkers(), nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid OPTIMIZE_WORKERS %q", v)
+	}
+	return n, nil
+}
+
+// register adds rec to the job table and checkpoints it, evicting the oldest
+// finished job when the table is full.
+func (m *JobManager) register(rec *jobRecord) error {
+	m.mu.Lock()
+	var evicted []string
+	if len(m.jobs) >= maxTrackedJobs {
+		var oldest *jobRecord
+		for _, r := range m.jobs {
+			finished := r.Job.Status == JobDone || r.Job.Status == JobFailed
+			if finished && (oldest == nil || r.Job.UpdatedAt.Before(oldest.Job.UpdatedAt)) {
+				oldest = r
+			}
+		}
+		if oldest == nil {
+			m.mu.Unlock()
+			return errTooManyJobs
+		}
+		delete(m.jobs, oldest.Job.ID)
+		evicted = append(evicted, oldest.Job.ID)
+	}
+	m.jobs[rec.Job.ID] = rec
+	m.mu.Unlock()
+
+	m.removeCheckpoints(evicted)
+	m.checkpoint(rec)
+	return nil
+}
+
+// startSearch registers rec and runs it if a search slot is free.
+func (m *JobManager) startSearch(ctx context.Context, rec *jobRecord, run func(context.Context, *jobRecord)) error {
+	m.mu.Lock()
+	if m.searches >= m.searchWorkers {
+		m.mu.Unlock()
+		return errSearchesBusy
+	}
+	m.searches++
+	m.mu.Unlock()
+
+	if err := m.register(rec); err != nil {
+		m.endSearch()
+		return err
+	}
+	m.goJob(ctx, func(ctx context.Context) {
+		defer m.endSearch()
+		run(ctx, rec)
+	})
+	return nil
+}
+
+// resumeSearch runs a search restored from a checkpoint. It was accepted
+// before the restart, so it takes a slot even when none is free.
+func (m *JobManager) resumeSearch(ctx context.Context, rec *jobRecord, run func(context.Context, *jobRecord)) {
+	m.mu.Lock()
+	m.searches++
+	m.mu.Unlock()
+	m.goJob(ctx, func(ctx context.Context) {
+		defer m.endSearch()
+		run(ctx, rec)
+	})
+}
+
+func (m *JobManager) endSearch() {
+	m.mu.Lock()
+	m.searches--
+	m.mu.Unlock()
+}
+
+// writeJobsBusy answers a job submission refused for lack of capacity.
+func writeJobsBusy(w http.ResponseWriter, err error) {
+	w.Header().Set("Retry-After", "10")
+	http.Error(w, err.Error(), http.StatusServiceUnavailable)
+}
+
+// goJob runs a job in the background until it returns or ctx ends, or the
+// manager stops.
+func (m *JobManager) goJob(ctx context.Context, run func(context.Context)) {
+	ctx, cancel := context.WithCancel(ctx)
+	unlink := context.AfterFunc(m.stopping, cancel)
+	m.running.Go(func() {
+		defer cancel()
+		defer unlink()
+		run(ctx)
+	})
+}
+
+// Drain stops the manager for a shutdown and waits, until ctx ends, for the
+// running jobs: pack jobs finish, searches checkpoint their progress, and
+// queued jobs are left for the restart to run. Jobs still running when ctx
+// ends are run again after the restart.
+func (m *JobManager) Drain(ctx context.Context) error {
+	m.stop()
+	return waitGroup(ctx, &m.running)
+}
+
+// Get returns a snapshot of the job with the given ID.
+func (m *JobManager) Get(id string) (Job, bool) {
+	job, _, ok := m.get(id)
+	return job, ok
+}
+
+func (m *JobManager) get(id string) (Job, string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.jobs[id]
+	if !ok {
+		return Job{}, "", false
+	}
+	return rec.Job, rec.Owner, true
+}
+
+// StartOptimize registers an optimization job and runs it in the background.
+// The job belongs to owner, the authenticated principal that submitted it.
+// It fails with errSearchesBusy when OPTIMIZE_WORKERS searches are running.
+func (m *JobManager) StartOptimize(ctx context.Context, owner string, req OptimizeRequest) (Job, error) {
+	budget := time.Duration(req.OptimizeSeconds) * time.Second
+	if budget <= 0 {
+		budget = defaultOptimizeDuration
+	}
+	budget = min(budget, maxOptimizeDuration)
+
+	now := time.Now().UTC()
+	rec := &jobRecord{
+		Job: Job{
+			ID:        newID(IDPrefixJob),
+			Kind:      JobKindOptimize,
+			Status:    JobQueued,
+			CreatedAt: now,
+			UpdatedAt: now,
+			Deadline:  now.Add(budget),
+		},
+		Owner:   owner,
+		Request: req,
+	}
+	job := rec.Job
+
+	if err := m.startSearch(ctx, rec, m.runOptimize); err != nil {
+		return Job{}, err
+	}
+	return job, nil
+}
+
+// runOptimize drives the search until the deadline, publishing the best plan
+// after every improvement and checkpointing at most every checkpointInterval.
+func (m *JobManager) runOptimize(ctx context.Context, rec *jobRecord) {
+	m.mu.Lock()
+	req := rec.Request
+	resume := rec.Search
+	deadline := rec.Job.Deadline
+	seed := uint64(rec.Job.CreatedAt.UnixNano())
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+	budget := deadline.Sub(rec.Job.CreatedAt)
+	rec.Job.Status = JobRunning
+	m.mu.Unlock()
+
+	search := packing.NewSearch(req.Items, req.Boxes, req.Options, seed, resume)
+	m.publish(rec, search, JobRunning)
+
+	lastCheckpoint := time.Now()
+	for time.Now().Before(deadline) && ctx.Err() == nil {
+		if !m.tracked(rec) {
+			// The job was deleted while running.
+			return
+		}
+		search.Cool(1 - float64(time.Until(deadline))/float64(budget))
+		if search.Step() {
+			m.publish(rec, search, JobRunning)
+		}
+		if time.Since(lastCheckpoint) >= checkpointInterval {
+			m.publish(rec, search, JobRunning)
+			m.checkpoint(rec)
+			lastCheckpoint = time.Now()
+		}
+	}
+
+	if ctx.Err() != nil {
+		// Leave the job running so it resumes from the checkpoint on restart.
+		m.checkpoint(rec)
+		return
+	}
+
+	// Persist the final state before reporting it, so a job seen as done is
+	// never resumed from an older checkpoint.
+	m.publish(rec, search, JobRunning)
+	m.mu.Lock()
+	final := *rec
+	final.Job.Status = JobDone
+	m.mu.Unlock()
+	m.checkpoint(&final)
+
+	m.mu.Lock()
+	rec.Job.Status = JobDone
+	m.mu.Unlock()
+}
+
+func (m *JobManager) publish(rec *jobRecord, search *packing.Search, status string) {
+	state := search.State
+	resp := newPackResponse(state.Packed, state.Unpacked, rec.Request.Boxes)
+	resp.Units, resp.UnitGrid = rec.Request.Units, rec.Request.UnitGrid
+	resp.Profile = rec.Request.activeProfile
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec.Search = &state
+	rec.Job.Status = status
+	rec.Job.Iterations = state.Iterations
+	rec.Job.Result = &resp
+	rec.Job.UpdatedAt = time.Now().UTC()
+}
+
+// tracked reports whether rec is still registered with the manager.
+func (m *JobManager) tracked(rec *jobRecord) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.jobs[rec.Job.ID] != nil
+}
+
+// checkpoint writes the job record to disk, replacing the previous
+// checkpoint atomically. Deleted jobs are not written back.
+func (m *JobManager) checkpoint(rec *jobRecord) {
+	if m.dir == "" {
+		return
+	}
+
+	m.mu.Lock()
+	if m.jobs[rec.Job.ID] == nil {
+		m.mu.Unlock()
+		return
+	}
+	data, err := json.Marshal(rec)
+	id := rec.Job.ID
+	m.mu.Unlock()
+	if err != nil {
+		slog.Error("checkpoint job failed", "job_id", id, "error", err)
+		return
+	}
+
+	path := filepath.Join(m.dir, id+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		slog.Error("checkpoint job failed", "job_id", id, "error", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		slog.Error("checkpoint job failed", "job_id", id, "error", err)
+	}
+}
+
+// removeCheckpoints deletes the checkpoint files of removed jobs.
+func (m *JobManager) removeCheckpoints(ids []string) {
+	if m.dir == "" {
+		return
+	}
+	for _, id := range ids {
+		if err := os.Remove(filepath.Join(m.dir, id+".json")); err != nil && !os.IsNotExist(err) {
+			slog.Error("remove checkpoint failed", "job_id", id, "error", err)
+		}
+	}
+}
+
+// Resume loads checkpointed jobs and restarts the ones that had not finished.
+func (m *JobManager) Resume(ctx context.Context) error {
+	if m.dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return fmt.Errorf("create checkpoint dir: %w", err)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(m.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read checkpoint: %w", err)
+		}
+		var rec jobRecord
+		if err := json.Unmarshal(data, &rec); err != nil {
+			slog.Warn("skipping corrupt checkpoint", "path", path, "error", err)
+			continue
+		}
+
+		m.mu.Lock()
+		m.jobs[rec.Job.ID] = &rec
+		m.mu.Unlock()
+
+		if rec.Job.Status == JobQueued || rec.Job.Status == JobRunning {
+			slog.Info("resuming job", "job_id", rec.Job.ID, "iterations", rec.Job.Iterations)
+			switch rec.Job.Kind {
+			case JobKindBoxSizes:
+				m.resumeSearch(ctx, &rec, m.runBoxSizes)
+			case JobKindPack:
+				if err := m.enqueuePack(&rec); err != nil {
+					m.mu.Lock()
+					rec.Job.Status = JobFailed
+					rec.Job.Error = err.Error()
+					m.mu.Unlock()
+					m.checkpoint(&rec)
+				}
+			default:
+				m.resumeSearch(ctx, &rec, m.runOptimize)
+			}
+		}
+	}
+	return nil
+}
+
+func handleOptimize(w http.ResponseWriter, r *http.Request) {
+	var req OptimizeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := applyProfile(&req.PackRequest, principalFrom(r.Context()), time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Items) == 0 || len(req.Boxes) == 0 {
+		http.Error(w, "Items and Boxes are required", http.StatusBadRequest)
+		return
+	}
+	limits := getSettings().Limits
+	if err := checkRequestSize(req.Items, req.Boxes, limits); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	if err := applyUnits(&req.PackRequest, limits); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	if err := validateInput(req.Items, req.Boxes, limits); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	if err := req.Options.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The job outlives the request, so it must not inherit its context.
+	job, err := jobs.StartOptimize(context.Background(), principalFrom(r.Context()), req)
+	if err != nil {
+		writeJobsBusy(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(job)
+}
+
+func handleJob(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/jobs/")
+	if _, err := parseID(id, IDPrefixJob); err != nil {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+
+	job, owner, ok := jobs.get(id)
+	if !ok || owner != principalFrom(r.Context()) {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(job)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"binpacker/pkg/packing"
+)
+
+func TestOptimizePackRequest(t *testing.T) {
+	req := PackRequest{
+		Items:         []packing.InputItem{{ID: "cube", W: 10, H: 10, D: 10, Quantity: 4}},
+		Boxes:         []packing.InputBox{{ID: "box", W: 20, H: 20, D: 20}},
+		Visualization: VizModeNone,
+		OptimizeMS:    50,
+	}
+	if err := validatePackRequest(context.Background(), &req); err == nil {
+		t.Error("Expected optimize_ms without optimize to be rejected")
+	}
+	req.Optimize = true
+	if err := validatePackRequest(context.Background(), &req); err != nil {
+		t.Fatalf("Expected optimize request to be valid, got %v", err)
+	}
+	if run := req.optimizeRun(); run.Budget != 50*time.Millisecond {
+		t.Errorf("Expected a 50ms budget, got %v", run.Budget)
+	}
+
+	resp := runPack(context.Background(), req, time.Now())
+	if len(resp.UnpackedItems) != 0 || len(resp.PackedBoxes) != 1 {
+		t.Errorf("Expected all cubes in one box, got %d boxes and %d unpacked", len(resp.PackedBoxes), len(resp.UnpackedItems))
+	}
+	if resp.OptimizeIterations == 0 {
+		t.Error("Expected the search to try some orders")
+	}
+
+	seed := uint64(42)
+	req.Seed = &seed
+	if err := req.validateOptimize(); err == nil {
+		t.Error("Expected optimize_ms with a seed to be rejected")
+	}
+}
+
+func TestOptimizeValidatesInput(t *testing.T) {
+	body := `{"items": [{"id": "cube", "w": 0, "h": 10, "d": 10, "quantity": 4}], "boxes": [{"id": "box", "w": 20, "h": 20, "d": 20}]}`
+	rec := httptest.NewRecorder()
+	handleOptimize(rec, httptest.NewRequest(http.MethodPost, "/optimize", strings.NewReader(body)))
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a zero side, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestOptimizeJobCheckpointAndResume(t *testing.T) {
+	dir := t.TempDir()
+	m := newJobManager(dir)
+
+	req := OptimizeRequest{
+		PackRequest: PackRequest{
+			Items: []packing.InputItem{{ID: "cube", W: 10, H: 10, D: 10, Quantity: 4}},
+			Boxes: []packing.InputBox{{ID: "box", W: 20, H: 20, D: 20}},
+		},
+		OptimizeSeconds: 1,
+	}
+	job, err := m.StartOptimize(context.Background(), "", req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, _ := m.Get(job.ID)
+		if got.Status == JobDone {
+			if got.Result == nil || len(got.Result.PackedBoxes) != 1 {
+				t.Fatalf("Expected a one-box result, got %+v", got.Result)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Job did not finish, status %s", got.Status)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	restarted := newJobManager(dir)
+	if err := restarted.Resume(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := restarted.Get(job.ID)
+	if !ok || got.Status != JobDone || got.Result == nil {
+		t.Errorf("Expected finished job to be restored from checkpoint, got %+v", got)
+	}
+}
+
+func TestPackJobRunsInBackground(t *testing.T) {
+	m := newJobManager("")
+	m.packWorkers = 1
+
+	req := PackRequest{
+		Items:         []packing.InputItem{{ID: "cube", W: 10, H: 10, D: 10, Quantity: 4}},
+		Boxes:         []packing.InputBox{{ID: "box", W: 20, H: 20, D: 20}},
+		Visualization: VizModeNone,
+	}
+	job, err := m.StartPack("key:abc", req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Kind != JobKindPack || job.Status != JobQueued {
+		t.Errorf("Expected a queued pack job, got %+v", job)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, owner, _ := m.get(job.ID)
+		if got.Status == JobDone {
+			if got.Result == nil || len(got.Result.PackedBoxes) != 1 || got.Result.PackID == "" {
+				t.Errorf("Expected a stored one-box result, got %+v", got.Result)
+			}
+			if owner != "key:abc" {
+				t.Errorf("Expected the job to belong to its submitter, got %q", owner)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Job did not finish, status %s", got.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSearchJobsAreBounded(t *testing.T) {
+	m := newJobManager("")
+	m.searchWorkers = 1
+	req := OptimizeRequest{
+		PackRequest: PackRequest{
+			Items: []packing.InputItem{{ID: "cube", W: 10, H: 10, D: 10, Quantity: 4}},
+			Boxes: []packing.InputBox{{ID: "box", W: 20, H: 20, D: 20}},
+		},
+		OptimizeSeconds: 60,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := m.StartOptimize(ctx, "", req); err != nil {
+		t.Fatalf("Expected the first search to start, got %v", err)
+	}
+	if _, err := m.StartOptimize(ctx, "", req); err != errSearchesBusy {
+		t.Errorf("Expected errSearchesBusy with the only slot taken, got %v", err)
+	}
+	if _, err := m.StartBoxSizes(ctx, "", RecommendBoxesRequest{}); err != errSearchesBusy {
+		t.Errorf("Expected box size searches to share the slots, got %v", err)
+	}
+
+	cancel()
+	m.running.Wait()
+	if _, err := m.StartOptimize(context.Background(), "", OptimizeRequest{PackRequest: req.PackRequest, OptimizeSeconds: 1}); err != nil {
+		t.Errorf("Expected the slot to be free once the search stopped, got %v", err)
+	}
+}
+
+func TestJobTableEvictsFinishedJobs(t *testing.T) {
+	m := newJobManager("")
+	old := time.Now().Add(-time.Hour)
+	for i := range maxTrackedJobs {
+		m.jobs[fmt.Sprint(i)] = &jobRecord{Job: Job{ID: fmt.Sprint(i), Status: JobRunning, UpdatedAt: old}}
+	}
+	if err := m.register(&jobRecord{Job: Job{ID: "new"}}); err != errTooManyJobs {
+		t.Fatalf("Expected errTooManyJobs with every job running, got %v", err)
+	}
+
+	m.jobs["7"].Job.Status = JobDone
+	if err := m.register(&jobRecord{Job: Job{ID: "new"}}); err != nil {
+		t.Fatalf("Expected room made by evicting a finished job, got %v", err)
+	}
+	if _, ok := m.Get("7"); ok || len(m.jobs) != maxTrackedJobs {
+		t.Errorf("Expected the finished job evicted and %d jobs tracked, got %d", maxTrackedJobs, len(m.jobs))
+	}
+}
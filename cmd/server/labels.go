@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"binpacker/pkg/packing"
+)
+
+// zplMaxContentLines caps the contents summary so it fits on a 4x6" label;
+// the rest is summed up in a final "+ N more" line.
+const zplMaxContentLines = 8
+
+// zplField escapes a value for a ^FH field: ^ and ~ start ZPL commands and _
+// is the hex escape character itself.
+func zplField(s string) string {
+	return strings.NewReplacer("_", "_5F", "^", "_5E", "~", "_7E").Replace(s)
+}
+
+// zplLabels renders one 4x6" label per packed box at 203 dpi: pack and box
+// number, box type and size, total weight, a contents summary, a QR code and
+// a Code 128 barcode. Both codes hold "{pack_id}/{box number}".
+func zplLabels(p storedPack) string {
+	byID := packing.BoxesByID(p.Boxes)
+	n := len(p.Response.PackedBoxes)
+
+	var b strings.Builder
+	for i, pb := range p.Response.PackedBoxes {
+		box := byID[pb.BoxID]
+		code := fmt.Sprintf("%s/%d", p.Response.PackID, i+1)
+
+		b.WriteString("^XA\n^CI28\n^PW812\n^LL1218\n")
+		fmt.Fprintf(&b, "^FO40,40^A0N,70,70^FDBox %d of %d^FS\n", i+1, n)
+		fmt.Fprintf(&b, "^FO40,130^A0N,32,32^FH^FD%s^FS\n", zplField(p.Response.PackID))
+		fmt.Fprintf(&b, "^FO40,180^A0N,32,32^FH^FD%s  %s x %s x %s^FS\n", zplField(pb.BoxID), p.Response.lengthText(box.W), p.Response.lengthText(box.H), p.Response.lengthText(box.D))
+		if pb.TotalWeight > 0 {
+			fmt.Fprintf(&b, "^FO40,230^A0N,40,40^FDWeight: %s^FS\n", strconv.FormatFloat(round1(pb.TotalWeight), 'f', -1, 64))
+		}
+		b.WriteString("^FO40,290^GB732,3,3^FS\n")
+
+		y := 320
+		for _, line := range contentsSummary(pb.Contents) {
+			fmt.Fprintf(&b, "^FO40,%d^A0N,30,30^FH^FD%s^FS\n", y, zplField(line))
+			y += 40
+		}
+
+		fmt.Fprintf(&b, "^FO40,700^BQN,2,6^FDQA,%s^FS\n", code)
+		fmt.Fprintf(&b, "^FO40,1000^BY2^BCN,120,Y,N,N^FH^FD%s^FS\n", zplField(code))
+		b.WriteString("^XZ\n")
+	}
+	return b.String()
+}
+
+// contentsSummary lists item counts in packing order, one line per item ID.
+func contentsSummary(contents []packing.Placement) []string {
+	var order []string
+	counts := make(map[string]int)
+	for _, c := range contents {
+		if counts[c.ItemID] == 0 {
+			order = append(order, c.ItemID)
+		}
+		counts[c.ItemID]++
+	}
+
+	lines := make([]string, 0, min(len(order), zplMaxContentLines))
+	for i, id := range order {
+		if i == zplMaxContentLines-1 && len(order) > zplMaxContentLines {
+			lines = append(lines, fmt.Sprintf("+ %d more item types", len(order)-i))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%d x %s", counts[id], id))
+	}
+	return lines
+}
+
+// handlePackLabels serves /packs/{id}/labels?format=zpl.
+func handlePackLabels(w http.ResponseWriter, r *http.Request, p storedPack) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if format := r.URL.Query().Get("format"); format != "" && format != "zpl" {
+		http.Error(w, fmt.Sprintf("unsupported label format %q: use %q", format, "zpl"), http.StatusBadRequest)
+		return
+	}
+
+	name := strings.ReplaceAll(p.Response.PackID, `"`, "") + ".zpl"
+	w.Header().Set("Content-Type", "application/zpl; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	_, _ = w.Write([]byte(zplLabels(p)))
+}
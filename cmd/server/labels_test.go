@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"binpacker/pkg/packing"
+)
+
+func TestPackLabelsZPL(t *testing.T) {
+	boxes := []packing.InputBox{{ID: "box^1", W: 10, H: 10, D: 10, MaxWeight: 4}}
+	packed, unpacked := packing.Pack([]packing.InputItem{{ID: "mug", W: 5, H: 5, D: 5, Quantity: 3, Weight: 1.5}}, boxes)
+	resp := newPackResponse(packed, unpacked, boxes)
+	resp.PackID = newID(IDPrefixPack)
+	store.SavePack("", "", resp, boxes)
+
+	rec := httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodGet, "/packs/"+resp.PackID+"/labels?format=zpl", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+	zpl := rec.Body.String()
+
+	if got := strings.Count(zpl, "^XA"); got != 2 {
+		t.Errorf("Expected one label per box (2), got %d", got)
+	}
+	for _, want := range []string{"Box 1 of 2", "Weight: 3", "2 x mug", "box_5E1", "^FDQA," + resp.PackID + "/2"} {
+		if !strings.Contains(zpl, want) {
+			t.Errorf("Expected %q in the labels", want)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodGet, "/packs/"+resp.PackID+"/labels?format=pdf", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unsupported format, got %d", rec.Code)
+	}
+}
+
+func TestContentsSummaryTruncates(t *testing.T) {
+	var contents []packing.Placement
+	for _, id := range strings.Split("abcdefghij", "") {
+		contents = append(contents, packing.Placement{ItemID: id})
+	}
+	lines := contentsSummary(contents)
+	if len(lines) != zplMaxContentLines || lines[len(lines)-1] != "+ 3 more item types" {
+		t.Errorf("Expected %d lines ending in a remainder, got %q", zplMaxContentLines, lines)
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"binpacker/pkg/packing"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	maxLiveItems       = 5000
+	liveReadLimit      = 1 << 20
+	livePongWait       = 60 * time.Second
+	livePingInterval   = 50 * time.Second
+	liveWriteTimeout   = 10 * time.Second
+	liveMessageBacklog = 64
+)
+
+// LiveMessage is sent by the client on the /pack/live WebSocket.
+//
+//	{"type": "init", "boxes": [...], "items": [...], ...pack options}
+//	{"type": "add", "items": [...]}
+//	{"type": "remove", "item_id": "mug", "quantity": 1}
+//	{"type": "repack"}
+type LiveMessage struct {
+	Type     string              `json:"type"`
+	Boxes    []packing.InputBox  `json:"boxes,omitempty"`
+	Items    []packing.InputItem `json:"items,omitempty"`
+	ItemID   string              `json:"item_id,omitempty"`
+	Quantity int                 `json:"quantity,omitempty"`
+	packing.Options
+}
+
+// LiveUpdate is sent back after each batch of client messages.
+type LiveUpdate struct {
+	Type string `json:"type"`
+	Seq  int    `json:"seq"`
+	livePack
+	// ChangedBoxes are the indexes of boxes whose contents changed since
+	// the previous update, so clients only need to redraw those.
+	ChangedBoxes []int  `json:"changed_boxes"`
+	Error        string `json:"error,omitempty"`
+}
+
+// livePack is PackResponse without its JSON methods, which would otherwise
+// take over the encoding of LiveUpdate. Live sessions don't take units.
+type livePack PackResponse
+
+// liveSession is the packing behind a /pack/live connection.
+type liveSession struct {
+	*packing.Session
+	boxes []packing.InputBox
+}
+
+func newLiveSession(boxes []packing.InputBox, opts packing.Options) *liveSession {
+	return &liveSession{Session: packing.NewSession(boxes, opts), boxes: boxes}
+}
+
+func (s *liveSession) apply(ctx context.Context, msg LiveMessage) error {
+	switch msg.Type {
+	case "add":
+		limits := getSettings().Limits
+		if err := checkRequestSize(msg.Items, nil, limits); err != nil {
+			return err
+		}
+		if err := validateInput(msg.Items, nil, limits); err != nil {
+			return err
+		}
+		units := 0
+		for _, it := range msg.Items {
+			// Compare before adding so huge quantities cannot overflow units.
+			if it.Quantity > maxLiveItems-s.Len()-units {
+				return fmt.Errorf("a live session holds at most %d items", maxLiveItems)
+			}
+			units += it.Quantity
+		}
+		return s.Add(ctx, msg.Items)
+	case "remove":
+		n := msg.Quantity
+		if n <= 0 {
+			n = 1
+		}
+		if s.Remove(ctx, msg.ItemID, n) == 0 {
+			return fmt.Errorf("item %q is not in the shipment", msg.ItemID)
+		}
+	case "repack":
+		return s.Repack(ctx)
+	default:
+		return fmt.Errorf("unknown message type %q", msg.Type)
+	}
+	return nil
+}
+
+func (s *liveSession) update(seq int) LiveUpdate {
+	packed, unpacked := s.Result()
+	return LiveUpdate{Type: "update", Seq: seq, livePack: livePack(newPackResponse(packed, unpacked, s.boxes)), ChangedBoxes: s.Changed()}
+}
+
+// liveRegistry tracks the open /pack/live connections. http.Server.Shutdown
+// neither closes nor waits for hijacked connections, so the server closes
+// them through closeAll and waits for them itself.
+type liveRegistry struct {
+	mu      sync.Mutex
+	closing chan struct{}
+	open    sync.WaitGroup
+}
+
+var liveSessions = &liveRegistry{closing: make(chan struct{})}
+
+// join registers a session and returns the channel closed when it should
+// end.
+func (l *liveRegistry) join() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.open.Add(1)
+	return l.closing
+}
+
+func (l *liveRegistry) leave() {
+	l.open.Done()
+}
+
+// closeAll asks the open sessions to close; sessions that join later are
+// not affected.
+func (l *liveRegistry) closeAll() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	close(l.closing)
+	l.closing = make(chan struct{})
+}
+
+// wait waits for the sessions to close, or until ctx ends.
+func (l *liveRegistry) wait(ctx context.Context) error {
+	return waitGroup(ctx, &l.open)
+}
+
+var liveUpgrader = websocket.Upgrader{
+	// Credentials travel in headers, not cookies, so cross-origin pages
+	// cannot ride on a user's session.
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// handleLive serves the /pack/live WebSocket. The first message must be an
+// init; after that, messages that arrive while an update is being computed
+// are applied together, so fast typing does not queue up stale results.
+func handleLive(w http.ResponseWriter, r *http.Request) {
+	conn, err := liveUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	closing := liveSessions.join()
+	defer liveSessions.leave()
+	defer conn.Close()
+
+	conn.SetReadLimit(liveReadLimit)
+	_ = conn.SetReadDeadline(time.Now().Add(livePongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(livePongWait))
+	})
+
+	msgs := make(chan LiveMessage, liveMessageBacklog)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(msgs)
+		for {
+			var msg LiveMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) && !errors.Is(err, websocket.ErrCloseSent) {
+					slog.WarnContext(r.Context(), "live session ended", "error", err)
+				}
+				return
+			}
+			select {
+			case msgs <- msg:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	send := func(u LiveUpdate) bool {
+		_ = conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+		return conn.WriteJSON(u) == nil
+	}
+
+	ping := time.NewTicker(livePingInterval)
+	defer ping.Stop()
+
+	var session *liveSession
+	seq := 0
+	for {
+		var msg LiveMessage
+		var ok bool
+		select {
+		case msg, ok = <-msgs:
+			if !ok {
+				return
+			}
+		case <-closing:
+			msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+			_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(liveWriteTimeout))
+			return
+		case <-ping.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+			continue
+		}
+
+		// Drain whatever else is already waiting into the same update.
+		batch := []LiveMessage{msg}
+	drain:
+		for {
+			select {
+			case m, ok := <-msgs:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, m)
+			default:
+				break drain
+			}
+		}
+
+		// Every batch re-packs, so it takes an interactive slot like /pack.
+		// A batch that gets none is dropped and the client told to resend.
+		release, err := lanePool(LaneInteractive).acquire(r.Context())
+		if err != nil {
+			packsRejected.Add(1)
+			seq++
+			if !send(LiveUpdate{Type: "update", Seq: seq, ChangedBoxes: []int{}, Error: errPackPoolBusy.Error()}) {
+				return
+			}
+			continue
+		}
+
+		// The batch gets the interactive lane's pack timeout, like a /pack.
+		packCtx, cancel := r.Context(), func() {}
+		if packTimeout := getSettings().packTimeout(LaneInteractive); packTimeout > 0 {
+			packCtx, cancel = context.WithTimeout(r.Context(), packTimeout)
+		}
+
+		var errs []string
+		for _, m := range batch {
+			if m.Type == "init" {
+				if len(m.Boxes) == 0 {
+					errs = append(errs, "init requires boxes")
+					continue
+				}
+				if err := validateBoxes("boxes", m.Boxes, getSettings().Limits); err != nil {
+					errs = append(errs, err.Error())
+					continue
+				}
+				if err := m.Options.Validate(); err != nil {
+					errs = append(errs, err.Error())
+					continue
+				}
+				session = newLiveSession(m.Boxes, m.Options)
+				m.Type = "add"
+			}
+			if session == nil {
+				errs = append(errs, "send an init message first")
+				continue
+			}
+			if m.Type == "add" && len(m.Items) == 0 {
+				continue
+			}
+			if err := session.apply(packCtx, m); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+				errs = append(errs, err.Error())
+			}
+		}
+		if errors.Is(packCtx.Err(), context.DeadlineExceeded) {
+			errs = append(errs, "packing timed out: items that could not be placed in time are unpacked, and a timed-out repack leaves the layout unchanged")
+		}
+		cancel()
+
+		seq++
+		u := LiveUpdate{Type: "update", Seq: seq, ChangedBoxes: []int{}}
+		if session != nil {
+			u = session.update(seq)
+		}
+		release()
+		if len(errs) > 0 {
+			u.Error = strings.Join(errs, "; ")
+		}
+		if !send(u) {
+			return
+		}
+	}
+}
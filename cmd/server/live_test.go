@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"binpacker/pkg/packing"
+	"github.com/gorilla/websocket"
+)
+
+func TestLiveSessionAddRemove(t *testing.T) {
+	s := newLiveSession([]packing.InputBox{{ID: "small", W: 10, H: 10, D: 10}}, packing.Options{})
+
+	if err := s.apply(context.Background(), LiveMessage{Type: "add", Items: []packing.InputItem{{ID: "cube", W: 10, H: 10, D: 5, Quantity: 3}}}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	u := s.update(1)
+	if len(u.PackedBoxes) != 2 {
+		t.Fatalf("Expected 2 boxes, got %d", len(u.PackedBoxes))
+	}
+
+	// Adding one more fills the second box without touching the first.
+	if err := s.apply(context.Background(), LiveMessage{Type: "add", Items: []packing.InputItem{{ID: "cube", W: 10, H: 10, D: 5, Quantity: 1}}}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	u = s.update(2)
+	if len(u.PackedBoxes) != 2 || len(u.ChangedBoxes) != 1 || u.ChangedBoxes[0] != 1 {
+		t.Errorf("Expected only box 1 to change, got %v", u.ChangedBoxes)
+	}
+
+	if err := s.apply(context.Background(), LiveMessage{Type: "remove", ItemID: "cube", Quantity: 2}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	u = s.update(3)
+	if len(u.PackedBoxes) != 1 {
+		t.Errorf("Expected the emptied box to be dropped, got %d boxes", len(u.PackedBoxes))
+	}
+
+	if err := s.apply(context.Background(), LiveMessage{Type: "remove", ItemID: "mug"}); err == nil {
+		t.Error("Expected an error removing an item that is not in the shipment")
+	}
+	huge := []packing.InputItem{{ID: "a", W: 1, H: 1, D: 1, Quantity: 1 << 62}, {ID: "b", W: 1, H: 1, D: 1, Quantity: 1 << 62}}
+	if err := s.apply(context.Background(), LiveMessage{Type: "add", Items: huge}); err == nil {
+		t.Error("Expected quantities whose sum overflows to be rejected")
+	}
+	if err := s.apply(context.Background(), LiveMessage{Type: "add", Items: []packing.InputItem{{ID: "big", W: 2_000_000, H: 1, D: 1, Quantity: 1}}}); err == nil {
+		t.Error("Expected a side over the limit to be rejected")
+	}
+	if s.Len() != 2 {
+		t.Errorf("Expected the rejected adds to leave 2 items, got %d", s.Len())
+	}
+}
+
+func TestLiveRepackMatchesFullPack(t *testing.T) {
+	boxes := []packing.InputBox{{ID: "small", W: 10, H: 10, D: 10}, {ID: "large", W: 20, H: 20, D: 20}}
+	items := []packing.InputItem{{ID: "a", W: 10, H: 10, D: 10, Quantity: 4}, {ID: "b", W: 5, H: 5, D: 5, Quantity: 6}}
+
+	s := newLiveSession(boxes, packing.Options{})
+	for _, it := range items {
+		if err := s.apply(context.Background(), LiveMessage{Type: "add", Items: []packing.InputItem{it}}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if err := s.apply(context.Background(), LiveMessage{Type: "repack"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want, _ := packing.PackWithOptions(items, boxes, packing.Options{})
+	got := s.update(1)
+	if len(got.PackedBoxes) != len(want) {
+		t.Errorf("Expected %d boxes after repack, got %d", len(want), len(got.PackedBoxes))
+	}
+	if s.Len() != 10 {
+		t.Errorf("Expected 10 items in the session, got %d", s.Len())
+	}
+}
+
+func TestLiveWebSocket(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(Packer))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/pack/live", nil)
+	if err != nil {
+		t.Fatalf("Expected to connect, got %v", err)
+	}
+	defer conn.Close()
+
+	var u LiveUpdate
+	if err := conn.WriteJSON(LiveMessage{Type: "add", Items: []packing.InputItem{{ID: "a", W: 1, H: 1, D: 1, Quantity: 1}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.ReadJSON(&u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Error == "" {
+		t.Error("Expected an error before init")
+	}
+
+	if err := conn.WriteJSON(LiveMessage{Type: "init", Boxes: []packing.InputBox{{ID: "box", W: 0, H: 10, D: 10}}}); err != nil {
+		t.Fatal(err)
+	}
+	u = LiveUpdate{}
+	if err := conn.ReadJSON(&u); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(u.Error, "boxes[0].w") {
+		t.Errorf("Expected the zero box side to be rejected, got %q", u.Error)
+	}
+
+	err = conn.WriteJSON(LiveMessage{
+		Type:  "init",
+		Boxes: []packing.InputBox{{ID: "box", W: 10, H: 10, D: 10}},
+		Items: []packing.InputItem{{ID: "a", W: 5, H: 5, D: 5, Quantity: 2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.ReadJSON(&u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Type != "update" || u.Seq != 3 || len(u.PackedBoxes) != 1 || len(u.PackedBoxes[0].Contents) != 2 {
+		t.Errorf("Expected one box with 2 items at seq 3, got %+v", u)
+	}
+}
+
+func TestLiveBatchTimesOut(t *testing.T) {
+	s := newLiveSession([]packing.InputBox{{ID: "box", W: 10, H: 10, D: 10}}, packing.Options{})
+	if err := s.apply(context.Background(), LiveMessage{Type: "add", Items: []packing.InputItem{{ID: "a", W: 5, H: 5, D: 5, Quantity: 2}}}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	s.Changed()
+	expired, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+	if err := s.apply(expired, LiveMessage{Type: "repack"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the repack to time out, got %v", err)
+	}
+	if u := s.update(1); len(u.PackedBoxes) != 1 || len(u.ChangedBoxes) != 0 {
+		t.Errorf("Expected a timed-out repack to leave the layout alone, got %+v", u)
+	}
+
+	defer setSettings(getSettings())
+	updateSettings(func(s *Settings) { s.PackTimeout = time.Nanosecond })
+
+	srv := httptest.NewServer(http.HandlerFunc(Packer))
+	defer srv.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/pack/live", nil)
+	if err != nil {
+		t.Fatalf("Expected to connect, got %v", err)
+	}
+	defer conn.Close()
+
+	err = conn.WriteJSON(LiveMessage{
+		Type:  "init",
+		Boxes: []packing.InputBox{{ID: "box", W: 10, H: 10, D: 10}},
+		Items: []packing.InputItem{{ID: "a", W: 5, H: 5, D: 5, Quantity: 2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var u LiveUpdate
+	if err := conn.ReadJSON(&u); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(u.Error, "timed out") || len(u.UnpackedItems) != 2 {
+		t.Errorf("Expected a timeout with both items unpacked, got %+v", u)
+	}
+}
+
+func TestLiveTakesPackSlot(t *testing.T) {
+	old := packs[LaneInteractive]
+	packs[LaneInteractive] = newPackPool(1, 0, 0)
+	defer func() { packs[LaneInteractive] = old }()
+	release, _ := packs[LaneInteractive].acquire(context.Background())
+
+	srv := httptest.NewServer(http.HandlerFunc(Packer))
+	defer srv.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/pack/live", nil)
+	if err != nil {
+		t.Fatalf("Expected to connect, got %v", err)
+	}
+	defer conn.Close()
+
+	init := LiveMessage{
+		Type:  "init",
+		Boxes: []packing.InputBox{{ID: "box", W: 10, H: 10, D: 10}},
+		Items: []packing.InputItem{{ID: "a", W: 5, H: 5, D: 5, Quantity: 2}},
+	}
+	var u LiveUpdate
+	if err := conn.WriteJSON(init); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.ReadJSON(&u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Error != errPackPoolBusy.Error() || len(u.PackedBoxes) != 0 {
+		t.Errorf("Expected the batch refused while every slot is taken, got %+v", u)
+	}
+
+	release()
+	if err := conn.WriteJSON(init); err != nil {
+		t.Fatal(err)
+	}
+	u = LiveUpdate{}
+	if err := conn.ReadJSON(&u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Error != "" || len(u.PackedBoxes) != 1 {
+		t.Errorf("Expected the resent batch packed, got %+v", u)
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// requestIDHeader carries the ID of a request. A client may send one to
+// trace a request through its own systems; the response always has one.
+const requestIDHeader = "X-Request-ID"
+
+// maxLoggedError is how much of an error response is logged as its error.
+const maxLoggedError = 256
+
+// logLevel is the level of the server log, LOG_LEVEL in the settings.
+var logLevel = new(slog.LevelVar)
+
+// newLogger logs JSON lines to w. Records logged with the context of a
+// request carry its ID.
+func newLogger(w io.Writer) *slog.Logger {
+	return slog.New(contextHandler{slog.NewJSONHandler(w, &slog.HandlerOptions{Level: logLevel})})
+}
+
+// contextHandler adds the request ID in the context to each record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestIDFrom(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// requestLog collects what handlers add to the log line of a request, which
+// is written to logger.
+type requestLog struct {
+	id      string
+	logger  *slog.Logger
+	mu      sync.Mutex
+	attrs   []slog.Attr
+	outcome string
+}
+
+type requestLogKey struct{}
+
+func requestLogFrom(ctx context.Context) *requestLog {
+	l, _ := ctx.Value(requestLogKey{}).(*requestLog)
+	return l
+}
+
+// requestIDFrom returns the ID of the request ctx belongs to, if any.
+func requestIDFrom(ctx context.Context) string {
+	if l := requestLogFrom(ctx); l != nil {
+		return l.id
+	}
+	return ""
+}
+
+// requestLogger returns the logger of the request ctx belongs to, or the
+// default logger outside a request.
+func requestLogger(ctx context.Context) *slog.Logger {
+	if l := requestLogFrom(ctx); l != nil {
+		return l.logger
+	}
+	return slog.Default()
+}
+
+// logAttrs adds attrs to the log line of the request ctx belongs to.
+func logAttrs(ctx context.Context, attrs ...slog.Attr) {
+	if l := requestLogFrom(ctx); l != nil {
+		l.mu.Lock()
+		l.attrs = append(l.attrs, attrs...)
+		l.mu.Unlock()
+	}
+}
+
+// logOutcome replaces the outcome of the request ctx belongs to, which is
+// otherwise worked out from the status.
+func logOutcome(ctx context.Context, outcome string) {
+	if l := requestLogFrom(ctx); l != nil {
+		l.mu.Lock()
+		l.outcome = outcome
+		l.mu.Unlock()
+	}
+}
+
+// requestID returns the ID the client sent, if it is safe to log, or a
+// fresh one.
+func requestID(r *http.Request) string {
+	id := r.Header.Get(requestIDHeader)
+	if id == "" || len(id) > 128 {
+		return newID(IDPrefixRequest)
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == ':':
+		default:
+			return newID(IDPrefixRequest)
+		}
+	}
+	return id
+}
+
+// LogMiddleware gives every request an ID, returned in X-Request-ID, and
+// logs one line when it completes: the ID, method, path, status, latency,
+// outcome and whatever the handler added with logAttrs. A failed request
+// also logs the start of its error response, so a failure a client reports
+// by ID can be found with its cause. Lines go to logger, which handlers
+// reach through requestLogger.
+func LogMiddleware(logger *slog.Logger, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		l := &requestLog{id: requestID(r), logger: logger}
+		w.Header().Set(requestIDHeader, l.id)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		ctx := context.WithValue(r.Context(), requestLogKey{}, l)
+		next(rec, r.WithContext(ctx))
+
+		level, outcome := slog.LevelInfo, "ok"
+		switch {
+		case rec.status >= 500:
+			level, outcome = slog.LevelError, "failed"
+		case rec.status >= 400:
+			level, outcome = slog.LevelWarn, "rejected"
+		}
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.outcome != "" {
+			outcome = l.outcome
+		}
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("outcome", outcome),
+		}
+		if len(rec.errBody) > 0 {
+			attrs = append(attrs, slog.String("error", strings.TrimSpace(string(rec.errBody))))
+		}
+		attrs = append(attrs, l.attrs...)
+		logger.LogAttrs(ctx, level, "request", attrs...)
+	}
+}
+
+// statusRecorder records the status of a response and the start of its
+// body if it is an error.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	errBody     []byte
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status, rec.wroteHeader = status, true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	if rec.status >= 400 && len(rec.errBody) < maxLoggedError {
+		n := min(len(b), maxLoggedError-len(rec.errBody))
+		rec.errBody = append(rec.errBody, b[:n]...)
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the flushing, deadlines and
+// full duplex of the underlying writer.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// Flush and Hijack serve handlers that assert the interfaces instead, like
+// the WebSocket upgrader of /pack/live.
+func (rec *statusRecorder) Flush() {
+	_ = http.NewResponseController(rec.ResponseWriter).Flush()
+}
+
+func (rec *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	rec.status, rec.wroteHeader = http.StatusSwitchingProtocols, true
+	return http.NewResponseController(rec.ResponseWriter).Hijack()
+}
+
+// logPackRequest logs the size of a pack request as sent.
+func logPackRequest(ctx context.Context, req PackRequest) {
+	units := 0
+	for _, it := range req.Items {
+		units += max(it.Quantity, 0)
+	}
+	logAttrs(ctx, slog.Int("items", len(req.Items)), slog.Int("units", units), slog.Int("box_types", len(req.Boxes)))
+}
+
+// logPackResponse logs the result of a pack, and marks a pack that left
+// units out as partial.
+func logPackResponse(ctx context.Context, resp PackResponse) {
+	logAttrs(ctx, slog.String("pack_id", resp.PackID), slog.Int("boxes", len(resp.PackedBoxes)), slog.Int("unpacked", len(resp.UnpackedItems)))
+	switch {
+	case resp.TimedOut:
+		logOutcome(ctx, "timed_out")
+	case len(resp.UnpackedItems) > 0:
+		logOutcome(ctx, "partial")
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// captureLog returns a logger writing to the returned buffer. The default
+// logger is left alone, since other tests' goroutines may still log to it.
+func captureLog() (*slog.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	return newLogger(&buf), &buf
+}
+
+func TestLogMiddleware(t *testing.T) {
+	logger, buf := captureLog()
+	handler := LogMiddleware(logger, Packer)
+
+	rec := httptest.NewRecorder()
+	body := `{"items": [{"id": "a", "w": 5, "h": 5, "d": 5, "quantity": 3}], "boxes": [{"id": "b", "w": 10, "h": 10, "d": 10}]}`
+	handler(rec, httptest.NewRequest(http.MethodPost, "/pack", strings.NewReader(body)))
+	id := rec.Header().Get(requestIDHeader)
+	if _, err := parseID(id, IDPrefixRequest); err != nil {
+		t.Fatalf("Expected a generated request ID, got %q", id)
+	}
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("Expected one JSON log line, got %q", buf)
+	}
+	if line["request_id"] != id || line["status"] != 200.0 || line["outcome"] != "ok" || line["units"] != 3.0 || line["boxes"] != 1.0 {
+		t.Errorf("Expected the request logged with its ID and counts, got %v", line)
+	}
+	if _, ok := line["latency_ms"]; !ok {
+		t.Errorf("Expected the latency logged, got %v", line)
+	}
+
+	buf.Reset()
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/pack", strings.NewReader(`{"items": []}`))
+	req.Header.Set(requestIDHeader, "client-trace:42")
+	handler(rec, req)
+	if got := rec.Header().Get(requestIDHeader); got != "client-trace:42" {
+		t.Errorf("Expected the client request ID echoed, got %q", got)
+	}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatal(err)
+	}
+	if line["level"] != "WARN" || line["outcome"] != "rejected" || line["error"] != "Items and Boxes are required" {
+		t.Errorf("Expected the rejection logged with its cause, got %v", line)
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set(requestIDHeader, "bad id\n{}")
+	handler(rec, req)
+	if got := rec.Header().Get(requestIDHeader); !strings.HasPrefix(got, IDPrefixRequest+"_") {
+		t.Errorf("Expected an unsafe client ID replaced, got %q", got)
+	}
+}
+
+func TestRecoverLogsRequestID(t *testing.T) {
+	logger, buf := captureLog()
+	handler := LogMiddleware(logger, RecoverMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	id := rec.Header().Get(requestIDHeader)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected the panic and the request logged, got %q", buf)
+	}
+	for _, l := range lines {
+		if !strings.Contains(l, `"request_id":"`+id+`"`) {
+			t.Errorf("Expected request ID %s in %s", id, l)
+		}
+	}
+	if !strings.Contains(lines[1], `"outcome":"failed"`) {
+		t.Errorf("Expected the request logged as failed, got %s", lines[1])
+	}
+}
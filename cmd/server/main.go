@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	slog.SetDefault(newLogger(os.Stderr))
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			fatal("command failed", "error", err)
+		}
+		return
+	}
+
+	allowed, err := parseCIDRs(os.Getenv("ALLOWED_CIDRS"))
+	if err != nil {
+		fatal("invalid ALLOWED_CIDRS", "error", err)
+	}
+	trustProxy := os.Getenv("TRUST_PROXY_HEADERS") == "true"
+
+	tlsConfig, err := tlsConfigFromEnv()
+	if err != nil {
+		fatal("invalid TLS configuration", "error", err)
+	}
+
+	s, err := loadSettings()
+	if err != nil {
+		fatal("invalid configuration", "error", err)
+	}
+	setSettings(s)
+	go reloadOnSIGHUP()
+
+	jobs = newJobManager(os.Getenv("CHECKPOINT_DIR"))
+	if jobs.packWorkers, err = packWorkersFromEnv(); err != nil {
+		fatal("invalid pack worker configuration", "error", err)
+	}
+	if jobs.searchWorkers, err = searchWorkersFromEnv(); err != nil {
+		fatal("invalid optimize worker configuration", "error", err)
+	}
+	if packs, err = packLanesFromEnv(); err != nil {
+		fatal("invalid pack pool configuration", "error", err)
+	}
+	if err := jobs.Resume(context.Background()); err != nil {
+		fatal("resume jobs failed", "error", err)
+	}
+
+	backend, err := storageFromEnv()
+	if err != nil {
+		fatal("invalid storage configuration", "error", err)
+	}
+	_, inMemory := backend.(*memoryStorage)
+	if backend, err = encryptedStorageFromEnv(backend); err != nil {
+		fatal("invalid storage encryption", "error", err)
+	}
+	store = newStoreOn(backend)
+	vizLimits, err := vizLimitsFromEnv(inMemory)
+	if err != nil {
+		fatal("invalid visualization limits", "error", err)
+	}
+	store.setVizLimits(vizLimits)
+	tasks := newBackgroundTasks()
+	if vizLimits.TTL > 0 {
+		tasks.Go(func(ctx context.Context) { runVizSweeper(ctx, min(vizLimits.TTL, defaultVizSweepInterval)) })
+	}
+
+	retention, err := retentionFromEnv()
+	if err != nil {
+		fatal("invalid retention policy", "error", err)
+	}
+	janitorInterval := defaultJanitorInterval
+	if v := os.Getenv("JANITOR_INTERVAL"); v != "" {
+		if janitorInterval, err = time.ParseDuration(v); err != nil || janitorInterval <= 0 {
+			fatal("invalid JANITOR_INTERVAL", "value", v)
+		}
+	}
+	tasks.Go(func(ctx context.Context) { runJanitor(ctx, retention, janitorInterval) })
+
+	if archive, err = archiverFromEnv(); err != nil {
+		fatal("invalid archive configuration", "error", err)
+	}
+	if archive != nil {
+		tasks.Go(archive.Run)
+	}
+	if telemetry, err = telemetryFromEnv(); err != nil {
+		fatal("invalid telemetry configuration", "error", err)
+	}
+	if telemetry != nil {
+		tasks.Go(func(ctx context.Context) { telemetry.Run(ctx, telemetryFlushEvery) })
+	}
+
+	if rates, baseCurrency, err = ratesFromEnv(); err != nil {
+		fatal("invalid currency configuration", "error", err)
+	}
+
+	if mode := os.Getenv("SERVICE_MODE"); mode != "" {
+		if err := setServiceMode(ServiceMode{Mode: mode, Message: os.Getenv("SERVICE_MODE_MESSAGE")}); err != nil {
+			fatal("invalid SERVICE_MODE", "error", err)
+		}
+	}
+
+	authChain, err := authenticatorsFromEnv()
+	if err != nil {
+		fatal("invalid authentication configuration", "error", err)
+	}
+	if len(authChain) == 0 {
+		slog.Warn("authentication disabled; all requests are allowed")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", IPAllowlistMiddleware(allowed, trustProxy, ModeMiddleware(AuthMiddleware(authChain, RateLimitMiddleware(trustProxy, Packer)))))
+	mux.HandleFunc("/metrics", IPAllowlistMiddleware(allowed, trustProxy, Metrics))
+	mux.HandleFunc("/selftest", IPAllowlistMiddleware(allowed, trustProxy, AdminMiddleware(SelfTest)))
+	mux.HandleFunc("/openapi.json", IPAllowlistMiddleware(allowed, trustProxy, OpenAPI))
+	mux.HandleFunc("/docs", IPAllowlistMiddleware(allowed, trustProxy, OpenAPI))
+	mux.HandleFunc("/admin/", IPAllowlistMiddleware(allowed, trustProxy, AdminMiddleware(Admin)))
+
+	timeouts, err := timeoutsFromEnv()
+	if err != nil {
+		fatal("invalid server timeouts", "error", err)
+	}
+	srv := newServer(LogMiddleware(slog.Default(), RecoverMiddleware(mux.ServeHTTP)), tlsConfig, timeouts, os.Getenv("H2C") == "true")
+
+	ln, addr, err := listenerFromEnv()
+	if err != nil {
+		fatal("listen failed", "error", err)
+	}
+
+	stopping, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	served := make(chan error, 1)
+	go func() {
+		if tlsConfig != nil {
+			slog.Info("server starting", "addr", addr, "tls", true, "client_certs", tlsConfig.ClientCAs != nil)
+			served <- srv.ServeTLS(ln, "", "")
+		} else {
+			slog.Info("server starting", "addr", addr, "h2c", srv.Protocols != nil)
+			served <- srv.Serve(ln)
+		}
+	}()
+	select {
+	case err := <-served:
+		fatal("server stopped", "error", err)
+	case <-stopping.Done():
+	}
+	// A second signal ends the process at once.
+	stop()
+	if err := shutdown(srv, tasks, timeouts.Shutdown); err != nil {
+		os.Exit(1)
+	}
+}
+
+// fatal logs msg as an error and exits, for failures the server cannot
+// start or keep running with.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a monotonically increasing metric exported on /metrics.
+type Counter struct {
+	name string
+	help string
+	v    atomic.Int64
+}
+
+var (
+	metricsMu sync.Mutex
+	counters  []*Counter
+)
+
+// newCounter registers a counter. Call it from package-level var blocks.
+func newCounter(name, help string) *Counter {
+	c := &Counter{name: name, help: help}
+	metricsMu.Lock()
+	counters = append(counters, c)
+	metricsMu.Unlock()
+	return c
+}
+
+func (c *Counter) Add(n int64) { c.v.Add(n) }
+
+func (c *Counter) Value() int64 { return c.v.Load() }
+
+// Metrics serves all registered metrics in the Prometheus text format.
+func Metrics(w http.ResponseWriter, r *http.Request) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	for _, c := range counters {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", c.name, c.help, c.name, c.name, c.Value())
+	}
+}
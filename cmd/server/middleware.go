@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strings"
+)
+
+type principalKey struct{}
+
+// principalFrom returns the authenticated caller identity stored by AuthMiddleware.
+func principalFrom(ctx context.Context) string {
+	p, _ := ctx.Value(principalKey{}).(string)
+	return p
+}
+
+// AuthMiddleware runs the request through each configured Authenticator in
+// order. The first provider that recognizes credentials decides the outcome.
+func AuthMiddleware(chain []Authenticator, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// If no provider is configured, skip validation (useful for local development)
+		if len(chain) == 0 {
+			next(w, r)
+			return
+		}
+
+		// Preflight requests never carry credentials
+		if r.Method == http.MethodOptions {
+			next(w, r)
+			return
+		}
+
+		for _, auth := range chain {
+			principal, err := auth.Authenticate(r)
+			if errors.Is(err, errNoCredentials) {
+				continue
+			}
+			if err != nil {
+				http.Error(w, "Unauthorized: "+auth.Name()+": "+err.Error(), http.StatusUnauthorized)
+				return
+			}
+
+			// Request is valid, proceed to the next handler
+			ctx := context.WithValue(r.Context(), principalKey{}, principal)
+			next(w, r.WithContext(ctx))
+			return
+		}
+
+		http.Error(w, "Unauthorized: missing credentials", http.StatusUnauthorized)
+	}
+}
+
+var panicsRecovered = newCounter("panics_recovered_total", "Handler panics answered with 500.")
+
+// RecoverMiddleware answers a panicking handler with 500 and logs the panic
+// with its stack, so one bad request neither kills the process nor leaves
+// the client with a dropped connection. The body is an RFC 9457 problem
+// document.
+func RecoverMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			// ErrAbortHandler is how handlers abort a response on purpose.
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			panicsRecovered.Add(1)
+			requestLogger(r.Context()).ErrorContext(r.Context(), "panic serving request", "method", r.Method, "path", r.URL.Path,
+				"panic", fmt.Sprint(v), "stack", string(debug.Stack()))
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(struct {
+				Type   string `json:"type"`
+				Title  string `json:"title"`
+				Status int    `json:"status"`
+				Detail string `json:"detail"`
+			}{"about:blank", http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError,
+				"The server failed to handle the request. It has been logged; retrying may not help."})
+		}()
+		next(w, r)
+	}
+}
+
+// IPAllowlistMiddleware rejects requests whose client address is outside the
+// given networks. An empty list allows every address.
+func IPAllowlistMiddleware(allowed []*net.IPNet, trustProxy bool, next http.HandlerFunc) http.HandlerFunc {
+	if len(allowed) == 0 {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		ip := clientIP(r, trustProxy)
+		for _, n := range allowed {
+			if ip != nil && n.Contains(ip) {
+				next(w, r)
+				return
+			}
+		}
+		http.Error(w, "Forbidden: client address not allowed", http.StatusForbidden)
+	}
+}
+
+// clientIP returns the caller address. X-Forwarded-For is only honoured when
+// the service sits behind a trusted proxy, and then only its rightmost entry,
+// which the proxy appended; everything to the left is set by the client.
+func clientIP(r *http.Request, trustProxy bool) net.IP {
+	if trustProxy {
+		if fwd := r.Header.Values("X-Forwarded-For"); len(fwd) > 0 {
+			last := fwd[len(fwd)-1]
+			if i := strings.LastIndex(last, ","); i >= 0 {
+				last = last[i+1:]
+			}
+			return net.ParseIP(strings.TrimSpace(last))
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+// parseCIDRs parses a comma-separated list of networks. Bare addresses are
+// treated as single-host networks.
+func parseCIDRs(s string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, part := range splitList(s) {
+		if !strings.Contains(part, "/") {
+			if ip := net.ParseIP(part); ip != nil && ip.To4() != nil {
+				part += "/32"
+			} else {
+				part += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(part)
+		if err != nil {
+			return nil, fmt.Errorf("parse CIDR %q: %w", part, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
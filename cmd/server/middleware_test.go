@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecoverMiddleware(t *testing.T) {
+	handler := RecoverMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		var items []int
+		_ = items[3]
+	})
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/pack", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected 500, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Expected application/problem+json, got %q", ct)
+	}
+	var problem struct {
+		Status int    `json:"status"`
+		Title  string `json:"title"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil || problem.Status != 500 {
+		t.Errorf("Expected a problem document with status 500, got %+v (%v)", problem, err)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/pack", nil)
+	r.RemoteAddr = "10.0.0.1:5000"
+	r.Header.Set("X-Forwarded-For", "192.168.1.5, 203.0.113.7")
+
+	if ip := clientIP(r, false); ip.String() != "10.0.0.1" {
+		t.Errorf("Expected the peer address without a trusted proxy, got %v", ip)
+	}
+	if ip := clientIP(r, true); ip.String() != "203.0.113.7" {
+		t.Errorf("Expected the entry appended by the proxy, got %v", ip)
+	}
+
+	r.Header.Add("X-Forwarded-For", "198.51.100.2")
+	if ip := clientIP(r, true); ip.String() != "198.51.100.2" {
+		t.Errorf("Expected the last entry across repeated headers, got %v", ip)
+	}
+}
+
+func TestIPAllowlistMiddleware(t *testing.T) {
+	allowed, err := parseCIDRs("192.168.1.0/24, 10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := IPAllowlistMiddleware(allowed, true, func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		remote, forwarded string
+		want              int
+	}{
+		{"10.0.0.1:5000", "", http.StatusOK},
+		{"10.0.0.2:5000", "", http.StatusForbidden},
+		{"10.0.0.2:5000", "192.168.1.5", http.StatusOK},
+		// A client-supplied entry in front of the proxy's must not count.
+		{"10.0.0.2:5000", "192.168.1.5, 203.0.113.7", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/pack", nil)
+		r.RemoteAddr = tt.remote
+		if tt.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, r)
+		if rec.Code != tt.want {
+			t.Errorf("%s via %q: expected %d, got %d", tt.remote, tt.forwarded, tt.want, rec.Code)
+		}
+	}
+}
+
+func TestParseCIDRs(t *testing.T) {
+	nets, err := parseCIDRs("10.0.0.0/8, 192.168.1.5, ::1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := []string{"10.0.0.0/8", "192.168.1.5/32", "::1/128"}
+	if len(nets) != len(want) {
+		t.Fatalf("Expected %d networks, got %d", len(want), len(nets))
+	}
+	for i, n := range nets {
+		if n.String() != want[i] {
+			t.Errorf("Expected %s, got %s", want[i], n)
+		}
+	}
+
+	if _, err := parseCIDRs("10.0.0.0/33"); err == nil {
+		t.Error("Expected an error for an invalid prefix length")
+	}
+}
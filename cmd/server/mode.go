@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Service modes switched at runtime through /admin/mode.
+const (
+	// ModeNormal serves everything.
+	ModeNormal = "normal"
+	// ModeReadOnly rejects new work (any non-GET request) with 503 but keeps
+	// serving stored results, e.g. while the persistence layer is migrated.
+	ModeReadOnly = "read_only"
+	// ModeMaintenance rejects every API request except /admin with 503.
+	ModeMaintenance = "maintenance"
+)
+
+const defaultMaintenanceMessage = "Service is under maintenance, please retry later"
+
+// ServiceMode is the current mode plus the message returned to rejected callers.
+type ServiceMode struct {
+	Mode    string `json:"mode"`
+	Message string `json:"message,omitempty"`
+}
+
+var (
+	modeMu      sync.RWMutex
+	currentMode = ServiceMode{Mode: ModeNormal}
+)
+
+func getServiceMode() ServiceMode {
+	modeMu.RLock()
+	defer modeMu.RUnlock()
+	return currentMode
+}
+
+func setServiceMode(m ServiceMode) error {
+	switch m.Mode {
+	case ModeNormal, ModeReadOnly, ModeMaintenance:
+	default:
+		return fmt.Errorf("unknown mode %q", m.Mode)
+	}
+	if m.Mode != ModeNormal && m.Message == "" {
+		m.Message = defaultMaintenanceMessage
+	}
+
+	modeMu.Lock()
+	defer modeMu.Unlock()
+	currentMode = m
+	return nil
+}
+
+// ModeMiddleware rejects requests the current service mode does not allow.
+func ModeMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mode := getServiceMode()
+
+		reject := false
+		switch mode.Mode {
+		case ModeReadOnly:
+			reject = r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodOptions
+		case ModeMaintenance:
+			reject = r.Method != http.MethodOptions
+		}
+
+		if reject {
+			w.Header().Set("Retry-After", "60")
+			http.Error(w, mode.Message, http.StatusServiceUnavailable)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apiOperation is one endpoint in the OpenAPI document. Request and
+// Response are zero values of the body types; their schemas are generated
+// from the Go structs, so the document cannot drift from the code.
+type apiOperation struct {
+	Method, Path, Summary string
+	Query                 []string
+	Request               any
+	// Status is the success status, 200 by default. ContentType is the
+	// response type when it is not JSON.
+	Status      int
+	Response    any
+	ContentType string
+}
+
+// apiOperations lists the documented endpoints of Packer.
+var apiOperations = []apiOperation{
+	{Method: http.MethodPost, Path: "/pack", Summary: "Pack items into boxes", Request: PackRequest{}, Response: PackResponse{}},
+	{Method: http.MethodPost, Path: "/pack/async", Summary: "Pack in the background", Request: PackRequest{}, Status: http.StatusAccepted, Response: Job{}},
+	{Method: http.MethodPost, Path: "/pack/stream", Summary: "Pack newline-delimited items, streaming boxes back as they are packed", ContentType: "application/x-ndjson"},
+	{Method: http.MethodPost, Path: "/optimize", Summary: "Search for a better packing in the background", Request: OptimizeRequest{}, Status: http.StatusAccepted, Response: Job{}},
+	{Method: http.MethodGet, Path: "/jobs/{id}", Summary: "Get a background job", Response: Job{}},
+	{Method: http.MethodPost, Path: "/consolidate", Summary: "Propose shipments combining orders", Request: ConsolidateRequest{}, Response: ConsolidateResponse{}},
+	{Method: http.MethodPost, Path: "/simulate-catalog", Summary: "Compare box catalogs over past orders", Request: SimulateCatalogRequest{}, Response: SimulateCatalogResponse{}},
+	{Method: http.MethodPost, Path: "/recommend-boxes", Summary: "Recommend box sizes for past orders in the background", Request: RecommendBoxesRequest{}, Status: http.StatusAccepted, Response: Job{}},
+	{Method: http.MethodPost, Path: "/fit-check", Summary: "Check which boxes an item fits", Request: FitCheckRequest{}, Response: FitCheckResponse{}},
+	{Method: http.MethodPost, Path: "/scenarios", Summary: "Save a pack request behind a link", Request: PackRequest{}, Status: http.StatusCreated, Response: ScenarioCreated{}},
+	{Method: http.MethodGet, Path: "/scenarios/{id}", Summary: "Pack a saved request", Response: PackResponse{}},
+	{Method: http.MethodDelete, Path: "/scenarios/{id}", Summary: "Delete a saved request", Status: http.StatusNoContent},
+	{Method: http.MethodGet, Path: "/scenarios/{id}/request", Summary: "Get a saved request", Response: PackRequest{}},
+	{Method: http.MethodGet, Path: "/packs/{id}", Summary: "Get a stored result", Response: PackResponse{}},
+	{Method: http.MethodDelete, Path: "/packs/{id}", Summary: "Delete a stored result", Status: http.StatusNoContent},
+	{Method: http.MethodGet, Path: "/packs/{id}/export", Summary: "Export a result as a spreadsheet", Query: []string{"format"}, ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+	{Method: http.MethodGet, Path: "/packs/{id}/labels", Summary: "Print box labels", Query: []string{"format"}, ContentType: "application/zpl"},
+	{Method: http.MethodGet, Path: "/packs/{id}/boxes/{n}/explanation", Summary: "Explain the box type chosen for a packed box", Response: BoxExplanation{}},
+	{Method: http.MethodGet, Path: "/result/{id}", Summary: "Get a stored result by pack or visualization ID", Response: PackResponse{}},
+	{Method: http.MethodGet, Path: "/visualize/{id}", Summary: "View a result in 3D", Query: []string{"view"}, ContentType: "text/html"},
+	{Method: http.MethodGet, Path: "/stats/summary", Summary: "Summarize stored results", Query: []string{"from", "to", "interval"}, Response: StatsSummary{}},
+	{Method: http.MethodGet, Path: "/account/data", Summary: "Export all data of the caller", Response: DataExport{}},
+	{Method: http.MethodDelete, Path: "/account/data", Summary: "Delete all data of the caller", Response: DataDeletion{}},
+}
+
+// openAPIDocument builds the document once; the operations never change
+// while the server runs.
+var openAPIDocument = sync.OnceValue(func() []byte {
+	b, _ := json.Marshal(buildOpenAPI(apiOperations))
+	return b
+})
+
+var pathParam = regexp.MustCompile(`\{(\w+)\}`)
+
+func buildOpenAPI(ops []apiOperation) map[string]any {
+	s := openAPISchemas{}
+	paths := map[string]map[string]any{}
+	for _, op := range ops {
+		o := map[string]any{"summary": op.Summary}
+		var params []any
+		for _, m := range pathParam.FindAllStringSubmatch(op.Path, -1) {
+			params = append(params, map[string]any{"name": m[1], "in": "path", "required": true, "schema": map[string]any{"type": "string"}})
+		}
+		for _, q := range op.Query {
+			params = append(params, map[string]any{"name": q, "in": "query", "schema": map[string]any{"type": "string"}})
+		}
+		if params != nil {
+			o["parameters"] = params
+		}
+		if op.Request != nil {
+			o["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{"application/json": map[string]any{"schema": s.schema(reflect.TypeOf(op.Request))}},
+			}
+		}
+
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		ok := map[string]any{"description": http.StatusText(status)}
+		switch {
+		case op.Response != nil:
+			ok["content"] = map[string]any{"application/json": map[string]any{"schema": s.schema(reflect.TypeOf(op.Response))}}
+		case op.ContentType != "":
+			ok["content"] = map[string]any{op.ContentType: map[string]any{}}
+		}
+		responses := map[string]any{strconv.Itoa(status): ok}
+		if op.Request != nil {
+			responses["400"] = map[string]any{"description": "The body is not a valid request"}
+		}
+		if strings.Contains(op.Path, "{") {
+			responses["404"] = map[string]any{"description": "Not found"}
+		}
+		o["responses"] = responses
+
+		if paths[op.Path] == nil {
+			paths[op.Path] = map[string]any{}
+		}
+		paths[op.Path][strings.ToLower(op.Method)] = o
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "3D Bin Packing API",
+			"version": "1.0",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": s,
+			"securitySchemes": map[string]any{
+				"apiKey":       map[string]any{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"rapidAPIUser": map[string]any{"type": "apiKey", "in": "header", "name": "X-RapidAPI-User"},
+			},
+		},
+		"security": []any{map[string]any{"apiKey": []string{}}, map[string]any{"rapidAPIUser": []string{}}},
+	}
+}
+
+// openAPISchemas holds the named schemas of the document by type name.
+type openAPISchemas map[string]any
+
+// lengthFields are the JSON names of lengths. They are whole numbers on the
+// grid, but may have decimals in JSON when the request sets units.
+var lengthFields = map[string]bool{"w": true, "h": true, "d": true, "x": true, "y": true, "z": true}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	rawType    = reflect.TypeOf(json.RawMessage{})
+	numberType = reflect.TypeOf(json.Number(""))
+)
+
+// schema describes t, adding named structs to s and referring to them.
+func (s openAPISchemas) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case rawType:
+		return map[string]any{}
+	case numberType:
+		return map[string]any{"type": "number"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Array:
+		return map[string]any{"type": "array", "items": s.schema(t.Elem()), "minItems": t.Len(), "maxItems": t.Len()}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		ref := map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+		if _, ok := s[t.Name()]; !ok {
+			// Mark the name first: structs may refer to themselves.
+			s[t.Name()] = nil
+			s[t.Name()] = s.object(t)
+		}
+		return ref
+	}
+	return map[string]any{}
+}
+
+// object describes the JSON fields of a struct, including those of
+// embedded structs.
+func (s openAPISchemas) object(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	s.fields(t, props)
+	return map[string]any{"type": "object", "properties": props}
+}
+
+func (s openAPISchemas) fields(t reflect.Type, props map[string]any) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				s.fields(ft, props)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if lengthFields[name] && f.Type.Kind() == reflect.Int {
+			props[name] = map[string]any{"type": "number"}
+			continue
+		}
+		props[name] = s.schema(f.Type)
+	}
+}
+
+// docsPage loads Swagger UI from a CDN, like the comparison page loads
+// three.js, and points it at /openapi.json.
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="utf-8">
+    <title>3D Bin Packing API</title>
+    <link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+    <div id="swagger-ui"></div>
+    <script src="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+    <script>
+        SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui"});
+    </script>
+</body>
+</html>
+`
+
+// OpenAPI serves the OpenAPI document at /openapi.json and Swagger UI at
+// /docs. Neither needs credentials, so gateways and SDK generators can
+// fetch the document.
+func OpenAPI(w http.ResponseWriter, r *http.Request) {
+	setCORSHeaders(w)
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	switch r.URL.Path {
+	case "/openapi.json":
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(openAPIDocument())
+	case "/docs":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(docsPage))
+	default:
+		http.NotFound(w, r)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAPIDocument(t *testing.T) {
+	rec := httptest.NewRecorder()
+	OpenAPI(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	var doc struct {
+		OpenAPI    string                               `json:"openapi"`
+		Paths      map[string]map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]map[string]any `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != "3.0.3" {
+		t.Errorf("Expected OpenAPI 3.0.3, got %q", doc.OpenAPI)
+	}
+	if _, ok := doc.Paths["/pack"]["post"]["requestBody"]; !ok {
+		t.Errorf("Expected POST /pack with a request body, got %v", doc.Paths["/pack"])
+	}
+
+	// Fields of the embedded packing.Options are flattened into the request.
+	req := doc.Components.Schemas["PackRequest"].Properties
+	for _, field := range []string{"items", "boxes", "units", "max_boxes"} {
+		if _, ok := req[field]; !ok {
+			t.Errorf("Expected PackRequest.%s in the schema", field)
+		}
+	}
+	if _, ok := req["activeProfile"]; ok {
+		t.Error("Expected unexported fields to be left out")
+	}
+	item := doc.Components.Schemas["InputItem"].Properties
+	if item["w"]["type"] != "number" || item["quantity"]["type"] != "integer" {
+		t.Errorf("Expected a number side and an integer quantity, got %v and %v", item["w"], item["quantity"])
+	}
+	if _, ok := item["Size"]; ok {
+		t.Error("Expected fields tagged json:\"-\" to be left out")
+	}
+}
+
+func TestOpenAPIDocsPage(t *testing.T) {
+	rec := httptest.NewRecorder()
+	OpenAPI(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Errorf("Expected an HTML page, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
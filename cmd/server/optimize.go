@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"binpacker/pkg/packing"
+)
+
+// A pack request searches for defaultOptimizeBudget unless optimize_ms says
+// otherwise, and never longer than maxOptimizeBudget; longer searches are
+// run as optimize jobs. A seeded search runs optimize_steps steps instead,
+// defaultOptimizeSteps unless set.
+const (
+	defaultOptimizeBudget = time.Second
+	maxOptimizeBudget     = 10 * time.Second
+	defaultOptimizeSteps  = 1000
+	maxOptimizeSteps      = 100000
+)
+
+// optimizeRun returns the search a request asked for.
+func (r *PackRequest) optimizeRun() packing.OptimizeRun {
+	run := packing.OptimizeRun{Budget: defaultOptimizeBudget, Steps: r.OptimizeSteps, Seed: r.Seed}
+	if r.OptimizeMS > 0 {
+		run.Budget = min(time.Duration(r.OptimizeMS)*time.Millisecond, maxOptimizeBudget)
+	}
+	if run.Seed != nil && run.Steps == 0 {
+		run.Steps = defaultOptimizeSteps
+	}
+	return run
+}
+
+// validateOptimize checks the optimize fields of a pack request.
+func (r *PackRequest) validateOptimize() error {
+	if r.OptimizeMS < 0 {
+		return errors.New("optimize_ms must not be negative")
+	}
+	if r.OptimizeSteps < 0 || r.OptimizeSteps > maxOptimizeSteps {
+		return fmt.Errorf("optimize_steps must be between 0 and %d", maxOptimizeSteps)
+	}
+	if (r.OptimizeMS > 0 || r.OptimizeSteps > 0) && !r.Optimize {
+		return errors.New("optimize_ms and optimize_steps need optimize")
+	}
+	if r.OptimizeMS > 0 && r.Seed != nil {
+		return errors.New("a seeded search is bounded by optimize_steps, not optimize_ms")
+	}
+	return nil
+}
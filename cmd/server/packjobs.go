@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// maxQueuedPackJobs bounds the /pack/async backlog; beyond it new jobs are
+// turned away with 503 rather than piling up in memory.
+const maxQueuedPackJobs = 1000
+
+var errPackQueueFull = errors.New("too many queued pack jobs, try again later")
+
+func defaultPackWorkers() int {
+	return max(1, runtime.NumCPU()-1)
+}
+
+// packWorkersFromEnv reads PACK_WORKERS, the number of /pack/async jobs
+// packed concurrently.
+func packWorkersFromEnv() (int, error) {
+	v := os.Getenv("PACK_WORKERS")
+	if v == "" {
+		return defaultPackWorkers(), nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid PACK_WORKERS %q", v)
+	}
+	return n, nil
+}
+
+// StartPack registers a pack job for a request that passed
+// validatePackRequest and queues it for the worker pool.
+func (m *JobManager) StartPack(owner string, req PackRequest) (Job, error) {
+	now := time.Now().UTC()
+	rec := &jobRecord{
+		Job: Job{
+			ID:        newID(IDPrefixJob),
+			Kind:      JobKindPack,
+			Status:    JobQueued,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		Owner:   owner,
+		Request: OptimizeRequest{PackRequest: req},
+	}
+	if err := m.register(rec); err != nil {
+		return Job{}, err
+	}
+	job := rec.Job
+
+	if err := m.enqueuePack(rec); err != nil {
+		m.mu.Lock()
+		delete(m.jobs, rec.Job.ID)
+		m.mu.Unlock()
+		m.removeCheckpoints([]string{rec.Job.ID})
+		return Job{}, err
+	}
+	return job, nil
+}
+
+// enqueuePack hands rec to the worker pool, starting the pool if needed.
+func (m *JobManager) enqueuePack(rec *jobRecord) error {
+	m.packStart.Do(func() {
+		for range m.packWorkers {
+			m.running.Go(m.packWorker)
+		}
+	})
+	select {
+	case m.packQueue <- rec:
+		return nil
+	default:
+		return errPackQueueFull
+	}
+}
+
+// packWorker runs queued pack jobs until the manager stops. A job it has
+// started runs to the end.
+func (m *JobManager) packWorker() {
+	for {
+		select {
+		case <-m.stopping.Done():
+			return
+		case rec := <-m.packQueue:
+			if m.stopping.Err() != nil {
+				// Left queued in its checkpoint, for the restart.
+				return
+			}
+			m.runPackJob(rec)
+		}
+	}
+}
+
+// runPackJob packs one queued request on behalf of the job owner. A job
+// deleted while queued is skipped; one interrupted by a restart is packed
+// again from the start.
+func (m *JobManager) runPackJob(rec *jobRecord) {
+	m.mu.Lock()
+	if m.jobs[rec.Job.ID] == nil {
+		m.mu.Unlock()
+		return
+	}
+	req := rec.Request.PackRequest
+	ctx := context.WithValue(context.Background(), principalKey{}, rec.Owner)
+	rec.Job.Status = JobRunning
+	rec.Job.UpdatedAt = time.Now().UTC()
+	m.mu.Unlock()
+
+	resp, err := packSafely(ctx, req)
+
+	m.mu.Lock()
+	rec.Job.UpdatedAt = time.Now().UTC()
+	if err != nil {
+		rec.Job.Status = JobFailed
+		rec.Job.Error = err.Error()
+	} else {
+		rec.Job.Status = JobDone
+		rec.Job.Result = &resp
+	}
+	m.mu.Unlock()
+	m.checkpoint(rec)
+}
+
+// packSafely runs runPack and turns a panic into an error, since no HTTP
+// server is there to recover it and a bad request must not take the pool
+// down.
+func packSafely(ctx context.Context, req PackRequest) (resp PackResponse, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			slog.ErrorContext(ctx, "pack job panicked", "panic", fmt.Sprint(p))
+			err = errors.New("internal error while packing")
+		}
+	}()
+	resp = runPack(ctx, req, time.Now())
+	return resp.inFrame(req.Boxes), nil
+}
+
+func handlePackAsync(w http.ResponseWriter, r *http.Request) {
+	var req PackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	logPackRequest(r.Context(), req)
+	if err := validatePackRequest(r.Context(), &req); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	job, err := jobs.StartPack(principalFrom(r.Context()), req)
+	if err != nil {
+		writeJobsBusy(w, err)
+		return
+	}
+	logAttrs(r.Context(), slog.String("job_id", job.ID))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(job)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"binpacker/pkg/packtest"
+)
+
+// TestPacktestFixturesMatchResponses keeps the built-in fixtures of
+// packtest in step with the service: every field they have must still be
+// in the response to the request they were recorded from.
+func TestPacktestFixturesMatchResponses(t *testing.T) {
+	for _, f := range packtest.Default() {
+		if f.Method != http.MethodPost {
+			continue
+		}
+		rec := httptest.NewRecorder()
+		Packer(rec, httptest.NewRequest(f.Method, f.Path, bytes.NewReader(f.Request)))
+		if rec.Code != f.Status {
+			t.Errorf("%s %s: expected %d, got %d: %s", f.Method, f.Path, f.Status, rec.Code, rec.Body)
+			continue
+		}
+		var fixture, live any
+		if err := json.Unmarshal(f.Body, &fixture); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &live); err != nil {
+			t.Fatal(err)
+		}
+		for _, missing := range missingFields("", fixture, live) {
+			t.Errorf("%s %s: fixture field %s is not in the response", f.Method, f.Path, missing)
+		}
+	}
+
+	// The job fixtures hold a Job, and its result the pack response.
+	for _, f := range packtest.Default() {
+		if f.Method == http.MethodGet && len(f.Body) > 0 {
+			dec := json.NewDecoder(bytes.NewReader(f.Body))
+			dec.DisallowUnknownFields()
+			var v any = &PackResponse{}
+			if f.Path == "/jobs/"+packtest.JobID {
+				v = &Job{}
+			}
+			if err := dec.Decode(v); err != nil {
+				t.Errorf("GET %s: %v", f.Path, err)
+			}
+		}
+	}
+}
+
+// missingFields returns the paths of the object fields in fixture that are
+// not in live, looking into the first element of arrays.
+func missingFields(path string, fixture, live any) []string {
+	var missing []string
+	switch f := fixture.(type) {
+	case map[string]any:
+		l, _ := live.(map[string]any)
+		for k, v := range f {
+			lv, ok := l[k]
+			if !ok {
+				missing = append(missing, path+"."+k)
+				continue
+			}
+			missing = append(missing, missingFields(path+"."+k, v, lv)...)
+		}
+	case []any:
+		l, _ := live.([]any)
+		if len(f) > 0 && len(l) > 0 {
+			missing = append(missing, missingFields(path+"[0]", f[0], l[0])...)
+		}
+	}
+	slices.Sort(missing)
+	return missing
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Lanes of synchronous packs. Each lane has its own slots, queue and
+// timeouts, so batch work queues behind batch work only and never delays a
+// checkout waiting on /pack.
+const (
+	LaneInteractive = "interactive"
+	LaneBatch       = "batch"
+)
+
+// Defaults of the lanes. Interactive requests wait briefly and are turned
+// away early; batch requests may wait and pack for longer.
+const (
+	defaultPackWait    = 10 * time.Second
+	defaultQueuedPacks = 100
+	defaultBatchWait   = time.Minute
+	defaultBatchQueued = 10
+)
+
+// packWriteMargin is the time left to write a response after its pack ran
+// for the whole lane timeout.
+const packWriteMargin = 30 * time.Second
+
+var errPackPoolBusy = errors.New("too many packs in progress, try again later")
+
+var (
+	packsRejected      = newCounter("packs_rejected_total", "Interactive requests turned away because no pack slot freed up in time.")
+	batchPacksRejected = newCounter("batch_packs_rejected_total", "Batch requests turned away because no pack slot freed up in time.")
+)
+
+// packPool bounds the packs of a lane that run while their request waits:
+// /pack, scenario runs and /pack/stream in the lane they ask for, and
+// /consolidate and /simulate-catalog in the batch lane. A burst of large
+// requests then queues for the CPUs instead of slowing every pack down
+// until all of them time out. /pack/async has its own pool; see packjobs.go.
+type packPool struct {
+	slots chan struct{}
+	// maxQueued bounds the requests waiting for a slot; further requests
+	// are turned away at once rather than waiting out maxWait.
+	maxQueued int64
+	waiting   atomic.Int64
+	maxWait   time.Duration
+}
+
+// packs holds the pool of each lane.
+var packs = map[string]*packPool{
+	LaneInteractive: newPackPool(runtime.NumCPU(), defaultQueuedPacks, defaultPackWait),
+	LaneBatch:       newPackPool(defaultBatchPacks(), defaultBatchQueued, defaultBatchWait),
+}
+
+func newPackPool(size, maxQueued int, maxWait time.Duration) *packPool {
+	return &packPool{slots: make(chan struct{}, size), maxQueued: int64(maxQueued), maxWait: maxWait}
+}
+
+// defaultBatchPacks leaves half the CPUs to interactive packs.
+func defaultBatchPacks() int {
+	return max(1, runtime.NumCPU()/2)
+}
+
+// validateLane rejects a lane that does not exist; the empty lane is
+// interactive.
+func validateLane(lane string) error {
+	if lane != "" && packs[lane] == nil {
+		return fmt.Errorf("unknown lane %q; expected %q or %q", lane, LaneInteractive, LaneBatch)
+	}
+	return nil
+}
+
+// lanePool returns the pool of a lane validateLane accepted.
+func lanePool(lane string) *packPool {
+	if lane == "" {
+		lane = LaneInteractive
+	}
+	return packs[lane]
+}
+
+// acquire waits for a free slot and returns the function that frees it. It
+// gives up at once if maxQueued requests are already waiting, and after
+// maxWait or when ctx ends.
+func (p *packPool) acquire(ctx context.Context) (func(), error) {
+	select {
+	case p.slots <- struct{}{}:
+		return p.release, nil
+	default:
+	}
+	if p.waiting.Add(1) > p.maxQueued {
+		p.waiting.Add(-1)
+		return nil, errPackPoolBusy
+	}
+	defer p.waiting.Add(-1)
+	timer := time.NewTimer(p.maxWait)
+	defer timer.Stop()
+	select {
+	case p.slots <- struct{}{}:
+		return p.release, nil
+	case <-timer.C:
+		return nil, errPackPoolBusy
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *packPool) release() {
+	<-p.slots
+}
+
+// acquirePackSlot takes a slot in the lane for the request or answers 503.
+// It first extends the write deadline of the connection to cover the
+// lane's queue and pack timeouts.
+func acquirePackSlot(w http.ResponseWriter, r *http.Request, lane string) (func(), bool) {
+	if lane == "" {
+		lane = LaneInteractive
+	}
+	logAttrs(r.Context(), slog.String("lane", lane))
+	pool := lanePool(lane)
+	extendWriteDeadline(w, r, pool.maxWait+getSettings().packTimeout(lane)+packWriteMargin)
+	release, err := pool.acquire(r.Context())
+	if err != nil {
+		if lane == LaneBatch {
+			batchPacksRejected.Add(1)
+		} else {
+			packsRejected.Add(1)
+		}
+		w.Header().Set("Retry-After", "10")
+		http.Error(w, errPackPoolBusy.Error(), http.StatusServiceUnavailable)
+		return nil, false
+	}
+	return release, true
+}
+
+// extendWriteDeadline moves the write deadline of the connection to budget
+// from now when the server's WriteTimeout would cut the response off
+// sooner, as it would for batch packs.
+func extendWriteDeadline(w http.ResponseWriter, r *http.Request, budget time.Duration) {
+	srv, ok := r.Context().Value(http.ServerContextKey).(*http.Server)
+	if !ok || srv.WriteTimeout <= 0 || budget <= srv.WriteTimeout {
+		return
+	}
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(budget))
+}
+
+// packLanesFromEnv reads the pools of the lanes. MAX_CONCURRENT_PACKS is
+// the number of interactive packs run at once, MAX_QUEUED_PACKS the number
+// waiting for one of them, and PACK_QUEUE_TIMEOUT how long they wait; the
+// BATCH_ variables set the same for the batch lane.
+func packLanesFromEnv() (map[string]*packPool, error) {
+	interactive, err := packPoolFromEnv("", runtime.NumCPU(), defaultQueuedPacks, defaultPackWait)
+	if err != nil {
+		return nil, err
+	}
+	batch, err := packPoolFromEnv("BATCH_", defaultBatchPacks(), defaultBatchQueued, defaultBatchWait)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]*packPool{LaneInteractive: interactive, LaneBatch: batch}, nil
+}
+
+func packPoolFromEnv(prefix string, size, queued int, wait time.Duration) (*packPool, error) {
+	concurrent := "MAX_CONCURRENT_PACKS"
+	if prefix != "" {
+		concurrent = prefix + "CONCURRENT_PACKS"
+	}
+	if v := os.Getenv(concurrent); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid %s %q", concurrent, v)
+		}
+		size = n
+	}
+	if v := os.Getenv(prefix + "MAX_QUEUED_PACKS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid %sMAX_QUEUED_PACKS %q", prefix, v)
+		}
+		queued = n
+	}
+	if v := os.Getenv(prefix + "PACK_QUEUE_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid %sPACK_QUEUE_TIMEOUT %q", prefix, v)
+		}
+		wait = d
+	}
+	return newPackPool(size, queued, wait), nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPackPoolBoundsConcurrentPacks(t *testing.T) {
+	pool := newPackPool(1, 1, 20*time.Millisecond)
+	release, err := pool.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Expected a free slot, got %v", err)
+	}
+	if _, err := pool.acquire(context.Background()); err != errPackPoolBusy {
+		t.Errorf("Expected the second pack to be turned away, got %v", err)
+	}
+
+	// A waiting pack gets the slot once it is freed.
+	pool.maxWait = time.Second
+	done := make(chan error)
+	go func() {
+		r, err := pool.acquire(context.Background())
+		if err == nil {
+			r()
+		}
+		done <- err
+	}()
+	time.Sleep(5 * time.Millisecond)
+	release()
+	if err := <-done; err != nil {
+		t.Errorf("Expected the waiting pack to run, got %v", err)
+	}
+}
+
+func TestPackAnswers503WhenPoolIsFull(t *testing.T) {
+	old := packs[LaneInteractive]
+	packs[LaneInteractive] = newPackPool(1, 1, 0)
+	defer func() { packs[LaneInteractive] = old }()
+	release, _ := packs[LaneInteractive].acquire(context.Background())
+	defer release()
+
+	body := `{"items": [{"id": "mug", "w": 5, "h": 5, "d": 5, "quantity": 1}], "boxes": [{"id": "box", "w": 10, "h": 10, "d": 10}]}`
+	rec := httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodPost, "/pack", strings.NewReader(body)))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected 503 with Retry-After, got %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestPackPoolQueueLimit(t *testing.T) {
+	pool := newPackPool(1, 1, time.Second)
+	release, _ := pool.acquire(context.Background())
+	done := make(chan error)
+	go func() {
+		r, err := pool.acquire(context.Background())
+		if err == nil {
+			r()
+		}
+		done <- err
+	}()
+	for pool.waiting.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	if _, err := pool.acquire(context.Background()); err != errPackPoolBusy || time.Since(start) > 100*time.Millisecond {
+		t.Errorf("Expected a pack past the queue limit turned away at once, got %v after %v", err, time.Since(start))
+	}
+	release()
+	if err := <-done; err != nil {
+		t.Errorf("Expected the queued pack to run, got %v", err)
+	}
+}
+
+func TestBatchLaneDoesNotBlockInteractive(t *testing.T) {
+	old := packs[LaneBatch]
+	packs[LaneBatch] = newPackPool(1, 0, 0)
+	defer func() { packs[LaneBatch] = old }()
+	release, _ := packs[LaneBatch].acquire(context.Background())
+	defer release()
+
+	pack := func(lane string) int {
+		body := `{"lane": "` + lane + `", "items": [{"id": "mug", "w": 5, "h": 5, "d": 5, "quantity": 1}], "boxes": [{"id": "box", "w": 10, "h": 10, "d": 10}]}`
+		rec := httptest.NewRecorder()
+		Packer(rec, httptest.NewRequest(http.MethodPost, "/pack", strings.NewReader(body)))
+		return rec.Code
+	}
+	if code := pack(LaneBatch); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 with the batch lane full, got %d", code)
+	}
+	if code := pack(LaneInteractive); code != http.StatusOK {
+		t.Errorf("Expected an interactive pack to run with the batch lane full, got %d", code)
+	}
+	if code := pack("urgent"); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown lane, got %d", code)
+	}
+}
+
+func TestPackTimeoutByLane(t *testing.T) {
+	s := Settings{PackTimeout: time.Second, BatchPackTimeout: time.Minute}
+	if s.packTimeout("") != time.Second || s.packTimeout(LaneInteractive) != time.Second || s.packTimeout(LaneBatch) != time.Minute {
+		t.Errorf("Expected each lane to have its own timeout, got %v and %v", s.packTimeout(""), s.packTimeout(LaneBatch))
+	}
+}
+
+func TestBatchPackOutlivesWriteTimeout(t *testing.T) {
+	old := packs[LaneBatch]
+	packs[LaneBatch] = newPackPool(1, 1, 0)
+	defer func() { packs[LaneBatch] = old }()
+	defer setSettings(getSettings())
+	updateSettings(func(s *Settings) { s.BatchPackTimeout = time.Second })
+
+	// A pack that runs past the server's WriteTimeout, scaled down from the
+	// 120s default, must still be able to answer.
+	get := func(acquire bool) (string, error) {
+		timeouts := defaultTimeouts
+		timeouts.Write = 100 * time.Millisecond
+		srv := newServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if acquire {
+				release, ok := acquirePackSlot(w, r, LaneBatch)
+				if !ok {
+					return
+				}
+				defer release()
+			}
+			time.Sleep(300 * time.Millisecond)
+			_, _ = io.WriteString(w, "packed")
+		}), nil, timeouts, false)
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		go srv.Serve(ln)
+		defer srv.Close()
+
+		resp, err := http.Get("http://" + ln.Addr().String() + "/consolidate")
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		return string(b), err
+	}
+
+	if body, err := get(false); err == nil && body == "packed" {
+		t.Fatal("Expected the write timeout to cut off a slow handler without a pack slot")
+	}
+	if body, err := get(true); err != nil || body != "packed" {
+		t.Errorf("Expected the batch pack to get its response, got %q, %v", body, err)
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"binpacker/pkg/packing"
+)
+
+// Profiles are named box catalogs and constraint sets kept on the server, so
+// clients can pack with "profile": "warehouse-a" instead of sending boxes.
+// A profile can switch to other catalogs on a schedule, e.g. fewer box types
+// for the weekend skeleton crew, and schedules can be limited to the dates
+// they are effective on, so a change can be configured ahead of time.
+//
+// Profiles are read from the JSON file named by PROFILES_FILE:
+//
+//	{
+//	  "warehouse-a": {
+//	    "tenants": ["key:3f9a1c0b2d4e"],
+//	    "timezone": "Europe/Lisbon",
+//	    "boxes": [...],
+//	    "constraints": [...],
+//	    "schedules": [
+//	      {"name": "peak", "effective_from": "2026-11-20", "effective_until": "2026-12-31", "boxes": [...]},
+//	      {"name": "night", "from": "22:00", "to": "06:00", "constraints": [...]},
+//	      {"name": "weekend", "days": ["sat", "sun"], "boxes": [...]}
+//	    ]
+//	  }
+//	}
+
+// Profile is a named catalog with its schedules.
+type Profile struct {
+	Boxes       []packing.InputBox `json:"boxes"`
+	Constraints []string           `json:"constraints,omitempty"`
+	// Tenants are the principals that may pack with the profile, such as
+	// "key:<fingerprint>" for an API key. Without tenants it is shared.
+	Tenants []string `json:"tenants,omitempty"`
+	// Timezone is the IANA location schedules are read in (default UTC).
+	Timezone string `json:"timezone,omitempty"`
+	// Schedules are tried in order; the first active one replaces the
+	// boxes and constraints it sets.
+	Schedules []ProfileSchedule `json:"schedules,omitempty"`
+
+	loc *time.Location
+}
+
+// ProfileSchedule is a variant of a profile that is active at some times.
+// Unset conditions always match.
+type ProfileSchedule struct {
+	Name string `json:"name"`
+	// EffectiveFrom and EffectiveUntil are the first and last day the
+	// schedule applies on, as YYYY-MM-DD.
+	EffectiveFrom  string `json:"effective_from,omitempty"`
+	EffectiveUntil string `json:"effective_until,omitempty"`
+	// Days are the weekdays it applies on: "mon" to "sun".
+	Days []string `json:"days,omitempty"`
+	// From and To bound the time of day as HH:MM, To exclusive and up to
+	// "24:00". A window with To before From runs past midnight, and counts
+	// the early hours as part of the day it started on.
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+
+	Boxes       []packing.InputBox `json:"boxes,omitempty"`
+	Constraints []string           `json:"constraints,omitempty"`
+}
+
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// profilesFromEnv reads and checks the file named by PROFILES_FILE. Without
+// one there are no profiles. A reload reads it again.
+func profilesFromEnv() (map[string]*Profile, error) {
+	path := os.Getenv("PROFILES_FILE")
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseProfiles(data)
+}
+
+func parseProfiles(data []byte) (map[string]*Profile, error) {
+	var set map[string]*Profile
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid profiles: %w", err)
+	}
+	for name, p := range set {
+		if err := p.check(); err != nil {
+			return nil, fmt.Errorf("profile %q: %w", name, err)
+		}
+	}
+	return set, nil
+}
+
+// check validates p and loads its time zone.
+func (p *Profile) check() error {
+	if len(p.Boxes) == 0 {
+		return errors.New("boxes are required")
+	}
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone %q", p.Timezone)
+	}
+	p.loc = loc
+	if err := packing.ValidateConstraints(p.Constraints); err != nil {
+		return err
+	}
+
+	for i, s := range p.Schedules {
+		if s.Name == "" {
+			return fmt.Errorf("schedules[%d]: name is required", i)
+		}
+		for _, d := range []string{s.EffectiveFrom, s.EffectiveUntil} {
+			if _, err := time.Parse(time.DateOnly, d); d != "" && err != nil {
+				return fmt.Errorf("schedule %q: invalid date %q, use YYYY-MM-DD", s.Name, d)
+			}
+		}
+		for _, d := range s.Days {
+			if !slices.Contains(weekdays, d) {
+				return fmt.Errorf("schedule %q: unknown day %q, use mon to sun", s.Name, d)
+			}
+		}
+		for _, t := range []string{s.From, s.To} {
+			if _, ok := minuteOfDay(t); t != "" && !ok {
+				return fmt.Errorf("schedule %q: invalid time %q, use HH:MM", s.Name, t)
+			}
+		}
+		if err := packing.ValidateConstraints(s.Constraints); err != nil {
+			return fmt.Errorf("schedule %q: %w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// minuteOfDay parses HH:MM, allowing "24:00" for the end of the day.
+func minuteOfDay(s string) (int, bool) {
+	var h, m int
+	if n, err := fmt.Sscanf(s, "%2d:%2d", &h, &m); err != nil || n != 2 || len(s) != 5 {
+		return 0, false
+	}
+	if h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m > 0) {
+		return 0, false
+	}
+	return h*60 + m, true
+}
+
+// activeAt reports whether s applies at local time t.
+func (s ProfileSchedule) activeAt(t time.Time) bool {
+	now := t.Hour()*60 + t.Minute()
+	from, _ := minuteOfDay(s.From)
+	to, ok := minuteOfDay(s.To)
+	if !ok {
+		to = 24 * 60
+	}
+	// The early hours of a window past midnight belong to the day before.
+	day := t
+	if from > to {
+		if now >= to && now < from {
+			return false
+		}
+		if now < to {
+			day = t.AddDate(0, 0, -1)
+		}
+	} else if now < from || now >= to {
+		return false
+	}
+
+	if len(s.Days) > 0 && !slices.Contains(s.Days, weekdays[day.Weekday()]) {
+		return false
+	}
+	date := day.Format(time.DateOnly)
+	if s.EffectiveFrom != "" && date < s.EffectiveFrom {
+		return false
+	}
+	return s.EffectiveUntil == "" || date <= s.EffectiveUntil
+}
+
+// resolve returns the boxes and constraints of p at t, and the name of the
+// schedule that supplied them ("" for the profile's own).
+func (p *Profile) resolve(t time.Time) ([]packing.InputBox, []string, string) {
+	boxes, constraints := p.Boxes, p.Constraints
+	local := t.In(p.loc)
+	for _, s := range p.Schedules {
+		if !s.activeAt(local) {
+			continue
+		}
+		if len(s.Boxes) > 0 {
+			boxes = s.Boxes
+		}
+		if s.Constraints != nil {
+			constraints = s.Constraints
+		}
+		return boxes, constraints, s.Name
+	}
+	return boxes, constraints, ""
+}
+
+// applyProfile fills in the boxes and constraints of the profile named in req
+// as of now. Constraints of the request are added to the profile's. Profiles
+// of other tenants are reported as unknown, as if they did not exist.
+func applyProfile(req *PackRequest, principal string, now time.Time) error {
+	if req.Profile == "" {
+		return nil
+	}
+	p, ok := getSettings().Profiles[req.Profile]
+	if !ok || (len(p.Tenants) > 0 && !slices.Contains(p.Tenants, principal)) {
+		return fmt.Errorf("unknown profile %q", req.Profile)
+	}
+	if len(req.Boxes) > 0 {
+		return errors.New("give either boxes or a profile, not both")
+	}
+	boxes, constraints, schedule := p.resolve(now)
+	req.Boxes = slices.Clone(boxes)
+	req.Constraints = append(slices.Clone(constraints), req.Constraints...)
+	req.activeProfile = strings.TrimSuffix(req.Profile+"/"+schedule, "/")
+	return nil
+}
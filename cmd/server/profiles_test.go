@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"binpacker/pkg/packing"
+)
+
+const testProfiles = `{
+	"warehouse-a": {
+		"timezone": "America/Chicago",
+		"boxes": [{"id": "small", "w": 10, "h": 10, "d": 10}, {"id": "large", "w": 40, "h": 40, "d": 40}],
+		"schedules": [
+			{"name": "peak", "effective_from": "2026-11-20", "effective_until": "2026-12-31", "boxes": [{"id": "peak", "w": 30, "h": 30, "d": 30}]},
+			{"name": "night", "days": ["fri"], "from": "22:00", "to": "06:00", "constraints": ["placement.y == 0"]},
+			{"name": "weekend", "days": ["sat", "sun"], "boxes": [{"id": "large", "w": 40, "h": 40, "d": 40}]}
+		]
+	}
+}`
+
+func TestProfileSchedules(t *testing.T) {
+	set, err := parseProfiles([]byte(testProfiles))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := set["warehouse-a"]
+	chicago, _ := time.LoadLocation("America/Chicago")
+
+	cases := []struct {
+		at       time.Time
+		schedule string
+		boxes    int
+	}{
+		{time.Date(2026, 10, 14, 12, 0, 0, 0, chicago), "", 2},        // Wednesday
+		{time.Date(2026, 10, 17, 12, 0, 0, 0, chicago), "weekend", 1}, // Saturday
+		{time.Date(2026, 10, 16, 23, 0, 0, 0, chicago), "night", 2},   // Friday night
+		{time.Date(2026, 10, 17, 3, 0, 0, 0, chicago), "night", 2},    // still Friday's shift
+		{time.Date(2026, 10, 17, 7, 0, 0, 0, chicago), "weekend", 1},
+		{time.Date(2026, 11, 21, 12, 0, 0, 0, chicago), "peak", 1}, // a peak Saturday
+		{time.Date(2027, 1, 1, 12, 0, 0, 0, chicago), "", 2},
+		// Saturday 02:00 in Chicago is still Friday night there.
+		{time.Date(2026, 10, 17, 7, 0, 0, 0, time.UTC), "night", 2},
+	}
+	for _, c := range cases {
+		boxes, constraints, schedule := p.resolve(c.at)
+		if schedule != c.schedule || len(boxes) != c.boxes {
+			t.Errorf("At %v: expected schedule %q with %d boxes, got %q with %d", c.at, c.schedule, c.boxes, schedule, len(boxes))
+		}
+		if (schedule == "night") != (len(constraints) == 1) {
+			t.Errorf("At %v: expected the night constraints only at night, got %v", c.at, constraints)
+		}
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	set, err := parseProfiles([]byte(testProfiles))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer setSettings(getSettings())
+	updateSettings(func(s *Settings) { s.Profiles = set })
+
+	req := PackRequest{Profile: "warehouse-a", Items: []packing.InputItem{{ID: "a", W: 1, H: 1, D: 1, Quantity: 1}}}
+	req.Constraints = []string{"item.volume < 100"}
+	saturday := time.Date(2026, 10, 17, 18, 0, 0, 0, time.UTC)
+	if err := applyProfile(&req, "", saturday); err != nil {
+		t.Fatal(err)
+	}
+	if len(req.Boxes) != 1 || req.Boxes[0].ID != "large" || req.activeProfile != "warehouse-a/weekend" {
+		t.Errorf("Expected the weekend catalog, got %+v from %q", req.Boxes, req.activeProfile)
+	}
+
+	req = PackRequest{Profile: "warehouse-a", Boxes: []packing.InputBox{{ID: "own", W: 1, H: 1, D: 1}}}
+	if err := applyProfile(&req, "", saturday); err == nil {
+		t.Error("Expected a profile together with boxes to be rejected")
+	}
+	req = PackRequest{Profile: "warehouse-b"}
+	if err := applyProfile(&req, "", saturday); err == nil {
+		t.Error("Expected an unknown profile to be rejected")
+	}
+
+	set["warehouse-a"].Tenants = []string{"key:a"}
+	req = PackRequest{Profile: "warehouse-a"}
+	if err := applyProfile(&req, "key:b", saturday); err == nil {
+		t.Error("Expected another tenant's profile to be rejected")
+	}
+	if err := applyProfile(&req, "key:a", saturday); err != nil {
+		t.Errorf("Expected the tenant to use its profile, got %v", err)
+	}
+
+	for _, bad := range []string{
+		`{"p": {"boxes": []}}`,
+		`{"p": {"boxes": [{"id": "b", "w": 1, "h": 1, "d": 1}], "timezone": "Mars/Olympus"}}`,
+		`{"p": {"boxes": [{"id": "b", "w": 1, "h": 1, "d": 1}], "schedules": [{"name": "s", "days": ["someday"]}]}}`,
+		`{"p": {"boxes": [{"id": "b", "w": 1, "h": 1, "d": 1}], "schedules": [{"name": "s", "from": "25:00"}]}}`,
+		`{"p": {"boxes": [{"id": "b", "w": 1, "h": 1, "d": 1}], "schedules": [{"name": "s", "effective_from": "next week"}]}}`,
+	} {
+		if _, err := parseProfiles([]byte(bad)); err == nil {
+			t.Errorf("Expected %s to be rejected", bad)
+		}
+	}
+}
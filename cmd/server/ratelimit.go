@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit bounds the requests of each caller with a token bucket: a
+// caller may send Burst requests at once, and PerMinute a minute after
+// that. A zero PerMinute disables the limit.
+type RateLimit struct {
+	PerMinute float64 `json:"per_minute"`
+	Burst     int     `json:"burst"`
+}
+
+// rateLimitFrom reads RATE_LIMIT, the requests a minute of each caller, and
+// RATE_LIMIT_BURST, which defaults to a minute's worth.
+func rateLimitFrom(getenv func(string) string) (RateLimit, error) {
+	var l RateLimit
+	if v := getenv("RATE_LIMIT"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || math.IsInf(f, 0) {
+			return RateLimit{}, fmt.Errorf("invalid RATE_LIMIT %q", v)
+		}
+		l.PerMinute = f
+		l.Burst = max(1, int(math.Ceil(f)))
+	}
+	if v := getenv("RATE_LIMIT_BURST"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return RateLimit{}, fmt.Errorf("invalid RATE_LIMIT_BURST %q", v)
+		}
+		l.Burst = n
+	}
+	return l, nil
+}
+
+var rateLimited = newCounter("rate_limited_total", "Requests answered with 429 because their caller was over RATE_LIMIT.")
+
+// rateLimiter holds a token bucket per caller.
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	swept   time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+var limiter = newRateLimiter()
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: make(map[string]*tokenBucket)}
+}
+
+// allow takes a token from the bucket of key. If there is none, it returns
+// how long until there is.
+func (l *rateLimiter) allow(key string, limit RateLimit, now time.Time) (bool, time.Duration) {
+	perSecond := limit.PerMinute / 60
+	burst := float64(limit.Burst)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now, perSecond, burst)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = min(burst, b.tokens+now.Sub(b.last).Seconds()*perSecond)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / perSecond * float64(time.Second))
+}
+
+// sweep drops, once a minute, the buckets that have refilled, which are the
+// same as new ones; otherwise every caller ever seen would be kept.
+func (l *rateLimiter) sweep(now time.Time, perSecond, burst float64) {
+	if now.Sub(l.swept) < time.Minute {
+		return
+	}
+	l.swept = now
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*perSecond >= burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RateLimitMiddleware answers 429 with Retry-After to a caller over
+// RATE_LIMIT, so one consumer cannot starve the others of the packer. The
+// caller is the authenticated principal, e.g. the RapidAPI user, or the
+// client address for requests without credentials. It runs after
+// AuthMiddleware: an unverified header must not pick the bucket.
+func RateLimitMiddleware(trustProxy bool, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := getSettings().RateLimit
+		if limit.PerMinute <= 0 || r.Method == http.MethodOptions {
+			next(w, r)
+			return
+		}
+		key := principalFrom(r.Context())
+		if key == "" {
+			key = "ip:" + clientIP(r, trustProxy).String()
+		}
+		ok, wait := limiter.allow(key, limit, time.Now())
+		if !ok {
+			rateLimited.Add(1)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "Too many requests: rate limit exceeded, try again later", http.StatusTooManyRequests)
+			return
+		}
+		next(w, r)
+	}
+}
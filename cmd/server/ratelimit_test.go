@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterRefills(t *testing.T) {
+	l := newRateLimiter()
+	limit := RateLimit{PerMinute: 60, Burst: 2}
+	now := time.Now()
+	for i := range 2 {
+		if ok, _ := l.allow("a", limit, now); !ok {
+			t.Fatalf("Expected request %d within the burst allowed", i)
+		}
+	}
+	ok, wait := l.allow("a", limit, now)
+	if ok || wait != time.Second {
+		t.Errorf("Expected a wait of 1s past the burst, got %t %v", ok, wait)
+	}
+	if ok, _ := l.allow("b", limit, now); !ok {
+		t.Error("Expected another caller to have its own bucket")
+	}
+	if ok, _ := l.allow("a", limit, now.Add(time.Second)); !ok {
+		t.Error("Expected a token back after a second")
+	}
+
+	l.allow("b", limit, now.Add(2*time.Minute))
+	if _, kept := l.buckets["a"]; kept {
+		t.Error("Expected a refilled bucket swept")
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	defer setSettings(getSettings())
+	old := limiter
+	limiter = newRateLimiter()
+	defer func() { limiter = old }()
+
+	handler := RateLimitMiddleware(false, func(w http.ResponseWriter, r *http.Request) {})
+	call := func(principal string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/jobs/x", nil)
+		if principal != "" {
+			req = req.WithContext(context.WithValue(req.Context(), principalKey{}, principal))
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	if rec := call(""); rec.Code != http.StatusOK {
+		t.Fatalf("Expected no limit by default, got %d", rec.Code)
+	}
+	updateSettings(func(s *Settings) { s.RateLimit = RateLimit{PerMinute: 6, Burst: 1} })
+	if rec := call("rapidapi:alice"); rec.Code != http.StatusOK {
+		t.Fatalf("Expected the first request allowed, got %d", rec.Code)
+	}
+	rec := call("rapidapi:alice")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "10" {
+		t.Errorf("Expected 429 with Retry-After 10, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := call("rapidapi:bob"); rec.Code != http.StatusOK {
+		t.Errorf("Expected another user unaffected, got %d", rec.Code)
+	}
+	if rec := call(""); rec.Code != http.StatusOK {
+		t.Errorf("Expected an anonymous client limited by address, got %d", rec.Code)
+	}
+	if rec := call(""); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected the same address limited, got %d", rec.Code)
+	}
+}
+
+func TestRateLimitFrom(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+	l, err := rateLimitFrom(env(map[string]string{"RATE_LIMIT": "120"}))
+	if err != nil || l.PerMinute != 120 || l.Burst != 120 {
+		t.Errorf("Expected a minute's burst by default, got %+v, %v", l, err)
+	}
+	if _, err := rateLimitFrom(env(map[string]string{"RATE_LIMIT": "-1"})); err == nil {
+		t.Error("Expected a negative RATE_LIMIT rejected")
+	}
+	if _, err := rateLimitFrom(env(map[string]string{"RATE_LIMIT": "10", "RATE_LIMIT_BURST": "0"})); err == nil {
+		t.Error("Expected a zero RATE_LIMIT_BURST rejected")
+	}
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"time"
+
+	"binpacker/pkg/packing"
+)
+
+const (
+	defaultRecommendCount = 3
+	maxRecommendCount     = 10
+	maxRecommendDuration  = 5 * time.Minute
+	// maxSizeCandidates caps the box sizes considered by the swap search; the
+	// most common order envelopes are kept.
+	maxSizeCandidates = 100
+)
+
+// RecommendBoxesRequest asks for the Count box sizes that minimize the
+// expected box cost over an order history.
+type RecommendBoxesRequest struct {
+	Orders  []Order  `json:"orders,omitempty"`
+	PackIDs []string `json:"pack_ids,omitempty"`
+	Count   int      `json:"count,omitempty"`
+	// Increment is the manufacturing step; recommended dimensions are
+	// multiples of it.
+	Increment int `json:"increment,omitempty"`
+	// A box costs CostPerBox plus CostPerArea per unit of board surface.
+	// Without either, cost is the surface area.
+	CostPerBox  float64 `json:"cost_per_box,omitempty"`
+	CostPerArea float64 `json:"cost_per_area,omitempty"`
+	CurrencyOptions
+	packing.Options
+}
+
+// BoxRecommendation is the result of a box size search.
+type BoxRecommendation struct {
+	Boxes        []packing.InputBox `json:"boxes"`
+	Orders       int                `json:"orders"`
+	Expected     CatalogStats       `json:"expected"`
+	CostPerOrder float64            `json:"cost_per_order"`
+	Currency     string             `json:"currency"`
+}
+
+func (r RecommendBoxesRequest) boxCost(dims [3]int) float64 {
+	perBox, perArea := r.CostPerBox, r.CostPerArea
+	if perBox == 0 && perArea == 0 {
+		perArea = 1
+	}
+	area := 2 * (dims[0]*dims[1] + dims[1]*dims[2] + dims[0]*dims[2])
+	return perBox + perArea*float64(area)
+}
+
+func roundUp(n, step int) int {
+	return (n + step - 1) / step * step
+}
+
+// orderEnvelope packs the order into an ample cube and returns the bounding
+// box of the result, rounded up to the increment with sides sorted from
+// longest to shortest.
+func orderEnvelope(o Order, increment int, opts packing.Options) ([3]int, bool) {
+	vol, side := 0, 0
+	for _, it := range o.Items {
+		vol += it.W * it.H * it.D * it.Quantity
+		side = max(side, it.W, it.H, it.D)
+	}
+	if vol == 0 {
+		return [3]int{}, false
+	}
+	side = max(side, int(math.Ceil(math.Cbrt(2*float64(vol)))))
+
+	for range 4 {
+		box := packing.InputBox{ID: "envelope", W: side, H: side, D: side}
+		packed, unpacked := packing.PackWithOptions(o.Items, []packing.InputBox{box}, opts)
+		if len(unpacked) == 0 && len(packed) == 1 {
+			var env [3]int
+			for _, p := range packed[0].Contents {
+				env[0] = max(env[0], p.X+p.W)
+				env[1] = max(env[1], p.Y+p.H)
+				env[2] = max(env[2], p.Z+p.D)
+			}
+			for i := range env {
+				env[i] = roundUp(env[i], increment)
+			}
+			slices.SortFunc(env[:], func(a, b int) int { return b - a })
+			return env, true
+		}
+		side *= 2
+	}
+	return [3]int{}, false
+}
+
+// covers reports whether a box with sorted sides a holds an envelope with
+// sorted sides b.
+func covers(a, b [3]int) bool {
+	return a[0] >= b[0] && a[1] >= b[1] && a[2] >= b[2]
+}
+
+// sizeSearch picks box sizes by swap search (k-medoids style) over candidate
+// sizes taken from the order envelopes. An order is charged the cheapest
+// chosen box covering its envelope; that proxy is verified by real packing
+// at the end.
+type sizeSearch struct {
+	envelopes  [][3]int
+	weights    []int
+	candidates [][3]int
+	costs      []float64
+}
+
+type sizeScore struct {
+	uncovered int
+	cost      float64
+}
+
+func (a sizeScore) compare(b sizeScore) int {
+	if c := cmp.Compare(a.uncovered, b.uncovered); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.cost, b.cost)
+}
+
+func newSizeSearch(envs [][3]int, req RecommendBoxesRequest) *sizeSearch {
+	counts := make(map[[3]int]int)
+	var largest [3]int
+	for _, e := range envs {
+		counts[e]++
+		for i := range largest {
+			largest[i] = max(largest[i], e[i])
+		}
+	}
+
+	s := &sizeSearch{}
+	for e, n := range counts {
+		s.envelopes = append(s.envelopes, e)
+		s.weights = append(s.weights, n)
+	}
+
+	// The most common envelopes make the best candidates; the componentwise
+	// maximum guarantees every order is covered by some choice.
+	cands := slices.Clone(s.envelopes)
+	slices.SortFunc(cands, func(a, b [3]int) int {
+		if c := cmp.Compare(counts[b], counts[a]); c != 0 {
+			return c
+		}
+		return slices.Compare(a[:], b[:])
+	})
+	if len(cands) > maxSizeCandidates-1 {
+		cands = cands[:maxSizeCandidates-1]
+	}
+	if !slices.Contains(cands, largest) {
+		cands = append(cands, largest)
+	}
+	s.candidates = cands
+	for _, c := range cands {
+		s.costs = append(s.costs, req.boxCost(c))
+	}
+	return s
+}
+
+func (s *sizeSearch) score(chosen []int) sizeScore {
+	var sc sizeScore
+	for i, e := range s.envelopes {
+		best := math.Inf(1)
+		for _, c := range chosen {
+			if s.costs[c] < best && covers(s.candidates[c], e) {
+				best = s.costs[c]
+			}
+		}
+		if math.IsInf(best, 1) {
+			sc.uncovered += s.weights[i]
+			continue
+		}
+		sc.cost += best * float64(s.weights[i])
+	}
+	return sc
+}
+
+// run builds a selection greedily, then swaps members for other candidates
+// while that lowers the score or until the deadline. It returns the chosen
+// candidate indices and the number of passes made.
+func (s *sizeSearch) run(ctx context.Context, k int, deadline time.Time) ([]int, int) {
+	k = min(k, len(s.candidates))
+
+	var chosen []int
+	for len(chosen) < k {
+		bestIdx, bestScore := -1, sizeScore{}
+		for c := range s.candidates {
+			if slices.Contains(chosen, c) {
+				continue
+			}
+			sc := s.score(append(chosen, c))
+			if bestIdx == -1 || sc.compare(bestScore) < 0 {
+				bestIdx, bestScore = c, sc
+			}
+		}
+		chosen = append(chosen, bestIdx)
+	}
+
+	current := s.score(chosen)
+	passes := 0
+	for improved := true; improved && time.Now().Before(deadline) && ctx.Err() == nil; {
+		improved = false
+		passes++
+		for i := range chosen {
+			for c := range s.candidates {
+				if slices.Contains(chosen, c) {
+					continue
+				}
+				prev := chosen[i]
+				chosen[i] = c
+				if sc := s.score(chosen); sc.compare(current) < 0 {
+					current, improved = sc, true
+				} else {
+					chosen[i] = prev
+				}
+			}
+		}
+	}
+	return chosen, passes
+}
+
+// recommendBoxes searches for box sizes and evaluates them by packing every
+// order with the recommended catalog.
+func recommendBoxes(ctx context.Context, req RecommendBoxesRequest, deadline time.Time) (BoxRecommendation, int, error) {
+	increment := max(req.Increment, 1)
+	count := req.Count
+	if count <= 0 {
+		count = defaultRecommendCount
+	}
+
+	var envs [][3]int
+	for _, o := range req.Orders {
+		if env, ok := orderEnvelope(o, increment, req.Options); ok {
+			envs = append(envs, env)
+		}
+	}
+	if len(envs) == 0 {
+		return BoxRecommendation{}, 0, fmt.Errorf("no order contains items with volume")
+	}
+
+	search := newSizeSearch(envs, req)
+	chosen, passes := search.run(ctx, count, deadline)
+
+	var boxes []packing.InputBox
+	for _, c := range chosen {
+		d := search.candidates[c]
+		boxes = append(boxes, packing.InputBox{W: d[0], H: d[2], D: d[1], Cost: search.costs[c]})
+	}
+	slices.SortFunc(boxes, func(a, b packing.InputBox) int { return cmp.Compare(a.Volume(), b.Volume()) })
+	for i := range boxes {
+		boxes[i].ID = fmt.Sprintf("box-%d", i+1)
+	}
+
+	byID := packing.BoxesByID(boxes)
+	tally := catalogTally{stats: CatalogStats{BoxUsage: map[string]int{}}}
+	for _, o := range req.Orders {
+		packed, unpacked := packing.PackWithOptions(o.Items, boxes, req.Options)
+		tally.add(packed, unpacked, byID)
+	}
+
+	// Costs are converted at the end so the search runs on the request's
+	// figures; the rate is the one current when the job finishes.
+	rate, currency, err := req.conversion(ctx)
+	if err != nil {
+		return BoxRecommendation{}, passes, err
+	}
+	for i := range boxes {
+		boxes[i].Cost *= rate
+	}
+	rec := BoxRecommendation{Boxes: boxes, Orders: len(req.Orders), Expected: tally.result(), Currency: currency}
+	rec.Expected.Cost *= rate
+	rec.CostPerOrder = rec.Expected.Cost / float64(len(req.Orders))
+	return rec, passes, nil
+}
+
+// StartBoxSizes registers a box size recommendation job and runs it in the
+// background. It shares the search slots of optimize jobs.
+func (m *JobManager) StartBoxSizes(ctx context.Context, owner string, req RecommendBoxesRequest) (Job, error) {
+	now := time.Now().UTC()
+	rec := &jobRecord{
+		Job: Job{
+			ID:        newID(IDPrefixJob),
+			Kind:      JobKindBoxSizes,
+			Status:    JobQueued,
+			CreatedAt: now,
+			UpdatedAt: now,
+			Deadline:  now.Add(maxRecommendDuration),
+		},
+		Owner:    owner,
+		BoxSizes: &req,
+	}
+	job := rec.Job
+
+	if err := m.startSearch(ctx, rec, m.runBoxSizes); err != nil {
+		return Job{}, err
+	}
+	return job, nil
+}
+
+// runBoxSizes runs a recommendation job. The search is not resumable, so a
+// job interrupted by a restart starts over.
+func (m *JobManager) runBoxSizes(ctx context.Context, rec *jobRecord) {
+	m.mu.Lock()
+	req := *rec.BoxSizes
+	deadline := rec.Job.Deadline
+	rec.Job.Status = JobRunning
+	m.mu.Unlock()
+
+	result, passes, err := recommendBoxes(ctx, req, deadline)
+	if ctx.Err() != nil {
+		return
+	}
+
+	m.mu.Lock()
+	rec.Job.Iterations = passes
+	rec.Job.UpdatedAt = time.Now().UTC()
+	if err != nil {
+		rec.Job.Status = JobFailed
+		rec.Job.Error = err.Error()
+	} else {
+		rec.Job.Status = JobDone
+		rec.Job.Recommendation = &result
+	}
+	m.mu.Unlock()
+	m.checkpoint(rec)
+}
+
+func handleRecommendBoxes(w http.ResponseWriter, r *http.Request) {
+	var req RecommendBoxesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.Count < 0 || req.Count > maxRecommendCount {
+		http.Error(w, fmt.Sprintf("count must be between 1 and %d", maxRecommendCount), http.StatusBadRequest)
+		return
+	}
+	if req.Increment < 0 || req.CostPerBox < 0 || req.CostPerArea < 0 {
+		http.Error(w, "increment and costs must not be negative", http.StatusBadRequest)
+		return
+	}
+	if err := req.Options.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, _, err := req.conversion(r.Context()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Resolve stored packs now so the job does not depend on them.
+	for _, id := range req.PackIDs {
+		p, ok := visiblePack(r, id)
+		if !ok {
+			http.Error(w, fmt.Sprintf("Pack %s not found", id), http.StatusNotFound)
+			return
+		}
+		req.Orders = append(req.Orders, orderFromPack(p.Response))
+	}
+	req.PackIDs = nil
+
+	if len(req.Orders) == 0 {
+		http.Error(w, "orders or pack_ids are required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Orders) > maxSimulateOrders {
+		http.Error(w, fmt.Sprintf("Too many orders in one request (max %d)", maxSimulateOrders), http.StatusBadRequest)
+		return
+	}
+	if err := validateOrders(req.Orders, getSettings().Limits); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	job, err := jobs.StartBoxSizes(context.Background(), principalFrom(r.Context()), req)
+	if err != nil {
+		writeJobsBusy(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(job)
+}
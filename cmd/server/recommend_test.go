@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"binpacker/pkg/packing"
+)
+
+func TestRecommendBoxesFindsOneSizePerCluster(t *testing.T) {
+	var orders []Order
+	for range 5 {
+		orders = append(orders, Order{ID: "small", Items: []packing.InputItem{{ID: "phone", W: 7, H: 2, D: 14, Quantity: 1}}})
+		orders = append(orders, Order{ID: "large", Items: []packing.InputItem{{ID: "boots", W: 30, H: 12, D: 20, Quantity: 1}}})
+	}
+	req := RecommendBoxesRequest{Orders: orders, Count: 2, Increment: 5}
+
+	rec, _, err := recommendBoxes(context.Background(), req, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rec.Boxes) != 2 {
+		t.Fatalf("Expected 2 boxes, got %+v", rec.Boxes)
+	}
+	for _, b := range rec.Boxes {
+		if b.W%5 != 0 || b.H%5 != 0 || b.D%5 != 0 {
+			t.Errorf("Expected dimensions in steps of 5, got %dx%dx%d", b.W, b.H, b.D)
+		}
+	}
+	if rec.Expected.Boxes != 10 || rec.Expected.UnpackedItems != 0 {
+		t.Errorf("Expected every order in a single box, got %+v", rec.Expected)
+	}
+	if rec.Expected.BoxUsage["box-1"] != 5 || rec.Expected.BoxUsage["box-2"] != 5 {
+		t.Errorf("Expected each box to serve one cluster, got %v", rec.Expected.BoxUsage)
+	}
+}
+
+func TestSizeSearchCoversLargestOrder(t *testing.T) {
+	envs := [][3]int{{10, 10, 10}, {10, 10, 10}, {40, 5, 5}}
+	s := newSizeSearch(envs, RecommendBoxesRequest{})
+
+	chosen, _ := s.run(context.Background(), 1, time.Now().Add(time.Second))
+	if sc := s.score(chosen); sc.uncovered != 0 {
+		t.Errorf("Expected a single box covering every order, got %v with %d uncovered", s.candidates[chosen[0]], sc.uncovered)
+	}
+}
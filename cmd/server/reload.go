@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Settings are the parts of the configuration that can change while the
+// server runs. A request reads them once, when it starts, so a reload never
+// changes them under a pack in flight.
+type Settings struct {
+	Limits      InputLimits
+	PackTimeout time.Duration
+	// BatchPackTimeout is the PackTimeout of the batch lane.
+	BatchPackTimeout time.Duration
+	Profiles         map[string]*Profile
+	LogLevel         slog.Level
+	RateLimit        RateLimit
+}
+
+var (
+	settingsMu sync.RWMutex
+	settings   = Settings{Limits: defaultInputLimits, PackTimeout: defaultPackTimeout, BatchPackTimeout: defaultBatchPackTimeout}
+)
+
+func getSettings() Settings {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	return settings
+}
+
+func setSettings(s Settings) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	settings = s
+	logLevel.Set(s.LogLevel)
+}
+
+// packTimeout returns the time a pack in the lane may take.
+func (s Settings) packTimeout(lane string) time.Duration {
+	if lane == LaneBatch {
+		return s.BatchPackTimeout
+	}
+	return s.PackTimeout
+}
+
+// updateSettings changes the settings in force with f.
+func updateSettings(f func(*Settings)) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	f(&settings)
+	logLevel.Set(settings.LogLevel)
+}
+
+// reloadableVars are the variables CONFIG_FILE may set.
+var reloadableVars = []string{
+	"MAX_ITEMS", "MAX_BOXES", "MAX_TOTAL_UNITS", "MAX_ITEM_QUANTITY", "MAX_DIMENSION",
+	"MAX_PACK_EVALUATIONS", "MAX_PACK_MEMORY_MB", "PACK_TIMEOUT", "BATCH_PACK_TIMEOUT", "LOG_LEVEL",
+	"RATE_LIMIT", "RATE_LIMIT_BURST",
+}
+
+// loadSettings reads the settings from the environment, overridden by the
+// KEY=VALUE lines of CONFIG_FILE if set, and the profiles from PROFILES_FILE.
+// Environment variables are fixed for the life of the process, so the files
+// are what an operator edits before a reload.
+func loadSettings() (Settings, error) {
+	getenv, err := configFileEnv(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		return Settings{}, err
+	}
+
+	s := Settings{PackTimeout: defaultPackTimeout, BatchPackTimeout: defaultBatchPackTimeout}
+	if s.Limits, err = inputLimitsFrom(getenv); err != nil {
+		return Settings{}, err
+	}
+	if v := getenv("PACK_TIMEOUT"); v != "" {
+		if s.PackTimeout, err = time.ParseDuration(v); err != nil || s.PackTimeout < 0 {
+			return Settings{}, fmt.Errorf("invalid PACK_TIMEOUT %q", v)
+		}
+	}
+	if v := getenv("BATCH_PACK_TIMEOUT"); v != "" {
+		if s.BatchPackTimeout, err = time.ParseDuration(v); err != nil || s.BatchPackTimeout < 0 {
+			return Settings{}, fmt.Errorf("invalid BATCH_PACK_TIMEOUT %q", v)
+		}
+	}
+	if s.RateLimit, err = rateLimitFrom(getenv); err != nil {
+		return Settings{}, err
+	}
+	if v := getenv("LOG_LEVEL"); v != "" {
+		if err := s.LogLevel.UnmarshalText([]byte(v)); err != nil {
+			return Settings{}, fmt.Errorf("invalid LOG_LEVEL %q", v)
+		}
+	}
+	if s.Profiles, err = profilesFromEnv(); err != nil {
+		return Settings{}, fmt.Errorf("invalid PROFILES_FILE: %w", err)
+	}
+	return s, nil
+}
+
+// configFileEnv returns a getenv that looks in the file at path before the
+// environment. Lines are KEY=VALUE; blank lines and lines starting with #
+// are skipped.
+func configFileEnv(path string) (func(string) string, error) {
+	if path == "" {
+		return os.Getenv, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CONFIG_FILE: %w", err)
+	}
+	vars := make(map[string]string)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		k, v, ok := strings.Cut(text, "=")
+		k = strings.TrimSpace(k)
+		if !ok || !slices.Contains(reloadableVars, k) {
+			return nil, fmt.Errorf("CONFIG_FILE line %d: expected one of %s=value", line, strings.Join(reloadableVars, ", "))
+		}
+		vars[k] = strings.TrimSpace(v)
+	}
+	return func(k string) string {
+		if v, ok := vars[k]; ok {
+			return v
+		}
+		return os.Getenv(k)
+	}, nil
+}
+
+// reloadSettings loads the settings again and puts them in force, or keeps
+// the old ones if the new ones are invalid.
+func reloadSettings() (Settings, error) {
+	s, err := loadSettings()
+	if err != nil {
+		slog.Error("reload: keeping the old configuration", "error", err)
+		return getSettings(), err
+	}
+	setSettings(s)
+	slog.Info("reload: configuration reloaded", "log_level", s.LogLevel.String())
+	return s, nil
+}
+
+// reloadOnSIGHUP reloads the settings on every SIGHUP.
+func reloadOnSIGHUP() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	for range ch {
+		_, _ = reloadSettings()
+	}
+}
+
+// settingsView is the JSON form of Settings.
+type settingsView struct {
+	Limits           InputLimits `json:"limits"`
+	PackTimeout      string      `json:"pack_timeout"`
+	BatchPackTimeout string      `json:"batch_pack_timeout"`
+	Profiles         []string    `json:"profiles"`
+	LogLevel         string      `json:"log_level"`
+	RateLimit        RateLimit   `json:"rate_limit"`
+}
+
+// handleAdminReload reloads the settings like SIGHUP, for deployments where
+// sending a signal is awkward, and reports those in force.
+func handleAdminReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s, err := reloadSettings()
+	if err != nil {
+		http.Error(w, "Configuration not reloaded: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(settingsView{
+		Limits:           s.Limits,
+		PackTimeout:      s.PackTimeout.String(),
+		BatchPackTimeout: s.BatchPackTimeout.String(),
+		Profiles:         slices.Sorted(maps.Keys(s.Profiles)),
+		LogLevel:         s.LogLevel.String(),
+		RateLimit:        s.RateLimit,
+	})
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReloadSettings(t *testing.T) {
+	defer setSettings(getSettings())
+	dir := t.TempDir()
+	config := filepath.Join(dir, "binpacker.conf")
+	profilesPath := filepath.Join(dir, "profiles.json")
+	t.Setenv("CONFIG_FILE", config)
+	t.Setenv("PROFILES_FILE", profilesPath)
+	t.Setenv("MAX_ITEMS", "50")
+
+	write := func(path, data string) {
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reload := func() int {
+		rec := httptest.NewRecorder()
+		handleAdminReload(rec, httptest.NewRequest(http.MethodPost, "/admin/reload", nil))
+		return rec.Code
+	}
+
+	write(config, "# spike settings\nMAX_BOXES=20\nPACK_TIMEOUT = 5s\nLOG_LEVEL=debug\n")
+	write(profilesPath, `{"p": {"boxes": [{"id": "b", "w": 1, "h": 1, "d": 1}]}}`)
+	if code := reload(); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	s := getSettings()
+	if s.Limits.MaxItems != 50 || s.Limits.MaxBoxes != 20 || s.PackTimeout != 5*time.Second || s.Profiles["p"] == nil {
+		t.Errorf("Expected the environment, file and profiles applied, got %+v", s)
+	}
+	if logLevel.Level() != slog.LevelDebug {
+		t.Errorf("Expected LOG_LEVEL to take effect, got %v", logLevel.Level())
+	}
+
+	write(config, "MAX_BOXES=0\n")
+	if code := reload(); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid limit, got %d", code)
+	}
+	write(config, "LISTEN_ADDR=:80\n")
+	if code := reload(); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a setting that cannot be reloaded, got %d", code)
+	}
+	if got := getSettings(); got.Limits.MaxBoxes != 20 {
+		t.Errorf("Expected a failed reload to keep the old settings, got %+v", got.Limits)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetentionPolicy says how long stored data is kept. A zero duration keeps
+// that kind of data until it is deleted explicitly.
+type RetentionPolicy struct {
+	Packs          time.Duration
+	Visualizations time.Duration
+	// Jobs applies to finished jobs only; running jobs are never purged.
+	Jobs time.Duration
+	// DeletedGrace is how long soft-deleted records linger before purging.
+	DeletedGrace time.Duration
+}
+
+const defaultJanitorInterval = time.Hour
+
+var defaultRetention = RetentionPolicy{
+	Packs:          90 * 24 * time.Hour,
+	Visualizations: 7 * 24 * time.Hour,
+	Jobs:           7 * 24 * time.Hour,
+	DeletedGrace:   24 * time.Hour,
+}
+
+var (
+	purgedPacks          = newCounter("purged_packs_total", "Stored pack results purged by the retention janitor.")
+	purgedVisualizations = newCounter("purged_visualizations_total", "Stored visualizations purged by the retention janitor.")
+	purgedJobs           = newCounter("purged_jobs_total", "Finished jobs purged by the retention janitor.")
+)
+
+// retentionFromEnv reads RETENTION_PACKS, RETENTION_VISUALIZATIONS,
+// RETENTION_JOBS and RETENTION_DELETED_GRACE on top of the defaults.
+func retentionFromEnv() (RetentionPolicy, error) {
+	p := defaultRetention
+	for _, f := range []struct {
+		env string
+		dst *time.Duration
+	}{
+		{"RETENTION_PACKS", &p.Packs},
+		{"RETENTION_VISUALIZATIONS", &p.Visualizations},
+		{"RETENTION_JOBS", &p.Jobs},
+		{"RETENTION_DELETED_GRACE", &p.DeletedGrace},
+	} {
+		v := os.Getenv(f.env)
+		if v == "" {
+			continue
+		}
+		d, err := parseRetention(v)
+		if err != nil {
+			return RetentionPolicy{}, fmt.Errorf("%s: %w", f.env, err)
+		}
+		*f.dst = d
+	}
+	return p, nil
+}
+
+// parseRetention accepts Go durations plus a day suffix, e.g. "90d".
+func parseRetention(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// expired reports whether a record created at created and soft-deleted at
+// deleted (zero if live) is due for purging.
+func expired(now, created, deleted time.Time, keep, grace time.Duration) bool {
+	if !deleted.IsZero() && now.Sub(deleted) >= grace {
+		return true
+	}
+	return keep > 0 && now.Sub(created) >= keep
+}
+
+// Purge permanently removes expired and soft-deleted records.
+func (s *Store) Purge(now time.Time, p RetentionPolicy) (packs, visualizations int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.packs(func(key string, rec storedPack) {
+		if expired(now, rec.CreatedAt, rec.DeletedAt, p.Packs, p.DeletedGrace) && s.remove(key) {
+			packs++
+		}
+	})
+	s.visualizations(func(key string, rec storedVisualization) {
+		if expired(now, rec.CreatedAt, rec.DeletedAt, p.Visualizations, p.DeletedGrace) && s.remove(key) {
+			visualizations++
+		}
+	})
+	return packs, visualizations
+}
+
+// Purge removes finished jobs, and their checkpoints, last updated more
+// than keep ago.
+func (m *JobManager) Purge(now time.Time, keep time.Duration) int {
+	if keep <= 0 {
+		return 0
+	}
+
+	m.mu.Lock()
+	var ids []string
+	for id, rec := range m.jobs {
+		finished := rec.Job.Status == JobDone || rec.Job.Status == JobFailed
+		if finished && now.Sub(rec.Job.UpdatedAt) >= keep {
+			delete(m.jobs, id)
+			ids = append(ids, id)
+		}
+	}
+	m.mu.Unlock()
+
+	m.removeCheckpoints(ids)
+	return len(ids)
+}
+
+// purgeExpired runs one janitor pass over all stored data.
+func purgeExpired(now time.Time, p RetentionPolicy) {
+	packs, vizs := store.Purge(now, p)
+	n := jobs.Purge(now, p.Jobs)
+
+	purgedPacks.Add(int64(packs))
+	purgedVisualizations.Add(int64(vizs))
+	purgedJobs.Add(int64(n))
+	if packs+vizs+n > 0 {
+		slog.Info("retention purge", "packs", packs, "visualizations", vizs, "jobs", n)
+	}
+}
+
+// runJanitor purges expired data every interval until ctx is done.
+func runJanitor(ctx context.Context, p RetentionPolicy, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			purgeExpired(now, p)
+		}
+	}
+}
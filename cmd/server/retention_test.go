@@ -0,0 +1,121 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRetention(t *testing.T) {
+	cases := map[string]time.Duration{
+		"90d": 90 * 24 * time.Hour,
+		"36h": 36 * time.Hour,
+		"0":   0,
+	}
+	for in, want := range cases {
+		got, err := parseRetention(in)
+		if err != nil || got != want {
+			t.Errorf("parseRetention(%q): expected %v, got %v (%v)", in, want, got, err)
+		}
+	}
+	for _, in := range []string{"-1d", "soon", "xd"} {
+		if _, err := parseRetention(in); err == nil {
+			t.Errorf("parseRetention(%q): expected an error", in)
+		}
+	}
+}
+
+func TestStorePurge(t *testing.T) {
+	s := newStore()
+	s.SaveVisualization("vz_old", "pk_live", "", "<html>")
+	s.SavePack("", "vz_old", PackResponse{PackID: "pk_live"}, nil)
+	s.SavePack("", "", PackResponse{PackID: "pk_deleted"}, nil)
+
+	if !s.DeletePack("", "pk_deleted") {
+		t.Fatal("Expected DeletePack to succeed")
+	}
+	if _, ok := s.Pack("", "pk_deleted"); ok {
+		t.Error("Expected a soft-deleted pack to be hidden")
+	}
+
+	policy := RetentionPolicy{Packs: 90 * 24 * time.Hour, Visualizations: 7 * 24 * time.Hour, DeletedGrace: time.Hour}
+
+	// Nothing has reached its retention or grace period yet.
+	if packs, vizs := s.Purge(time.Now(), policy); packs != 0 || vizs != 0 {
+		t.Errorf("Expected nothing purged, got %d packs and %d visualizations", packs, vizs)
+	}
+
+	packs, vizs := s.Purge(time.Now().Add(8*24*time.Hour), policy)
+	if packs != 1 || vizs != 1 {
+		t.Errorf("Expected 1 pack and 1 visualization purged, got %d and %d", packs, vizs)
+	}
+	if _, ok := s.Pack("", "pk_live"); !ok {
+		t.Error("Expected the live pack to be kept")
+	}
+	if _, ok := s.Visualization("", "vz_old"); ok {
+		t.Error("Expected the visualization to be purged")
+	}
+}
+
+func TestJobPurgeKeepsRunningJobs(t *testing.T) {
+	m := newJobManager("")
+	old := time.Now().Add(-48 * time.Hour)
+	m.jobs["jb_done"] = &jobRecord{Job: Job{ID: "jb_done", Status: JobDone, UpdatedAt: old}}
+	m.jobs["jb_running"] = &jobRecord{Job: Job{ID: "jb_running", Status: JobRunning, UpdatedAt: old}}
+
+	if n := m.Purge(time.Now(), 24*time.Hour); n != 1 {
+		t.Errorf("Expected 1 job purged, got %d", n)
+	}
+	if _, ok := m.Get("jb_running"); !ok {
+		t.Error("Expected the running job to be kept")
+	}
+}
+
+func TestVisualizationExpiry(t *testing.T) {
+	s := newStore()
+	s.setVizLimits(vizLimits{TTL: time.Hour})
+	s.SaveVisualization("vz_a", "pk_a", "", "<html>")
+
+	if n := s.SweepVisualizations(time.Now().Add(time.Minute)); n != 0 {
+		t.Errorf("Expected nothing to expire before the TTL, got %d", n)
+	}
+	if n := s.SweepVisualizations(time.Now().Add(2 * time.Hour)); n != 1 {
+		t.Errorf("Expected 1 expired visualization, got %d", n)
+	}
+	v, ok := s.Visualization("", "vz_a")
+	if !ok || v.ExpiredAt.IsZero() || v.HTML != "" {
+		t.Errorf("Expected an expired record without HTML, got %+v (%v)", v, ok)
+	}
+}
+
+func TestVisualizationLRUCap(t *testing.T) {
+	s := newStore()
+	s.setVizLimits(vizLimits{MaxEntries: 2})
+	s.SaveVisualization("vz_a", "pk_a", "", "<html>a")
+	s.SaveVisualization("vz_b", "pk_b", "", "<html>b")
+	s.Visualization("", "vz_a")
+	s.SaveVisualization("vz_c", "pk_c", "", "<html>c")
+
+	for id, live := range map[string]bool{"vz_a": true, "vz_b": false, "vz_c": true} {
+		v, ok := s.Visualization("", id)
+		if !ok || v.ExpiredAt.IsZero() != live {
+			t.Errorf("%s: expected live=%v, got %+v", id, live, v)
+		}
+	}
+}
+
+func TestVizLimitsDefaultInMemory(t *testing.T) {
+	t.Setenv("VIZ_TTL", "")
+	t.Setenv("VIZ_MAX_ENTRIES", "")
+	l, err := vizLimitsFromEnv(true)
+	if err != nil || l.TTL != defaultMemoryVizTTL || l.MaxEntries != defaultMemoryVizMaxEntries {
+		t.Errorf("Expected the memory defaults, got %+v (%v)", l, err)
+	}
+	if l, _ := vizLimitsFromEnv(false); l != (vizLimits{}) {
+		t.Errorf("Expected no limits on a shared backend, got %+v", l)
+	}
+
+	t.Setenv("VIZ_MAX_ENTRIES", "0")
+	if l, _ := vizLimitsFromEnv(true); l.MaxEntries != 0 || l.TTL != defaultMemoryVizTTL {
+		t.Errorf("Expected VIZ_MAX_ENTRIES=0 to lift only the cap, got %+v", l)
+	}
+}
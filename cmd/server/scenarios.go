@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Scenarios are stored pack requests behind a link. Unlike packs they are
+// not kept per tenant: the random scenario ID is what makes the link
+// shareable, e.g. with support or in a bug report, so anyone holding it
+// can run the request. Only the owner can delete it.
+const scenarioKeyPrefix = "scenarios/"
+
+// storedScenario is a pack request as posted. It is decoded again on every
+// run, so the request is always packed by the current code and settings.
+type storedScenario struct {
+	Owner     string
+	CreatedAt time.Time
+	Request   json.RawMessage
+}
+
+// ScenarioCreated answers POST /scenarios.
+type ScenarioCreated struct {
+	ScenarioID string `json:"scenario_id"`
+	URL        string `json:"url"`
+}
+
+func scenarioKey(id string) string {
+	return scenarioKeyPrefix + id
+}
+
+// SaveScenario stores a pack request for owner.
+func (s *Store) SaveScenario(owner, id string, request json.RawMessage) {
+	s.save(scenarioKey(id), storedScenario{Owner: owner, CreatedAt: time.Now().UTC(), Request: request})
+}
+
+// Scenario returns a stored scenario of any owner.
+func (s *Store) Scenario(id string) (storedScenario, bool) {
+	var sc storedScenario
+	return sc, s.load(scenarioKey(id), &sc)
+}
+
+// scenarios calls fn with the key and record of every stored scenario.
+func (s *Store) scenarios(fn func(key string, sc storedScenario)) {
+	s.scan(scenarioKeyPrefix, func(key string) {
+		var sc storedScenario
+		if s.load(key, &sc) {
+			fn(key, sc)
+		}
+	})
+}
+
+// handleScenarios serves POST /scenarios.
+func handleScenarios(w http.ResponseWriter, r *http.Request) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	var req PackRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		http.Error(w, "Invalid pack request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Items) == 0 || (len(req.Boxes) == 0 && req.Profile == "") {
+		http.Error(w, "Items and Boxes are required", http.StatusBadRequest)
+		return
+	}
+	if err := checkRequestSize(req.Items, req.Boxes, getSettings().Limits); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	var compact bytes.Buffer
+	_ = json.Compact(&compact, raw)
+	id := newID(IDPrefixScenario)
+	store.SaveScenario(principalFrom(r.Context()), id, compact.Bytes())
+
+	url := "/scenarios/" + id
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", url)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(ScenarioCreated{ScenarioID: id, URL: url})
+}
+
+// handleScenario serves /scenarios/{id}: GET packs the stored request
+// afresh and answers like /pack, GET /scenarios/{id}/request returns the
+// request itself and DELETE removes a scenario of the caller.
+func handleScenario(w http.ResponseWriter, r *http.Request) {
+	id, sub, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/scenarios/"), "/")
+	var sc storedScenario
+	ok := false
+	if _, err := parseID(id, IDPrefixScenario); err == nil && (sub == "" || sub == "request") {
+		sc, ok = store.Scenario(id)
+	}
+	if !ok {
+		http.Error(w, "Scenario not found", http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && sub == "request":
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(sc.Request)
+	case r.Method == http.MethodGet:
+		runScenario(w, r, sc)
+	case r.Method == http.MethodDelete && sub == "":
+		if sc.Owner != principalFrom(r.Context()) {
+			http.Error(w, "Scenario not found", http.StatusNotFound)
+			return
+		}
+		store.remove(scenarioKey(id))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// runScenario packs a stored request as the caller, so the result is
+// stored for them and the request is validated against today's limits.
+func runScenario(w http.ResponseWriter, r *http.Request, sc storedScenario) {
+	// Running stores a new result, which read-only mode does not allow.
+	if mode := getServiceMode(); mode.Mode == ModeReadOnly {
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, mode.Message, http.StatusServiceUnavailable)
+		return
+	}
+	start := time.Now()
+	var req PackRequest
+	if err := json.Unmarshal(sc.Request, &req); err != nil {
+		writeRequestError(w, errors.New("stored request no longer decodes: "+err.Error()))
+		return
+	}
+	if err := validatePackRequest(r.Context(), &req); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	release, ok := acquirePackSlot(w, r, req.Lane)
+	if !ok {
+		return
+	}
+	defer release()
+
+	resp := runPack(r.Context(), req, start)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp.inFrame(req.Boxes))
+}
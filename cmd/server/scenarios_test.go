@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestScenarios(t *testing.T) {
+	body := `{"items": [{"id": "cube", "w": 5, "h": 5, "d": 5, "quantity": 9}],
+		"boxes": [{"id": "box", "w": 10, "h": 10, "d": 10}], "visualization": "none"}`
+	asKey := func(r *http.Request, key string) *http.Request {
+		return r.WithContext(context.WithValue(r.Context(), principalKey{}, key))
+	}
+
+	rec := httptest.NewRecorder()
+	Packer(rec, asKey(httptest.NewRequest(http.MethodPost, "/scenarios", strings.NewReader(body)), "key:a"))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body)
+	}
+	var created ScenarioCreated
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(created.ScenarioID, IDPrefixScenario+"_") || created.URL != "/scenarios/"+created.ScenarioID {
+		t.Errorf("Expected a scenario ID and link, got %+v", created)
+	}
+
+	// Anyone with the link can run it, and every run packs afresh.
+	var packIDs []string
+	for range 2 {
+		rec = httptest.NewRecorder()
+		Packer(rec, asKey(httptest.NewRequest(http.MethodGet, created.URL, nil), "key:b"))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body)
+		}
+		var resp PackResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.PackedBoxes) != 2 {
+			t.Errorf("Expected 2 boxes, got %d", len(resp.PackedBoxes))
+		}
+		packIDs = append(packIDs, resp.PackID)
+	}
+	if packIDs[0] == packIDs[1] {
+		t.Error("Expected each run to produce a new result")
+	}
+	if _, ok := store.Pack("key:b", packIDs[0]); !ok {
+		t.Error("Expected the result to be stored for the caller who ran it")
+	}
+
+	rec = httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodGet, created.URL+"/request", nil))
+	var stored PackRequest
+	if err := json.NewDecoder(rec.Body).Decode(&stored); err != nil || len(stored.Items) != 1 || stored.Visualization != VizModeNone {
+		t.Errorf("Expected the stored request, got %+v, %v", stored, err)
+	}
+
+	// Only the owner can delete it.
+	for key, want := range map[string]int{"key:b": http.StatusNotFound, "key:a": http.StatusNoContent} {
+		rec = httptest.NewRecorder()
+		Packer(rec, asKey(httptest.NewRequest(http.MethodDelete, created.URL, nil), key))
+		if rec.Code != want {
+			t.Errorf("%s: expected status %d, got %d", key, want, rec.Code)
+		}
+		if key == "key:b" {
+			continue
+		}
+		rec = httptest.NewRecorder()
+		Packer(rec, httptest.NewRequest(http.MethodGet, created.URL, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 after deletion, got %d", rec.Code)
+		}
+	}
+}
+
+func TestScenarioRejectsBadRequests(t *testing.T) {
+	for name, body := range map[string]string{
+		"not JSON":  `{"items":`,
+		"no boxes":  `{"items": [{"id": "a", "w": 1, "h": 1, "d": 1, "quantity": 1}]}`,
+		"bad field": `{"items": "a", "boxes": []}`,
+	} {
+		rec := httptest.NewRecorder()
+		Packer(rec, httptest.NewRequest(http.MethodPost, "/scenarios", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", name, rec.Code)
+		}
+	}
+	rec := httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodGet, "/scenarios/"+newID(IDPrefixPack), nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a pack ID, got %d", rec.Code)
+	}
+}
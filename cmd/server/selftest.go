@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"binpacker/pkg/packing"
+	"binpacker/pkg/visualize"
+)
+
+// GET /selftest packs a canned order, checks the result, renders it and
+// round-trips a record through storage, so a deployer can see that an
+// instance works end to end rather than just that it answers. Nothing is
+// kept: the pack is not stored, archived or recorded in telemetry. It is
+// served behind AdminMiddleware, since anonymous callers must not be able to
+// make it pack and write to storage.
+
+// selftestItems and selftestBoxes are the canned order. Everything fits, in
+// more than one box.
+var (
+	selftestItems = []packing.InputItem{
+		{ID: "cube", W: 10, H: 10, D: 10, Quantity: 8, Weight: 1},
+		{ID: "slab", W: 20, H: 5, D: 20, Quantity: 2, Weight: 4},
+		{ID: "rod", W: 5, H: 5, D: 30, Quantity: 3, Weight: 0.5, KeepUpright: true},
+	}
+	selftestBoxes = []packing.InputBox{
+		{ID: "small", W: 20, H: 20, D: 20, MaxWeight: 8},
+		{ID: "large", W: 30, H: 30, D: 30},
+	}
+)
+
+// SelfTestResult is the /selftest response.
+type SelfTestResult struct {
+	OK         bool            `json:"ok"`
+	DurationMS float64         `json:"duration_ms"`
+	Checks     []SelfTestCheck `json:"checks"`
+}
+
+// SelfTestCheck is one step of a self test.
+type SelfTestCheck struct {
+	Name       string  `json:"name"`
+	OK         bool    `json:"ok"`
+	DurationMS float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// SelfTest serves the self test: 200 when every check passes, 503 when not.
+func SelfTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	res := runSelfTest(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if !res.OK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// runSelfTest runs the checks in order, stopping at the first failure since
+// each needs the one before.
+func runSelfTest(ctx context.Context) SelfTestResult {
+	start := time.Now()
+	res := SelfTestResult{OK: true}
+	var packed []packing.PackedBox
+	var html string
+
+	checks := []struct {
+		name string
+		run  func() error
+	}{
+		{"pack", func() error {
+			var unpacked []packing.InputItem
+			var err error
+			packed, unpacked, err = packing.PackContext(ctx, selftestItems, selftestBoxes, packing.Options{})
+			if err != nil {
+				return err
+			}
+			if len(unpacked) > 0 {
+				return fmt.Errorf("%d items left unpacked", len(unpacked))
+			}
+			return nil
+		}},
+		{"invariants", func() error { return checkSelfTestPack(packed) }},
+		{"visualization", func() error {
+			var err error
+			html, err = visualize.HTML(visualize.Data{
+				PackedBoxes: packed,
+				Boxes:       selftestBoxes,
+				RequestID:   "selftest",
+			})
+			if err == nil && len(html) == 0 {
+				err = errors.New("empty visualization")
+			}
+			return err
+		}},
+		{"storage", func() error { return checkStorage(ctx, []byte(html)) }},
+	}
+
+	for _, c := range checks {
+		t := time.Now()
+		err := c.run()
+		check := SelfTestCheck{Name: c.name, OK: err == nil, DurationMS: millis(time.Since(t))}
+		if err != nil {
+			check.Error = err.Error()
+		}
+		res.Checks = append(res.Checks, check)
+		if err != nil {
+			res.OK = false
+			break
+		}
+	}
+	res.DurationMS = millis(time.Since(start))
+	return res
+}
+
+// checkSelfTestPack checks the canned result: every item placed once,
+// inside its box, without overlaps, and within the box weight limits.
+func checkSelfTestPack(packed []packing.PackedBox) error {
+	if err := packing.CheckLayout(packed, selftestBoxes); err != nil {
+		return err
+	}
+	want := make(map[string]int)
+	for _, it := range selftestItems {
+		want[it.ID] += it.Quantity
+	}
+	byID := packing.BoxesByID(selftestBoxes)
+	for i, pb := range packed {
+		var weight float64
+		for _, p := range pb.Contents {
+			want[p.ItemID]--
+			weight += p.Weight
+		}
+		if limit := byID[pb.BoxID].MaxWeight; limit > 0 && weight > limit {
+			return fmt.Errorf("box %d: weight %g exceeds the limit of %g", i, weight, limit)
+		}
+	}
+	for _, it := range selftestItems {
+		if n := want[it.ID]; n != 0 {
+			return fmt.Errorf("item %q placed %d times, want %d", it.ID, it.Quantity-n, it.Quantity)
+		}
+	}
+	return nil
+}
+
+// checkStorage writes value to the storage backend, reads it back and
+// deletes it.
+func checkStorage(ctx context.Context, value []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, storageTimeout)
+	defer cancel()
+	key := "selftest/" + newID(IDPrefixPack)
+	if err := store.backend.Put(ctx, key, value); err != nil {
+		return fmt.Errorf("put: %w", err)
+	}
+	defer store.backend.Delete(context.WithoutCancel(ctx), key)
+	got, err := store.backend.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("get: %w", err)
+	}
+	if !bytes.Equal(got, value) {
+		return errors.New("read back a different value")
+	}
+	return nil
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
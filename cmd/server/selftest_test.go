@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"binpacker/pkg/packing"
+)
+
+func TestSelfTest(t *testing.T) {
+	rec := httptest.NewRecorder()
+	SelfTest(rec, httptest.NewRequest(http.MethodGet, "/selftest", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var res SelfTestResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if !res.OK || len(res.Checks) != 4 {
+		t.Errorf("Expected 4 passing checks, got %+v", res)
+	}
+
+	keys, _ := store.backend.Keys(context.Background(), "selftest/")
+	if len(keys) != 0 {
+		t.Errorf("Expected the storage check to clean up, got %v", keys)
+	}
+}
+
+func TestSelfTestCatchesBrokenPack(t *testing.T) {
+	packed, _, _ := packing.PackContext(context.Background(), selftestItems, selftestBoxes, packing.Options{})
+	packed[0].Contents = packed[0].Contents[1:]
+	if err := checkSelfTestPack(packed); err == nil {
+		t.Error("Expected a missing item to fail the check")
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// systemd passes activated sockets starting at file descriptor 3.
+const listenFDsStart = 3
+
+// serverTimeouts bound how long a client may hold a connection, and how
+// long a shutdown waits for the work in flight. A zero value disables that
+// timeout.
+type serverTimeouts struct {
+	ReadHeader time.Duration
+	Read       time.Duration
+	Write      time.Duration
+	Idle       time.Duration
+	Shutdown   time.Duration
+}
+
+// defaultTimeouts leave room for large /pack requests while cutting off
+// slow or stalled clients. WebSocket sessions are not affected once upgraded.
+// A shutdown ends before the 30 seconds Kubernetes allows after SIGTERM.
+var defaultTimeouts = serverTimeouts{
+	ReadHeader: 10 * time.Second,
+	Read:       60 * time.Second,
+	Write:      120 * time.Second,
+	Idle:       120 * time.Second,
+	Shutdown:   25 * time.Second,
+}
+
+// timeoutsFromEnv reads SERVER_READ_HEADER_TIMEOUT, SERVER_READ_TIMEOUT,
+// SERVER_WRITE_TIMEOUT, SERVER_IDLE_TIMEOUT and SERVER_SHUTDOWN_TIMEOUT as
+// Go durations.
+func timeoutsFromEnv() (serverTimeouts, error) {
+	t := defaultTimeouts
+	for _, f := range []struct {
+		env string
+		dst *time.Duration
+	}{
+		{"SERVER_READ_HEADER_TIMEOUT", &t.ReadHeader},
+		{"SERVER_READ_TIMEOUT", &t.Read},
+		{"SERVER_WRITE_TIMEOUT", &t.Write},
+		{"SERVER_IDLE_TIMEOUT", &t.Idle},
+		{"SERVER_SHUTDOWN_TIMEOUT", &t.Shutdown},
+	} {
+		v := os.Getenv(f.env)
+		if v == "" {
+			continue
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return serverTimeouts{}, fmt.Errorf("invalid %s %q", f.env, v)
+		}
+		*f.dst = d
+	}
+	return t, nil
+}
+
+// newServer builds the HTTP server. With h2c set, HTTP/2 is also accepted
+// over plain TCP, as used by Cloud Run's end-to-end HTTP/2 option; over TLS
+// HTTP/2 is always negotiated.
+func newServer(handler http.Handler, tlsConfig *tls.Config, t serverTimeouts, h2c bool) *http.Server {
+	srv := &http.Server{
+		Handler:           handler,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: t.ReadHeader,
+		ReadTimeout:       t.Read,
+		WriteTimeout:      t.Write,
+		IdleTimeout:       t.Idle,
+	}
+	if h2c {
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetHTTP2(true)
+		srv.Protocols.SetUnencryptedHTTP2(true)
+	}
+	srv.RegisterOnShutdown(liveSessions.closeAll)
+	return srv
+}
+
+// listenerFromEnv picks where to listen: a socket handed over by systemd
+// socket activation, the Unix socket at UNIX_SOCKET, or TCP on PORT
+// (default 8080). The returned string describes the address for logging.
+func listenerFromEnv() (net.Listener, string, error) {
+	if ln, err := systemdListener(); ln != nil || err != nil {
+		return ln, "systemd socket", err
+	}
+
+	if path := os.Getenv("UNIX_SOCKET"); path != "" {
+		// A socket file left behind by an earlier run would make Listen fail.
+		if fi, err := os.Lstat(path); err == nil && fi.Mode().Type() == fs.ModeSocket {
+			if err := os.Remove(path); err != nil {
+				return nil, "", fmt.Errorf("remove stale socket: %w", err)
+			}
+		}
+		ln, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, "", err
+		}
+		if mode := os.Getenv("UNIX_SOCKET_MODE"); mode != "" {
+			perm, err := strconv.ParseUint(mode, 8, 32)
+			if err != nil {
+				ln.Close()
+				return nil, "", fmt.Errorf("invalid UNIX_SOCKET_MODE %q", mode)
+			}
+			if err := os.Chmod(path, fs.FileMode(perm)); err != nil {
+				ln.Close()
+				return nil, "", err
+			}
+		}
+		return ln, "unix:" + path, nil
+	}
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	ln, err := net.Listen("tcp", ":"+port)
+	return ln, ":" + port, err
+}
+
+// systemdListener returns the first socket passed via LISTEN_FDS, or nil if
+// the process was not socket-activated.
+func systemdListener() (net.Listener, error) {
+	if os.Getenv("LISTEN_PID") != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 1 {
+		return nil, nil
+	}
+	if n > 1 {
+		return nil, errors.New("only one activated socket is supported")
+	}
+
+	// Child processes must not inherit the activation.
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	f := os.NewFile(listenFDsStart, "systemd-socket")
+	defer f.Close()
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("systemd socket: %w", err)
+	}
+	return ln, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"binpacker/pkg/packing"
+)
+
+func TestTimeoutsFromEnv(t *testing.T) {
+	t.Setenv("SERVER_WRITE_TIMEOUT", "5m")
+	t.Setenv("SERVER_IDLE_TIMEOUT", "0")
+
+	got, err := timeoutsFromEnv()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got.Write != 5*time.Minute || got.Idle != 0 || got.ReadHeader != defaultTimeouts.ReadHeader {
+		t.Errorf("Expected overrides on top of the defaults, got %+v", got)
+	}
+
+	t.Setenv("SERVER_READ_TIMEOUT", "soon")
+	if _, err := timeoutsFromEnv(); err == nil {
+		t.Error("Expected an error for an invalid duration")
+	}
+}
+
+func TestListenerFromEnvUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "packer.sock")
+	t.Setenv("UNIX_SOCKET", path)
+
+	// A stale socket from an earlier run is replaced.
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	ln, addr, err := listenerFromEnv()
+	if err != nil {
+		t.Fatalf("Expected to listen, got %v", err)
+	}
+	defer ln.Close()
+	if addr != "unix:"+path {
+		t.Errorf("Expected address unix:%s, got %s", path, addr)
+	}
+
+	srv := newServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}), nil, defaultTimeouts, true)
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://packer/")
+	if err != nil {
+		t.Fatalf("Expected a response over the socket, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTeapot {
+		t.Errorf("Expected status 418, got %d", resp.StatusCode)
+	}
+}
+
+func TestRunPackTimesOut(t *testing.T) {
+	defer setSettings(getSettings())
+	updateSettings(func(s *Settings) { s.PackTimeout = time.Nanosecond })
+
+	req := PackRequest{
+		Items:         []packing.InputItem{{ID: "cube", W: 1, H: 1, D: 1, Quantity: 10}},
+		Boxes:         []packing.InputBox{{ID: "box", W: 10, H: 10, D: 10}},
+		Visualization: VizModeNone,
+	}
+	resp := runPack(context.Background(), req, time.Now())
+	if !resp.TimedOut || len(resp.UnpackedItems) != 10 {
+		t.Errorf("Expected a timed out result with everything unpacked, got %+v", resp)
+	}
+}
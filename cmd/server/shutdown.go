@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// backgroundTasks are the tasks that run as long as the server: sweepers
+// and exporters. They stop when the server shuts down, flushing what they
+// hold.
+type backgroundTasks struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newBackgroundTasks() *backgroundTasks {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &backgroundTasks{ctx: ctx, cancel: cancel}
+}
+
+// Go runs task until the tasks stop.
+func (b *backgroundTasks) Go(task func(context.Context)) {
+	b.wg.Go(func() { task(b.ctx) })
+}
+
+// stop stops the tasks and waits, until ctx ends, for them to return.
+func (b *backgroundTasks) stop(ctx context.Context) error {
+	b.cancel()
+	return waitGroup(ctx, &b.wg)
+}
+
+// waitGroup waits for wg, or until ctx ends.
+func waitGroup(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// shutdown stops the server within timeout: it stops accepting connections
+// and waits for the requests in flight, closes the /pack/live sessions and
+// waits for them, then for the running pack jobs, and then stops the
+// background tasks. Whatever is still running when the time
+// is up is cut off; jobs checkpointed in CHECKPOINT_DIR run again after the
+// restart.
+func shutdown(srv *http.Server, tasks *backgroundTasks, timeout time.Duration) error {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	slog.Info("shutting down", "timeout", timeout.String())
+
+	var errs []error
+	if err := srv.Shutdown(ctx); err != nil {
+		errs = append(errs, err)
+		// Requests still running are cut off.
+		_ = srv.Close()
+	}
+	if err := liveSessions.wait(ctx); err != nil {
+		errs = append(errs, errors.New("live sessions still open"))
+	}
+	if err := jobs.Drain(ctx); err != nil {
+		errs = append(errs, errors.New("pack jobs still running"))
+	}
+	if err := tasks.stop(ctx); err != nil {
+		errs = append(errs, errors.New("background tasks still running"))
+	}
+	err := errors.Join(errs...)
+	if err != nil {
+		slog.Warn("shutdown cut short", "error", err, "elapsed_ms", time.Since(start).Milliseconds())
+	} else {
+		slog.Info("shutdown complete", "elapsed_ms", time.Since(start).Milliseconds())
+	}
+	return err
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"binpacker/pkg/packing"
+	"github.com/gorilla/websocket"
+)
+
+func TestShutdownWaitsForRequests(t *testing.T) {
+	old := jobs
+	jobs = newJobManager("")
+	defer func() { jobs = old }()
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	srv := newServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		_, _ = io.WriteString(w, "packed")
+	}), nil, defaultTimeouts, false)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(ln)
+
+	type result struct {
+		body string
+		err  error
+	}
+	got := make(chan result)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/pack")
+		if err != nil {
+			got <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		got <- result{string(b), err}
+	}()
+	<-entered
+
+	tasks := newBackgroundTasks()
+	flushed := make(chan struct{})
+	tasks.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		close(flushed)
+	})
+	done := make(chan error)
+	go func() { done <- shutdown(srv, tasks, 5*time.Second) }()
+	select {
+	case err := <-done:
+		t.Fatalf("Expected shutdown to wait for the request, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if r := <-got; r.err != nil || r.body != "packed" {
+		t.Errorf("Expected the request in flight to complete, got %q, %v", r.body, r.err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Expected a clean shutdown, got %v", err)
+	}
+	select {
+	case <-flushed:
+	default:
+		t.Error("Expected the background tasks stopped")
+	}
+}
+
+func TestShutdownGivesUpAfterTimeout(t *testing.T) {
+	old := jobs
+	jobs = newJobManager("")
+	defer func() { jobs = old }()
+
+	tasks := newBackgroundTasks()
+	tasks.Go(func(context.Context) { time.Sleep(time.Second) })
+	srv := newServer(http.NotFoundHandler(), nil, defaultTimeouts, false)
+	start := time.Now()
+	if err := shutdown(srv, tasks, 20*time.Millisecond); err == nil || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Expected shutdown cut short after its timeout, got %v after %v", err, time.Since(start))
+	}
+}
+
+func TestDrainStopsJobs(t *testing.T) {
+	m := newJobManager("")
+	m.packWorkers = 1
+	req := PackRequest{
+		Items:         []packing.InputItem{{ID: "cube", W: 10, H: 10, D: 10, Quantity: 4}},
+		Boxes:         []packing.InputBox{{ID: "box", W: 20, H: 20, D: 20}},
+		Visualization: VizModeNone,
+	}
+	search, err := m.StartOptimize(context.Background(), "", OptimizeRequest{PackRequest: req, OptimizeSeconds: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	packed, err := m.StartPack("", req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for job, _ := m.Get(packed.ID); job.Status != JobDone; job, _ = m.Get(packed.ID) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.Drain(ctx); err != nil {
+		t.Fatalf("Expected the jobs to stop, got %v", err)
+	}
+	// A stopped search is left running, to resume after the restart.
+	if job, _ := m.Get(search.ID); job.Status != JobRunning {
+		t.Errorf("Expected the search left running, got %s", job.Status)
+	}
+
+	queued, err := m.StartPack("", req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if job, _ := m.Get(queued.ID); job.Status != JobQueued {
+		t.Errorf("Expected no job started after draining, got %s", job.Status)
+	}
+}
+
+func TestShutdownClosesLiveSessions(t *testing.T) {
+	old := jobs
+	jobs = newJobManager("")
+	defer func() { jobs = old }()
+
+	srv := newServer(http.HandlerFunc(Packer), nil, defaultTimeouts, false)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(ln)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+ln.Addr().String()+"/pack/live", nil)
+	if err != nil {
+		t.Fatalf("Expected to connect, got %v", err)
+	}
+	defer conn.Close()
+	err = conn.WriteJSON(LiveMessage{Type: "init", Boxes: []packing.InputBox{{ID: "box", W: 10, H: 10, D: 10}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var u LiveUpdate
+	if err := conn.ReadJSON(&u); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() { done <- shutdown(srv, newBackgroundTasks(), 5*time.Second) }()
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("Expected the session closed as going away, got %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Expected a clean shutdown, got %v", err)
+	}
+}
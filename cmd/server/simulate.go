@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"binpacker/pkg/packing"
+)
+
+// maxSimulateOrders bounds a simulation; every order is packed once per
+// catalog.
+const maxSimulateOrders = 5000
+
+// SimulateCatalogRequest replays order history against the current and a
+// proposed box catalog. Orders can be uploaded, taken from stored pack
+// results by ID, or both.
+type SimulateCatalogRequest struct {
+	Orders        []Order            `json:"orders,omitempty"`
+	PackIDs       []string           `json:"pack_ids,omitempty"`
+	CurrentBoxes  []packing.InputBox `json:"current_boxes"`
+	ProposedBoxes []packing.InputBox `json:"proposed_boxes"`
+	CurrencyOptions
+	packing.Options
+}
+
+// CatalogStats summarizes packing all simulated orders with one catalog.
+type CatalogStats struct {
+	Boxes         int            `json:"boxes"`
+	BoxUsage      map[string]int `json:"box_usage"`
+	Cost          float64        `json:"cost"`
+	Utilization   float64        `json:"utilization_percent"`
+	UnpackedItems int            `json:"unpacked_items"`
+}
+
+// CatalogDelta is proposed minus current; negative boxes and cost are savings.
+type CatalogDelta struct {
+	Boxes         int     `json:"boxes"`
+	Cost          float64 `json:"cost"`
+	Utilization   float64 `json:"utilization_points"`
+	UnpackedItems int     `json:"unpacked_items"`
+}
+
+// SimulateCatalogResponse compares the two catalogs over the replayed orders.
+type SimulateCatalogResponse struct {
+	Orders   int          `json:"orders"`
+	Currency string       `json:"currency"`
+	Current  CatalogStats `json:"current"`
+	Proposed CatalogStats `json:"proposed"`
+	Delta    CatalogDelta `json:"delta"`
+	// WorseOrders lists orders that need more boxes or leave more items
+	// unpacked with the proposed catalog.
+	WorseOrders []string `json:"worse_orders,omitempty"`
+}
+
+// catalogTally accumulates CatalogStats.
+type catalogTally struct {
+	stats     CatalogStats
+	boxVolume int
+	itemVol   int
+}
+
+func (t *catalogTally) add(packed []packing.PackedBox, unpacked []packing.InputItem, boxes map[string]packing.InputBox) {
+	t.stats.Boxes += len(packed)
+	for _, pb := range packed {
+		b := boxes[pb.BoxID]
+		t.stats.BoxUsage[pb.BoxID]++
+		t.stats.Cost += b.Cost
+		t.boxVolume += b.Volume()
+		for _, p := range pb.Contents {
+			t.itemVol += p.W * p.H * p.D
+		}
+	}
+	t.stats.UnpackedItems += len(unpacked)
+}
+
+func (t *catalogTally) result() CatalogStats {
+	if t.boxVolume > 0 {
+		t.stats.Utilization = float64(t.itemVol) / float64(t.boxVolume) * 100
+	}
+	return t.stats
+}
+
+// simulateCatalog packs every order with both catalogs.
+func simulateCatalog(orders []Order, current, proposed []packing.InputBox, opts packing.Options) SimulateCatalogResponse {
+	curByID, propByID := packing.BoxesByID(current), packing.BoxesByID(proposed)
+	cur := catalogTally{stats: CatalogStats{BoxUsage: map[string]int{}}}
+	prop := catalogTally{stats: CatalogStats{BoxUsage: map[string]int{}}}
+
+	resp := SimulateCatalogResponse{Orders: len(orders)}
+	for _, o := range orders {
+		curPacked, curUnpacked := packing.PackWithOptions(o.Items, current, opts)
+		propPacked, propUnpacked := packing.PackWithOptions(o.Items, proposed, opts)
+		cur.add(curPacked, curUnpacked, curByID)
+		prop.add(propPacked, propUnpacked, propByID)
+
+		if len(propUnpacked) > len(curUnpacked) || len(propPacked) > len(curPacked) {
+			resp.WorseOrders = append(resp.WorseOrders, o.ID)
+		}
+	}
+
+	resp.Current, resp.Proposed = cur.result(), prop.result()
+	resp.Delta = CatalogDelta{
+		Boxes:         resp.Proposed.Boxes - resp.Current.Boxes,
+		Cost:          resp.Proposed.Cost - resp.Current.Cost,
+		Utilization:   resp.Proposed.Utilization - resp.Current.Utilization,
+		UnpackedItems: resp.Proposed.UnpackedItems - resp.Current.UnpackedItems,
+	}
+	return resp
+}
+
+// orderFromPack rebuilds the item list of a stored pack result. Placed items
+// keep the orientation they were packed in, which is fine since items may be
+// rotated freely.
+func orderFromPack(resp PackResponse) Order {
+	o := Order{ID: resp.PackID}
+	for _, pb := range resp.PackedBoxes {
+		for _, p := range pb.Contents {
+			o.Items = append(o.Items, packing.InputItem{ID: p.ItemID, W: p.W, H: p.H, D: p.D, Quantity: 1})
+		}
+	}
+	// Unpacked items are listed once per instance.
+	for _, it := range resp.UnpackedItems {
+		it.Quantity = 1
+		o.Items = append(o.Items, it)
+	}
+	return o
+}
+
+func handleSimulateCatalog(w http.ResponseWriter, r *http.Request) {
+	var req SimulateCatalogRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.CurrentBoxes) == 0 || len(req.ProposedBoxes) == 0 {
+		http.Error(w, "current_boxes and proposed_boxes are required", http.StatusBadRequest)
+		return
+	}
+	if err := req.Options.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rate, currency, err := req.conversion(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	orders := req.Orders
+	for _, id := range req.PackIDs {
+		p, ok := visiblePack(r, id)
+		if !ok {
+			http.Error(w, fmt.Sprintf("Pack %s not found", id), http.StatusNotFound)
+			return
+		}
+		orders = append(orders, orderFromPack(p.Response))
+	}
+
+	if len(orders) == 0 {
+		http.Error(w, "orders or pack_ids are required", http.StatusBadRequest)
+		return
+	}
+	if len(orders) > maxSimulateOrders {
+		http.Error(w, fmt.Sprintf("Too many orders to simulate in one request (max %d)", maxSimulateOrders), http.StatusBadRequest)
+		return
+	}
+	limits := getSettings().Limits
+	if err := validateOrders(orders, limits); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	if err := validateBoxes("current_boxes", req.CurrentBoxes, limits); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	if err := validateBoxes("proposed_boxes", req.ProposedBoxes, limits); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	release, ok := acquirePackSlot(w, r, LaneBatch)
+	if !ok {
+		return
+	}
+	defer release()
+
+	resp := simulateCatalog(orders, req.CurrentBoxes, req.ProposedBoxes, req.Options)
+	resp.Currency = currency
+	resp.Current.Cost *= rate
+	resp.Proposed.Cost *= rate
+	resp.Delta.Cost *= rate
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+
+	"binpacker/pkg/packing"
+)
+
+func TestSimulateCatalogReportsSavings(t *testing.T) {
+	orders := []Order{
+		{ID: "o1", Items: []packing.InputItem{{ID: "book", W: 20, H: 5, D: 30, Quantity: 2}}},
+		{ID: "o2", Items: []packing.InputItem{{ID: "book", W: 20, H: 5, D: 30, Quantity: 1}}},
+	}
+	current := []packing.InputBox{{ID: "large", W: 40, H: 40, D: 40, Cost: 2}}
+	proposed := []packing.InputBox{{ID: "mailer", W: 22, H: 12, D: 32, Cost: 0.5}}
+
+	resp := simulateCatalog(orders, current, proposed, packing.Options{})
+
+	if resp.Current.Boxes != 2 || resp.Proposed.Boxes != 2 {
+		t.Errorf("Expected one box per order with both catalogs, got %d and %d", resp.Current.Boxes, resp.Proposed.Boxes)
+	}
+	if resp.Delta.Cost != -3 {
+		t.Errorf("Expected a cost delta of -3, got %v", resp.Delta.Cost)
+	}
+	if resp.Delta.Utilization <= 0 {
+		t.Errorf("Expected utilization to improve, got delta %v", resp.Delta.Utilization)
+	}
+	if resp.Proposed.BoxUsage["mailer"] != 2 {
+		t.Errorf("Expected the mailer to be used twice, got %v", resp.Proposed.BoxUsage)
+	}
+	if len(resp.WorseOrders) != 0 {
+		t.Errorf("Expected no worse orders, got %v", resp.WorseOrders)
+	}
+}
+
+func TestOrderFromPackExpandsUnpackedItems(t *testing.T) {
+	o := orderFromPack(PackResponse{
+		PackID:        "pk_1",
+		PackedBoxes:   []packing.PackedBox{{BoxID: "b", Contents: []packing.Placement{{ItemID: "a", W: 1, H: 2, D: 3}}}},
+		UnpackedItems: []packing.InputItem{{ID: "big", W: 9, H: 9, D: 9, Quantity: 2}, {ID: "big", W: 9, H: 9, D: 9, Quantity: 2}},
+	})
+
+	total := 0
+	for _, it := range o.Items {
+		total += it.Quantity
+	}
+	if o.ID != "pk_1" || total != 3 {
+		t.Errorf("Expected 3 items for order pk_1, got %d for %s", total, o.ID)
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// maxTopBoxTypes bounds the top_box_types list of a summary.
+const maxTopBoxTypes = 10
+
+// StatsSummary holds operations KPIs over the stored packs in a time range.
+type StatsSummary struct {
+	From             *time.Time     `json:"from,omitempty"`
+	To               *time.Time     `json:"to,omitempty"`
+	Packs            int            `json:"packs"`
+	AvgUtilization   float64        `json:"avg_utilization_percent"`
+	AvgBoxesPerOrder float64        `json:"avg_boxes_per_order"`
+	UnpackedRate     float64        `json:"unpacked_rate_percent"`
+	TopBoxTypes      []BoxTypeCount `json:"top_box_types"`
+	Trend            []StatsBucket  `json:"trend"`
+}
+
+// BoxTypeCount is how often a box type was used.
+type BoxTypeCount struct {
+	BoxID string  `json:"box_id"`
+	Count int     `json:"count"`
+	Share float64 `json:"share_percent"`
+}
+
+// StatsBucket summarizes one day or week of the trend.
+type StatsBucket struct {
+	Period         string  `json:"period"`
+	Packs          int     `json:"packs"`
+	AvgUtilization float64 `json:"avg_utilization_percent"`
+	UnpackedRate   float64 `json:"unpacked_rate_percent"`
+}
+
+// statsTally accumulates the averages shared by the summary and its buckets.
+type statsTally struct {
+	packs, boxes     int
+	items, unpacked  int
+	utilizationTotal float64
+}
+
+func (t *statsTally) add(resp PackResponse) {
+	t.packs++
+	t.boxes += len(resp.PackedBoxes)
+	t.utilizationTotal += resp.Utilization
+	for _, pb := range resp.PackedBoxes {
+		t.items += len(pb.Contents)
+	}
+	t.items += len(resp.UnpackedItems)
+	t.unpacked += len(resp.UnpackedItems)
+}
+
+func (t *statsTally) avgUtilization() float64 {
+	if t.packs == 0 {
+		return 0
+	}
+	return t.utilizationTotal / float64(t.packs)
+}
+
+func (t *statsTally) unpackedRate() float64 {
+	if t.items == 0 {
+		return 0
+	}
+	return float64(t.unpacked) / float64(t.items) * 100
+}
+
+// PacksBetween returns the live packs of owner created in [from, to), oldest
+// first. A zero bound is open.
+func (s *Store) PacksBetween(owner string, from, to time.Time) []storedPack {
+	var out []storedPack
+	s.packsOf(owner, func(_ string, p storedPack) {
+		if p.Owner != owner || !p.DeletedAt.IsZero() {
+			return
+		}
+		if (!from.IsZero() && p.CreatedAt.Before(from)) || (!to.IsZero() && !p.CreatedAt.Before(to)) {
+			return
+		}
+		out = append(out, p)
+	})
+	slices.SortFunc(out, func(a, b storedPack) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return out
+}
+
+// summarize computes the KPIs over packs, bucketing the trend by day or week
+// (starting Monday, UTC).
+func summarize(packs []storedPack, interval string) StatsSummary {
+	var total statsTally
+	usage := make(map[string]int)
+	var buckets []StatsBucket
+	var bucket statsTally
+
+	flush := func(period string) {
+		if bucket.packs > 0 {
+			buckets = append(buckets, StatsBucket{
+				Period:         period,
+				Packs:          bucket.packs,
+				AvgUtilization: bucket.avgUtilization(),
+				UnpackedRate:   bucket.unpackedRate(),
+			})
+		}
+		bucket = statsTally{}
+	}
+
+	period := ""
+	for _, p := range packs {
+		if pp := statsPeriod(p.CreatedAt, interval); pp != period {
+			flush(period)
+			period = pp
+		}
+		total.add(p.Response)
+		bucket.add(p.Response)
+		for _, pb := range p.Response.PackedBoxes {
+			usage[pb.BoxID]++
+		}
+	}
+	flush(period)
+
+	sum := StatsSummary{
+		Packs:          total.packs,
+		AvgUtilization: total.avgUtilization(),
+		UnpackedRate:   total.unpackedRate(),
+		TopBoxTypes:    []BoxTypeCount{},
+		Trend:          buckets,
+	}
+	if sum.Trend == nil {
+		sum.Trend = []StatsBucket{}
+	}
+	if total.packs > 0 {
+		sum.AvgBoxesPerOrder = float64(total.boxes) / float64(total.packs)
+	}
+	for id, n := range usage {
+		sum.TopBoxTypes = append(sum.TopBoxTypes, BoxTypeCount{BoxID: id, Count: n, Share: float64(n) / float64(total.boxes) * 100})
+	}
+	slices.SortFunc(sum.TopBoxTypes, func(a, b BoxTypeCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.BoxID, b.BoxID)
+	})
+	if len(sum.TopBoxTypes) > maxTopBoxTypes {
+		sum.TopBoxTypes = sum.TopBoxTypes[:maxTopBoxTypes]
+	}
+	return sum
+}
+
+func statsPeriod(t time.Time, interval string) string {
+	t = t.UTC()
+	if interval == "week" {
+		t = t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+	}
+	return t.Format("2006-01-02")
+}
+
+// parseStatsTime accepts RFC 3339 timestamps or dates. A date used as the
+// end of the range includes that whole day.
+func parseStatsTime(v string, end bool) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: use RFC 3339 or YYYY-MM-DD", v)
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// handleStatsSummary serves GET /stats/summary?from=&to=&interval=day|week
+// over the caller's stored packs.
+func handleStatsSummary(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from, err := parseStatsTime(q.Get("from"), false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := parseStatsTime(q.Get("to"), true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	interval := q.Get("interval")
+	if interval == "" {
+		interval = "day"
+	}
+	if interval != "day" && interval != "week" {
+		http.Error(w, `interval must be "day" or "week"`, http.StatusBadRequest)
+		return
+	}
+
+	sum := summarize(store.PacksBetween(principalFrom(r.Context()), from, to), interval)
+	if !from.IsZero() {
+		sum.From = &from
+	}
+	if !to.IsZero() {
+		sum.To = &to
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(sum)
+}
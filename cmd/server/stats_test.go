@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"binpacker/pkg/packing"
+)
+
+func TestSummarize(t *testing.T) {
+	day1 := time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	box := func(id string, items int) packing.PackedBox {
+		return packing.PackedBox{BoxID: id, Contents: make([]packing.Placement, items)}
+	}
+	packs := []storedPack{
+		{CreatedAt: day1, Response: PackResponse{Utilization: 80, PackedBoxes: []packing.PackedBox{box("small", 3)}}},
+		{CreatedAt: day1.Add(time.Hour), Response: PackResponse{Utilization: 60, PackedBoxes: []packing.PackedBox{box("small", 2), box("large", 1)}, UnpackedItems: []packing.InputItem{{ID: "x"}}}},
+		{CreatedAt: day2, Response: PackResponse{Utilization: 40, PackedBoxes: []packing.PackedBox{box("large", 1)}}},
+	}
+
+	sum := summarize(packs, "day")
+	if sum.Packs != 3 || sum.AvgUtilization != 60 {
+		t.Errorf("Expected 3 packs at 60%% utilization, got %d at %.1f%%", sum.Packs, sum.AvgUtilization)
+	}
+	if sum.AvgBoxesPerOrder != 4.0/3 {
+		t.Errorf("Expected %.3f boxes per order, got %.3f", 4.0/3, sum.AvgBoxesPerOrder)
+	}
+	if sum.UnpackedRate != 12.5 {
+		t.Errorf("Expected a 12.5%% unpacked rate, got %.2f%%", sum.UnpackedRate)
+	}
+	if len(sum.TopBoxTypes) != 2 || sum.TopBoxTypes[0].BoxID != "large" || sum.TopBoxTypes[0].Share != 50 {
+		t.Errorf("Expected large first (ties sorted by ID), got %+v", sum.TopBoxTypes)
+	}
+	if len(sum.Trend) != 2 || sum.Trend[0].Period != "2026-10-12" || sum.Trend[0].Packs != 2 {
+		t.Errorf("Expected two daily buckets, got %+v", sum.Trend)
+	}
+
+	if weekly := summarize(packs, "week"); len(weekly.Trend) != 1 || weekly.Trend[0].Period != "2026-10-12" {
+		t.Errorf("Expected one bucket for the week of Monday 2026-10-12, got %+v", weekly.Trend)
+	}
+}
+
+func TestPacksBetween(t *testing.T) {
+	s := newStore()
+	s.SavePack("alice", "", PackResponse{PackID: "pk_a"}, nil)
+	s.SavePack("bob", "", PackResponse{PackID: "pk_b"}, nil)
+	s.SavePack("alice", "", PackResponse{PackID: "pk_deleted"}, nil)
+	s.DeletePack("alice", "pk_deleted")
+
+	if got := s.PacksBetween("alice", time.Time{}, time.Time{}); len(got) != 1 || got[0].Response.PackID != "pk_a" {
+		t.Errorf("Expected only alice's live pack, got %d packs", len(got))
+	}
+	if got := s.PacksBetween("alice", time.Now().Add(time.Hour), time.Time{}); len(got) != 0 {
+		t.Errorf("Expected no packs after the range start, got %d", len(got))
+	}
+
+	to, err := parseStatsTime("2026-10-15", true)
+	if err != nil || !to.Equal(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected a date end bound to include the whole day, got %v (%v)", to, err)
+	}
+}
@@ -0,0 +1,413 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	storageTimeout    = 10 * time.Second
+	redisIdleConns    = 4
+	redisScanPageSize = 500
+)
+
+// errNotStored is returned by Storage.Get for a missing key.
+var errNotStored = errors.New("not stored")
+
+// Storage is the key-value backend behind Store. Keys are slash-separated
+// paths such as "packs/<tenant>/pk_...".
+type Storage interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, value []byte) error
+	// Delete removes key; deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+	// Keys lists the keys starting with prefix, in no particular order.
+	Keys(ctx context.Context, prefix string) ([]string, error)
+}
+
+// storageFromEnv selects the backend from STORAGE_URL: empty or "memory" for
+// the process memory, "redis://[:password@]host:port[/db]", or
+// "s3://bucket/prefix" / "gs://bucket/prefix" with STORAGE_* credentials.
+func storageFromEnv() (Storage, error) {
+	raw := os.Getenv("STORAGE_URL")
+	if raw == "" || raw == "memory" {
+		return newMemoryStorage(), nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid STORAGE_URL: %w", err)
+	}
+	switch u.Scheme {
+	case "redis":
+		return newRedisStorage(u)
+	case "s3", "gs":
+		sink, err := s3SinkFromEnv(u.Scheme, u.Host, "STORAGE")
+		if err != nil {
+			return nil, err
+		}
+		return &s3Storage{sink: sink, prefix: strings.Trim(u.Path, "/")}, nil
+	default:
+		return nil, fmt.Errorf("unsupported STORAGE_URL scheme %q: use memory, redis, s3 or gs", u.Scheme)
+	}
+}
+
+// memoryStorage keeps everything in process memory, which is lost on
+// restart.
+type memoryStorage struct {
+	mu sync.RWMutex
+	m  map[string][]byte
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{m: make(map[string][]byte)}
+}
+
+func (s *memoryStorage) Get(_ context.Context, key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.m[key]
+	if !ok {
+		return nil, errNotStored
+	}
+	return v, nil
+}
+
+func (s *memoryStorage) Put(_ context.Context, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m[key] = value
+	return nil
+}
+
+func (s *memoryStorage) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.m, key)
+	return nil
+}
+
+func (s *memoryStorage) Keys(_ context.Context, prefix string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var keys []string
+	for k := range s.m {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+// redisStorage speaks just enough RESP for GET, SET, DEL and SCAN, which
+// saves pulling in a client library. Connections are reused through a
+// small idle pool.
+type redisStorage struct {
+	addr     string
+	password string
+	db       int
+	idle     chan *redisConn
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func newRedisStorage(u *url.URL) (*redisStorage, error) {
+	s := &redisStorage{addr: u.Host, idle: make(chan *redisConn, redisIdleConns)}
+	if s.addr == "" {
+		return nil, errors.New("STORAGE_URL must name a redis host")
+	}
+	if _, _, err := net.SplitHostPort(s.addr); err != nil {
+		s.addr = net.JoinHostPort(s.addr, "6379")
+	}
+	if u.User != nil {
+		s.password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		n, err := strconv.Atoi(db)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid redis database %q", db)
+		}
+		s.db = n
+	}
+	return s, nil
+}
+
+// do runs one command. A connection is only returned to the pool after a
+// complete reply, so a failed call never leaves a half-read reply behind.
+func (s *redisStorage) do(ctx context.Context, args ...string) (any, error) {
+	c, err := s.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = c.SetDeadline(deadline)
+	} else {
+		_ = c.SetDeadline(time.Time{})
+	}
+	reply, err := c.call(args...)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	select {
+	case s.idle <- c:
+	default:
+		c.Close()
+	}
+	if e, ok := reply.(redisError); ok {
+		return nil, e
+	}
+	return reply, nil
+}
+
+func (s *redisStorage) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case c := <-s.idle:
+		return c, nil
+	default:
+	}
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, err
+	}
+	c := &redisConn{Conn: nc, r: bufio.NewReader(nc)}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = c.SetDeadline(deadline)
+	}
+	setup := [][]string{}
+	if s.password != "" {
+		setup = append(setup, []string{"AUTH", s.password})
+	}
+	if s.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(s.db)})
+	}
+	for _, cmd := range setup {
+		reply, err := c.call(cmd...)
+		if err == nil {
+			if e, ok := reply.(redisError); ok {
+				err = e
+			}
+		}
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("redis %s: %w", cmd[0], err)
+		}
+	}
+	return c, nil
+}
+
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+func (c *redisConn) call(args ...string) (any, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := io.WriteString(c, b.String()); err != nil {
+		return nil, err
+	}
+	return readRESP(c.r)
+}
+
+// readRESP reads one reply: a string, redisError, int64, nil for a null
+// bulk string, or []any for an array.
+func readRESP(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return redisError(line[1:]), nil
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = readRESP(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
+
+func (s *redisStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	reply, err := s.do(ctx, "GET", key)
+	if err != nil {
+		return nil, err
+	}
+	v, ok := reply.(string)
+	if !ok {
+		return nil, errNotStored
+	}
+	return []byte(v), nil
+}
+
+func (s *redisStorage) Put(ctx context.Context, key string, value []byte) error {
+	_, err := s.do(ctx, "SET", key, string(value))
+	return err
+}
+
+func (s *redisStorage) Delete(ctx context.Context, key string) error {
+	_, err := s.do(ctx, "DEL", key)
+	return err
+}
+
+func (s *redisStorage) Keys(ctx context.Context, prefix string) ([]string, error) {
+	pattern := strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`).Replace(prefix) + "*"
+	// SCAN may return a key more than once.
+	seen := make(map[string]bool)
+	var keys []string
+	cursor := "0"
+	for {
+		reply, err := s.do(ctx, "SCAN", cursor, "MATCH", pattern, "COUNT", strconv.Itoa(redisScanPageSize))
+		if err != nil {
+			return nil, err
+		}
+		page, ok := reply.([]any)
+		if !ok || len(page) != 2 {
+			return nil, errors.New("redis: malformed SCAN reply")
+		}
+		cursor, _ = page[0].(string)
+		batch, _ := page[1].([]any)
+		for _, k := range batch {
+			if k, ok := k.(string); ok && !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+		if cursor == "0" || cursor == "" {
+			return keys, nil
+		}
+	}
+}
+
+// s3Storage keeps each key as an object below prefix in an S3 or GCS
+// bucket, using the archive's request signing.
+type s3Storage struct {
+	sink   *s3Sink
+	prefix string
+}
+
+func (s *s3Storage) object(key string) string {
+	if s.prefix == "" {
+		return key
+	}
+	return s.prefix + "/" + key
+}
+
+func (s *s3Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.sink.do(ctx, http.MethodGet, s.object(key), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errNotStored
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("get %s: %s", key, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (s *s3Storage) Put(ctx context.Context, key string, value []byte) error {
+	resp, err := s.sink.do(ctx, http.MethodPut, s.object(key), nil, value)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("put %s: %s", key, resp.Status)
+	}
+	return nil
+}
+
+func (s *s3Storage) Delete(ctx context.Context, key string) error {
+	resp, err := s.sink.do(ctx, http.MethodDelete, s.object(key), nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("delete %s: %s", key, resp.Status)
+	}
+	return nil
+}
+
+// Keys pages through ListObjectsV2.
+func (s *s3Storage) Keys(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	q := url.Values{"list-type": {"2"}, "prefix": {s.object(prefix)}}
+	for {
+		resp, err := s.sink.do(ctx, http.MethodGet, "", q, nil)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Contents []struct {
+				Key string `xml:"Key"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		status := resp.Status
+		ok := resp.StatusCode/100 == 2
+		resp.Body.Close()
+		if !ok {
+			return nil, fmt.Errorf("list %s: %s", prefix, status)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %w", prefix, err)
+		}
+		for _, c := range page.Contents {
+			keys = append(keys, strings.TrimPrefix(strings.TrimPrefix(c.Key, s.prefix), "/"))
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return keys, nil
+		}
+		q.Set("continuation-token", page.NextContinuationToken)
+	}
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"binpacker/pkg/packing"
+)
+
+// testStorage runs the Storage contract against s.
+func testStorage(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := s.Get(ctx, "packs/pk_missing"); err != errNotStored {
+		t.Errorf("Expected errNotStored for a missing key, got %v", err)
+	}
+	for _, k := range []string{"packs/pk_a", "packs/pk_b", "visualizations/vz_a"} {
+		if err := s.Put(ctx, k, []byte("value of "+k)); err != nil {
+			t.Fatalf("Put %s: %v", k, err)
+		}
+	}
+	if got, err := s.Get(ctx, "packs/pk_a"); err != nil || string(got) != "value of packs/pk_a" {
+		t.Errorf("Expected the stored value back, got %q (%v)", got, err)
+	}
+
+	keys, err := s.Keys(ctx, "packs/")
+	slices.Sort(keys)
+	if err != nil || !slices.Equal(keys, []string{"packs/pk_a", "packs/pk_b"}) {
+		t.Errorf("Expected the two pack keys, got %v (%v)", keys, err)
+	}
+
+	if err := s.Delete(ctx, "packs/pk_a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := s.Delete(ctx, "packs/pk_a"); err != nil {
+		t.Errorf("Expected deleting a missing key to succeed, got %v", err)
+	}
+	if _, err := s.Get(ctx, "packs/pk_a"); err != errNotStored {
+		t.Errorf("Expected a deleted key to be gone, got %v", err)
+	}
+}
+
+func TestMemoryStorage(t *testing.T) {
+	testStorage(t, newMemoryStorage())
+}
+
+func TestRedisStorage(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go fakeRedis(ln)
+
+	s, err := newRedisStorage(&url.URL{Scheme: "redis", Host: ln.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStorage(t, s)
+}
+
+// fakeRedis serves GET, SET, DEL and a single-page SCAN from a map.
+func fakeRedis(ln net.Listener) {
+	var mu sync.Mutex
+	data := make(map[string]string)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			r := bufio.NewReader(conn)
+			for {
+				reply, err := readRESP(r)
+				if err != nil {
+					return
+				}
+				var args []string
+				for _, a := range reply.([]any) {
+					args = append(args, a.(string))
+				}
+				mu.Lock()
+				var out string
+				switch args[0] {
+				case "GET":
+					if v, ok := data[args[1]]; ok {
+						out = "$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n"
+					} else {
+						out = "$-1\r\n"
+					}
+				case "SET":
+					data[args[1]] = args[2]
+					out = "+OK\r\n"
+				case "DEL":
+					delete(data, args[1])
+					out = ":1\r\n"
+				case "SCAN":
+					prefix := strings.TrimSuffix(args[3], "*")
+					var keys []string
+					for k := range data {
+						if strings.HasPrefix(k, prefix) {
+							keys = append(keys, "$"+strconv.Itoa(len(k))+"\r\n"+k+"\r\n")
+						}
+					}
+					out = "*2\r\n$1\r\n0\r\n*" + strconv.Itoa(len(keys)) + "\r\n" + strings.Join(keys, "")
+				default:
+					out = "-ERR unknown command\r\n"
+				}
+				mu.Unlock()
+				if _, err := io.WriteString(conn, out); err != nil {
+					return
+				}
+			}
+		}()
+	}
+}
+
+func TestS3Storage(t *testing.T) {
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+			http.Error(w, "unsigned", http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		key := strings.TrimPrefix(r.URL.Path, "/results/")
+		switch {
+		case r.URL.Path == "/results" && r.URL.Query().Get("list-type") == "2":
+			var page struct {
+				XMLName  xml.Name `xml:"ListBucketResult"`
+				Contents []struct{ Key string }
+			}
+			for k := range objects {
+				if strings.HasPrefix(k, r.URL.Query().Get("prefix")) {
+					page.Contents = append(page.Contents, struct{ Key string }{k})
+				}
+			}
+			_ = xml.NewEncoder(w).Encode(page)
+		case r.Method == http.MethodPut:
+			objects[key], _ = io.ReadAll(r.Body)
+		case r.Method == http.MethodGet:
+			body, ok := objects[key]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(body)
+		case r.Method == http.MethodDelete:
+			delete(objects, key)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("STORAGE_URL", "s3://results/prod")
+	t.Setenv("STORAGE_ENDPOINT", srv.URL)
+	t.Setenv("STORAGE_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("STORAGE_SECRET_ACCESS_KEY", "secret")
+	s, err := storageFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStorage(t, s)
+	if _, ok := objects["prod/visualizations/vz_a"]; !ok {
+		t.Errorf("Expected objects below the URL prefix, got %v", slices.Collect(maps.Keys(objects)))
+	}
+}
+
+func TestStoreOnBackend(t *testing.T) {
+	s := newStoreOn(newMemoryStorage())
+	s.SaveVisualization("vz_a", "pk_a", "key:a", "<html>")
+	s.SavePack("key:a", "vz_a", PackResponse{PackID: "pk_a", Utilization: 42}, []packing.InputBox{{ID: "box", W: 1, H: 1, D: 1}})
+
+	// A second store on the same backend stands in for a restarted process.
+	restarted := newStoreOn(s.backend)
+	p, ok := restarted.Pack("key:a", "pk_a")
+	if !ok || p.Owner != "key:a" || p.Response.Utilization != 42 || len(p.Boxes) != 1 {
+		t.Errorf("Expected the pack to survive a restart, got %+v", p)
+	}
+	if v, ok := restarted.Visualization("key:a", "vz_a"); !ok || v.HTML != "<html>" {
+		t.Errorf("Expected the visualization to survive a restart, got %+v", v)
+	}
+}
+
+func TestStoreTenantIsolation(t *testing.T) {
+	s := newStoreOn(newMemoryStorage())
+	s.SaveVisualization("vz_a", "pk_a", "key:a", "<html>")
+	s.SavePack("key:a", "vz_a", PackResponse{PackID: "pk_a"}, nil)
+
+	if _, ok := s.backend.(*memoryStorage).m[packKey("key:a", "pk_a")]; !ok {
+		t.Errorf("Expected the pack under its tenant, got keys %v", slices.Collect(maps.Keys(s.backend.(*memoryStorage).m)))
+	}
+	if _, ok := s.Pack("key:b", "pk_a"); ok {
+		t.Error("Expected another tenant not to see the pack")
+	}
+	if _, ok := s.Visualization("key:b", "vz_a"); ok {
+		t.Error("Expected another tenant not to see the visualization")
+	}
+	if s.DeletePack("key:b", "pk_a") {
+		t.Error("Expected another tenant not to delete the pack")
+	}
+	if _, ok := s.Pack("key:a", "pk_a"); !ok {
+		t.Error("Expected the owner to see the pack")
+	}
+
+	// Records stored before tenants are still found by their owner only.
+	s.save(packKeyPrefix+"pk_old", storedPack{Owner: "key:a"})
+	if _, ok := s.Pack("key:a", "pk_old"); !ok {
+		t.Error("Expected the owner to see an unnamespaced pack")
+	}
+	if _, ok := s.Pack("key:b", "pk_old"); ok {
+		t.Error("Expected another tenant not to see an unnamespaced pack")
+	}
+}
+
+func TestEncryptedStorage(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	oldKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32))
+	backend := newMemoryStorage()
+	s, err := newEncryptedStorage(backend, key, oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStorage(t, s)
+
+	ctx := context.Background()
+	if raw, _ := backend.Get(ctx, "packs/pk_b"); bytes.Contains(raw, []byte("value of")) {
+		t.Errorf("Expected the backend to hold ciphertext, got %q", raw)
+	}
+
+	// A record moved to another key no longer opens.
+	raw, _ := backend.Get(ctx, "packs/pk_b")
+	_ = backend.Put(ctx, "packs/pk_c", raw)
+	if _, err := s.Get(ctx, "packs/pk_c"); err == nil {
+		t.Error("Expected a record moved to another key to be rejected")
+	}
+
+	// Records of the old key and from before encryption are still read.
+	old, _ := newEncryptedStorage(backend, oldKey)
+	_ = old.Put(ctx, "packs/pk_old", []byte("old"))
+	_ = backend.Put(ctx, "packs/pk_plain", []byte("plain"))
+	for k, want := range map[string]string{"packs/pk_old": "old", "packs/pk_plain": "plain"} {
+		if got, err := s.Get(ctx, k); err != nil || string(got) != want {
+			t.Errorf("Expected %q under %s, got %q (%v)", want, k, got, err)
+		}
+	}
+
+	if _, err := newEncryptedStorage(backend, "c2hvcnQ="); err == nil {
+		t.Error("Expected a short key to be rejected")
+	}
+}
@@ -0,0 +1,421 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"binpacker/pkg/packing"
+)
+
+// storedPack is a packing result kept for later retrieval. DeletedAt marks a
+// soft delete: the record is hidden at once and purged by the janitor.
+type storedPack struct {
+	Owner           string
+	CreatedAt       time.Time
+	DeletedAt       time.Time
+	VisualizationID string
+	Response        PackResponse
+	// Boxes is the box catalog the result was packed with.
+	Boxes []packing.InputBox
+	// Decisions holds how each packed box was chosen, nil for boxes the
+	// algorithm did not compare; see explain.go.
+	Decisions []*packing.BoxDecision
+}
+
+// storedVisualization is rendered HTML for a pack. Once ExpiresAt has
+// passed, or the visualization is evicted, the HTML is dropped and
+// ExpiredAt set; see vizexpiry.go.
+type storedVisualization struct {
+	PackID    string
+	Owner     string
+	CreatedAt time.Time
+	DeletedAt time.Time
+	ExpiresAt time.Time
+	ExpiredAt time.Time
+	HTML      string
+}
+
+// Key prefixes of the records in Storage. Records are kept under the tenant
+// of their owner, as in "packs/<tenant>/pk_...", so that no lookup by ID can
+// reach another tenant's record. Records stored before tenants sit directly
+// below the prefix and are still found, subject to the same owner check.
+const (
+	packKeyPrefix          = "packs/"
+	visualizationKeyPrefix = "visualizations/"
+)
+
+// publicTenant holds the records of unauthenticated callers.
+const publicTenant = "public"
+
+// tenantOf returns the key segment of owner's records. Principals are hashed
+// so that keys stay path-safe and do not reveal them.
+func tenantOf(owner string) string {
+	if owner == "" {
+		return publicTenant
+	}
+	sum := sha256.Sum256([]byte(owner))
+	return hex.EncodeToString(sum[:8])
+}
+
+func packKey(owner, id string) string {
+	return packKeyPrefix + tenantOf(owner) + "/" + id
+}
+
+func visualizationKey(owner, id string) string {
+	return visualizationKeyPrefix + tenantOf(owner) + "/" + id
+}
+
+// Store keeps pack results and visualizations in a Storage backend as JSON
+// records. Backend errors are logged: a result that cannot be stored is
+// still returned to the caller, and one that cannot be read is not found.
+type Store struct {
+	// mu serializes read-modify-write updates such as soft deletes within
+	// this process.
+	mu      sync.Mutex
+	backend Storage
+
+	vizLimits vizLimits
+	vizLRU    vizLRU
+}
+
+// store is the process-wide result store; main replaces it once the backend
+// is known.
+var store = newStore()
+
+// newStore returns a store kept in process memory.
+func newStore() *Store {
+	return newStoreOn(newMemoryStorage())
+}
+
+func newStoreOn(backend Storage) *Store {
+	return &Store{backend: backend}
+}
+
+// load reads the record under key into v and reports whether it exists.
+func (s *Store) load(key string, v any) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+	defer cancel()
+	data, err := s.backend.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, errNotStored) {
+			slog.Error("storage get failed", "key", key, "error", err)
+		}
+		return false
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		slog.Error("storage decode failed", "key", key, "error", err)
+		return false
+	}
+	return true
+}
+
+func (s *Store) save(key string, v any) {
+	data, err := json.Marshal(v)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+		defer cancel()
+		err = s.backend.Put(ctx, key, data)
+	}
+	if err != nil {
+		slog.Error("storage put failed", "key", key, "error", err)
+	}
+}
+
+func (s *Store) remove(key string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+	defer cancel()
+	if err := s.backend.Delete(ctx, key); err != nil {
+		slog.Error("storage delete failed", "key", key, "error", err)
+		return false
+	}
+	return true
+}
+
+// packs calls fn with the key and record of every stored pack, of all
+// tenants.
+func (s *Store) packs(fn func(key string, p storedPack)) {
+	s.scan(packKeyPrefix, func(key string) {
+		var p storedPack
+		if s.load(key, &p) {
+			fn(key, p)
+		}
+	})
+}
+
+// visualizations calls fn with the key and record of every stored
+// visualization, of all tenants.
+func (s *Store) visualizations(fn func(key string, v storedVisualization)) {
+	s.scan(visualizationKeyPrefix, func(key string) {
+		var v storedVisualization
+		if s.load(key, &v) {
+			fn(key, v)
+		}
+	})
+}
+
+// packsOf calls fn with the key and record of the stored packs that can
+// belong to owner: those of owner's tenant and those stored before tenants.
+// Callers still check the owner of the latter.
+func (s *Store) packsOf(owner string, fn func(key string, p storedPack)) {
+	s.scanTenant(packKeyPrefix, owner, func(key string) {
+		var p storedPack
+		if s.load(key, &p) {
+			fn(key, p)
+		}
+	})
+}
+
+// visualizationsOf is packsOf for visualizations.
+func (s *Store) visualizationsOf(owner string, fn func(key string, v storedVisualization)) {
+	s.scanTenant(visualizationKeyPrefix, owner, func(key string) {
+		var v storedVisualization
+		if s.load(key, &v) {
+			fn(key, v)
+		}
+	})
+}
+
+// scanTenant calls fn with the keys below prefix in owner's tenant and the
+// flat keys directly below prefix, skipping the records of other tenants.
+func (s *Store) scanTenant(prefix, owner string, fn func(key string)) {
+	s.scan(prefix+tenantOf(owner)+"/", fn)
+	s.scan(prefix, func(key string) {
+		if !strings.Contains(strings.TrimPrefix(key, prefix), "/") {
+			fn(key)
+		}
+	})
+}
+
+func (s *Store) scan(prefix string, fn func(key string)) {
+	ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+	keys, err := s.backend.Keys(ctx, prefix)
+	cancel()
+	if err != nil {
+		slog.Error("storage list failed", "prefix", prefix, "error", err)
+		return
+	}
+	for _, k := range keys {
+		fn(k)
+	}
+}
+
+// pack loads the pack id of owner, soft-deleted or not, and returns its key.
+func (s *Store) pack(owner, id string) (string, storedPack, bool) {
+	for _, key := range []string{packKey(owner, id), packKeyPrefix + id} {
+		var p storedPack
+		if s.load(key, &p) && p.Owner == owner {
+			return key, p, true
+		}
+	}
+	return "", storedPack{}, false
+}
+
+// visualization loads the visualization id of owner and returns its key.
+func (s *Store) visualization(owner, id string) (string, storedVisualization, bool) {
+	for _, key := range []string{visualizationKey(owner, id), visualizationKeyPrefix + id} {
+		var v storedVisualization
+		if s.load(key, &v) && v.Owner == owner {
+			return key, v, true
+		}
+	}
+	return "", storedVisualization{}, false
+}
+
+// SavePack stores a result and the boxes it was packed with. The inline
+// visualization fields are dropped since the visualization is stored
+// separately under vizID.
+func (s *Store) SavePack(owner, vizID string, resp PackResponse, boxes []packing.InputBox) {
+	resp.VisualizationHTML = ""
+	resp.VisualizationDataURI = ""
+
+	s.save(packKey(owner, resp.PackID), storedPack{
+		Owner:           owner,
+		CreatedAt:       time.Now().UTC(),
+		VisualizationID: vizID,
+		Response:        resp,
+		Boxes:           boxes,
+		Decisions:       resp.decisions,
+	})
+}
+
+// Pack returns a result stored for owner unless it is missing or
+// soft-deleted.
+func (s *Store) Pack(owner, id string) (storedPack, bool) {
+	_, p, ok := s.pack(owner, id)
+	if !ok || !p.DeletedAt.IsZero() {
+		return storedPack{}, false
+	}
+	return p, true
+}
+
+// SaveVisualization stores rendered visualization HTML for a pack, evicting
+// the least recently viewed visualization beyond the size cap.
+func (s *Store) SaveVisualization(id, packID, owner, html string) {
+	now := time.Now().UTC()
+	v := storedVisualization{
+		PackID:    packID,
+		Owner:     owner,
+		CreatedAt: now,
+		HTML:      html,
+	}
+	if s.vizLimits.TTL > 0 {
+		v.ExpiresAt = now.Add(s.vizLimits.TTL)
+	}
+	key := visualizationKey(owner, id)
+	s.save(key, v)
+
+	for _, evicted := range s.vizLRU.touch(key) {
+		s.expireVisualization(evicted, now)
+	}
+}
+
+// Visualization returns a visualization stored for owner unless it is
+// missing or soft-deleted. An expired one is returned without HTML and with
+// ExpiredAt set.
+func (s *Store) Visualization(owner, id string) (storedVisualization, bool) {
+	key, v, ok := s.visualization(owner, id)
+	if !ok || !v.DeletedAt.IsZero() {
+		return storedVisualization{}, false
+	}
+	if v.ExpiredAt.IsZero() && !v.ExpiresAt.IsZero() && !time.Now().Before(v.ExpiresAt) {
+		// Not swept yet.
+		v.ExpiredAt, v.HTML = v.ExpiresAt, ""
+	}
+	if v.ExpiredAt.IsZero() {
+		s.vizLRU.touch(key)
+	}
+	return v, true
+}
+
+// DeletePack soft-deletes a pack of owner and its visualization.
+func (s *Store) DeletePack(owner, id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, p, ok := s.pack(owner, id)
+	if !ok || !p.DeletedAt.IsZero() {
+		return false
+	}
+	now := time.Now().UTC()
+	p.DeletedAt = now
+	s.save(key, p)
+
+	if p.VisualizationID == "" {
+		return true
+	}
+	if key, v, ok := s.visualization(owner, p.VisualizationID); ok && v.DeletedAt.IsZero() {
+		v.DeletedAt = now
+		s.save(key, v)
+	}
+	return true
+}
+
+// visiblePack looks up a pack for the calling principal. Packs stored by an
+// authenticated caller are only visible to that caller.
+func visiblePack(r *http.Request, id string) (storedPack, bool) {
+	if _, err := parseID(id, IDPrefixPack); err != nil {
+		return storedPack{}, false
+	}
+	return store.Pack(principalFrom(r.Context()), id)
+}
+
+func handlePackResource(w http.ResponseWriter, r *http.Request) {
+	id, sub, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/packs/"), "/")
+	p, ok := visiblePack(r, id)
+	box, isExplanation := explanationBox(sub)
+	if !ok || (sub != "" && sub != "export" && sub != "labels" && !isExplanation) {
+		http.Error(w, "Pack not found", http.StatusNotFound)
+		return
+	}
+	switch {
+	case sub == "export":
+		handlePackExport(w, r, p)
+		return
+	case sub == "labels":
+		handlePackLabels(w, r, p)
+		return
+	case isExplanation:
+		handleBoxExplanation(w, r, p, box)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(p.Response.inFrame(p.Boxes))
+	case http.MethodDelete:
+		store.DeletePack(principalFrom(r.Context()), id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleResult serves a stored result as JSON by its pack ID or by the
+// visualization ID from visualization_url, which is the ID some clients
+// keep. It outlives the visualization HTML.
+func handleResult(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/result/")
+	if _, err := parseID(id, IDPrefixVisualization); err == nil {
+		viz, ok := store.Visualization(principalFrom(r.Context()), id)
+		if !ok {
+			http.Error(w, "Result not found", http.StatusNotFound)
+			return
+		}
+		id = viz.PackID
+	}
+
+	p, ok := visiblePack(r, id)
+	if !ok {
+		http.Error(w, "Result not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(p.Response.inFrame(p.Boxes))
+}
+
+func handleVisualization(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/visualize/")
+	if _, err := parseID(id, IDPrefixVisualization); err != nil {
+		http.Error(w, "Visualization not found", http.StatusNotFound)
+		return
+	}
+
+	owner := principalFrom(r.Context())
+	viz, ok := store.Visualization(owner, id)
+	if !ok {
+		http.Error(w, "Visualization not found", http.StatusNotFound)
+		return
+	}
+
+	html := viz.HTML
+	table := r.URL.Query().Get("view") == "table"
+	if !viz.ExpiredAt.IsZero() && !table {
+		// The table view is rendered from the pack, which outlives the 3D view.
+		http.Error(w, "Visualization expired; the table view and GET /packs/"+viz.PackID+" are still available", http.StatusGone)
+		return
+	}
+	if table {
+		p, ok := store.Pack(owner, viz.PackID)
+		if !ok {
+			http.Error(w, "Visualization not found", http.StatusNotFound)
+			return
+		}
+		var err error
+		if html, err = GenerateTableHTML(p); err != nil {
+			http.Error(w, "Failed to render table view", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(html))
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"binpacker/pkg/packing"
+)
+
+const (
+	// streamBatchUnits is how many units a stream collects before packing
+	// them, which bounds the memory of a stream however long it runs.
+	streamBatchUnits = 1000
+	// streamIdleTimeout is how long a stream may go without a record in
+	// either direction; it replaces the server timeouts, which would
+	// otherwise end every large stream.
+	streamIdleTimeout = time.Minute
+)
+
+// StreamRecord is one line of a /pack/stream response:
+//
+//	{"type": "box", "box": {...}}
+//	{"type": "unpacked", "item": {...}}
+//	{"type": "summary", "summary": {...}}
+//	{"type": "error", "error": "..."}
+//
+// A stream that completes ends with the summary; one that fails ends with
+// the error.
+type StreamRecord struct {
+	Type    string             `json:"type"`
+	Box     *packing.PackedBox `json:"box,omitempty"`
+	Item    *packing.InputItem `json:"item,omitempty"`
+	Summary *StreamSummary     `json:"summary,omitempty"`
+	Error   string             `json:"error,omitempty"`
+}
+
+// StreamSummary totals a /pack/stream response.
+type StreamSummary struct {
+	Boxes    int               `json:"boxes"`
+	Units    int               `json:"units"`
+	Unpacked int               `json:"unpacked"`
+	Warnings []packing.Warning `json:"warnings,omitempty"`
+}
+
+// packStream packs the items of a /pack/stream request in batches, writing
+// each box back once no later item can go into it.
+type packStream struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	enc     *json.Encoder
+	header  PackRequest
+	started bool
+	summary StreamSummary
+}
+
+// checkStreamRequest rejects options that need the whole request at once,
+// which a stream never has.
+func checkStreamRequest(req PackRequest) error {
+	switch {
+	case req.Units != "":
+		return errors.New("a stream takes lengths as whole numbers; units are not supported")
+	case req.MaxBoxes > 0:
+		return errors.New("max_boxes is not supported on a stream")
+	case req.KeepGroupsTogether:
+		return errors.New("keep_groups_together is not supported on a stream")
+	case req.Optimize:
+		return errors.New("optimize is not supported on a stream")
+	case req.ExpectedBoxes > 0:
+		return errors.New("expected_boxes is not supported on a stream")
+	}
+	for i, b := range req.Boxes {
+		if b.Quantity > 0 {
+			return fmt.Errorf("boxes[%d]: box stock is not supported on a stream", i)
+		}
+	}
+	return nil
+}
+
+// handlePackStream serves POST /pack/stream. The body is NDJSON: a pack
+// request without items, then one item per line.
+func handlePackStream(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	// Boxes are written back while items are still being read.
+	_ = rc.EnableFullDuplex()
+	_ = rc.SetReadDeadline(time.Now().Add(streamIdleTimeout))
+
+	dec := json.NewDecoder(r.Body)
+	s := &packStream{w: w, rc: rc, enc: json.NewEncoder(w)}
+	if err := dec.Decode(&s.header); err != nil {
+		http.Error(w, "Invalid JSON in the first record, the request", http.StatusBadRequest)
+		return
+	}
+	if err := checkStreamRequest(s.header); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// fresh counts the units read since the last batch; the contents of the
+	// box carried over do not make a batch.
+	pending := s.header.Items
+	fresh := streamUnits(pending)
+	s.header.Items = nil
+	for n := 1; ; n++ {
+		var it packing.InputItem
+		err := dec.Decode(&it)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			s.fail(fmt.Errorf("item record %d: %w", n, err))
+			return
+		}
+		_ = rc.SetReadDeadline(time.Now().Add(streamIdleTimeout))
+		pending = append(pending, it)
+		if fresh += max(it.Quantity, 0); fresh >= streamBatchUnits {
+			if pending, err = s.pack(r.Context(), pending, false); err != nil {
+				s.fail(err)
+				return
+			}
+			fresh = 0
+		}
+	}
+	if len(pending) > 0 {
+		if _, err := s.pack(r.Context(), pending, true); err != nil {
+			s.fail(err)
+			return
+		}
+	}
+	logAttrs(r.Context(), slog.Int("boxes", s.summary.Boxes), slog.Int("units", s.summary.Units), slog.Int("unpacked", s.summary.Unpacked))
+	s.write(StreamRecord{Type: "summary", Summary: &s.summary})
+}
+
+func streamUnits(items []packing.InputItem) int {
+	n := 0
+	for _, it := range items {
+		n += max(it.Quantity, 0)
+	}
+	return n
+}
+
+// pack packs a batch and writes its boxes. Unless the batch is the last,
+// the last box may still take items to come, so its contents are returned
+// to be packed with the next batch.
+func (s *packStream) pack(ctx context.Context, items []packing.InputItem, final bool) ([]packing.InputItem, error) {
+	req := s.header
+	req.Items = items
+	// Validation may rewrite the boxes, which the next batch needs as sent.
+	req.Boxes = slices.Clone(s.header.Boxes)
+	if err := validatePackRequest(ctx, &req); err != nil {
+		return nil, err
+	}
+	if err := checkStreamRequest(req); err != nil {
+		return nil, err
+	}
+
+	release, err := lanePool(req.Lane).acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	guard, _ := newDegenerateGuard(req)
+	guarded, warnings, _ := guard.apply(req.Items)
+	guarded, _ = packing.SplitMultipacks(guarded, req.Boxes)
+	packCtx, cancel := ctx, context.CancelFunc(func() {})
+	if packTimeout := getSettings().packTimeout(req.Lane); packTimeout > 0 {
+		packCtx, cancel = context.WithTimeout(ctx, packTimeout)
+	}
+	packed, unpacked, err := packing.PackContext(packCtx, guarded, req.Boxes, req.Options)
+	cancel()
+	release()
+	if err != nil {
+		return nil, errors.New("packing a batch ran out of time; offer fewer box types")
+	}
+	s.summary.Warnings = append(s.summary.Warnings, req.merged...)
+	s.summary.Warnings = append(s.summary.Warnings, warnings...)
+
+	var carry []packing.InputItem
+	if !final && len(packed) > 0 {
+		carry = carryOver(packed[len(packed)-1], req.Items, guarded)
+		packed = packed[:len(packed)-1]
+	}
+	packing.Measure(packed, req.Boxes)
+	for i := range packed {
+		s.summary.Boxes++
+		s.summary.Units += len(packed[i].Contents)
+		s.write(StreamRecord{Type: "box", Box: &packed[i]})
+	}
+	for i := range unpacked {
+		s.summary.Unpacked++
+		s.write(StreamRecord{Type: "unpacked", Item: &unpacked[i]})
+	}
+	return carry, nil
+}
+
+// carryOver returns the items placed in pb as entries to pack again. They
+// are taken from items as sent where possible, so a clamped or split item
+// is not guarded twice.
+func carryOver(pb packing.PackedBox, items, guarded []packing.InputItem) []packing.InputItem {
+	var carry []packing.InputItem
+	index := map[string]int{}
+	for _, p := range pb.Contents {
+		if i, ok := index[p.ItemID]; ok {
+			carry[i].Quantity++
+			continue
+		}
+		i := slices.IndexFunc(items, func(it packing.InputItem) bool { return it.ID == p.ItemID })
+		it := packing.InputItem{}
+		if i >= 0 {
+			it = items[i]
+		} else if i = slices.IndexFunc(guarded, func(it packing.InputItem) bool { return it.ID == p.ItemID }); i >= 0 {
+			it = guarded[i]
+		}
+		it.Quantity = 1
+		index[p.ItemID] = len(carry)
+		carry = append(carry, it)
+	}
+	return carry
+}
+
+func (s *packStream) write(rec StreamRecord) {
+	if !s.started {
+		s.w.Header().Set("Content-Type", "application/x-ndjson")
+		s.started = true
+	}
+	_ = s.rc.SetWriteDeadline(time.Now().Add(streamIdleTimeout))
+	_ = s.enc.Encode(rec)
+	_ = s.rc.Flush()
+}
+
+// fail ends the stream with err: as an error record once records have been
+// written, or as a usual error response before.
+func (s *packStream) fail(err error) {
+	if !s.started {
+		if errors.Is(err, errPackPoolBusy) {
+			s.w.Header().Set("Retry-After", "10")
+			http.Error(s.w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		writeRequestError(s.w, err)
+		return
+	}
+	s.write(StreamRecord{Type: "error", Error: err.Error()})
+}
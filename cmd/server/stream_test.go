@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func streamRecords(t *testing.T, body string) (*httptest.ResponseRecorder, []StreamRecord) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodPost, "/pack/stream", strings.NewReader(body)))
+	var records []StreamRecord
+	if rec.Header().Get("Content-Type") != "application/x-ndjson" {
+		return rec, nil
+	}
+	sc := bufio.NewScanner(rec.Body)
+	for sc.Scan() {
+		var r StreamRecord
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			t.Fatalf("Expected NDJSON, got %q: %v", sc.Text(), err)
+		}
+		records = append(records, r)
+	}
+	return rec, records
+}
+
+func TestPackStream(t *testing.T) {
+	var body strings.Builder
+	body.WriteString(`{"boxes": [{"id": "box", "w": 10, "h": 10, "d": 10}]}` + "\n")
+	// 1500 cubes fill 12 boxes of 125 over two batches, and the box left
+	// open by the first batch is finished by the second.
+	for i := range 1500 {
+		fmt.Fprintf(&body, `{"id": "cube-%d", "w": 2, "h": 2, "d": 2, "quantity": 1}`+"\n", i%3)
+	}
+	body.WriteString(`{"id": "huge", "w": 20, "h": 20, "d": 20, "quantity": 1}` + "\n")
+
+	rec, records := streamRecords(t, body.String())
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("Expected an NDJSON stream, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	boxes, units, unpacked := 0, 0, 0
+	for _, r := range records {
+		switch r.Type {
+		case "box":
+			boxes++
+			units += len(r.Box.Contents)
+		case "unpacked":
+			unpacked++
+		}
+	}
+	if boxes != 12 || units != 1500 || unpacked != 1 {
+		t.Errorf("Expected 1500 units in 12 boxes and 1 unpacked, got %d in %d and %d", units, boxes, unpacked)
+	}
+	last := records[len(records)-1]
+	if last.Type != "summary" || last.Summary.Boxes != 12 || last.Summary.Units != 1500 {
+		t.Errorf("Expected a closing summary, got %+v", last)
+	}
+}
+
+func TestPackStreamErrors(t *testing.T) {
+	rec, _ := streamRecords(t, `{"units": "cm", "boxes": [{"id": "box", "w": 10, "h": 10, "d": 10}]}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for units, got %d", rec.Code)
+	}
+
+	rec, _ = streamRecords(t, `{"boxes": [{"id": "box", "w": 10, "h": 10, "d": 10}]}`+"\n"+`{"id": "mug", "w": 0, "h": 5, "d": 5, "quantity": 1}`)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for an invalid item before any box, got %d", rec.Code)
+	}
+
+	body := `{"boxes": [{"id": "box", "w": 10, "h": 10, "d": 10}]}` + "\n" +
+		`{"id": "cube", "w": 2, "h": 2, "d": 2, "quantity": 1000}` + "\n" +
+		`{"id": "bad"`
+	rec, records := streamRecords(t, body)
+	if rec.Code != http.StatusOK || len(records) == 0 || records[len(records)-1].Type != "error" {
+		t.Errorf("Expected boxes and then an error record, got %d %+v", rec.Code, records)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+
+	"binpacker/pkg/packing"
+)
+
+// tableBox is one box in the accessible table view.
+type tableBox struct {
+	Index       int
+	BoxID       string
+	W, H, D     int
+	Utilization float64
+	Contents    []packing.Placement
+}
+
+type tableData struct {
+	PackID        string
+	Boxes         []tableBox
+	UnpackedItems []packing.InputItem
+	Utilization   float64
+}
+
+// GenerateTableHTML renders a stored pack as plain HTML tables, for screen
+// readers, keyboard users and clients without WebGL.
+func GenerateTableHTML(p storedPack) (string, error) {
+	t, err := template.New("table").Funcs(template.FuncMap{
+		"add":    func(a, b int) int { return a + b },
+		"length": p.Response.lengthText,
+	}).Parse(tableTemplate)
+	if err != nil {
+		return "", fmt.Errorf("parse template: %w", err)
+	}
+
+	byID := packing.BoxesByID(p.Boxes)
+	data := tableData{
+		PackID:        p.Response.PackID,
+		UnpackedItems: p.Response.UnpackedItems,
+		Utilization:   p.Response.Utilization,
+	}
+	for i, pb := range p.Response.PackedBoxes {
+		b := byID[pb.BoxID]
+		tb := tableBox{Index: i + 1, BoxID: pb.BoxID, W: b.W, H: b.H, D: b.D, Contents: pb.Contents}
+		if vol := b.Volume(); vol > 0 {
+			used := 0
+			for _, c := range pb.Contents {
+				used += c.W * c.H * c.D
+			}
+			tb.Utilization = float64(used) / float64(vol) * 100
+		}
+		data.Boxes = append(data.Boxes, tb)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("execute template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+const tableTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Packing Result {{.PackID}} - Table View</title>
+    <style>
+        body {
+            font-family: system-ui, sans-serif;
+            margin: 0 auto;
+            max-width: 960px;
+            padding: 24px;
+            line-height: 1.5;
+            color: #111827;
+            background: #ffffff;
+        }
+        .skip-link { position: absolute; left: -9999px; }
+        .skip-link:focus { position: static; }
+        table { border-collapse: collapse; width: 100%; margin: 12px 0 32px; }
+        caption { text-align: left; font-weight: 600; padding-bottom: 6px; }
+        th, td { border: 1px solid #6b7280; padding: 6px 10px; text-align: right; }
+        th:first-child, td:first-child, th:nth-child(2), td:nth-child(2) { text-align: left; }
+        thead th { background: #e5e7eb; }
+        .scroll { overflow-x: auto; }
+        .scroll:focus, a:focus { outline: 3px solid #2563eb; outline-offset: 2px; }
+        dl { display: grid; grid-template-columns: max-content auto; gap: 4px 16px; }
+        dt { font-weight: 600; }
+    </style>
+</head>
+<body>
+    <a class="skip-link" href="#boxes">Skip to box contents</a>
+    <main>
+        <h1>Packing result {{.PackID}}</h1>
+        <section aria-labelledby="summary-heading">
+            <h2 id="summary-heading">Summary</h2>
+            <dl>
+                <dt>Boxes used</dt><dd>{{len .Boxes}}</dd>
+                <dt>Items not packed</dt><dd>{{len .UnpackedItems}}</dd>
+                <dt>Utilization</dt><dd>{{printf "%.1f" .Utilization}}%</dd>
+            </dl>
+            <p>Positions are measured from the back-left-bottom corner of each box: x runs along the width, y is the height above the floor, z runs along the depth.</p>
+        </section>
+
+        <section id="boxes" aria-labelledby="boxes-heading">
+            <h2 id="boxes-heading">Box contents</h2>
+            {{- range .Boxes}}
+            <h3 id="box-{{.Index}}">Box {{.Index}}: {{.BoxID}} ({{length .W}} × {{length .H}} × {{length .D}})</h3>
+            <p>{{len .Contents}} items, {{printf "%.1f" .Utilization}}% of the box volume used.</p>
+            <div class="scroll" role="region" tabindex="0" aria-labelledby="box-{{.Index}}">
+                <table>
+                    <caption>Item placements in box {{.Index}}, in packing order</caption>
+                    <thead>
+                        <tr>
+                            <th scope="col">#</th>
+                            <th scope="col">Item</th>
+                            <th scope="col"><abbr title="Position along the width">X</abbr></th>
+                            <th scope="col"><abbr title="Height above the floor">Y</abbr></th>
+                            <th scope="col"><abbr title="Position along the depth">Z</abbr></th>
+                            <th scope="col"><abbr title="Width">W</abbr></th>
+                            <th scope="col"><abbr title="Height">H</abbr></th>
+                            <th scope="col"><abbr title="Depth">D</abbr></th>
+                        </tr>
+                    </thead>
+                    <tbody>
+                    {{- range $i, $p := .Contents}}
+                        <tr>
+                            <td>{{add $i 1}}</td>
+                            <th scope="row">{{$p.ItemID}}</th>
+                            <td>{{length $p.X}}</td><td>{{length $p.Y}}</td><td>{{length $p.Z}}</td>
+                            <td>{{length $p.W}}</td><td>{{length $p.H}}</td><td>{{length $p.D}}</td>
+                        </tr>
+                    {{- end}}
+                    </tbody>
+                </table>
+            </div>
+            {{- end}}
+        </section>
+
+        {{- if .UnpackedItems}}
+        <section aria-labelledby="unpacked-heading">
+            <h2 id="unpacked-heading">Items not packed</h2>
+            <ul>
+            {{- range .UnpackedItems}}
+                <li>{{.ID}} ({{length .W}} × {{length .H}} × {{length .D}})</li>
+            {{- end}}
+            </ul>
+        </section>
+        {{- end}}
+    </main>
+</body>
+</html>`
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"binpacker/pkg/packing"
+)
+
+func TestVisualizationTableView(t *testing.T) {
+	packID, vizID := newID(IDPrefixPack), newID(IDPrefixVisualization)
+	resp := PackResponse{
+		PackID:        packID,
+		PackedBoxes:   []packing.PackedBox{{BoxID: "small", Contents: []packing.Placement{{ItemID: "mug", X: 0, Y: 0, Z: 0, W: 5, H: 5, D: 5}}}},
+		UnpackedItems: []packing.InputItem{{ID: "lamp", W: 50, H: 50, D: 50, Quantity: 1}},
+	}
+	store.SaveVisualization(vizID, packID, "", "<html>3d</html>")
+	store.SavePack("", vizID, resp, []packing.InputBox{{ID: "small", W: 10, H: 10, D: 10}})
+
+	rec := httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodGet, "/visualize/"+vizID+"?view=table", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{`<th scope="row">mug</th>`, "12.5% of the box volume", "<li>lamp"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected table view to contain %q", want)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodGet, "/visualize/"+vizID, nil))
+	if rec.Body.String() != "<html>3d</html>" {
+		t.Errorf("Expected the 3D view by default, got %q", rec.Body)
+	}
+}
+
+func TestExpiredVisualizationIsGone(t *testing.T) {
+	packID, vizID := newID(IDPrefixPack), newID(IDPrefixVisualization)
+	store.SaveVisualization(vizID, packID, "", "<html>3d</html>")
+	store.SavePack("", vizID, PackResponse{PackID: packID}, []packing.InputBox{{ID: "small", W: 10, H: 10, D: 10}})
+	store.expireVisualization(visualizationKey("", vizID), time.Now())
+
+	rec := httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodGet, "/visualize/"+vizID, nil))
+	if rec.Code != http.StatusGone {
+		t.Errorf("Expected 410, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodGet, "/visualize/"+vizID+"?view=table", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the table view to outlive the 3D view, got %d", rec.Code)
+	}
+}
+
+func TestResultByVisualizationID(t *testing.T) {
+	packID, vizID := newID(IDPrefixPack), newID(IDPrefixVisualization)
+	store.SaveVisualization(vizID, packID, "", "<html>3d</html>")
+	store.SavePack("", vizID, PackResponse{PackID: packID, Utilization: 12.5}, []packing.InputBox{{ID: "small", W: 10, H: 10, D: 10}})
+
+	for _, id := range []string{vizID, packID} {
+		rec := httptest.NewRecorder()
+		Packer(rec, httptest.NewRequest(http.MethodGet, "/result/"+id, nil))
+		var got PackResponse
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil || got.PackID != packID || got.Utilization != 12.5 {
+			t.Errorf("/result/%s: expected the stored response, got %d %+v (%v)", id, rec.Code, got, err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodGet, "/result/"+newID(IDPrefixVisualization), nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown ID, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	telemetryQueueSize  = 1024
+	telemetryBatchSize  = 500
+	telemetryFlushEvery = 10 * time.Second
+	telemetryTimeout    = 30 * time.Second
+
+	// gceTokenURL serves access tokens for the service account on Cloud Run
+	// and GCE.
+	gceTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+var (
+	telemetryExported = newCounter("telemetry_rows_exported_total", "Pack telemetry rows written to the warehouse.")
+	telemetryErrors   = newCounter("telemetry_errors_total", "Telemetry batches that failed to export.")
+	telemetryDropped  = newCounter("telemetry_dropped_total", "Telemetry rows dropped because the export queue was full.")
+)
+
+// telemetry is the process-wide pack telemetry exporter; nil when disabled.
+var telemetry *TelemetryExporter
+
+// TelemetryRow is one pack's metrics as written to the warehouse table.
+type TelemetryRow struct {
+	Timestamp     time.Time `json:"timestamp"`
+	PackID        string    `json:"pack_id"`
+	Principal     string    `json:"principal"`
+	Region        string    `json:"region"`
+	Boxes         int       `json:"boxes"`
+	BoxIDs        []string  `json:"box_ids"`
+	PackedItems   int       `json:"packed_items"`
+	UnpackedItems int       `json:"unpacked_items"`
+	TotalVolume   int       `json:"total_volume"`
+	Utilization   float64   `json:"utilization_percent"`
+	DurationMS    float64   `json:"duration_ms"`
+}
+
+func newTelemetryRow(ctx context.Context, resp PackResponse, took time.Duration) TelemetryRow {
+	row := TelemetryRow{
+		Timestamp:     time.Now().UTC(),
+		PackID:        resp.PackID,
+		Principal:     principalFrom(ctx),
+		Region:        idRegion(),
+		Boxes:         len(resp.PackedBoxes),
+		BoxIDs:        make([]string, len(resp.PackedBoxes)),
+		UnpackedItems: len(resp.UnpackedItems),
+		TotalVolume:   resp.TotalVolume,
+		Utilization:   resp.Utilization,
+		DurationMS:    float64(took.Microseconds()) / 1000,
+	}
+	for i, pb := range resp.PackedBoxes {
+		row.BoxIDs[i] = pb.BoxID
+		row.PackedItems += len(pb.Contents)
+	}
+	return row
+}
+
+// rowWriter inserts a batch of rows into a warehouse table.
+type rowWriter interface {
+	WriteRows(ctx context.Context, rows []TelemetryRow) error
+}
+
+// TelemetryExporter batches pack telemetry off the request path. Rows are
+// dropped, not blocked on, when the warehouse falls behind.
+type TelemetryExporter struct {
+	writer rowWriter
+	rows   chan TelemetryRow
+}
+
+func newTelemetryExporter(w rowWriter) *TelemetryExporter {
+	return &TelemetryExporter{writer: w, rows: make(chan TelemetryRow, telemetryQueueSize)}
+}
+
+// telemetryFromEnv configures the exporter from TELEMETRY_SINK
+// ("clickhouse" or "bigquery"). It returns nil when TELEMETRY_SINK is unset.
+func telemetryFromEnv() (*TelemetryExporter, error) {
+	client := &http.Client{Timeout: telemetryTimeout}
+	switch sink := os.Getenv("TELEMETRY_SINK"); sink {
+	case "":
+		return nil, nil
+	case "clickhouse":
+		u := os.Getenv("CLICKHOUSE_URL")
+		if u == "" {
+			return nil, errors.New("CLICKHOUSE_URL is required for the clickhouse sink")
+		}
+		table := os.Getenv("CLICKHOUSE_TABLE")
+		if table == "" {
+			table = "pack_telemetry"
+		}
+		return newTelemetryExporter(&clickHouseWriter{
+			client:   client,
+			url:      strings.TrimRight(u, "/"),
+			table:    table,
+			user:     os.Getenv("CLICKHOUSE_USER"),
+			password: os.Getenv("CLICKHOUSE_PASSWORD"),
+		}), nil
+	case "bigquery":
+		parts := strings.Split(os.Getenv("BIGQUERY_TABLE"), ".")
+		if len(parts) != 3 || slices.Contains(parts, "") {
+			return nil, errors.New("BIGQUERY_TABLE must be project.dataset.table")
+		}
+		w := &bigQueryWriter{
+			client:   client,
+			endpoint: "https://bigquery.googleapis.com",
+			project:  parts[0],
+			dataset:  parts[1],
+			table:    parts[2],
+			tokens:   &gceTokenSource{client: client, url: gceTokenURL, static: os.Getenv("BIGQUERY_ACCESS_TOKEN")},
+		}
+		return newTelemetryExporter(w), nil
+	default:
+		return nil, fmt.Errorf("unknown TELEMETRY_SINK %q", sink)
+	}
+}
+
+// Record queues a row for export.
+func (e *TelemetryExporter) Record(row TelemetryRow) {
+	if e == nil {
+		return
+	}
+	select {
+	case e.rows <- row:
+	default:
+		telemetryDropped.Add(1)
+	}
+}
+
+// Run writes queued rows in batches of up to telemetryBatchSize, at least
+// every telemetryFlushEvery, until ctx is cancelled.
+func (e *TelemetryExporter) Run(ctx context.Context, flushEvery time.Duration) {
+	ticker := time.NewTicker(flushEvery)
+	defer ticker.Stop()
+
+	var batch []TelemetryRow
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		// Use a fresh context so the final flush on shutdown still runs.
+		wctx, cancel := context.WithTimeout(context.Background(), telemetryTimeout)
+		defer cancel()
+		if err := e.writer.WriteRows(wctx, batch); err != nil {
+			telemetryErrors.Add(1)
+			slog.Error("telemetry export failed", "rows", len(batch), "error", err)
+		} else {
+			telemetryExported.Add(int64(len(batch)))
+		}
+		batch = nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			flush()
+			return
+		case row := <-e.rows:
+			batch = append(batch, row)
+			if len(batch) >= telemetryBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// clickHouseWriter inserts rows through the ClickHouse HTTP interface as
+// JSONEachRow.
+type clickHouseWriter struct {
+	client         *http.Client
+	url            string
+	table          string
+	user, password string
+}
+
+func (c *clickHouseWriter) WriteRows(ctx context.Context, rows []TelemetryRow) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, r := range rows {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+
+	q := url.Values{}
+	q.Set("query", "INSERT INTO "+c.table+" FORMAT JSONEachRow")
+	q.Set("date_time_input_format", "best_effort")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/?"+q.Encode(), &body)
+	if err != nil {
+		return err
+	}
+	if c.user != "" {
+		req.Header.Set("X-ClickHouse-User", c.user)
+		req.Header.Set("X-ClickHouse-Key", c.password)
+	}
+	return doTelemetryRequest(c.client, req)
+}
+
+// bigQueryWriter streams rows with the BigQuery insertAll API. Pack IDs are
+// used as insert IDs so retried batches are deduplicated.
+type bigQueryWriter struct {
+	client                  *http.Client
+	endpoint                string
+	project, dataset, table string
+	tokens                  *gceTokenSource
+}
+
+func (b *bigQueryWriter) WriteRows(ctx context.Context, rows []TelemetryRow) error {
+	type insertRow struct {
+		InsertID string       `json:"insertId"`
+		JSON     TelemetryRow `json:"json"`
+	}
+	payload := struct {
+		Rows []insertRow `json:"rows"`
+	}{}
+	for _, r := range rows {
+		payload.Rows = append(payload.Rows, insertRow{InsertID: r.PackID, JSON: r})
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	token, err := b.tokens.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("access token: %w", err)
+	}
+	u := fmt.Sprintf("%s/bigquery/v2/projects/%s/datasets/%s/tables/%s/insertAll",
+		b.endpoint, url.PathEscape(b.project), url.PathEscape(b.dataset), url.PathEscape(b.table))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("insertAll: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	// Row-level failures come back with 200.
+	var result struct {
+		InsertErrors []json.RawMessage `json:"insertErrors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if n := len(result.InsertErrors); n > 0 {
+		return fmt.Errorf("insertAll: %d rows rejected: %s", n, result.InsertErrors[0])
+	}
+	return nil
+}
+
+func doTelemetryRequest(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// gceTokenSource returns a fixed token if one is configured, and otherwise
+// fetches and caches service account tokens from the metadata server.
+type gceTokenSource struct {
+	client *http.Client
+	url    string
+	static string
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func (s *gceTokenSource) Token(ctx context.Context) (string, error) {
+	if s.static != "" {
+		return s.static, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Refresh a minute early so a token never expires mid-request.
+	if s.token != "" && time.Now().Before(s.expires.Add(-time.Minute)) {
+		return s.token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata server: %s", resp.Status)
+	}
+	var t struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+		return "", err
+	}
+	s.token = t.AccessToken
+	s.expires = time.Now().Add(time.Duration(t.ExpiresIn) * time.Second)
+	return s.token, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type captureWriter struct{ rows chan []TelemetryRow }
+
+func (c captureWriter) WriteRows(_ context.Context, rows []TelemetryRow) error {
+	c.rows <- rows
+	return nil
+}
+
+func TestTelemetryExporterFlushesOnShutdown(t *testing.T) {
+	w := captureWriter{rows: make(chan []TelemetryRow, 1)}
+	e := newTelemetryExporter(w)
+	e.Record(TelemetryRow{PackID: "pk_1"})
+	e.Record(TelemetryRow{PackID: "pk_2"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		e.Run(ctx, time.Hour)
+		close(done)
+	}()
+	// Give Run time to drain the queue before shutting down.
+	for len(e.rows) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if got := <-w.rows; len(got) != 2 {
+		t.Errorf("Expected 2 rows in the final batch, got %d", len(got))
+	}
+}
+
+func TestClickHouseWriter(t *testing.T) {
+	var query string
+	var lines int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("query")
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			lines++
+		}
+	}))
+	defer srv.Close()
+
+	c := &clickHouseWriter{client: srv.Client(), url: srv.URL, table: "pack_telemetry"}
+	if err := c.WriteRows(context.Background(), []TelemetryRow{{PackID: "pk_1"}, {PackID: "pk_2"}}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if query != "INSERT INTO pack_telemetry FORMAT JSONEachRow" || lines != 2 {
+		t.Errorf("Expected 2 JSONEachRow lines, got %d for query %q", lines, query)
+	}
+}
+
+func TestBigQueryWriterReportsRowErrors(t *testing.T) {
+	var auth string
+	var req struct {
+		Rows []struct {
+			InsertID string `json:"insertId"`
+		} `json:"rows"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if !strings.HasSuffix(r.URL.Path, "/projects/p/datasets/d/tables/t/insertAll") {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"insertErrors": [{"index": 0, "errors": [{"reason": "invalid"}]}]}`))
+	}))
+	defer srv.Close()
+
+	b := &bigQueryWriter{
+		client: srv.Client(), endpoint: srv.URL,
+		project: "p", dataset: "d", table: "t",
+		tokens: &gceTokenSource{static: "token"},
+	}
+	err := b.WriteRows(context.Background(), []TelemetryRow{{PackID: "pk_1"}})
+	if err == nil || !strings.Contains(err.Error(), "1 rows rejected") {
+		t.Errorf("Expected the rejected row to be reported, got %v", err)
+	}
+	if auth != "Bearer token" || len(req.Rows) != 1 || req.Rows[0].InsertID != "pk_1" {
+		t.Errorf("Expected one row with insert ID pk_1 and a bearer token, got %+v (%q)", req, auth)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCert writes a self-signed certificate and key and returns their paths.
+func writeTestCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "binpacker-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestTLSConfigFromEnv(t *testing.T) {
+	t.Setenv("TLS_CERT_FILE", "")
+	if cfg, err := tlsConfigFromEnv(); cfg != nil || err != nil {
+		t.Errorf("Expected plain HTTP without TLS_CERT_FILE, got %v, %v", cfg, err)
+	}
+
+	certFile, keyFile := writeTestCert(t)
+	t.Setenv("TLS_CERT_FILE", certFile)
+	if _, err := tlsConfigFromEnv(); err == nil {
+		t.Error("Expected an error when TLS_KEY_FILE is missing")
+	}
+
+	t.Setenv("TLS_KEY_FILE", keyFile)
+	cfg, err := tlsConfigFromEnv()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.ClientAuth != tls.NoClientCert {
+		t.Errorf("Expected no client certificates without TLS_CLIENT_CA_FILE, got %v", cfg.ClientAuth)
+	}
+
+	t.Setenv("TLS_CLIENT_CA_FILE", certFile)
+	cfg, err = tlsConfigFromEnv()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.ClientAuth != tls.RequireAndVerifyClientCert || cfg.ClientCAs == nil {
+		t.Errorf("Expected mutual TLS with TLS_CLIENT_CA_FILE, got %v", cfg.ClientAuth)
+	}
+
+	t.Setenv("TLS_CLIENT_CA_FILE", keyFile)
+	if _, err := tlsConfigFromEnv(); err == nil {
+		t.Error("Expected an error for a client CA file without certificates")
+	}
+}
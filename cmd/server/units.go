@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Lengths in a request are plain numbers. Without units they must be whole
+// and are packed as given. With units they may have decimals, and items and
+// boxes may name their own unit, for catalogs that mix metric and imperial
+// sizes. Such a request is moved onto an integer grid before validation:
+// every length is converted to micrometres and divided by the coarsest step
+// that keeps them all whole, so the packer itself only sees whole numbers.
+// PackResponse records the unit and the step, and converts its lengths back
+// to the unit whenever it is encoded as JSON.
+
+// Units accepted in PackRequest.Units and on items and boxes.
+const (
+	UnitMillimetre = "mm"
+	UnitCentimetre = "cm"
+	UnitMetre      = "m"
+	UnitInch       = "in"
+	UnitFoot       = "ft"
+)
+
+// micrometresPer is the length of each unit in micrometres. All of them are
+// whole, so a length with up to three decimals in mm converts exactly.
+var micrometresPer = map[string]int64{
+	UnitMillimetre: 1000,
+	UnitCentimetre: 10_000,
+	UnitMetre:      1_000_000,
+	UnitInch:       25_400,
+	UnitFoot:       304_800,
+}
+
+// maxMicrometres keeps converted lengths exact in a float64.
+const maxMicrometres = 1 << 52
+
+// sides returns w, h and d as decoded, with their decimals.
+func sides(w, h, d int, size *[3]float64) [3]float64 {
+	if size != nil {
+		return *size
+	}
+	return [3]float64{float64(w), float64(h), float64(d)}
+}
+
+// applyUnits moves a request with units onto its packing grid and records
+// the grid step in req.UnitGrid. Requests without units are left alone.
+func applyUnits(req *PackRequest, l InputLimits) error {
+	if req.UnitGrid != 0 {
+		return errors.New("unit_grid_um is set by the server; give lengths in units instead")
+	}
+	if req.Units == "" {
+		for i, it := range req.Items {
+			if it.Units != "" {
+				return fmt.Errorf("items[%d] has units %q but the request has none; set units on the request", i, it.Units)
+			}
+		}
+		for i, b := range req.Boxes {
+			if b.Units != "" {
+				return fmt.Errorf("boxes[%d] has units %q but the request has none; set units on the request", i, b.Units)
+			}
+		}
+		return nil
+	}
+
+	// Every length is collected in micrometres with where it goes, and
+	// written back once the grid step is known.
+	type length struct {
+		dst  *int
+		um   int64
+		list string
+		i    int
+		side string
+	}
+	var lengths []length
+	var errs ValidationErrors
+	unit := func(list string, i int, name string) (int64, bool) {
+		if name == "" {
+			name = req.Units
+		}
+		per, ok := micrometresPer[name]
+		if !ok {
+			errs = append(errs, FieldError{List: list, Index: i, Field: "units", Reason: fmt.Sprintf("unknown unit %q", name)})
+		}
+		return per, ok
+	}
+	add := func(dst *int, v float64, per int64, list string, i int, side string) {
+		um := math.Round(v * float64(per))
+		if math.Abs(um) > maxMicrometres {
+			errs = append(errs, FieldError{List: list, Index: i, Field: side, Reason: "is too large"})
+			return
+		}
+		lengths = append(lengths, length{dst: dst, um: int64(um), list: list, i: i, side: side})
+	}
+	addSides := func(w, h, d *int, size *[3]float64, per int64, list string, i int) {
+		s := sides(*w, *h, *d, size)
+		add(w, s[0], per, list, i, "w")
+		add(h, s[1], per, list, i, "h")
+		add(d, s[2], per, list, i, "d")
+	}
+
+	perReq, ok := micrometresPer[req.Units]
+	if !ok {
+		return fmt.Errorf("unknown units %q: use mm, cm, m, in or ft", req.Units)
+	}
+	for i := range req.Items {
+		it := &req.Items[i]
+		if per, ok := unit("items", i, it.Units); ok {
+			addSides(&it.W, &it.H, &it.D, it.Size, per, "items", i)
+			if it.Inner != nil {
+				addSides(&it.Inner.W, &it.Inner.H, &it.Inner.D, nil, per, "items", i)
+			}
+			if it.WallClearance != 0 {
+				add(&it.WallClearance, float64(it.WallClearance), per, "items", i, "wall_clearance")
+			}
+		}
+		it.Units, it.Size = "", nil
+	}
+	for i := range req.Boxes {
+		b := &req.Boxes[i]
+		if per, ok := unit("boxes", i, b.Units); ok {
+			addSides(&b.W, &b.H, &b.D, b.Size, per, "boxes", i)
+		}
+		b.Units, b.Size = "", nil
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	other := func(dst *int) {
+		if dst != nil {
+			add(dst, float64(*dst), perReq, "", 0, "")
+		}
+	}
+	if req.Aisle != nil {
+		other(&req.Aisle.Width)
+		other(&req.Aisle.Height)
+		other(req.Aisle.Offset)
+	}
+	if req.AxleLoad != nil {
+		other(&req.AxleLoad.RearAxle)
+		other(&req.AxleLoad.FrontAxle)
+	}
+	other(&req.MinDimension)
+	other(req.OverhangTolerance)
+
+	var step int64
+	for _, n := range lengths {
+		step = gcd(step, max(n.um, -n.um))
+	}
+	step = max(step, 1)
+	for _, n := range lengths {
+		v := n.um / step
+		if n.list != "" && v > int64(l.MaxDimension) {
+			errs = append(errs, FieldError{List: n.list, Index: n.i, Field: n.side,
+				Reason: fmt.Sprintf("needs %d steps of the %d µm packing grid, more than %d; round the lengths to fewer decimals", v, step, l.MaxDimension)})
+		}
+		*n.dst = int(v)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	req.UnitGrid = int(step)
+	return nil
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// Lengths and volumes of a PackResponse in JSON, by object. Lengths nest by
+// key, e.g. the contents of packed_boxes.
+var (
+	responseLengths = map[string][]string{
+		"packed_boxes":   {"w", "h", "d"},
+		"contents":       {"x", "y", "z", "w", "h", "d"},
+		"axle_loads":     {"load_center"},
+		"unpacked_items": {"w", "h", "d", "wall_clearance"},
+		"inner":          {"w", "h", "d"},
+		"suggestions":    {"w", "h", "d"},
+	}
+	responseVolumes = map[string][]string{
+		"":             {"total_volume"},
+		"packed_boxes": {"used_volume", "free_volume"},
+	}
+)
+
+// unitScale returns how many of the response unit one grid step is.
+func (resp PackResponse) unitScale() float64 {
+	return float64(resp.UnitGrid) / float64(micrometresPer[resp.Units])
+}
+
+// length converts a grid length of resp into its unit.
+func (resp PackResponse) length(v int) float64 {
+	if resp.UnitGrid == 0 {
+		return float64(v)
+	}
+	return roundLength(float64(v) * resp.unitScale())
+}
+
+// volume converts a grid volume of resp into its unit.
+func (resp PackResponse) volume(v int) float64 {
+	if resp.UnitGrid == 0 {
+		return float64(v)
+	}
+	s := resp.unitScale()
+	return float64(v) * s * s * s
+}
+
+// lengthText formats a grid length of resp in its unit for display.
+func (resp PackResponse) lengthText(v int) string {
+	return strconv.FormatFloat(resp.length(v), 'f', -1, 64)
+}
+
+// roundLength drops the float noise of a unit conversion. Micrometres in
+// the coarsest unit take six decimals.
+func roundLength(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
+
+func (resp PackResponse) MarshalJSON() ([]byte, error) {
+	type plain PackResponse
+	b, err := json.Marshal(plain(resp))
+	if err != nil || resp.UnitGrid == 0 {
+		return b, err
+	}
+	return rescaleJSON(b, func(v float64) float64 { return resp.length(int(v)) }, func(v float64) float64 { return resp.volume(int(v)) })
+}
+
+func (resp *PackResponse) UnmarshalJSON(b []byte) error {
+	type plain PackResponse
+	var units struct {
+		Units    string `json:"units"`
+		UnitGrid int    `json:"unit_grid_um"`
+	}
+	if err := json.Unmarshal(b, &units); err != nil {
+		return err
+	}
+	if units.UnitGrid != 0 {
+		s := PackResponse{Units: units.Units, UnitGrid: units.UnitGrid}.unitScale()
+		var err error
+		if b, err = rescaleJSON(b, func(v float64) float64 { return math.Round(v / s) }, func(v float64) float64 { return math.Round(v / (s * s * s)) }); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(b, (*plain)(resp))
+}
+
+// rescaleJSON applies length and volume to the lengths and volumes of an
+// encoded PackResponse. Other numbers are kept as written.
+func rescaleJSON(b []byte, length, volume func(float64) float64) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	scale := func(obj map[string]any, fields []string, f func(float64) float64) {
+		for _, field := range fields {
+			if n, ok := obj[field].(json.Number); ok {
+				if v, err := n.Float64(); err == nil {
+					obj[field] = f(v)
+				}
+			}
+		}
+	}
+	var walk func(key string, v any)
+	walk = func(key string, v any) {
+		switch v := v.(type) {
+		case []any:
+			for _, e := range v {
+				walk(key, e)
+			}
+		case map[string]any:
+			scale(v, responseLengths[key], length)
+			scale(v, responseVolumes[key], volume)
+			for k, e := range v {
+				if _, ok := responseLengths[k]; ok {
+					walk(k, e)
+				}
+			}
+		}
+	}
+	walk("", doc)
+	return json.Marshal(doc)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"binpacker/pkg/packing"
+)
+
+func TestApplyUnitsMixedCatalog(t *testing.T) {
+	var req PackRequest
+	body := `{
+		"units": "mm",
+		"items": [{"id": "cube", "w": 6, "h": 6, "d": 6, "quantity": 8, "units": "in"}],
+		"boxes": [{"id": "crate", "w": 30.48, "h": 30.48, "d": 30.48, "units": "cm"}]
+	}`
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyUnits(&req, defaultInputLimits); err != nil {
+		t.Fatal(err)
+	}
+	// 6 in is 152400 µm and 30.48 cm is 304800 µm.
+	if req.UnitGrid != 152400 || req.Items[0].W != 1 || req.Boxes[0].W != 2 {
+		t.Fatalf("Expected a 152400 µm grid with sides 1 and 2, got %d, %d and %d", req.UnitGrid, req.Items[0].W, req.Boxes[0].W)
+	}
+
+	packed, unpacked := packing.Pack(req.Items, req.Boxes)
+	resp := newPackResponse(packed, unpacked, req.Boxes)
+	resp.Units, resp.UnitGrid = req.Units, req.UnitGrid
+	b, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out struct {
+		TotalVolume float64 `json:"total_volume"`
+		PackedBoxes []struct {
+			W        float64 `json:"w"`
+			Contents []struct {
+				X, W float64
+			} `json:"contents"`
+		} `json:"packed_boxes"`
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.PackedBoxes) != 1 || out.PackedBoxes[0].W != 304.8 || len(out.PackedBoxes[0].Contents) != 8 {
+		t.Fatalf("Expected one 304.8 mm box with 8 cubes, got %s", b)
+	}
+	for _, c := range out.PackedBoxes[0].Contents {
+		if c.W != 152.4 || (c.X != 0 && c.X != 152.4) {
+			t.Errorf("Expected 152.4 mm cubes on the grid, got %+v", c)
+		}
+	}
+	if want := 304.8 * 304.8 * 304.8; out.TotalVolume < want-1e-6 || out.TotalVolume > want+1e-6 {
+		t.Errorf("Expected a total volume of %v mm³, got %v", want, out.TotalVolume)
+	}
+
+	// Stored responses are read back onto the grid.
+	var back PackResponse
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back.PackedBoxes, resp.PackedBoxes) || back.TotalVolume != resp.TotalVolume {
+		t.Errorf("Expected the response to survive a JSON round trip, got %+v", back)
+	}
+}
+
+func TestUnitsRequired(t *testing.T) {
+	var req PackRequest
+	body := `{"items": [{"id": "a", "w": 1.5, "h": 2, "d": 2, "quantity": 1}], "boxes": [{"id": "b", "w": 10, "h": 10, "d": 10}]}`
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatal(err)
+	}
+	var errs ValidationErrors
+	err := validateInput(req.Items, req.Boxes, defaultInputLimits)
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Field != "w" || !strings.Contains(errs[0].Reason, "units") {
+		t.Errorf("Expected a single error asking for units, got %v", err)
+	}
+
+	req.Boxes[0].Units = UnitInch
+	if err := applyUnits(&req, defaultInputLimits); err == nil {
+		t.Error("Expected entry units without request units to be rejected")
+	}
+
+	req = PackRequest{
+		Units: UnitMetre,
+		Items: []packing.InputItem{{ID: "a", W: 1, H: 1, D: 1, Quantity: 1}},
+		Boxes: []packing.InputBox{{ID: "b", W: 12, H: 3, D: 3, Size: &[3]float64{12.000001, 3, 3}}},
+	}
+	if err := applyUnits(&req, defaultInputLimits); !errors.As(err, &errs) || errs[0].List != "boxes" {
+		t.Errorf("Expected a grid finer than max_dimension to be rejected, got %v", err)
+	}
+}
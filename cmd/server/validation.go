@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"binpacker/pkg/packing"
+)
+
+// InputLimits bounds what a single pack request may ask for. They guard
+// against data errors such as a quantity typed into the dimension column as
+// much as against requests too large to pack.
+type InputLimits struct {
+	// MaxItems and MaxBoxes are the number of entries in items and boxes.
+	MaxItems int `json:"max_items"`
+	MaxBoxes int `json:"max_boxes"`
+	// MaxTotalUnits caps the sum of the item quantities, which is the
+	// number of units the packer places.
+	MaxTotalUnits int `json:"max_total_units"`
+	// MaxQuantity caps the quantity of a single entry.
+	MaxQuantity int `json:"max_item_quantity"`
+	// MaxDimension caps every item and box side.
+	MaxDimension int `json:"max_dimension"`
+	// MaxPackEvaluations and MaxPackMemoryMB are the compute budget of a
+	// pack, checked against packing.EstimateCost; see budget.go.
+	MaxPackEvaluations int `json:"max_pack_evaluations"`
+	MaxPackMemoryMB    int `json:"max_pack_memory_mb"`
+}
+
+var defaultInputLimits = InputLimits{
+	MaxItems:      10000,
+	MaxBoxes:      1000,
+	MaxTotalUnits: 50000,
+	MaxQuantity:   10000,
+	MaxDimension:  1_000_000,
+	// About a minute of packing, the default PACK_TIMEOUT.
+	MaxPackEvaluations: 1_000_000_000,
+	MaxPackMemoryMB:    1024,
+}
+
+// inputLimitsFrom reads MAX_ITEMS, MAX_BOXES, MAX_TOTAL_UNITS,
+// MAX_ITEM_QUANTITY, MAX_DIMENSION, MAX_PACK_EVALUATIONS and
+// MAX_PACK_MEMORY_MB from getenv on top of the defaults.
+func inputLimitsFrom(getenv func(string) string) (InputLimits, error) {
+	l := defaultInputLimits
+	for _, f := range []struct {
+		env string
+		dst *int
+	}{
+		{"MAX_ITEMS", &l.MaxItems},
+		{"MAX_BOXES", &l.MaxBoxes},
+		{"MAX_TOTAL_UNITS", &l.MaxTotalUnits},
+		{"MAX_ITEM_QUANTITY", &l.MaxQuantity},
+		{"MAX_DIMENSION", &l.MaxDimension},
+		{"MAX_PACK_EVALUATIONS", &l.MaxPackEvaluations},
+		{"MAX_PACK_MEMORY_MB", &l.MaxPackMemoryMB},
+	} {
+		v := getenv(f.env)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return InputLimits{}, fmt.Errorf("invalid %s %q", f.env, v)
+		}
+		*f.dst = n
+	}
+	return l, nil
+}
+
+// errTooLarge is a request over one of the size limits, which is answered
+// with 413 Content Too Large.
+type errTooLarge struct {
+	msg string
+}
+
+func (e errTooLarge) Error() string { return e.msg }
+
+// checkRequestSize enforces the count limits of l. It runs before anything
+// expands the items into units, so it adds quantities up with care.
+func checkRequestSize(items []packing.InputItem, boxes []packing.InputBox, l InputLimits) error {
+	if len(items) > l.MaxItems {
+		return errTooLarge{fmt.Sprintf("too many items: %d entries, the limit is %d", len(items), l.MaxItems)}
+	}
+	if len(boxes) > l.MaxBoxes {
+		return errTooLarge{fmt.Sprintf("too many boxes: %d entries, the limit is %d", len(boxes), l.MaxBoxes)}
+	}
+	units := 0
+	for _, it := range items {
+		if it.Quantity > l.MaxTotalUnits-units {
+			return errTooLarge{fmt.Sprintf("too many units: the item quantities add up to more than %d; split the request", l.MaxTotalUnits)}
+		}
+		units += max(it.Quantity, 0)
+	}
+	return nil
+}
+
+// FieldError is one problem with a field of an entry of items or boxes,
+// located by List and Index.
+type FieldError struct {
+	List   string `json:"list"`
+	Index  int    `json:"index"`
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+func (e FieldError) path() string {
+	return fmt.Sprintf("%s[%d].%s", e.List, e.Index, e.Field)
+}
+
+// ValidationErrors lists every field error of a request, which is answered
+// with 422 Unprocessable Entity.
+type ValidationErrors []FieldError
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.path() + ": " + e.Reason
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// validateInput checks the items and boxes of a request against l: IDs must
+// be present and item IDs unique, sides and quantities must be in range.
+// Duplicate box IDs only raise a warning; see inputWarnings.
+func validateInput(items []packing.InputItem, boxes []packing.InputBox, l InputLimits) error {
+	var errs ValidationErrors
+	add := func(list string, i int, field, reason string) {
+		errs = append(errs, FieldError{List: list, Index: i, Field: field, Reason: reason})
+	}
+	dims := func(list string, i, w, h, d int, size *[3]float64) {
+		for k, side := range []struct {
+			field string
+			v     int
+		}{{"w", w}, {"h", h}, {"d", d}} {
+			if size != nil && size[k] != math.Trunc(size[k]) {
+				add(list, i, side.field, "must be a whole number; set units to give lengths with decimals")
+			} else if side.v < 1 {
+				add(list, i, side.field, "must be at least 1")
+			} else if side.v > l.MaxDimension {
+				add(list, i, side.field, fmt.Sprintf("must be at most %d", l.MaxDimension))
+			}
+		}
+	}
+
+	firstItem := make(map[string]int, len(items))
+	for i, it := range items {
+		if it.ID == "" {
+			add("items", i, "id", "must not be empty")
+		} else if j, dup := firstItem[it.ID]; dup {
+			add("items", i, "id", fmt.Sprintf("duplicates items[%d] with other sides or options; give them distinct IDs", j))
+		} else {
+			firstItem[it.ID] = i
+		}
+		dims("items", i, it.W, it.H, it.D, it.Size)
+		if slices.Contains(it.IncompatibleWith, it.ID) {
+			add("items", i, "incompatible_with", "must not name the item itself")
+		}
+		if it.WallClearance < 0 {
+			add("items", i, "wall_clearance", "must not be negative")
+		}
+		if it.Compressibility < 0 || it.Compressibility >= 100 {
+			add("items", i, "compressibility", "must be a percentage from 0 up to, but not including, 100")
+		}
+		if it.Quantity < 1 {
+			add("items", i, "quantity", "must be at least 1")
+		} else if it.Quantity > l.MaxQuantity {
+			add("items", i, "quantity", fmt.Sprintf("must be at most %d", l.MaxQuantity))
+		}
+	}
+	for i, b := range boxes {
+		if b.ID == "" {
+			add("boxes", i, "id", "must not be empty")
+		}
+		dims("boxes", i, b.W, b.H, b.D, b.Size)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateOrders runs checkRequestSize and validateInput for endpoints that
+// pack several orders. The size limits apply to all orders together, since
+// the orders may be merged or are packed within one request; item IDs only
+// need to be unique within an order.
+func validateOrders(orders []Order, l InputLimits) error {
+	var all []packing.InputItem
+	for _, o := range orders {
+		all = append(all, o.Items...)
+	}
+	if err := checkRequestSize(all, nil, l); err != nil {
+		return err
+	}
+
+	var errs ValidationErrors
+	for i, o := range orders {
+		errs = append(errs, relabel(validateInput(o.Items, nil, l), fmt.Sprintf("orders[%d].items", i))...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateBoxes checks a box catalog sent under the JSON field list.
+func validateBoxes(list string, boxes []packing.InputBox, l InputLimits) error {
+	if err := checkRequestSize(nil, boxes, l); err != nil {
+		return err
+	}
+	if errs := relabel(validateInput(nil, boxes, l), list); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// relabel returns the field errors of err with their list renamed.
+func relabel(err error, list string) ValidationErrors {
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		return nil
+	}
+	for i := range errs {
+		errs[i].List = list
+	}
+	return errs
+}
+
+// writeRequestError answers a request that failed validation: 413 for
+// errTooLarge and errOverBudget, 422 with the field errors as JSON for
+// ValidationErrors and 400 with the message otherwise.
+func writeRequestError(w http.ResponseWriter, err error) {
+	var errs ValidationErrors
+	if errors.As(err, new(errTooLarge)) || errors.As(err, new(errOverBudget)) {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if !errors.As(err, &errs) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	_ = json.NewEncoder(w).Encode(struct {
+		Error  string       `json:"error"`
+		Fields []FieldError `json:"fields"`
+	}{"invalid request", errs})
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"binpacker/pkg/packing"
+)
+
+func TestValidateInput(t *testing.T) {
+	items := []packing.InputItem{
+		{ID: "mug", W: 5, H: 5, D: 5, Quantity: 1},
+		{ID: "", W: 0, H: 5, D: -1, Quantity: 1},
+		{ID: "mug", W: 5, H: 5, D: 5, Quantity: 500},
+	}
+	boxes := []packing.InputBox{{ID: "box", W: 10, H: 10, D: 2000}}
+	limits := InputLimits{MaxItems: 10, MaxQuantity: 100, MaxDimension: 1000}
+
+	err := validateInput(items, boxes, limits)
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.path())
+	}
+	want := "items[1].id items[1].w items[1].d items[2].id items[2].quantity boxes[0].d"
+	if strings.Join(got, " ") != want {
+		t.Errorf("Expected errors for %s, got %v", want, errs)
+	}
+
+	if err := validateInput(items[:1], boxes[:0], limits); err != nil {
+		t.Errorf("Expected a valid request to pass, got %v", err)
+	}
+
+	items[0].IncompatibleWith = []string{"mug"}
+	if err := validateInput(items[:1], boxes[:0], limits); err == nil {
+		t.Error("Expected an item incompatible with itself to be rejected")
+	}
+	items[0].IncompatibleWith, items[0].WallClearance = nil, -1
+	if err := validateInput(items[:1], boxes[:0], limits); err == nil {
+		t.Error("Expected a negative wall clearance to be rejected")
+	}
+}
+
+func TestPackAnswers422(t *testing.T) {
+	body := `{"items": [{"id": "mug", "w": 0, "h": 5, "d": 5, "quantity": 1}], "boxes": [{"id": "box", "w": 10, "h": 10, "d": 10}]}`
+	rec := httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodPost, "/pack", strings.NewReader(body)))
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected 422, got %d: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Fields []FieldError `json:"fields"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || len(resp.Fields) != 1 {
+		t.Fatalf("Expected one field error, got %+v (%v)", resp, err)
+	}
+	if f := resp.Fields[0]; f.List != "items" || f.Index != 0 || f.Field != "w" {
+		t.Errorf("Expected items[0].w, got %+v", f)
+	}
+}
+
+func TestCheckRequestSize(t *testing.T) {
+	limits := InputLimits{MaxItems: 2, MaxBoxes: 1, MaxTotalUnits: 100}
+	items := []packing.InputItem{{ID: "a", Quantity: 60}, {ID: "b", Quantity: 40}}
+	boxes := []packing.InputBox{{ID: "box"}}
+
+	if err := checkRequestSize(items, boxes, limits); err != nil {
+		t.Errorf("Expected a request at the limits to pass, got %v", err)
+	}
+	for name, req := range map[string]struct {
+		items []packing.InputItem
+		boxes []packing.InputBox
+	}{
+		"items": {append(items, packing.InputItem{ID: "c", Quantity: 0}), boxes},
+		"boxes": {items, append(boxes, boxes...)},
+		"units": {[]packing.InputItem{{ID: "a", Quantity: 60}, {ID: "b", Quantity: 41}}, boxes},
+		"huge":  {[]packing.InputItem{{ID: "a", Quantity: 1 << 62}, {ID: "b", Quantity: 1 << 62}}, boxes},
+	} {
+		if _, ok := checkRequestSize(req.items, req.boxes, limits).(errTooLarge); !ok {
+			t.Errorf("%s: expected errTooLarge", name)
+		}
+	}
+
+	body := `{"items": [{"id": "mug", "w": 1, "h": 1, "d": 1, "quantity": 100000000}], "boxes": [{"id": "box", "w": 10, "h": 10, "d": 10}]}`
+	rec := httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodPost, "/pack", strings.NewReader(body)))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestPackMergesDuplicateItems(t *testing.T) {
+	body := `{"items": [
+		{"id": "mug", "w": 4, "h": 5, "d": 4, "quantity": 1},
+		{"id": "mug", "w": 5, "h": 4, "d": 4, "quantity": 1},
+		{"id": "mug", "w": 4, "h": 4, "d": 5, "quantity": 1}
+	], "boxes": [{"id": "box", "w": 10, "h": 10, "d": 10}]}`
+	rec := httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodPost, "/pack", strings.NewReader(body)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var resp PackResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.PackedBoxes) != 1 || len(resp.PackedBoxes[0].Contents) != 3 {
+		t.Errorf("Expected all 3 mugs in one box, got %+v", resp.PackedBoxes)
+	}
+	merged := false
+	for _, w := range resp.Warnings {
+		merged = merged || w.Code == packing.WarnItemsMerged
+	}
+	if !merged {
+		t.Errorf("Expected an items_merged warning, got %+v", resp.Warnings)
+	}
+}
+
+func TestPackRejectsNegativeDuplicates(t *testing.T) {
+	body := `{"items": [
+		{"id": "mug", "w": 4, "h": 5, "d": 4, "quantity": 3},
+		{"id": "mug", "w": 4, "h": 5, "d": 4, "quantity": -2}
+	], "boxes": [{"id": "box", "w": 10, "h": 10, "d": 10}]}`
+	rec := httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodPost, "/pack", strings.NewReader(body)))
+
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), `"field":"quantity"`) {
+		t.Errorf("Expected 422 for the negative quantity, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestMultiOrderEndpointsCheckLimits(t *testing.T) {
+	huge := `{"id": "o1", "items": [{"id": "mug", "w": 1, "h": 1, "d": 1, "quantity": 100000000}]}`
+	flat := `{"id": "o2", "items": [{"id": "mug", "w": 1, "h": 0, "d": 1, "quantity": 1}]}`
+	box := `[{"id": "box", "w": 10, "h": 10, "d": 10}]`
+
+	tests := []struct {
+		path, body string
+		want       int
+	}{
+		{"/consolidate", `{"orders": [` + huge + `], "boxes": ` + box + `}`, http.StatusRequestEntityTooLarge},
+		{"/consolidate", `{"orders": [` + flat + `], "boxes": ` + box + `}`, http.StatusUnprocessableEntity},
+		{"/simulate-catalog", `{"orders": [` + huge + `], "current_boxes": ` + box + `, "proposed_boxes": ` + box + `}`, http.StatusRequestEntityTooLarge},
+		{"/simulate-catalog", `{"orders": [` + flat + `], "current_boxes": ` + box + `, "proposed_boxes": ` + box + `}`, http.StatusUnprocessableEntity},
+		{"/recommend-boxes", `{"orders": [` + huge + `]}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		Packer(rec, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+		if rec.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d: %s", tt.path, tt.want, rec.Code, rec.Body)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodPost, "/consolidate", strings.NewReader(`{"orders": [`+flat+`], "boxes": `+box+`}`)))
+	var resp struct {
+		Fields []FieldError `json:"fields"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || len(resp.Fields) != 1 || resp.Fields[0].path() != "orders[0].items[0].h" {
+		t.Errorf("Expected orders[0].items[0].h, got %+v (%v)", resp.Fields, err)
+	}
+}
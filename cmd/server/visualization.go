@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"binpacker/pkg/packing"
+	"binpacker/pkg/visualize"
+)
+
+// Visualization modes for PackRequest.Visualization.
+const (
+	// VizModeCDN returns HTML that loads three.js from a CDN.
+	VizModeCDN = "cdn"
+	// VizModeDataURI returns standalone HTML with three.js inlined, for
+	// clients that must open it offline.
+	VizModeDataURI = "data_uri"
+	// VizModeNone skips the visualization.
+	VizModeNone = "none"
+)
+
+func validateVizMode(mode string) error {
+	switch mode {
+	case "", VizModeCDN, VizModeDataURI, VizModeNone:
+		return nil
+	}
+	return fmt.Errorf("unknown visualization %q: use %q, %q or %q", mode, VizModeCDN, VizModeDataURI, VizModeNone)
+}
+
+// attachVisualization renders the visualization of resp, stores it under
+// vizID and fills in the response fields. The stored copy always loads its
+// scripts from a CDN; in VizModeDataURI the response gets a standalone copy.
+func attachVisualization(resp *PackResponse, boxes []packing.InputBox, mode, vizID, owner string) error {
+	data := visualize.Data{
+		PackedBoxes: resp.PackedBoxes,
+		Boxes:       boxes,
+		RequestID:   vizID,
+		TableURL:    "/visualize/" + vizID + "?view=table",
+	}
+	if resp.CoordinateFrame != nil {
+		g, err := newFrameGizmo(*resp.CoordinateFrame)
+		if err != nil {
+			return err
+		}
+		data.Frame = &g
+	}
+	stored, err := visualize.HTML(data)
+	if err != nil {
+		return err
+	}
+
+	html := stored
+	if mode == VizModeDataURI {
+		// Links back to the server are useless offline.
+		data.Standalone, data.TableURL = true, ""
+		if html, err = visualize.HTML(data); err != nil {
+			return err
+		}
+	}
+
+	store.SaveVisualization(vizID, resp.PackID, owner, stored)
+	resp.VisualizationURL = "/visualize/" + vizID
+	resp.VisualizationHTML = html
+	resp.VisualizationDataURI = "data:text/html;base64," + base64.StdEncoding.EncodeToString([]byte(html))
+	return nil
+}
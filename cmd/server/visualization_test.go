@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"binpacker/pkg/packing"
+)
+
+func TestDataURIVisualizationIsStandalone(t *testing.T) {
+	body, _ := json.Marshal(PackRequest{
+		Items:         []packing.InputItem{{ID: "cube", W: 5, H: 5, D: 5, Quantity: 2}},
+		Boxes:         []packing.InputBox{{ID: "box", W: 10, H: 10, D: 10}},
+		Visualization: VizModeDataURI,
+	})
+	rec := httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodPost, "/pack", bytes.NewReader(body)))
+
+	var resp PackResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(resp.VisualizationHTML, "cdnjs.cloudflare.com") {
+		t.Error("Expected no CDN scripts in standalone HTML")
+	}
+	if strings.Contains(resp.VisualizationHTML, "<script src=") {
+		t.Error("Expected every script to be inlined")
+	}
+	if !strings.HasPrefix(resp.VisualizationDataURI, "data:text/html;base64,") {
+		t.Errorf("Expected a data URI, got %.40q", resp.VisualizationDataURI)
+	}
+
+	// The stored copy served by the API stays small.
+	vizID := strings.TrimPrefix(resp.VisualizationURL, "/visualize/")
+	viz, ok := store.Visualization("", vizID)
+	if !ok || !strings.Contains(viz.HTML, "cdnjs.cloudflare.com") {
+		t.Error("Expected the stored visualization to load scripts from the CDN")
+	}
+}
+
+func TestVisualizationNone(t *testing.T) {
+	body, _ := json.Marshal(PackRequest{
+		Items:         []packing.InputItem{{ID: "cube", W: 5, H: 5, D: 5, Quantity: 1}},
+		Boxes:         []packing.InputBox{{ID: "box", W: 10, H: 10, D: 10}},
+		Visualization: VizModeNone,
+	})
+	rec := httptest.NewRecorder()
+	Packer(rec, httptest.NewRequest(http.MethodPost, "/pack", bytes.NewReader(body)))
+
+	var resp PackResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.VisualizationHTML != "" || resp.VisualizationURL != "" {
+		t.Error("Expected no visualization")
+	}
+}
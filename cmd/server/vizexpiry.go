@@ -0,0 +1,166 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultVizSweepInterval = time.Minute
+
+// Visualizations kept in process memory are bounded by default, since
+// nothing else stops them from taking all of it.
+const (
+	defaultMemoryVizTTL        = 24 * time.Hour
+	defaultMemoryVizMaxEntries = 1000
+)
+
+var expiredVisualizations = newCounter("expired_visualizations_total", "Stored visualizations expired by VIZ_TTL or evicted by VIZ_MAX_ENTRIES.")
+
+// vizLimits bounds stored visualization HTML, which is by far the largest
+// thing the store keeps. An expired visualization keeps its record, so
+// /visualize/{id} can answer 410 Gone rather than 404, until the retention
+// janitor purges it. Zero values mean no limit.
+type vizLimits struct {
+	TTL        time.Duration
+	MaxEntries int
+}
+
+// vizLimitsFromEnv reads VIZ_TTL, a duration as for the retention settings,
+// and VIZ_MAX_ENTRIES. With inMemory they default to the memory limits
+// instead of none.
+func vizLimitsFromEnv(inMemory bool) (vizLimits, error) {
+	var l vizLimits
+	if inMemory {
+		l = vizLimits{TTL: defaultMemoryVizTTL, MaxEntries: defaultMemoryVizMaxEntries}
+	}
+	if v := os.Getenv("VIZ_TTL"); v != "" {
+		d, err := parseRetention(v)
+		if err != nil {
+			return vizLimits{}, fmt.Errorf("VIZ_TTL: %w", err)
+		}
+		l.TTL = d
+	}
+	if v := os.Getenv("VIZ_MAX_ENTRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return vizLimits{}, fmt.Errorf("invalid VIZ_MAX_ENTRIES %q", v)
+		}
+		l.MaxEntries = n
+	}
+	return l, nil
+}
+
+// setVizLimits applies l to visualizations saved from now on.
+func (s *Store) setVizLimits(l vizLimits) {
+	s.vizLimits = l
+	s.vizLRU.setMax(l.MaxEntries)
+}
+
+// vizLRU orders the live visualizations saved by this process from most to
+// least recently saved or viewed. It only tracks their keys while a cap is
+// set.
+type vizLRU struct {
+	mu    sync.Mutex
+	max   int
+	order list.List
+	elems map[string]*list.Element
+}
+
+func (l *vizLRU) setMax(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.max = n
+}
+
+// touch moves key to the front and returns the keys that fell off the end.
+func (l *vizLRU) touch(key string) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.max <= 0 {
+		return nil
+	}
+	if e, ok := l.elems[key]; ok {
+		l.order.MoveToFront(e)
+		return nil
+	}
+	if l.elems == nil {
+		l.elems = make(map[string]*list.Element)
+	}
+	l.elems[key] = l.order.PushFront(key)
+
+	var evicted []string
+	for l.order.Len() > l.max {
+		e := l.order.Back()
+		l.order.Remove(e)
+		delete(l.elems, e.Value.(string))
+		evicted = append(evicted, e.Value.(string))
+	}
+	return evicted
+}
+
+func (l *vizLRU) forget(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.elems[key]; ok {
+		l.order.Remove(e)
+		delete(l.elems, key)
+	}
+}
+
+// expireVisualization drops the HTML of the live visualization stored under
+// key and reports whether it did.
+func (s *Store) expireVisualization(key string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var v storedVisualization
+	if !s.load(key, &v) || !v.DeletedAt.IsZero() || !v.ExpiredAt.IsZero() {
+		return false
+	}
+	v.ExpiredAt, v.HTML = now, ""
+	s.save(key, v)
+	s.vizLRU.forget(key)
+	expiredVisualizations.Add(1)
+	return true
+}
+
+// SweepVisualizations expires every live visualization whose TTL has passed
+// and returns how many it expired.
+func (s *Store) SweepVisualizations(now time.Time) int {
+	var due []string
+	s.visualizations(func(key string, v storedVisualization) {
+		if v.DeletedAt.IsZero() && v.ExpiredAt.IsZero() && !v.ExpiresAt.IsZero() && !now.Before(v.ExpiresAt) {
+			due = append(due, key)
+		}
+	})
+	n := 0
+	for _, key := range due {
+		if s.expireVisualization(key, now) {
+			n++
+		}
+	}
+	return n
+}
+
+// runVizSweeper expires visualizations past their TTL every interval until
+// ctx is done.
+func runVizSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if n := store.SweepVisualizations(now); n > 0 {
+				slog.Info("visualizations expired past VIZ_TTL", "count", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// emuPerPixel converts pixels to the EMUs used for drawing sizes.
+const emuPerPixel = 9525
+
+// xlsxBold is a string cell rendered in bold.
+type xlsxBold string
+
+// xlsxSheet is one worksheet. Cells are strings, xlsxBold, ints or float64s;
+// nil leaves a cell empty.
+type xlsxSheet struct {
+	Name      string
+	Rows      [][]any
+	ColWidths []float64
+	// Image is an optional PNG anchored at the top-left of column ImageCol.
+	Image          []byte
+	ImageW, ImageH int
+	ImageCol       int
+}
+
+// writeXLSX writes a minimal Office Open XML workbook: inline strings, one
+// bold style and at most one picture per sheet, which is all the load plan
+// export needs.
+func writeXLSX(w io.Writer, sheets []xlsxSheet) error {
+	zw := zip.NewWriter(w)
+	put := func(name, content string) error {
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(f, content)
+		return err
+	}
+
+	const header = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+	const relsNS = `http://schemas.openxmlformats.org/package/2006/relationships`
+	const docRel = `http://schemas.openxmlformats.org/officeDocument/2006/relationships`
+
+	var types, wbSheets, wbRels strings.Builder
+	types.WriteString(header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Default Extension="png" ContentType="image/png"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+
+	for i, sh := range sheets {
+		n := i + 1
+		fmt.Fprintf(&types, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&wbSheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(sh.Name), n, n)
+		fmt.Fprintf(&wbRels, `<Relationship Id="rId%d" Type="%s/worksheet" Target="worksheets/sheet%d.xml"/>`, n, docRel, n)
+
+		if err := put(fmt.Sprintf("xl/worksheets/sheet%d.xml", n), header+sheetXML(sh)); err != nil {
+			return err
+		}
+		if sh.Image == nil {
+			continue
+		}
+
+		fmt.Fprintf(&types, `<Override PartName="/xl/drawings/drawing%d.xml" ContentType="application/vnd.openxmlformats-officedocument.drawing+xml"/>`, n)
+		if err := put(fmt.Sprintf("xl/worksheets/_rels/sheet%d.xml.rels", n), header+
+			`<Relationships xmlns="`+relsNS+`">`+
+			fmt.Sprintf(`<Relationship Id="rId1" Type="%s/drawing" Target="../drawings/drawing%d.xml"/>`, docRel, n)+
+			`</Relationships>`); err != nil {
+			return err
+		}
+		if err := put(fmt.Sprintf("xl/drawings/drawing%d.xml", n), header+drawingXML(sh)); err != nil {
+			return err
+		}
+		if err := put(fmt.Sprintf("xl/drawings/_rels/drawing%d.xml.rels", n), header+
+			`<Relationships xmlns="`+relsNS+`">`+
+			fmt.Sprintf(`<Relationship Id="rId1" Type="%s/image" Target="../media/image%d.png"/>`, docRel, n)+
+			`</Relationships>`); err != nil {
+			return err
+		}
+		f, err := zw.Create(fmt.Sprintf("xl/media/image%d.png", n))
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(sh.Image); err != nil {
+			return err
+		}
+	}
+	types.WriteString(`</Types>`)
+	fmt.Fprintf(&wbRels, `<Relationship Id="rId%d" Type="%s/styles" Target="styles.xml"/>`, len(sheets)+1, docRel)
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", types.String()},
+		{"_rels/.rels", header + `<Relationships xmlns="` + relsNS + `">` +
+			`<Relationship Id="rId1" Type="` + docRel + `/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="` + docRel + `">` +
+			`<sheets>` + wbSheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", header + `<Relationships xmlns="` + relsNS + `">` + wbRels.String() + `</Relationships>`},
+		{"xl/styles.xml", header + xlsxStyles},
+	}
+	for _, p := range parts {
+		if err := put(p.name, p.content); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// xlsxStyles has two cell formats: 0 is the default, 1 is bold.
+const xlsxStyles = `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+	`</styleSheet>`
+
+func sheetXML(sh xlsxSheet) string {
+	var b strings.Builder
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`)
+	if len(sh.ColWidths) > 0 {
+		b.WriteString(`<cols>`)
+		for i, w := range sh.ColWidths {
+			fmt.Fprintf(&b, `<col min="%d" max="%d" width="%g" customWidth="1"/>`, i+1, i+1, w)
+		}
+		b.WriteString(`</cols>`)
+	}
+	b.WriteString(`<sheetData>`)
+	for r, row := range sh.Rows {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		for c, v := range row {
+			ref := xlsxColumn(c) + strconv.Itoa(r+1)
+			switch v := v.(type) {
+			case nil:
+			case xlsxBold:
+				fmt.Fprintf(&b, `<c r="%s" t="inlineStr" s="1"><is><t>%s</t></is></c>`, ref, xmlEscape(string(v)))
+			case string:
+				fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`, ref, xmlEscape(v))
+			case int:
+				fmt.Fprintf(&b, `<c r="%s"><v>%d</v></c>`, ref, v)
+			case float64:
+				fmt.Fprintf(&b, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'f', -1, 64))
+			default:
+				fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`, ref, xmlEscape(fmt.Sprint(v)))
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData>`)
+	if sh.Image != nil {
+		b.WriteString(`<drawing r:id="rId1"/>`)
+	}
+	b.WriteString(`</worksheet>`)
+	return b.String()
+}
+
+func drawingXML(sh xlsxSheet) string {
+	return fmt.Sprintf(`<xdr:wsDr xmlns:xdr="http://schemas.openxmlformats.org/drawingml/2006/spreadsheetDrawing" `+
+		`xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" `+
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`+
+		`<xdr:oneCellAnchor><xdr:from><xdr:col>%d</xdr:col><xdr:colOff>0</xdr:colOff><xdr:row>0</xdr:row><xdr:rowOff>0</xdr:rowOff></xdr:from>`+
+		`<xdr:ext cx="%d" cy="%d"/>`+
+		`<xdr:pic><xdr:nvPicPr><xdr:cNvPr id="2" name="Top view"/><xdr:cNvPicPr><a:picLocks noChangeAspect="1"/></xdr:cNvPicPr></xdr:nvPicPr>`+
+		`<xdr:blipFill><a:blip r:embed="rId1"/><a:stretch><a:fillRect/></a:stretch></xdr:blipFill>`+
+		`<xdr:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></xdr:spPr>`+
+		`</xdr:pic><xdr:clientData/></xdr:oneCellAnchor></xdr:wsDr>`,
+		sh.ImageCol, sh.ImageW*emuPerPixel, sh.ImageH*emuPerPixel, sh.ImageW*emuPerPixel, sh.ImageH*emuPerPixel)
+}
+
+// xlsxColumn returns the column letters for a zero-based index: A, B, ... AA.
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
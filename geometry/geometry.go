@@ -0,0 +1,126 @@
+// Package geometry is the axis-aligned cuboid math behind the packer: overlap
+// and containment tests, free space subtraction and item orientations.
+//
+// Coordinates are integers in the packer's canonical frame: X runs along the
+// width (W), Y along the height (H) and Z along the depth (D). A cuboid spans
+// [X, X+W) × [Y, Y+H) × [Z, Z+D), so cuboids that only share a face do not
+// overlap.
+package geometry
+
+// Cuboid is an axis-aligned box with its minimum corner at X, Y, Z.
+type Cuboid struct {
+	X, Y, Z int
+	W, H, D int
+}
+
+// Volume returns W × H × D.
+func (c Cuboid) Volume() int {
+	return c.W * c.H * c.D
+}
+
+// Empty reports whether c has no volume.
+func (c Cuboid) Empty() bool {
+	return c.W <= 0 || c.H <= 0 || c.D <= 0
+}
+
+// Overlaps reports whether a and b share any volume.
+func Overlaps(a, b Cuboid) bool {
+	return a.X < b.X+b.W && a.X+a.W > b.X &&
+		a.Y < b.Y+b.H && a.Y+a.H > b.Y &&
+		a.Z < b.Z+b.D && a.Z+a.D > b.Z
+}
+
+// Intersect returns the volume a and b share, and false when they do not
+// overlap.
+func Intersect(a, b Cuboid) (Cuboid, bool) {
+	if !Overlaps(a, b) {
+		return Cuboid{}, false
+	}
+	x, y, z := max(a.X, b.X), max(a.Y, b.Y), max(a.Z, b.Z)
+	return Cuboid{
+		X: x, Y: y, Z: z,
+		W: min(a.X+a.W, b.X+b.W) - x,
+		H: min(a.Y+a.H, b.Y+b.H) - y,
+		D: min(a.Z+a.D, b.Z+b.D) - z,
+	}, true
+}
+
+// Contains reports whether inner lies entirely within outer. Every cuboid
+// contains itself.
+func Contains(outer, inner Cuboid) bool {
+	return outer.X <= inner.X && outer.Y <= inner.Y && outer.Z <= inner.Z &&
+		outer.X+outer.W >= inner.X+inner.W &&
+		outer.Y+outer.H >= inner.Y+inner.H &&
+		outer.Z+outer.D >= inner.Z+inner.D
+}
+
+// ContainsPoint reports whether the point x, y, z lies in c, including its
+// minimum faces but not its maximum ones.
+func ContainsPoint(c Cuboid, x, y, z int) bool {
+	return x >= c.X && x < c.X+c.W &&
+		y >= c.Y && y < c.Y+c.H &&
+		z >= c.Z && z < c.Z+c.D
+}
+
+// Subtract returns the parts of space outside cut as up to six maximal
+// slabs, one per face of cut. The slabs overlap each other but not cut, and
+// together with cut they cover space. space itself is returned when the two
+// do not overlap.
+func Subtract(space, cut Cuboid) []Cuboid {
+	if !Overlaps(space, cut) {
+		return []Cuboid{space}
+	}
+	s := space
+	slabs := []Cuboid{
+		{X: s.X, Y: s.Y, Z: s.Z, W: cut.X - s.X, H: s.H, D: s.D},
+		{X: cut.X + cut.W, Y: s.Y, Z: s.Z, W: s.X + s.W - (cut.X + cut.W), H: s.H, D: s.D},
+		{X: s.X, Y: s.Y, Z: s.Z, W: s.W, H: cut.Y - s.Y, D: s.D},
+		{X: s.X, Y: cut.Y + cut.H, Z: s.Z, W: s.W, H: s.Y + s.H - (cut.Y + cut.H), D: s.D},
+		{X: s.X, Y: s.Y, Z: s.Z, W: s.W, H: s.H, D: cut.Z - s.Z},
+		{X: s.X, Y: s.Y, Z: cut.Z + cut.D, W: s.W, H: s.H, D: s.Z + s.D - (cut.Z + cut.D)},
+	}
+	out := slabs[:0]
+	for _, slab := range slabs {
+		if !slab.Empty() {
+			out = append(out, slab)
+		}
+	}
+	return out
+}
+
+// RemoveContained drops every cuboid that lies inside another one of the
+// list, keeping the first of identical cuboids.
+func RemoveContained(cuboids []Cuboid) []Cuboid {
+	out := make([]Cuboid, 0, len(cuboids))
+	for i, c := range cuboids {
+		contained := false
+		for j, other := range cuboids {
+			if i == j || (other == c && j > i) {
+				continue
+			}
+			if Contains(other, c) {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// RotationNames name the orientations in the order RotationsOf returns them:
+// the letters are the original sides that end up along the width, height
+// and depth. "whd" is the unrotated orientation.
+var RotationNames = [6]string{"whd", "wdh", "hwd", "hdw", "dwh", "dhw"}
+
+// RotationsOf returns the six axis-aligned orientations of a w × h × d item
+// as width, height and depth extents, in RotationNames order. Items with
+// equal sides yield repeated orientations.
+func RotationsOf(w, h, d int) [6][3]int {
+	return [6][3]int{
+		{w, h, d}, {w, d, h}, {h, w, d},
+		{h, d, w}, {d, w, h}, {d, h, w},
+	}
+}
@@ -0,0 +1,156 @@
+package geometry
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIntersect(t *testing.T) {
+	a := Cuboid{X: 0, Y: 0, Z: 0, W: 10, H: 10, D: 10}
+	b := Cuboid{X: 5, Y: 8, Z: -2, W: 10, H: 10, D: 4}
+
+	got, ok := Intersect(a, b)
+	want := Cuboid{X: 5, Y: 8, Z: 0, W: 5, H: 2, D: 2}
+	if !ok || got != want {
+		t.Errorf("Expected %+v, got %+v (%v)", want, got, ok)
+	}
+
+	touching := Cuboid{X: 10, Y: 0, Z: 0, W: 5, H: 5, D: 5}
+	if _, ok := Intersect(a, touching); ok {
+		t.Error("Expected cuboids sharing only a face not to intersect")
+	}
+}
+
+func TestContains(t *testing.T) {
+	outer := Cuboid{W: 10, H: 10, D: 10}
+	if !Contains(outer, outer) {
+		t.Error("Expected a cuboid to contain itself")
+	}
+	if !Contains(outer, Cuboid{X: 2, Y: 2, Z: 2, W: 8, H: 8, D: 8}) {
+		t.Error("Expected a cuboid flush with the far corner to be contained")
+	}
+	if Contains(outer, Cuboid{X: 2, W: 9, H: 1, D: 1}) {
+		t.Error("Expected a cuboid sticking out not to be contained")
+	}
+	if !ContainsPoint(outer, 0, 0, 0) || ContainsPoint(outer, 10, 0, 0) {
+		t.Error("Expected the minimum faces to be inside and the maximum faces outside")
+	}
+}
+
+func TestSubtract(t *testing.T) {
+	space := Cuboid{W: 10, H: 10, D: 10}
+	got := Subtract(space, Cuboid{W: 4, H: 10, D: 10})
+	if want := []Cuboid{{X: 4, W: 6, H: 10, D: 10}}; !slices.Equal(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	// A cut in the middle leaves one slab per face.
+	if got := Subtract(space, Cuboid{X: 4, Y: 4, Z: 4, W: 2, H: 2, D: 2}); len(got) != 6 {
+		t.Errorf("Expected 6 slabs, got %+v", got)
+	}
+
+	apart := Cuboid{X: 20, W: 1, H: 1, D: 1}
+	if got := Subtract(space, apart); !slices.Equal(got, []Cuboid{space}) {
+		t.Errorf("Expected the space back unchanged, got %+v", got)
+	}
+}
+
+func TestRemoveContained(t *testing.T) {
+	big := Cuboid{W: 10, H: 10, D: 10}
+	small := Cuboid{X: 1, W: 2, H: 2, D: 2}
+	other := Cuboid{X: 20, W: 2, H: 2, D: 2}
+
+	got := RemoveContained([]Cuboid{small, big, big, other})
+	if want := []Cuboid{big, other}; !slices.Equal(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
+
+func TestRotationsOf(t *testing.T) {
+	rots := RotationsOf(1, 2, 3)
+	if rots[0] != [3]int{1, 2, 3} {
+		t.Errorf("Expected the unrotated orientation first, got %v", rots[0])
+	}
+	for i, r := range rots {
+		if r[0]*r[1]*r[2] != 6 {
+			t.Errorf("Orientation %s changes the volume: %v", RotationNames[i], r)
+		}
+		// The name spells which original side ends up on each axis.
+		side := map[byte]int{'w': 1, 'h': 2, 'd': 3}
+		name := RotationNames[i]
+		if got := [3]int{side[name[0]], side[name[1]], side[name[2]]}; got != r {
+			t.Errorf("Orientation %s: expected %v, got %v", name, got, r)
+		}
+	}
+}
+
+// cuboid builds a small non-empty cuboid from fuzz input.
+func cuboid(x, y, z int8, w, h, d uint8) Cuboid {
+	return Cuboid{X: int(x), Y: int(y), Z: int(z), W: int(w%16) + 1, H: int(h%16) + 1, D: int(d%16) + 1}
+}
+
+func FuzzIntersect(f *testing.F) {
+	f.Add(int8(0), int8(0), int8(0), uint8(9), uint8(9), uint8(9), int8(5), int8(8), int8(-2), uint8(9), uint8(9), uint8(3))
+	f.Fuzz(func(t *testing.T, ax, ay, az int8, aw, ah, ad uint8, bx, by, bz int8, bw, bh, bd uint8) {
+		a, b := cuboid(ax, ay, az, aw, ah, ad), cuboid(bx, by, bz, bw, bh, bd)
+
+		i, ok := Intersect(a, b)
+		j, ok2 := Intersect(b, a)
+		if ok != ok2 || i != j {
+			t.Fatalf("Intersect is not symmetric: %+v/%v and %+v/%v", i, ok, j, ok2)
+		}
+		if ok != Overlaps(a, b) {
+			t.Fatalf("Intersect and Overlaps disagree for %+v and %+v", a, b)
+		}
+		if ok && (i.Empty() || !Contains(a, i) || !Contains(b, i)) {
+			t.Fatalf("Intersection %+v of %+v and %+v is not inside both", i, a, b)
+		}
+	})
+}
+
+func FuzzSubtract(f *testing.F) {
+	f.Add(int8(0), int8(0), int8(0), uint8(9), uint8(9), uint8(9), int8(4), int8(4), int8(4), uint8(1), uint8(1), uint8(1))
+	f.Fuzz(func(t *testing.T, sx, sy, sz int8, sw, sh, sd uint8, cx, cy, cz int8, cw, ch, cd uint8) {
+		space, cut := cuboid(sx, sy, sz, sw, sh, sd), cuboid(cx, cy, cz, cw, ch, cd)
+		slabs := Subtract(space, cut)
+
+		for _, s := range slabs {
+			if s.Empty() || !Contains(space, s) || Overlaps(s, cut) {
+				t.Fatalf("Slab %+v of %+v minus %+v is empty, outside the space or overlaps the cut", s, space, cut)
+			}
+		}
+		// Every unit cell of the space lies in the cut or in a slab.
+		for x := space.X; x < space.X+space.W; x++ {
+			for y := space.Y; y < space.Y+space.H; y++ {
+				for z := space.Z; z < space.Z+space.D; z++ {
+					covered := ContainsPoint(cut, x, y, z)
+					for _, s := range slabs {
+						covered = covered || ContainsPoint(s, x, y, z)
+					}
+					if !covered {
+						t.Fatalf("Cell %d,%d,%d of %+v is lost subtracting %+v", x, y, z, space, cut)
+					}
+				}
+			}
+		}
+	})
+}
+
+func TestGridNear(t *testing.T) {
+	g := NewGrid(Cuboid{W: 100, H: 100, D: 100}, [3]int{10, 10, 10})
+	g.Insert(0, Cuboid{X: 0, Y: 0, Z: 0, W: 10, H: 10, D: 10})
+	g.Insert(1, Cuboid{X: 5, Y: 0, Z: 5, W: 30, H: 10, D: 30})
+	g.Insert(2, Cuboid{X: 90, Y: 90, Z: 90, W: 10, H: 10, D: 10})
+
+	got := slices.Sorted(g.Near(Cuboid{X: 8, Y: 2, Z: 8, W: 4, H: 4, D: 4}))
+	if want := []int{0, 1}; !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	got = slices.Sorted(g.Near(Cuboid{X: 95, Y: 95, Z: 95, W: 20, H: 20, D: 20}))
+	if want := []int{2}; !slices.Equal(got, want) {
+		t.Errorf("Expected %v for a region past the bounds, got %v", want, got)
+	}
+	if got := slices.Collect(g.Near(Cuboid{X: 50, Y: 50, Z: 50})); len(got) != 0 {
+		t.Errorf("Expected nothing near an empty region, got %v", got)
+	}
+}
@@ -0,0 +1,97 @@
+package geometry
+
+import "iter"
+
+// maxGridCells bounds the cells of a Grid along each axis.
+const maxGridCells = 64
+
+// Grid indexes cuboids by the cells of a uniform grid they cover, so the
+// cuboids near a region are found without looking at every one. Cuboids are
+// identified by consecutive integers from 0. A Grid is not safe for
+// concurrent use, and Near must not be called again while its result is
+// being iterated.
+type Grid struct {
+	bounds Cuboid
+	cell   [3]int
+	n      [3]int
+	cells  [][]int32
+
+	// seen[id] == gen marks the ids Near has already yielded.
+	seen []uint32
+	gen  uint32
+}
+
+// NewGrid returns an empty grid over bounds with cells of about cell along
+// each axis, larger where bounds would otherwise need more than
+// maxGridCells of them.
+func NewGrid(bounds Cuboid, cell [3]int) *Grid {
+	g := &Grid{bounds: bounds}
+	for axis, size := range [3]int{bounds.W, bounds.H, bounds.D} {
+		c := max(1, cell[axis], (size+maxGridCells-1)/maxGridCells)
+		g.cell[axis] = c
+		g.n[axis] = max(1, (size+c-1)/c)
+	}
+	g.cells = make([][]int32, g.n[0]*g.n[1]*g.n[2])
+	return g
+}
+
+// Insert adds c under id, the next unused one.
+func (g *Grid) Insert(id int, c Cuboid) {
+	g.seen = append(g.seen, 0)
+	lo, hi := g.span(c)
+	for x := lo[0]; x <= hi[0]; x++ {
+		for y := lo[1]; y <= hi[1]; y++ {
+			for z := lo[2]; z <= hi[2]; z++ {
+				i := g.index(x, y, z)
+				g.cells[i] = append(g.cells[i], int32(id))
+			}
+		}
+	}
+}
+
+// Near yields once each id whose cuboid shares a cell with c. The cuboids
+// may not overlap c themselves; callers test the candidates.
+func (g *Grid) Near(c Cuboid) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		if c.Empty() {
+			return
+		}
+		g.gen++
+		lo, hi := g.span(c)
+		for x := lo[0]; x <= hi[0]; x++ {
+			for y := lo[1]; y <= hi[1]; y++ {
+				for z := lo[2]; z <= hi[2]; z++ {
+					for _, id := range g.cells[g.index(x, y, z)] {
+						if g.seen[id] == g.gen {
+							continue
+						}
+						g.seen[id] = g.gen
+						if !yield(int(id)) {
+							return
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// span returns the first and last cell c covers along each axis. Parts of c
+// outside the bounds fall into the border cells.
+func (g *Grid) span(c Cuboid) (lo, hi [3]int) {
+	from := [3]int{c.X - g.bounds.X, c.Y - g.bounds.Y, c.Z - g.bounds.Z}
+	size := [3]int{c.W, c.H, c.D}
+	for axis := range 3 {
+		lo[axis] = clampCell(from[axis]/g.cell[axis], g.n[axis])
+		hi[axis] = clampCell((from[axis]+max(size[axis], 1)-1)/g.cell[axis], g.n[axis])
+	}
+	return lo, hi
+}
+
+func clampCell(i, n int) int {
+	return min(max(i, 0), n-1)
+}
+
+func (g *Grid) index(x, y, z int) int {
+	return (x*g.n[1]+y)*g.n[2] + z
+}
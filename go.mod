@@ -2,4 +2,4 @@ module binpacker
 
 go 1.25.4
 
-require github.com/google/uuid v1.6.0
+require github.com/gorilla/websocket v1.5.3
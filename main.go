@@ -1,22 +0,0 @@
-package main
-
-import (
-	"log"
-	"net/http"
-	"os"
-)
-
-func main() {
-	mux := http.NewServeMux()
-	mux.HandleFunc("/", RapidAPIMiddleware(Packer))
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-
-	log.Printf("server starting on :%s", port)
-	if err := http.ListenAndServe(":"+port, mux); err != nil {
-		log.Fatalf("server stopped: %v", err)
-	}
-}
@@ -0,0 +1,75 @@
+package packing
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Aisle axes: the box side a channel runs along.
+const (
+	AisleAlongWidth = "width"
+	AisleAlongDepth = "depth"
+)
+
+// Aisle is a straight channel at floor level that must stay empty, running
+// the whole length of the box, e.g. for forklift tines or inspection.
+type Aisle struct {
+	// Axis is the side the channel runs along: "width" or "depth".
+	Axis string `json:"axis"`
+	// Width is the channel size across the other horizontal side, Height
+	// its clear height.
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// Offset is the distance from the left wall (for "depth") or the back
+	// wall (for "width") to the channel. Without one it is centered.
+	Offset *int `json:"offset,omitempty"`
+}
+
+func (a *Aisle) validate() error {
+	if a == nil {
+		return nil
+	}
+	if a.Axis != AisleAlongWidth && a.Axis != AisleAlongDepth {
+		return fmt.Errorf("unknown aisle axis %q: use %q or %q", a.Axis, AisleAlongWidth, AisleAlongDepth)
+	}
+	if a.Width <= 0 || a.Height <= 0 {
+		return errors.New("aisle width and height must be positive")
+	}
+	if a.Offset != nil && *a.Offset < 0 {
+		return errors.New("aisle offset must not be negative")
+	}
+	return nil
+}
+
+// region returns the keep-out volume of the aisle in box as a placement, and
+// false when the box is too small to hold the channel.
+func (a *Aisle) region(box InputBox) (Placement, bool) {
+	across, along := box.W, box.D
+	if a.Axis == AisleAlongWidth {
+		across, along = box.D, box.W
+	}
+	off := (across - a.Width) / 2
+	if a.Offset != nil {
+		off = *a.Offset
+	}
+	if off < 0 || off+a.Width > across || a.Height > box.H {
+		return Placement{}, false
+	}
+	if a.Axis == AisleAlongWidth {
+		return Placement{ItemID: "aisle", Z: off, W: along, H: a.Height, D: a.Width}, true
+	}
+	return Placement{ItemID: "aisle", X: off, W: a.Width, H: a.Height, D: along}, true
+}
+
+// CheckAisle reports the first box the aisle does not fit in.
+func CheckAisle(a *Aisle, boxes []InputBox) error {
+	if a == nil {
+		return nil
+	}
+	for _, b := range boxes {
+		if _, ok := a.region(b); !ok {
+			return fmt.Errorf("box %q is too small for the aisle", b.ID)
+		}
+	}
+	return nil
+}
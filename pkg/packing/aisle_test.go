@@ -0,0 +1,31 @@
+package packing
+
+import "testing"
+
+func TestAisleKeepsChannelClear(t *testing.T) {
+	// A 30-wide box with a 10-wide channel down the middle leaves two
+	// 10-wide lanes, one either side.
+	boxes := []InputBox{{ID: "box", W: 30, H: 10, D: 10}}
+	items := []InputItem{{ID: "cube", W: 10, H: 10, D: 10, Quantity: 3}}
+	opts := Options{Aisle: &Aisle{Axis: AisleAlongDepth, Width: 10, Height: 10}}
+
+	packed, unpacked := PackWithOptions(items, boxes, opts)
+	if len(packed) != 2 || len(unpacked) != 0 {
+		t.Fatalf("Expected 2 boxes and nothing unpacked, got %d and %d", len(packed), len(unpacked))
+	}
+	if len(packed[0].Contents) != 2 {
+		t.Errorf("Expected both lanes of the first box to be used, got %+v", packed[0].Contents)
+	}
+	aisle, _ := opts.Aisle.region(boxes[0])
+	for _, pb := range packed {
+		for _, p := range pb.Contents {
+			if boxesOverlap(aisle, p.X, p.Y, p.Z, p.W, p.H, p.D) {
+				t.Errorf("Expected nothing in the aisle, got %+v", p)
+			}
+		}
+	}
+
+	if err := CheckAisle(&Aisle{Axis: AisleAlongWidth, Width: 20, Height: 5}, boxes); err == nil {
+		t.Error("Expected an aisle wider than the box to be rejected")
+	}
+}
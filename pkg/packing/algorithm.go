@@ -0,0 +1,369 @@
+package packing
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Algorithms for distributing items over boxes, selected by
+// Options.Algorithm. They trade packing quality for speed, and make it
+// possible to benchmark the heuristics against each other on real orders.
+const (
+	// AlgorithmExtremePoints fills one box at a time, trying every box type
+	// for the items left and keeping the best by the objective. Each box is
+	// filled by the selected strategy. It is the default and the slowest.
+	AlgorithmExtremePoints = "extreme_points"
+	// AlgorithmFFDShelf is First-Fit Decreasing: items, tallest first, go
+	// into the first open box with room, which is filled in shelves: rows
+	// from the left wall, rows from the back, and layers from the floor.
+	AlgorithmFFDShelf = "ffd_shelf"
+	// AlgorithmBestFit puts each item, largest first, into the open box it
+	// fills the most, at its best extreme point.
+	AlgorithmBestFit = "best_fit"
+	// AlgorithmGuillotine is best fit across boxes with guillotine cuts
+	// within them: each item takes the corner of the free block it fills
+	// best, and the rest of the block is cut into the space beside, in
+	// front of and on top of it.
+	AlgorithmGuillotine = "guillotine"
+)
+
+// Algorithm packs items, in the order PackContext sorted them, into boxes,
+// sorted by volume. check, if it returns an error, rejects options
+// the algorithm cannot honour.
+type Algorithm interface {
+	Pack(ctx context.Context, items []itemToPack, boxes []InputBox, opts Options) ([]PackedBox, []InputItem)
+	check(o Options) error
+}
+
+var algorithms = map[string]Algorithm{
+	AlgorithmExtremePoints: boxByBox{},
+	AlgorithmFFDShelf:      openBoxes{name: AlgorithmFFDShelf, firstFit: true, open: newShelfBox, order: sortItemsByHeight},
+	AlgorithmBestFit:       openBoxes{name: AlgorithmBestFit, open: newPointBox, policies: true},
+	AlgorithmGuillotine:    openBoxes{name: AlgorithmGuillotine, open: newGuillotineBox},
+}
+
+// algorithmFor returns the algorithm selected by opts, extreme points by
+// default.
+func algorithmFor(opts Options) Algorithm {
+	if a, ok := algorithms[opts.Algorithm]; ok {
+		return a
+	}
+	return algorithms[AlgorithmExtremePoints]
+}
+
+func (o Options) validateAlgorithm() error {
+	if o.Algorithm == "" {
+		return nil
+	}
+	a, ok := algorithms[o.Algorithm]
+	if !ok {
+		names := slices.Sorted(maps.Keys(algorithms))
+		return fmt.Errorf("unknown algorithm %q: use one of %v", o.Algorithm, names)
+	}
+	return a.check(o)
+}
+
+// boxByBox is the default algorithm; see packSorted.
+type boxByBox struct{}
+
+func (boxByBox) Pack(ctx context.Context, items []itemToPack, boxes []InputBox, opts Options) ([]PackedBox, []InputItem) {
+	return packSorted(ctx, items, boxes, opts)
+}
+
+func (boxByBox) check(Options) error { return nil }
+
+// boxFiller places items into one box as they come.
+type boxFiller interface {
+	place(item itemToPack) bool
+	state() *boxState
+}
+
+// openBoxes packs items one at a time into the boxes opened so far, and
+// opens the largest box type in stock that takes the item when none does.
+// Once every item is placed, each box is swapped for the type the objective
+// prefers among those that hold its contents, usually a smaller one.
+type openBoxes struct {
+	name string
+	// firstFit tries the open boxes in the order they were opened, rather
+	// than the fullest first.
+	firstFit bool
+	open     func(ctx context.Context, box InputBox, opts Options, minSide int) boxFiller
+	// order, if set, re-sorts the items before packing.
+	order func([]itemToPack)
+	// policies is set if the filler honours placement_policy and
+	// group_skus.
+	policies bool
+}
+
+func (a openBoxes) check(o Options) error {
+	switch {
+	case o.Strategy != "" && o.Strategy != StrategyExtremePoints:
+		return fmt.Errorf("strategy %q only applies to algorithm %q", o.Strategy, AlgorithmExtremePoints)
+	case o.Objective == ObjectiveBalance:
+		return fmt.Errorf("algorithm %q cannot be combined with objective \"balance\"", a.name)
+	case o.KeepGroupsTogether:
+		return fmt.Errorf("keep_groups_together needs algorithm %q", AlgorithmExtremePoints)
+	case o.Spillover != "":
+		return fmt.Errorf("spillover needs algorithm %q", AlgorithmExtremePoints)
+	case !a.policies && (o.PlacementPolicy != "" && o.PlacementPolicy != PlacementBackLeft || o.GroupSKUs):
+		return fmt.Errorf("algorithm %q packs from the back left corner; placement_policy and group_skus need %q or %q", a.name, AlgorithmExtremePoints, AlgorithmBestFit)
+	case a.name == AlgorithmFFDShelf && o.Aisle != nil:
+		return errors.New("shelves run wall to wall and cannot leave an aisle; use another algorithm")
+	}
+	return nil
+}
+
+func (a openBoxes) Pack(ctx context.Context, items []itemToPack, boxes []InputBox, opts Options) ([]PackedBox, []InputItem) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	if a.order != nil {
+		items = slices.Clone(items)
+		a.order(items)
+	}
+	minSide := suffixMinSides(items)[0]
+	stock := newBoxStock(boxes)
+
+	var open []boxFiller
+	var types []int
+	var unpacked []InputItem
+	for _, item := range items {
+		if ctx.Err() != nil || !a.placeInOpen(open, item) && !a.openFor(ctx, item, boxes, stock, &open, &types, minSide, opts) {
+			unpacked = append(unpacked, item.InputItem)
+		}
+	}
+
+	packed := make([]PackedBox, len(open))
+	for i, f := range open {
+		if ctx.Err() == nil {
+			f, types[i] = a.shrink(ctx, f, types[i], boxes, stock, minSide, opts)
+		}
+		packed[i] = newPackedBox(boxes[types[i]].ID, f.state().placements)
+	}
+	return packed, unpacked
+}
+
+// placeInOpen places item into the first open box that takes it, trying the
+// fullest first unless firstFit is set.
+func (a openBoxes) placeInOpen(open []boxFiller, item itemToPack) bool {
+	order := slices.Clone(open)
+	if !a.firstFit {
+		slices.SortStableFunc(order, func(x, y boxFiller) int {
+			sx, sy := x.state(), y.state()
+			return cmp.Compare(sx.capVol-sx.packedVol, sy.capVol-sy.packedVol)
+		})
+	}
+	for _, f := range order {
+		if f.place(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// openFor opens a box for item, unless max_boxes is reached or no type in
+// stock takes it.
+func (a openBoxes) openFor(ctx context.Context, item itemToPack, boxes []InputBox, stock boxStock, open *[]boxFiller, types *[]int, minSide int, opts Options) bool {
+	if opts.MaxBoxes > 0 && len(*open) == opts.MaxBoxes {
+		return false
+	}
+	for i := len(boxes) - 1; i >= 0; i-- {
+		if stock[i] == 0 {
+			continue
+		}
+		f := a.open(ctx, boxes[i], opts, minSide)
+		if f.place(item) {
+			stock.take(i)
+			*open = append(*open, f)
+			*types = append(*types, i)
+			return true
+		}
+	}
+	return false
+}
+
+// shrink repacks the contents of f, a box of type cur, into the type the
+// objective prefers among those in stock that hold all of them.
+func (a openBoxes) shrink(ctx context.Context, f boxFiller, cur int, boxes []InputBox, stock boxStock, minSide int, opts Options) (boxFiller, int) {
+	contents := f.state().items
+	vol := f.state().packedVol
+	best, bestFiller := cur, f
+	for j, box := range boxes {
+		if j == cur || stock[j] == 0 || !opts.prefers(box, vol, boxes[best], vol) {
+			continue
+		}
+		trial := a.open(ctx, box, opts, minSide)
+		fits := true
+		for _, it := range contents {
+			if !trial.place(it) {
+				fits = false
+				break
+			}
+		}
+		if fits {
+			best, bestFiller = j, trial
+		}
+	}
+	if best != cur {
+		if stock[cur] >= 0 {
+			stock[cur]++
+		}
+		stock.take(best)
+	}
+	return bestFiller, best
+}
+
+// sortItemsByHeight orders items tallest first, then by volume, for shelf
+// packing.
+func sortItemsByHeight(items []itemToPack) {
+	slices.SortStableFunc(items, func(a, b itemToPack) int {
+		return cmp.Compare(b.H, a.H)
+	})
+}
+
+// pointBox fills a box at extreme points, like the default algorithm.
+type pointBox struct {
+	*boxState
+	minSide int
+}
+
+func newPointBox(ctx context.Context, box InputBox, opts Options, minSide int) boxFiller {
+	return pointBox{newBoxState(ctx, box, opts), minSide}
+}
+
+func (b pointBox) place(item itemToPack) bool { return b.boxState.place(item, b.minSide) }
+
+func (b pointBox) state() *boxState { return b.boxState }
+
+// shelfBox fills a box in rows along its width, rows of a layer from the
+// back, and layers from the floor. A row is as deep and a layer as high as
+// the first item in it. Items settle onto whatever is below them, which
+// can be lower than the layer floor.
+type shelfBox struct {
+	*boxState
+	shelf
+}
+
+// shelf is the position of a shelfBox: the floor and height of the current
+// layer, the back and depth of the current row, and the next free x in it.
+type shelf struct {
+	layerY, layerH int
+	rowZ, rowD     int
+	x              int
+}
+
+func newShelfBox(ctx context.Context, box InputBox, opts Options, _ int) boxFiller {
+	return &shelfBox{boxState: newBoxState(ctx, box, opts)}
+}
+
+func (b *shelfBox) state() *boxState { return b.boxState }
+
+// place tries the current row, then a new row, then a new layer.
+func (b *shelfBox) place(item itemToPack) bool {
+	if !b.admits(item) {
+		return false
+	}
+	cur := b.shelf
+	for _, s := range []shelf{
+		cur,
+		{layerY: cur.layerY, layerH: cur.layerH, rowZ: cur.rowZ + cur.rowD},
+		{layerY: cur.layerY + cur.layerH},
+	} {
+		for _, rot := range Rotations(item.InputItem) {
+			w, h, d := rot[0], rot[1], rot[2]
+			if (s.layerH > 0 && h > s.layerH) || (s.rowD > 0 && d > s.rowD) {
+				continue
+			}
+			x, z := awayFromWalls(item, b.box, s.x, s.rowZ, w, d)
+			if x < s.x || z < s.rowZ {
+				continue
+			}
+			y := restingHeight(b.placements, x, z, w, d)
+			if !b.allowedAt(item, x, y, z, w, h, d) {
+				continue
+			}
+			b.add(item, x, y, z, w, h, d)
+			b.shelf = shelf{layerY: s.layerY, layerH: max(s.layerH, h), rowZ: s.rowZ, rowD: max(s.rowD, z-s.rowZ+d), x: x + w}
+			return true
+		}
+	}
+	return false
+}
+
+// restingHeight returns the height an item with footprint w × d at (x, z)
+// comes to rest at: the highest top below it, or the floor.
+func restingHeight(placements []Placement, x, z, w, d int) int {
+	y := 0
+	for _, p := range placements {
+		if footprintsOverlap(p, x, z, w, d) {
+			y = max(y, p.Y+p.H)
+		}
+	}
+	return y
+}
+
+// guillotineBox keeps the free space of a box as blocks, and cuts the block
+// an item goes into in three: beside it at full height and depth, in front
+// of it at its width, and on top of it within its footprint. Every block
+// thus rests on the floor or on a single item.
+type guillotineBox struct {
+	*boxState
+	free []FreeSpace
+}
+
+func newGuillotineBox(ctx context.Context, box InputBox, opts Options, _ int) boxFiller {
+	s := newBoxState(ctx, box, opts)
+	// The free space of a new box is the box less the aisle.
+	return &guillotineBox{boxState: s, free: slices.Clone(s.spaces)}
+}
+
+func (b *guillotineBox) state() *boxState { return b.boxState }
+
+// place puts item in the corner of the free block it leaves the least of.
+func (b *guillotineBox) place(item itemToPack) bool {
+	if !b.admits(item) {
+		return false
+	}
+	best, bestLeft := -1, 0
+	var bestRot [3]int
+	var bestX, bestZ int
+	for i, f := range b.free {
+		for _, rot := range Rotations(item.InputItem) {
+			w, h, d := rot[0], rot[1], rot[2]
+			// An item kept off the walls sits away from the corner, and
+			// the gap is cut off with it.
+			x, z := awayFromWalls(item, b.box, f.X, f.Z, w, d)
+			if x < f.X || z < f.Z || x+w > f.X+f.W || h > f.H || z+d > f.Z+f.D {
+				continue
+			}
+			left := f.W*f.H*f.D - (x+w-f.X)*h*(z+d-f.Z)
+			if (best != -1 && left >= bestLeft) || !b.allowedAt(item, x, f.Y, z, w, h, d) {
+				continue
+			}
+			best, bestLeft, bestRot, bestX, bestZ = i, left, rot, x, z
+		}
+	}
+	if best == -1 {
+		return false
+	}
+
+	f := b.free[best]
+	h := bestRot[1]
+	b.add(item, bestX, f.Y, bestZ, bestRot[0], h, bestRot[2])
+	w, d := bestX+bestRot[0]-f.X, bestZ+bestRot[2]-f.Z
+	b.free = slices.Delete(b.free, best, best+1)
+	for _, c := range []FreeSpace{
+		{X: f.X + w, Y: f.Y, Z: f.Z, W: f.W - w, H: f.H, D: f.D},
+		{X: f.X, Y: f.Y, Z: f.Z + d, W: w, H: f.H, D: f.D - d},
+		{X: f.X, Y: f.Y + h, Z: f.Z, W: w, H: f.H - h, D: d},
+	} {
+		if c.W > 0 && c.H > 0 && c.D > 0 {
+			b.free = append(b.free, c)
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package packing
+
+import (
+	"context"
+	"testing"
+)
+
+// TestAlgorithmConformance runs every algorithm over the strategy
+// conformance scenarios, with as many boxes as it opens. Every box must keep
+// the same invariants as a box filled by a strategy, and every unit must be
+// either packed or unpacked.
+func TestAlgorithmConformance(t *testing.T) {
+	for name, alg := range algorithms {
+		for _, sc := range conformanceScenarios() {
+			t.Run(name+"/"+sc.name, func(t *testing.T) {
+				opts := sc.opts
+				opts.Algorithm = name
+				if err := opts.Validate(); err != nil {
+					t.Skipf("algorithm does not support the scenario: %v", err)
+				}
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				if sc.cancel {
+					cancel()
+				}
+
+				items := expandItems(sc.items)
+				sortItemsByVolume(items)
+				boxes := []InputBox{sc.box}
+				packed, unpacked := alg.Pack(ctx, items, boxes, opts.withCompiledConstraints())
+
+				specs := make(map[string]itemToPack)
+				for _, it := range items {
+					specs[it.ID] = it
+				}
+				units := len(unpacked)
+				for _, pb := range packed {
+					contents := make([]itemToPack, len(pb.Contents))
+					all := make([]bool, len(pb.Contents))
+					vol := 0
+					for i, p := range pb.Contents {
+						contents[i], all[i] = specs[p.ItemID], true
+						vol += contents[i].volume
+					}
+					units += len(contents)
+					checkConformance(t, sc.box, contents, pb.Contents, all, vol, opts)
+				}
+				if units != len(items) {
+					t.Errorf("Expected %d units packed or unpacked, got %d", len(items), units)
+				}
+				if sc.cancel && len(packed) != 0 {
+					t.Errorf("Expected nothing packed once cancelled, got %d boxes", len(packed))
+				}
+			})
+		}
+	}
+}
+
+func TestAlgorithms(t *testing.T) {
+	items := []InputItem{
+		{ID: "carton", W: 10, H: 10, D: 10, Quantity: 12},
+		{ID: "flat", W: 20, H: 5, D: 20, Quantity: 2},
+	}
+	boxes := []InputBox{{ID: "small", W: 20, H: 20, D: 20}, {ID: "large", W: 40, H: 20, D: 40}}
+	for name := range algorithms {
+		packed, unpacked := PackWithOptions(items, boxes, Options{Algorithm: name})
+		if len(unpacked) != 0 {
+			t.Errorf("%s: expected everything packed, got %d unpacked", name, len(unpacked))
+		}
+		if err := CheckLayout(packed, boxes); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		// 20000 units of volume need more than one small box.
+		if len(packed) == 0 || len(packed) > 2 {
+			t.Errorf("%s: expected one or two boxes, got %d", name, len(packed))
+		}
+	}
+
+	// Open boxes are swapped for the smallest type that holds them.
+	packed, _ := PackWithOptions([]InputItem{{ID: "carton", W: 10, H: 10, D: 10, Quantity: 2}}, boxes, Options{Algorithm: AlgorithmGuillotine})
+	if len(packed) != 1 || packed[0].BoxID != "small" {
+		t.Errorf("Expected two cartons in one small box, got %+v", packed)
+	}
+
+	for _, bad := range []Options{
+		{Algorithm: "simulated_annealing"},
+		{Algorithm: AlgorithmBestFit, Strategy: StrategyWallBuilding},
+		{Algorithm: AlgorithmFFDShelf, Aisle: &Aisle{Axis: AisleAlongDepth, Width: 1, Height: 1}},
+		{Algorithm: AlgorithmGuillotine, PlacementPolicy: PlacementFrontRight},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("Expected %+v to be rejected", bad)
+		}
+	}
+}
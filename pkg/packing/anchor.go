@@ -0,0 +1,77 @@
+package packing
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Placement policies selecting the floor corner packing starts from. X runs
+// left to right and Z back to front; items are always packed bottom-up.
+const (
+	PlacementBackLeft    = "back_left"
+	PlacementBackRight   = "back_right"
+	PlacementFrontLeft   = "front_left"
+	PlacementFrontRight  = "front_right"
+	PlacementAlternating = "alternating"
+)
+
+// validatePlacementPolicy reports an error for unknown policy names. The
+// empty string selects the default back-left corner.
+func validatePlacementPolicy(policy string) error {
+	switch policy {
+	case "", PlacementBackLeft, PlacementBackRight, PlacementFrontLeft, PlacementFrontRight, PlacementAlternating, PlacementAxleLoad:
+		return nil
+	default:
+		return fmt.Errorf("unknown placement_policy %q", policy)
+	}
+}
+
+// anchorCorner describes which walls a placement is pushed against.
+type anchorCorner struct {
+	mirrorX bool // pack from the right wall instead of the left
+	mirrorZ bool // pack from the front wall instead of the back
+}
+
+// anchorFor resolves the corner used for a candidate at height y. The
+// alternating policy switches between back-left and front-right on every
+// layer, which interlocks layers the way pallet loads are built by hand.
+func anchorFor(policy string, y int, layers []int) anchorCorner {
+	switch policy {
+	case PlacementBackRight:
+		return anchorCorner{mirrorX: true}
+	case PlacementFrontLeft:
+		return anchorCorner{mirrorZ: true}
+	case PlacementFrontRight:
+		return anchorCorner{mirrorX: true, mirrorZ: true}
+	case PlacementAlternating:
+		layer, _ := slices.BinarySearch(layers, y)
+		odd := layer%2 == 1
+		return anchorCorner{mirrorX: odd, mirrorZ: odd}
+	default:
+		return anchorCorner{}
+	}
+}
+
+// distance returns how far an item at x/z with width w and depth d is from
+// the anchor walls, used to rank candidates.
+func (a anchorCorner) distance(box InputBox, x, z, w, d int) (int, int) {
+	if a.mirrorX {
+		x = box.W - (x + w)
+	}
+	if a.mirrorZ {
+		z = box.D - (z + d)
+	}
+	return x, z
+}
+
+// layerBases returns the sorted, distinct base heights of the placed items.
+// The index of a height in this list is its layer number.
+func layerBases(placements []Placement) []int {
+	bases := make([]int, 0, len(placements)+1)
+	bases = append(bases, 0)
+	for _, p := range placements {
+		bases = append(bases, p.Y)
+	}
+	slices.Sort(bases)
+	return slices.Compact(bases)
+}
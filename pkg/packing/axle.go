@@ -0,0 +1,145 @@
+package packing
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"slices"
+)
+
+// PlacementAxleLoad packs heavy items first, low and in the preferred
+// longitudinal zones of a truck or trailer, and estimates the resulting axle
+// loads. The box depth is the length of the vehicle; the back wall (z = 0)
+// is taken as its rear.
+const PlacementAxleLoad = "axle_load"
+
+// defaultAxleZones favours the centre-rear of the load space: five zones of
+// equal length, rear to front.
+var defaultAxleZones = []float64{2, 3, 3, 2, 1}
+
+// AxleLoad configures the axle_load placement policy. Positions are
+// distances from the back wall along the box depth.
+type AxleLoad struct {
+	// RearAxle and FrontAxle are where the load is carried, e.g. the
+	// trailer axle group and the kingpin. They default to 20% and 90% of
+	// the box depth.
+	RearAxle  int `json:"rear_axle,omitempty"`
+	FrontAxle int `json:"front_axle,omitempty"`
+	// Zones weight equal-length slices of the depth, rear to front; heavy
+	// items go to the highest weighted zone with room. Defaults to
+	// defaultAxleZones.
+	Zones []float64 `json:"zones,omitempty"`
+}
+
+// AxleLoads estimates how a box's load is shared between its two axles,
+// treating the box as a beam resting on them. A negative load means the
+// axle is lifted.
+type AxleLoads struct {
+	Rear  float64 `json:"rear"`
+	Front float64 `json:"front"`
+	// LoadCenter is the centre of gravity of the contents, measured from
+	// the back wall.
+	LoadCenter float64 `json:"load_center"`
+}
+
+func (a *AxleLoad) validate(policy string) error {
+	if a == nil {
+		return nil
+	}
+	if policy != PlacementAxleLoad {
+		return errors.New("axle_load requires placement_policy \"axle_load\"")
+	}
+	if a.RearAxle < 0 || a.FrontAxle < 0 {
+		return errors.New("axle positions must not be negative")
+	}
+	if a.RearAxle > 0 && a.FrontAxle > 0 && a.RearAxle >= a.FrontAxle {
+		return errors.New("rear_axle must be behind front_axle")
+	}
+	positive := false
+	for _, z := range a.Zones {
+		if z < 0 {
+			return errors.New("axle_load zones must not be negative")
+		}
+		positive = positive || z > 0
+	}
+	if len(a.Zones) > 0 && !positive {
+		return errors.New("axle_load zones need at least one positive weight")
+	}
+	return nil
+}
+
+// axles returns the rear and front axle positions for box.
+func (a *AxleLoad) axles(box InputBox) (float64, float64) {
+	rear, front := float64(box.D)/5, float64(box.D)*9/10
+	if a != nil && a.RearAxle > 0 {
+		rear = float64(a.RearAxle)
+	}
+	if a != nil && a.FrontAxle > 0 {
+		front = float64(a.FrontAxle)
+	}
+	return rear, front
+}
+
+func (a *AxleLoad) zones() []float64 {
+	if a != nil && len(a.Zones) > 0 {
+		return a.Zones
+	}
+	return defaultAxleZones
+}
+
+// zoneCost ranks an item spanning z to z+d of box: 0 in a highest weighted
+// zone, up to the box depth in a zone weighted zero, so it is on the same
+// scale as the distance terms of the default placement score.
+func (a *AxleLoad) zoneCost(box InputBox, z, d int) int {
+	zones := a.zones()
+	i := min((2*z+d)*len(zones)/(2*box.D), len(zones)-1)
+	return int((1 - zones[i]/slices.Max(zones)) * float64(box.D))
+}
+
+// zonePositions returns positions in free space ep, other than (x, y, z),
+// that centre an item of depth d in each zone as far as ep allows, since
+// extreme points alone would only offer the rear end of the space.
+func (a *AxleLoad) zonePositions(box InputBox, ep FreeSpace, x, y, z, d int) [][3]int {
+	if ep.D < d {
+		return nil
+	}
+	n := len(a.zones())
+	var out [][3]int
+	for i := range n {
+		center := (2*i + 1) * box.D / (2 * n)
+		pz := min(max(center-d/2, ep.Z), ep.Z+ep.D-d)
+		if pz != z && !slices.Contains(out, [3]int{x, y, pz}) {
+			out = append(out, [3]int{x, y, pz})
+		}
+	}
+	return out
+}
+
+// axleLoads computes the axle loads of a packed box.
+func (a *AxleLoad) axleLoads(pb PackedBox, box InputBox) *AxleLoads {
+	var moment float64
+	for _, p := range pb.Contents {
+		moment += p.Weight * (float64(p.Z) + float64(p.D)/2)
+	}
+	loads := &AxleLoads{}
+	if pb.TotalWeight == 0 {
+		return loads
+	}
+	rear, front := a.axles(box)
+	loads.LoadCenter = round1(moment / pb.TotalWeight)
+	loads.Front = round1(pb.TotalWeight * (moment/pb.TotalWeight - rear) / (front - rear))
+	loads.Rear = round1(pb.TotalWeight - loads.Front)
+	return loads
+}
+
+// sortItemsByWeight moves heavy items to the front, keeping the volume order
+// among items of equal weight.
+func sortItemsByWeight(items []itemToPack) {
+	slices.SortStableFunc(items, func(a, b itemToPack) int {
+		return cmp.Compare(b.Weight, a.Weight)
+	})
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,106 @@
+package packing
+
+import (
+	"cmp"
+	"context"
+	"slices"
+)
+
+// ObjectiveBalance splits the load evenly over a fixed number of containers
+// (for example two trucks leaving together) instead of filling one box
+// completely before opening the next.
+const ObjectiveBalance = "balance"
+
+// packBalanced spreads items over exactly opts.Containers boxes of a single
+// type. The smallest box type that takes every item is used; if none does,
+// the type that packs the most volume wins and the rest is left unpacked.
+// Types with fewer than opts.Containers boxes in stock are skipped.
+func packBalanced(ctx context.Context, items []itemToPack, boxes []InputBox, opts Options) ([]PackedBox, []InputItem) {
+	var bestStates []*boxState
+	var bestUnpacked []itemToPack
+	bestPackedVol := -1
+
+	for _, box := range boxes {
+		if box.Quantity > 0 && box.Quantity < opts.Containers {
+			continue
+		}
+		states, unpacked := distributeBalanced(ctx, items, box, opts)
+
+		packedVol := 0
+		for _, st := range states {
+			packedVol += st.packedVol
+		}
+		if packedVol > bestPackedVol {
+			bestStates, bestUnpacked, bestPackedVol = states, unpacked, packedVol
+		}
+		if len(unpacked) == 0 {
+			break
+		}
+	}
+
+	var packedBoxes []PackedBox
+	for _, st := range bestStates {
+		if len(st.placements) == 0 {
+			continue
+		}
+		packedBoxes = append(packedBoxes, newPackedBox(st.box.ID, st.placements))
+	}
+
+	var unpackedItems []InputItem
+	for _, item := range bestUnpacked {
+		unpackedItems = append(unpackedItems, item.InputItem)
+	}
+
+	return packedBoxes, unpackedItems
+}
+
+// distributeBalanced gives each item, largest first, to the least loaded
+// container it fits in. A container's load is the share of its volume in use
+// plus the share of its MaxWeight, or without one of the total item weight,
+// that it carries, so dense items are spread out as well as large ones.
+func distributeBalanced(ctx context.Context, items []itemToPack, box InputBox, opts Options) ([]*boxState, []itemToPack) {
+	states := make([]*boxState, opts.Containers)
+	for i := range states {
+		states[i] = newBoxState(ctx, box, opts)
+	}
+
+	weightCap := box.MaxWeight
+	if weightCap <= 0 {
+		for _, item := range items {
+			weightCap += item.Weight
+		}
+	}
+	load := func(st *boxState) float64 {
+		l := float64(st.packedVol) / float64(box.W*box.H*box.D)
+		if weightCap > 0 {
+			l += st.weight / weightCap
+		}
+		return l
+	}
+
+	minSides := suffixMinSides(items)
+	order := make([]int, len(states))
+
+	var unpacked []itemToPack
+	for i, item := range items {
+		for j := range order {
+			order[j] = j
+		}
+		slices.SortStableFunc(order, func(a, b int) int {
+			return cmp.Compare(load(states[a]), load(states[b]))
+		})
+
+		placed := false
+		for _, j := range order {
+			if states[j].place(item, minSides[i+1]) {
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			unpacked = append(unpacked, item)
+		}
+	}
+
+	return states, unpacked
+}
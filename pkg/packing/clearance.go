@@ -0,0 +1,26 @@
+package packing
+
+// An item with a wall_clearance, such as temperature-sensitive goods in an
+// uninsulated container, keeps at least that gap to the four side walls of
+// its box. The walls are moved inward by the clearance for that item only:
+// other items still use the space next to the walls, and items with a
+// clearance may stand on the floor and stack as usual.
+
+// clearOfWalls reports whether a w × d footprint of item at (x, z) keeps
+// its wall clearance in box.
+func clearOfWalls(item itemToPack, box InputBox, x, z, w, d int) bool {
+	c := item.WallClearance
+	return x >= c && z >= c && x+w <= box.W-c && z+d <= box.D-c
+}
+
+// awayFromWalls moves a w × d footprint of item at (x, z) inward as far as
+// its wall clearance needs. Candidate positions come from the walls and the
+// items already placed, so without this an item with a clearance would
+// never find a first position.
+func awayFromWalls(item itemToPack, box InputBox, x, z, w, d int) (int, int) {
+	c := item.WallClearance
+	if c == 0 {
+		return x, z
+	}
+	return max(c, min(x, box.W-c-w)), max(c, min(z, box.D-c-d))
+}
@@ -0,0 +1,139 @@
+package packing
+
+import (
+	"cmp"
+	"context"
+	"slices"
+)
+
+// columnShare is the share of the item volume a carton type needs before
+// column_stacking builds towers of it.
+const columnShare = 0.25
+
+// columnGroup is the units of one carton type stacked into towers of height
+// units, each standing in orientation rot.
+type columnGroup struct {
+	units  []int // indexes into the items being packed
+	rot    [3]int
+	height int
+	volume int
+}
+
+// packColumns builds full-height towers of the dominant carton types first,
+// the way loaders stack uniform cartons by hand, then places everything
+// left over at extreme points.
+func packColumns(ctx context.Context, items []itemToPack, box InputBox, opts Options) ([]Placement, []bool, int) {
+	state := newBoxState(ctx, box, opts)
+	packed := make([]bool, len(items))
+	packedVol := 0
+	minSide := suffixMinSides(items)[0]
+
+	for _, g := range columnGroups(items, box) {
+		for units := g.units; len(units) >= g.height; units = units[g.height:] {
+			if !state.placeColumn(items[units[0]], g.rot, g.height, minSide) {
+				break
+			}
+			for _, i := range units[:g.height] {
+				packed[i] = true
+				packedVol += items[i].volume
+			}
+		}
+	}
+	for i, item := range items {
+		if !packed[i] && state.place(item, minSide) {
+			packed[i] = true
+			packedVol += item.volume
+		}
+	}
+	return state.placements, packed, packedVol
+}
+
+// columnGroups returns the carton types worth stacking into columns, largest
+// total volume first: those with at least columnShare of the item volume and
+// enough units for two towers. Each stands in the orientation that fits the
+// most units in box.
+func columnGroups(items []itemToPack, box InputBox) []columnGroup {
+	total := 0
+	byID := make(map[string]*columnGroup)
+	var groups []*columnGroup
+	for i, it := range items {
+		total += it.volume
+		g, ok := byID[it.ID]
+		if !ok {
+			g = &columnGroup{}
+			byID[it.ID] = g
+			groups = append(groups, g)
+		}
+		g.units = append(g.units, i)
+		g.volume += it.volume
+	}
+
+	var out []columnGroup
+	for _, g := range groups {
+		unit := items[g.units[0]]
+		if float64(g.volume) < columnShare*float64(total) {
+			continue
+		}
+		best := 0
+		for _, r := range Rotations(unit.InputItem) {
+			n := columnHeight(unit, box, r[1])
+			if fit := (box.W / r[0]) * (box.D / r[2]) * n; n >= 2 && fit > best {
+				best, g.rot, g.height = fit, r, n
+			}
+		}
+		if best > 0 && len(g.units) >= 2*g.height {
+			out = append(out, *g)
+		}
+	}
+	slices.SortStableFunc(out, func(a, b columnGroup) int { return cmp.Compare(b.volume, a.volume) })
+	return out
+}
+
+// columnHeight returns how many units of height h stack in box without
+// breaking the unit's stacking limits.
+func columnHeight(unit itemToPack, box InputBox, h int) int {
+	n := box.H / h
+	if !unit.stackable() {
+		return min(n, 1)
+	}
+	if unit.MaxStackWeight > 0 && unit.Weight > 0 {
+		n = min(n, 1+int(unit.MaxStackWeight/unit.Weight))
+	}
+	if unit.MaxStackUnits > 0 {
+		n = min(n, unit.MaxStackUnits)
+	}
+	return n
+}
+
+// placeColumn places a tower of n units standing in orientation rot as one
+// upright block, then records its units one by one so later items see the
+// stacking limits of the unit they rest on.
+func (s *boxState) placeColumn(unit itemToPack, rot [3]int, n, minSide int) bool {
+	column := unit
+	column.W, column.H, column.D = rot[0], rot[1]*n, rot[2]
+	column.KeepUpright, column.AllowedRotations = true, nil
+	if unit.MaxStackUnits > 0 {
+		// The column counts as one unit of a run.
+		column.MaxStackUnits = unit.MaxStackUnits - (n - 1)
+	}
+	column.Weight = unit.Weight * float64(n)
+	column.volume = unit.volume * n
+	column.maxDim = max(column.W, column.H, column.D)
+	if !s.place(column, minSide) {
+		return false
+	}
+
+	last := len(s.placements) - 1
+	p := s.placements[last]
+	s.placements, s.items = s.placements[:last], s.items[:last]
+	for j := range n {
+		s.placements = append(s.placements, Placement{
+			ItemID: unit.ID,
+			X:      p.X, Y: p.Y + j*rot[1], Z: p.Z,
+			W: p.W, H: rot[1], D: p.D,
+			Weight: unit.Weight,
+		})
+		s.items = append(s.items, unit)
+	}
+	return true
+}
@@ -0,0 +1,292 @@
+package packing
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Compaction is a post-processing pass over a packed box. Every item slides
+// down and towards the anchor walls until it touches a wall or another
+// item, closing the gaps the extreme points leave behind and collecting the
+// free space at the far end of the box. Items stacked wholly on a moved item
+// move with it; items that only partly rested on it settle onto whatever is
+// below them. A move is only kept if every item it disturbs is still
+// supported and within its stacking limits and constraints.
+
+// maxCompactPasses bounds the passes over a box. Every move brings an item
+// closer to the anchor corner, so compaction stops on its own; the bound
+// just caps the work on large boxes.
+const maxCompactPasses = 8
+
+// Axes as indexes into a position or size triple.
+const (
+	axisX = iota
+	axisY
+	axisZ
+)
+
+// compactMove slides items along axis, towards the far wall if far is set.
+type compactMove struct {
+	axis int
+	far  bool
+}
+
+// compactMoves returns the slides for policy: gravity first, then the
+// anchor walls. Policies that place items by layer or zone only compact
+// along the axes they don't control.
+func compactMoves(policy string) []compactMove {
+	moves := []compactMove{{axis: axisY}}
+	switch policy {
+	case PlacementAlternating:
+		return moves
+	case PlacementAxleLoad:
+		return append(moves, compactMove{axis: axisX})
+	}
+	anchor := anchorFor(policy, 0, nil)
+	return append(moves, compactMove{axis: axisZ, far: anchor.mirrorZ}, compactMove{axis: axisX, far: anchor.mirrorX})
+}
+
+func (p Placement) pos(axis int) int { return [3]int{p.X, p.Y, p.Z}[axis] }
+
+func (p Placement) size(axis int) int { return [3]int{p.W, p.H, p.D}[axis] }
+
+func (p Placement) moved(axis, by int) Placement {
+	switch axis {
+	case axisX:
+		p.X += by
+	case axisY:
+		p.Y += by
+	default:
+		p.Z += by
+	}
+	return p
+}
+
+// compactor holds a box being compacted. items is parallel to placements.
+type compactor struct {
+	box        InputBox
+	opts       Options
+	placements []Placement
+	items      []itemToPack
+	keepOut    []Placement
+}
+
+// compactBox returns placements after compaction; items holds the specs
+// behind placements.
+func compactBox(box InputBox, placements []Placement, items []itemToPack, keepOut []Placement, opts Options) []Placement {
+	c := &compactor{box: box, opts: opts, placements: slices.Clone(placements), items: items, keepOut: keepOut}
+	moves := compactMoves(opts.PlacementPolicy)
+	order := make([]int, len(placements))
+	for pass := 0; pass < maxCompactPasses; pass++ {
+		changed := false
+		for _, m := range moves {
+			// Items nearest the wall go first, so they don't block the
+			// items behind them.
+			for i := range order {
+				order[i] = i
+			}
+			slices.SortFunc(order, func(a, b int) int {
+				pa, pb := c.placements[a], c.placements[b]
+				if m.far {
+					return cmp.Compare(pb.pos(m.axis)+pb.size(m.axis), pa.pos(m.axis)+pa.size(m.axis))
+				}
+				return cmp.Compare(pa.pos(m.axis), pb.pos(m.axis))
+			})
+			for _, i := range order {
+				by := c.slide(c.placements, i, m)
+				if by != 0 && c.tryMove(i, m.axis, by) {
+					changed = true
+				}
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	return c.placements
+}
+
+// slide returns how far item i of placements can move along m before it
+// hits a wall, another item or a keep-out region: negative towards the
+// origin, positive towards the far wall.
+func (c *compactor) slide(placements []Placement, i int, m compactMove) int {
+	p := placements[i]
+	start, end := p.pos(m.axis), p.pos(m.axis)+p.size(m.axis)
+	room := start
+	if m.far {
+		room = [3]int{c.box.W, c.box.H, c.box.D}[m.axis] - end
+	}
+	block := func(o Placement) {
+		if !overlapsAcross(p, o, m.axis) {
+			return
+		}
+		if oStart, oEnd := o.pos(m.axis), o.pos(m.axis)+o.size(m.axis); m.far && oStart >= end {
+			room = min(room, oStart-end)
+		} else if !m.far && oEnd <= start {
+			room = min(room, start-oEnd)
+		}
+	}
+	for j, o := range placements {
+		if j != i {
+			block(o)
+		}
+	}
+	for _, o := range c.keepOut {
+		block(o)
+	}
+	if m.far {
+		return room
+	}
+	return -room
+}
+
+// overlapsAcross reports whether a and b overlap on the two axes other than
+// axis, i.e. whether one would hit the other sliding along axis.
+func overlapsAcross(a, b Placement, axis int) bool {
+	for k := range 3 {
+		if k != axis && (a.pos(k) >= b.pos(k)+b.size(k) || b.pos(k) >= a.pos(k)+a.size(k)) {
+			return false
+		}
+	}
+	return true
+}
+
+// tryMove moves item i along axis, carrying the items stacked wholly on top
+// of it, and lets the items that only partly rested on it fall until they
+// land, and so on up. It keeps the result and returns true only if nothing
+// collides and every disturbed item is still stable.
+func (c *compactor) tryMove(i, axis, by int) bool {
+	trial := slices.Clone(c.placements)
+	carried := c.stackOn(i)
+	for _, k := range carried {
+		trial[k] = trial[k].moved(axis, by)
+	}
+	for _, k := range carried {
+		p := trial[k]
+		if !FitsInBox(c.box, p.X, p.Y, p.Z, p.W, p.H, p.D) || !clearOfWalls(c.items[k], c.box, p.X, p.Z, p.W, p.D) || hasOverlap(c.keepOut, p.X, p.Y, p.Z, p.W, p.H, p.D) {
+			return false
+		}
+		for j, o := range trial {
+			if !slices.Contains(carried, j) && boxesOverlap(o, p.X, p.Y, p.Z, p.W, p.H, p.D) {
+				return false
+			}
+		}
+	}
+
+	touched := slices.Clone(carried)
+	var queue []int
+	for _, k := range carried {
+		for _, j := range c.restingOn(trial, k, c.placements[k]) {
+			if !slices.Contains(carried, j) {
+				queue = append(queue, j)
+			}
+		}
+	}
+	for len(queue) > 0 {
+		j := queue[0]
+		queue = queue[1:]
+		touched = append(touched, j)
+		before := trial[j]
+		if by := c.slide(trial, j, compactMove{axis: axisY}); by != 0 {
+			trial[j] = before.moved(axisY, by)
+			queue = append(queue, c.restingOn(trial, j, before)...)
+		}
+	}
+	for _, k := range touched {
+		if !c.stable(trial, k) {
+			return false
+		}
+	}
+	c.placements = trial
+	return true
+}
+
+// stackOn returns item i and the items stacked on it whose bases lie wholly
+// on an item of the stack, which move along with it.
+func (c *compactor) stackOn(i int) []int {
+	stack := []int{i}
+	for n := 0; n < len(stack); n++ {
+		p := c.placements[stack[n]]
+		for _, j := range c.restingOn(c.placements, stack[n], p) {
+			o := c.placements[j]
+			within := o.X >= p.X && o.X+o.W <= p.X+p.W && o.Z >= p.Z && o.Z+o.D <= p.Z+p.D
+			if within && !slices.Contains(stack, j) {
+				stack = append(stack, j)
+			}
+		}
+	}
+	return stack
+}
+
+// restingOn returns the items of placements, other than i, that sit on top
+// of p, the old position of item i.
+func (c *compactor) restingOn(placements []Placement, i int, p Placement) []int {
+	var out []int
+	for j, o := range placements {
+		if j != i && o.Y == p.Y+p.H && footprintsOverlap(p, o.X, o.Z, o.W, o.D) {
+			out = append(out, j)
+		}
+	}
+	return out
+}
+
+// stable reports whether item k of placements is supported, within the
+// stacking limits of the items below and above it, and allowed by the
+// constraints.
+func (c *compactor) stable(placements []Placement, k int) bool {
+	p, item := placements[k], c.items[k]
+	others := slices.Delete(slices.Clone(placements), k, k+1)
+	placed := slices.Delete(slices.Clone(c.items), k, k+1)
+
+	if p.Y > 0 && !supported(slices.All(others), p.X, p.Y, p.Z, p.W, p.D, c.opts.minSupport()) {
+		return false
+	}
+	if p.Y > 0 && !stackingAllows(item, slices.All(others), others, placed, p.X, p.Y, p.Z, p.W, p.D) {
+		return false
+	}
+	if !stackRunAllows(item, others, p.X, p.Y, p.Z, p.W, p.H, p.D) {
+		return false
+	}
+	var load float64
+	for _, o := range others {
+		if o.Y >= p.Y+p.H && footprintsOverlap(p, o.X, o.Z, o.W, o.D) {
+			if !item.stackable() {
+				return false
+			}
+			load += o.Weight
+		}
+	}
+	if item.MaxStackWeight > 0 && load > item.MaxStackWeight {
+		return false
+	}
+	if len(c.opts.compiled) > 0 {
+		env := placementEnv{item: item, box: c.box, placements: others, x: p.X, y: p.Y, z: p.Z, w: p.W, h: p.H, d: p.D}
+		return c.opts.allows(&env)
+	}
+	return true
+}
+
+// compactPacked compacts every packed box.
+func compactPacked(packed []PackedBox, boxes []InputBox, inputItems []InputItem, opts Options) {
+	byID := BoxesByID(boxes)
+	specs := make(map[string]itemToPack)
+	for _, it := range expandItems(inputItems) {
+		if _, ok := specs[it.ID]; !ok {
+			specs[it.ID] = it
+		}
+	}
+	for i, pb := range packed {
+		box := byID[pb.BoxID]
+		items := make([]itemToPack, len(pb.Contents))
+		for j, p := range pb.Contents {
+			items[j] = specs[p.ItemID]
+		}
+		var keepOut []Placement
+		if opts.Aisle != nil {
+			if aisle, ok := opts.Aisle.region(box); ok {
+				keepOut = []Placement{aisle}
+			}
+		}
+		packed[i].Contents = compactBox(box, pb.Contents, items, keepOut, opts)
+	}
+}
@@ -0,0 +1,63 @@
+package packing
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompactSlidesToWalls(t *testing.T) {
+	box := InputBox{ID: "box", W: 10, H: 10, D: 10}
+	items := []itemToPack{{InputItem: InputItem{ID: "a"}}}
+	got := compactBox(box, []Placement{{ItemID: "a", X: 4, Z: 3, W: 4, H: 4, D: 4}}, items, nil, Options{})
+	if want := (Placement{ItemID: "a", W: 4, H: 4, D: 4}); got[0] != want {
+		t.Errorf("Expected the item in the back-left corner, got %+v", got[0])
+	}
+
+	got = compactBox(box, []Placement{{ItemID: "a", X: 4, Z: 3, W: 4, H: 4, D: 4}}, items, nil, Options{PlacementPolicy: PlacementFrontRight})
+	if got[0].X != 6 || got[0].Z != 6 {
+		t.Errorf("Expected the item in the front-right corner, got %+v", got[0])
+	}
+}
+
+func TestCompactKeepsStacksStable(t *testing.T) {
+	box := InputBox{ID: "box", W: 10, H: 10, D: 10}
+	items := []itemToPack{
+		{InputItem: InputItem{ID: "base"}},
+		{InputItem: InputItem{ID: "top"}},
+		{InputItem: InputItem{ID: "wall"}},
+	}
+	placements := []Placement{
+		{ItemID: "base", X: 4, W: 4, H: 4, D: 4},
+		{ItemID: "top", X: 4, Y: 4, W: 4, H: 2, D: 4},
+		{ItemID: "wall", X: 0, W: 2, H: 2, D: 2},
+	}
+
+	got := compactBox(box, placements, items, nil, Options{})
+	if err := CheckLayout([]PackedBox{{BoxID: "box", Contents: got}}, []InputBox{box}); err != nil {
+		t.Fatalf("Expected a valid layout, got %v: %+v", err, got)
+	}
+	for i, p := range got {
+		if p.Y > 0 && !supported(slices.All(append(got[:i:i], got[i+1:]...)), p.X, p.Y, p.Z, p.W, p.D, defaultMinSupportPercent) {
+			t.Errorf("Expected %s to stay supported, got %+v", p.ItemID, got)
+		}
+	}
+	if got[0].X != 2 || got[1].X != 2 || got[1].Y != 4 {
+		t.Errorf("Expected the base to carry the top up against the wall item, got %+v", got)
+	}
+}
+
+func TestPackWithCompact(t *testing.T) {
+	boxes := []InputBox{{ID: "box", W: 30, H: 10, D: 10}}
+	items := []InputItem{{ID: "cube", W: 10, H: 10, D: 10, Quantity: 2}}
+	opts := Options{Compact: true, Aisle: &Aisle{Axis: AisleAlongDepth, Width: 10, Height: 10}}
+
+	packed, _ := PackWithOptions(items, boxes, opts)
+	if len(packed) != 1 || len(packed[0].Contents) != 2 {
+		t.Fatalf("Expected one box with both cubes, got %+v", packed)
+	}
+	for _, p := range packed[0].Contents {
+		if p.X == 10 {
+			t.Errorf("Expected compaction to respect the aisle, got %+v", p)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package packing
+
+import "math"
+
+// compressionSteps is the number of sizes between an item's full and fully
+// compressed ones that place tries.
+const compressionSteps = 4
+
+// compressions returns the squeezed forms of a compressible item to try,
+// least squeezed first. Every side shrinks by the same percentage, rounded
+// up so an item is never squeezed beyond its Compressibility.
+func compressions(item itemToPack) []itemToPack {
+	if item.Compressibility <= 0 || item.compression > 0 {
+		return nil
+	}
+	var forms []itemToPack
+	last := [3]int{item.W, item.H, item.D}
+	for step := 1; step <= compressionSteps; step++ {
+		pct := item.Compressibility * float64(step) / compressionSteps
+		c := item
+		c.W, c.H, c.D = squeeze(item.W, pct), squeeze(item.H, pct), squeeze(item.D, pct)
+		if [3]int{c.W, c.H, c.D} == last {
+			continue
+		}
+		last = [3]int{c.W, c.H, c.D}
+		c.volume = c.W * c.H * c.D
+		c.maxDim = max(c.W, c.H, c.D)
+		c.compression = pct
+		forms = append(forms, c)
+	}
+	return forms
+}
+
+// minSqueezedSide returns the shortest side item can take, squeezed as far as
+// its Compressibility allows.
+func minSqueezedSide(item itemToPack) int {
+	side := min(item.W, item.H, item.D)
+	if item.Compressibility <= 0 || item.compression > 0 {
+		return side
+	}
+	return squeeze(side, item.Compressibility)
+}
+
+func squeeze(side int, pct float64) int {
+	// The tolerance keeps float noise from rounding 9.000000000000002 up.
+	return max(1, int(math.Ceil(float64(side)*(1-pct/100)-1e-9)))
+}
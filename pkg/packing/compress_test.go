@@ -0,0 +1,42 @@
+package packing
+
+import (
+	"testing"
+)
+
+func TestCompressibleItemIsSqueezedToFit(t *testing.T) {
+	boxes := []InputBox{{ID: "mailer", W: 30, H: 10, D: 20}}
+	pillow := InputItem{ID: "pillow", W: 30, H: 12, D: 20, Quantity: 1, Compressibility: 20}
+
+	packed, unpacked := PackWithOptions([]InputItem{pillow}, boxes, Options{})
+	if len(unpacked) != 0 || len(packed) != 1 {
+		t.Fatalf("Expected the pillow to be squeezed into the mailer, got %d unpacked", len(unpacked))
+	}
+	p := packed[0].Contents[0]
+	// 10% is too little for the 12 high side, 15% squeezes it to 10.2,
+	// rounded up to 11; only the full 20% brings it down to 10.
+	if p.Compression != 20 || p.W > 30 || p.H > 10 || p.D > 20 {
+		t.Errorf("Expected 20%% compression within the box, got %+v", p)
+	}
+
+	// An item that fits as it is is not squeezed.
+	pillow.H = 10
+	packed, _ = PackWithOptions([]InputItem{pillow}, boxes, Options{})
+	if p := packed[0].Contents[0]; p.Compression != 0 || p.H != 10 {
+		t.Errorf("Expected no compression, got %+v", p)
+	}
+
+	// Nor is one squeezed beyond its limit.
+	pillow.H, pillow.Compressibility = 12, 10
+	if _, unpacked := PackWithOptions([]InputItem{pillow}, boxes, Options{}); len(unpacked) != 1 {
+		t.Errorf("Expected the pillow to stay unpacked at 10%% compressibility, got %d unpacked", len(unpacked))
+	}
+}
+
+func TestCompressions(t *testing.T) {
+	forms := compressions(itemToPack{InputItem: InputItem{W: 10, H: 10, D: 10, Compressibility: 10}})
+	// 2.5%, 5% and 7.5% all round back up to 10.
+	if len(forms) != 1 || forms[0].W != 9 || forms[0].compression != 10 {
+		t.Errorf("Expected one form squeezed by 10%% to 9, got %+v", forms)
+	}
+}
@@ -0,0 +1,182 @@
+package packing
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CSV files have a header row naming their columns, like the JSON fields,
+// in any order. id, w, h and d are required; an empty cell is zero. Sides
+// may have decimals, as in JSON.
+//
+//	id,w,h,d,quantity,weight
+//	mug,8,10,8,4,0.3
+var (
+	itemCSVColumns = []string{"id", "w", "h", "d", "quantity", "weight"}
+	boxCSVColumns  = []string{"id", "w", "h", "d", "quantity", "max_weight"}
+	// A mixed file has a type column saying whether each row is an
+	// "item" or a "box".
+	mixedCSVColumns = []string{"type", "id", "w", "h", "d", "quantity", "weight", "max_weight"}
+)
+
+// ReadItemsCSV reads items from CSV with the columns id, w, h, d, quantity
+// and optionally weight.
+func ReadItemsCSV(r io.Reader) ([]InputItem, error) {
+	items, _, err := readCSV(r, "item")
+	return items, err
+}
+
+// ReadBoxesCSV reads boxes from CSV with the columns id, w, h and d, and
+// optionally quantity, the stock, and max_weight.
+func ReadBoxesCSV(r io.Reader) ([]InputBox, error) {
+	_, boxes, err := readCSV(r, "box")
+	return boxes, err
+}
+
+// ReadCSV reads items and boxes from one CSV file whose type column says
+// which each row is, "item" or "box". weight only applies to items and
+// max_weight to boxes.
+//
+//	type,id,w,h,d,quantity,weight,max_weight
+//	item,mug,8,10,8,4,0.3,
+//	box,small,20,20,20,,,5
+func ReadCSV(r io.Reader) ([]InputItem, []InputBox, error) {
+	return readCSV(r, "")
+}
+
+// readCSV reads rows of kind, or of the kind in their type column if kind
+// is empty.
+func readCSV(r io.Reader, kind string) ([]InputItem, []InputBox, error) {
+	allowed, required := mixedCSVColumns, mixedCSVColumns[:5]
+	switch kind {
+	case "item":
+		allowed, required = itemCSVColumns, itemCSVColumns[:4]
+	case "box":
+		allowed, required = boxCSVColumns, boxCSVColumns[:4]
+	}
+
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("read CSV header: %w", err)
+	}
+	col := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(allowed, name) {
+			return nil, nil, fmt.Errorf("unknown CSV column %q: use %s", name, strings.Join(allowed, ", "))
+		}
+		col[name] = i
+	}
+	for _, name := range required {
+		if _, ok := col[name]; !ok {
+			return nil, nil, fmt.Errorf("missing CSV column %q", name)
+		}
+	}
+
+	var items []InputItem
+	var boxes []InputBox
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		row := csvRow{rec: rec, col: col}
+		rowKind := kind
+		if rowKind == "" {
+			rowKind = strings.ToLower(row.get("type"))
+		}
+		switch rowKind {
+		case "item":
+			it := InputItem{ID: row.get("id"), Quantity: row.int("quantity"), Weight: row.float("weight")}
+			it.W, it.H, it.D, it.Size = row.sides()
+			items = append(items, it)
+		case "box":
+			b := InputBox{ID: row.get("id"), Quantity: row.int("quantity"), MaxWeight: row.float("max_weight")}
+			b.W, b.H, b.D, b.Size = row.sides()
+			boxes = append(boxes, b)
+		default:
+			row.fail(fmt.Errorf("type must be item or box, got %q", rowKind))
+		}
+		if row.err != nil {
+			return nil, nil, fmt.Errorf("CSV line %d: %w", line, row.err)
+		}
+	}
+	return items, boxes, nil
+}
+
+// csvRow reads the cells of one record by column name, keeping the first
+// parse error.
+type csvRow struct {
+	rec []string
+	col map[string]int
+	err error
+}
+
+func (r *csvRow) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *csvRow) get(name string) string {
+	i, ok := r.col[name]
+	if !ok || i >= len(r.rec) {
+		return ""
+	}
+	return strings.TrimSpace(r.rec[i])
+}
+
+func (r *csvRow) int(name string) int {
+	s := r.get(name)
+	if s == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		r.fail(fmt.Errorf("%s must be a whole number, got %q", name, s))
+	}
+	return n
+}
+
+func (r *csvRow) float(name string) float64 {
+	s := r.get(name)
+	if s == "" {
+		return 0
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		r.fail(fmt.Errorf("%s must be a number, got %q", name, s))
+	}
+	return f
+}
+
+// sides decodes w, h and d like the JSON fields; see sides.go.
+func (r *csvRow) sides() (int, int, int, *[3]float64) {
+	var n [3]json.Number
+	for i, name := range []string{"w", "h", "d"} {
+		if s := r.get(name); s != "" {
+			if _, err := strconv.ParseFloat(s, 64); err != nil {
+				r.fail(fmt.Errorf("%s must be a number, got %q", name, s))
+				return 0, 0, 0, nil
+			}
+			n[i] = json.Number(s)
+		}
+	}
+	w, h, d, size, err := decodeSides(n[0], n[1], n[2])
+	if err != nil {
+		r.fail(err)
+	}
+	return w, h, d, size
+}
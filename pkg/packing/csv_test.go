@@ -0,0 +1,51 @@
+package packing
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	items, boxes, err := ReadCSV(strings.NewReader("type,id,w,h,d,quantity,weight,max_weight\n" +
+		"item,mug,8,10,8,4,0.3,\n" +
+		"box,small,20,20.5,20,3,,5\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || !reflect.DeepEqual(items[0], InputItem{ID: "mug", W: 8, H: 10, D: 8, Quantity: 4, Weight: 0.3}) {
+		t.Errorf("Expected one mug, got %+v", items)
+	}
+	if len(boxes) != 1 || boxes[0].Quantity != 3 || boxes[0].MaxWeight != 5 || boxes[0].Size == nil || boxes[0].Size[1] != 20.5 {
+		t.Errorf("Expected one box with a decimal side, got %+v", boxes)
+	}
+
+	// Columns may come in any order; missing ones are zero.
+	items, err = ReadItemsCSV(strings.NewReader("quantity, id, d, h, w\n2, a, 3, 2, 1\n"))
+	if err != nil || len(items) != 1 || !reflect.DeepEqual(items[0], InputItem{ID: "a", W: 1, H: 2, D: 3, Quantity: 2}) {
+		t.Errorf("Expected item a, got %+v, %v", items, err)
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	for name, read := range map[string]func() error{
+		"unknown column": func() error { _, err := ReadItemsCSV(strings.NewReader("id,w,h,d,colour\na,1,1,1,red\n")); return err },
+		"box column": func() error {
+			_, err := ReadItemsCSV(strings.NewReader("id,w,h,d,max_weight\na,1,1,1,1\n"))
+			return err
+		},
+		"missing column": func() error { _, err := ReadBoxesCSV(strings.NewReader("id,w,h\nb,1,1\n")); return err },
+		"missing type":   func() error { _, _, err := ReadCSV(strings.NewReader("id,w,h,d\na,1,1,1\n")); return err },
+		"bad type":       func() error { _, _, err := ReadCSV(strings.NewReader("type,id,w,h,d\npallet,a,1,1,1\n")); return err },
+		"bad side":       func() error { _, err := ReadItemsCSV(strings.NewReader("id,w,h,d\na,one,1,1\n")); return err },
+		"bad quantity": func() error {
+			_, err := ReadItemsCSV(strings.NewReader("id,w,h,d,quantity\na,1,1,1,1.5\n"))
+			return err
+		},
+		"ragged row": func() error { _, err := ReadItemsCSV(strings.NewReader("id,w,h,d\na,1,1\n")); return err },
+	} {
+		if err := read(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
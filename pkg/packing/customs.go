@@ -0,0 +1,122 @@
+package packing
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// CustomsLine is one commercial invoice line: all units of one HS code from
+// one country of origin.
+type CustomsLine struct {
+	HSCode        string  `json:"hs_code,omitempty"`
+	OriginCountry string  `json:"origin_country,omitempty"`
+	Quantity      int     `json:"quantity"`
+	Value         float64 `json:"value"`
+	Weight        float64 `json:"weight,omitempty"`
+}
+
+// BoxCustoms rolls up the customs lines of one packed box. Box is the
+// 1-based index into packed_boxes.
+type BoxCustoms struct {
+	Box         int           `json:"box"`
+	BoxID       string        `json:"box_id"`
+	Lines       []CustomsLine `json:"lines"`
+	TotalValue  float64       `json:"total_value"`
+	TotalWeight float64       `json:"total_weight,omitempty"`
+}
+
+// CustomsSummary is the customs section of a pack response: per-box rollups
+// and the same lines totalled over the whole shipment.
+type CustomsSummary struct {
+	Boxes       []BoxCustoms  `json:"boxes"`
+	Lines       []CustomsLine `json:"lines"`
+	TotalValue  float64       `json:"total_value"`
+	TotalWeight float64       `json:"total_weight,omitempty"`
+}
+
+var (
+	hsCodePattern  = regexp.MustCompile(`^[0-9]{6,10}$`)
+	countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+// ValidateCustoms checks the customs fields of items: HS codes are 6 to 10
+// digits, countries are ISO 3166-1 alpha-2 codes and values are not negative.
+func ValidateCustoms(items []InputItem) error {
+	for _, it := range items {
+		if it.HSCode != "" && !hsCodePattern.MatchString(it.HSCode) {
+			return fmt.Errorf("item %q: hs_code must be 6 to 10 digits", it.ID)
+		}
+		if it.OriginCountry != "" && !countryPattern.MatchString(it.OriginCountry) {
+			return fmt.Errorf("item %q: origin_country must be a two-letter ISO country code", it.ID)
+		}
+		if it.Value < 0 {
+			return fmt.Errorf("item %q: value must not be negative", it.ID)
+		}
+	}
+	return nil
+}
+
+// CustomsRollup totals the customs data of the packed items, per box and per
+// shipment. It returns nil when no item carries an HS code or a value, so
+// domestic packs get no customs section.
+func CustomsRollup(items []InputItem, packed []PackedBox) *CustomsSummary {
+	byID := make(map[string]InputItem, len(items))
+	declared := false
+	for _, it := range items {
+		if _, ok := byID[it.ID]; !ok {
+			byID[it.ID] = it
+		}
+		declared = declared || it.HSCode != "" || it.Value > 0
+	}
+	if !declared {
+		return nil
+	}
+
+	type lineKey struct{ hs, origin string }
+	shipment := make(map[lineKey]*CustomsLine)
+	summary := &CustomsSummary{Boxes: make([]BoxCustoms, 0, len(packed))}
+	for i, pb := range packed {
+		lines := make(map[lineKey]*CustomsLine)
+		for _, p := range pb.Contents {
+			it := byID[p.ItemID]
+			k := lineKey{it.HSCode, it.OriginCountry}
+			for _, m := range []map[lineKey]*CustomsLine{lines, shipment} {
+				l, ok := m[k]
+				if !ok {
+					l = &CustomsLine{HSCode: k.hs, OriginCountry: k.origin}
+					m[k] = l
+				}
+				l.Quantity++
+				l.Value += it.Value
+				l.Weight += p.Weight
+			}
+		}
+		bc := BoxCustoms{Box: i + 1, BoxID: pb.BoxID, Lines: sortedLines(lines)}
+		for _, l := range bc.Lines {
+			bc.TotalValue += l.Value
+			bc.TotalWeight += l.Weight
+		}
+		summary.Boxes = append(summary.Boxes, bc)
+	}
+	summary.Lines = sortedLines(shipment)
+	for _, l := range summary.Lines {
+		summary.TotalValue += l.Value
+		summary.TotalWeight += l.Weight
+	}
+	return summary
+}
+
+// sortedLines orders invoice lines by HS code, then country of origin, so the
+// output is stable.
+func sortedLines[K comparable](m map[K]*CustomsLine) []CustomsLine {
+	lines := make([]CustomsLine, 0, len(m))
+	for _, l := range m {
+		lines = append(lines, *l)
+	}
+	slices.SortFunc(lines, func(a, b CustomsLine) int {
+		return cmp.Or(cmp.Compare(a.HSCode, b.HSCode), cmp.Compare(a.OriginCountry, b.OriginCountry))
+	})
+	return lines
+}
@@ -0,0 +1,35 @@
+package packing
+
+import "testing"
+
+func TestCustomsRollup(t *testing.T) {
+	items := []InputItem{
+		{ID: "shirt", W: 5, H: 5, D: 5, Quantity: 3, Weight: 0.5, HSCode: "610910", Value: 12, OriginCountry: "PT"},
+		{ID: "mug", W: 5, H: 5, D: 5, Quantity: 1, Weight: 1, HSCode: "691200", Value: 8, OriginCountry: "CN"},
+	}
+	packed := []PackedBox{
+		newPackedBox("a", []Placement{{ItemID: "shirt", Weight: 0.5}, {ItemID: "shirt", Weight: 0.5}, {ItemID: "mug", Weight: 1}}),
+		newPackedBox("b", []Placement{{ItemID: "shirt", Weight: 0.5}}),
+	}
+
+	c := CustomsRollup(items, packed)
+	if c == nil {
+		t.Fatal("Expected a customs section")
+	}
+	if c.TotalValue != 44 || c.TotalWeight != 2.5 {
+		t.Errorf("Expected shipment value 44 and weight 2.5, got %g and %g", c.TotalValue, c.TotalWeight)
+	}
+	if len(c.Lines) != 2 || c.Lines[1].HSCode != "691200" || c.Lines[0].Quantity != 3 {
+		t.Errorf("Expected two lines sorted by HS code, got %+v", c.Lines)
+	}
+	if len(c.Boxes) != 2 || c.Boxes[0].TotalValue != 32 || c.Boxes[1].Box != 2 {
+		t.Errorf("Expected per-box rollups, got %+v", c.Boxes)
+	}
+
+	if CustomsRollup([]InputItem{{ID: "plain"}}, packed) != nil {
+		t.Error("Expected no customs section without customs data")
+	}
+	if err := ValidateCustoms([]InputItem{{ID: "x", OriginCountry: "Portugal"}}); err == nil {
+		t.Error("Expected a non-ISO country to be rejected")
+	}
+}
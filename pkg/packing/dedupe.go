@@ -0,0 +1,85 @@
+package packing
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// MergeDuplicates merges the entries of an item that differ only in their
+// quantity into the first of them, summing the quantities. Upstream systems
+// often send one row per unit of a SKU; packing them as one entry gives the
+// same result with less work.
+//
+// The sides of an item that may be packed in any orientation are compared
+// in any order, so 10x20x30 and 30x10x20 are the same item. The merged
+// entry keeps the sides of the first. Entries with the same ID that differ
+// otherwise are left alone; validation rejects them. So are entries with a
+// quantity below 1, which would otherwise be summed away before validation
+// sees them.
+func MergeDuplicates(items []InputItem) ([]InputItem, []Warning) {
+	first := make(map[string]int, len(items))
+	entries := make([]int, 0, len(items))
+	var merged []InputItem
+	for _, it := range items {
+		if it.Quantity < 1 {
+			merged = append(merged, it)
+			entries = append(entries, 1)
+			continue
+		}
+		key := mergeKey(it)
+		if i, ok := first[key]; ok {
+			merged[i].Quantity += it.Quantity
+			entries[i]++
+			continue
+		}
+		first[key] = len(merged)
+		merged = append(merged, it)
+		entries = append(entries, 1)
+	}
+	if len(merged) == len(items) {
+		return items, nil
+	}
+
+	var warnings []Warning
+	for i, it := range merged {
+		if entries[i] > 1 {
+			warnings = append(warnings, Warning{
+				Code:    WarnItemsMerged,
+				Message: fmt.Sprintf("%d entries of item %q were merged into one of quantity %d", entries[i], it.ID, it.Quantity),
+				ItemID:  it.ID,
+			})
+		}
+	}
+	return merged, warnings
+}
+
+// mergeKey identifies the entries of an item that MergeDuplicates merges.
+func mergeKey(it InputItem) string {
+	it.Quantity = 0
+	if it.Size != nil {
+		size := *it.Size
+		it.Size = nil
+		it.W, it.H, it.D = 0, 0, 0
+		if freelyRotatable(it) {
+			slices.Sort(size[:])
+		}
+		sides, _ := json.Marshal(size)
+		key, _ := json.Marshal(it)
+		return string(sides) + string(key)
+	}
+	if freelyRotatable(it) {
+		sides := []int{it.W, it.H, it.D}
+		slices.Sort(sides)
+		it.W, it.H, it.D = sides[0], sides[1], sides[2]
+	}
+	key, _ := json.Marshal(it)
+	return string(key)
+}
+
+// freelyRotatable reports whether any orientation of the item may be
+// packed. The sides of a multipack case are tied to the layout of its
+// units, so they keep their order.
+func freelyRotatable(it InputItem) bool {
+	return !it.KeepUpright && len(it.AllowedRotations) == 0 && it.Inner == nil
+}
@@ -0,0 +1,47 @@
+package packing
+
+import (
+	"testing"
+)
+
+func TestMergeDuplicates(t *testing.T) {
+	items := []InputItem{
+		{ID: "mug", W: 8, H: 10, D: 8, Quantity: 2},
+		{ID: "plate", W: 20, H: 2, D: 20, Quantity: 1},
+		{ID: "mug", W: 10, H: 8, D: 8, Quantity: 3},
+		{ID: "mug", W: 8, H: 10, D: 8, Quantity: 1, Weight: 0.3},
+		{ID: "vase", W: 10, H: 30, D: 10, Quantity: 1, KeepUpright: true},
+		{ID: "vase", W: 30, H: 10, D: 10, Quantity: 1, KeepUpright: true},
+	}
+
+	merged, warnings := MergeDuplicates(items)
+	if len(merged) != 5 {
+		t.Fatalf("Expected 5 entries, got %+v", merged)
+	}
+	if m := merged[0]; m.ID != "mug" || m.Quantity != 5 || m.W != 8 || m.H != 10 {
+		t.Errorf("Expected the first mug entry with quantity 5, got %+v", m)
+	}
+	if merged[2].Weight != 0.3 || merged[2].Quantity != 1 {
+		t.Errorf("Expected the mug with another weight to stay apart, got %+v", merged[2])
+	}
+	if merged[3].ID != "vase" || merged[4].ID != "vase" {
+		t.Errorf("Expected upright vases with other sides to stay apart, got %+v", merged[3:])
+	}
+	if len(warnings) != 1 || warnings[0].Code != WarnItemsMerged || warnings[0].ItemID != "mug" {
+		t.Errorf("Expected one items_merged warning for mug, got %+v", warnings)
+	}
+
+	if _, warnings := MergeDuplicates(items[:2]); warnings != nil {
+		t.Errorf("Expected no warnings without duplicates, got %+v", warnings)
+	}
+
+	// A negative quantity must reach validation rather than cancel out.
+	negative := []InputItem{
+		{ID: "mug", W: 8, H: 10, D: 8, Quantity: 3},
+		{ID: "mug", W: 8, H: 10, D: 8, Quantity: -2},
+		{ID: "mug", W: 8, H: 10, D: 8, Quantity: 0},
+	}
+	if merged, _ := MergeDuplicates(negative); len(merged) != 3 || merged[0].Quantity != 3 || merged[1].Quantity != -2 {
+		t.Errorf("Expected entries below quantity 1 to stay apart, got %+v", merged)
+	}
+}
@@ -0,0 +1,10 @@
+// Package packing is the 3D bin packer behind the HTTP service: it places
+// items into boxes, respecting orientation, weight, stacking and the other
+// constraints in Options, and reports where each item went.
+//
+//	packed, unpacked := packing.PackWithOptions(items, boxes, packing.Options{})
+//
+// Sides are whole numbers in one unit, with coordinates as in package
+// geometry. The server in cmd/server is a thin wrapper: it converts units,
+// validates requests against its limits and renders the result.
+package packing
@@ -0,0 +1,102 @@
+package packing
+
+import (
+	"math"
+	"unsafe"
+)
+
+// Estimate is the projected cost of packing a request, worked out from its
+// size alone so it can be checked before anything is expanded. It is an
+// order of magnitude, not a prediction: the packer stops early on whatever
+// fits poorly, and prunes points that leave no room.
+type Estimate struct {
+	// Units is the number of units placed, the sum of the quantities.
+	Units    int `json:"units"`
+	BoxTypes int `json:"box_types"`
+	// UnitsPerBox is how many average units fill the largest box.
+	UnitsPerBox int `json:"units_per_box"`
+	// ExtremePoints is the number of candidate positions in a full box;
+	// every placement adds up to three.
+	ExtremePoints int `json:"extreme_points"`
+	// Evaluations is the number of position and rotation pairs tried.
+	Evaluations int64 `json:"evaluations"`
+	// MemoryBytes is the projected peak memory of the pack.
+	MemoryBytes int64 `json:"memory_bytes"`
+}
+
+// Per-unit sizes for MemoryBytes: the expanded unit, and a placement with
+// the points it adds in every box type tried.
+var (
+	unitBytes      = float64(unsafe.Sizeof(itemToPack{}))
+	placementBytes = float64(unsafe.Sizeof(Placement{}) + 3*unsafe.Sizeof(FreeSpace{}))
+)
+
+// EstimateCost projects the cost of packing items into boxes with the
+// greedy packer. Every box opened tries each box type with each remaining
+// unit at each extreme point in each rotation, and the points of a box grow
+// with the units in it.
+func EstimateCost(items []InputItem, boxes []InputBox, opts Options) Estimate {
+	e := Estimate{BoxTypes: len(boxes)}
+	var volume, rotations float64
+	for _, it := range items {
+		q := float64(max(it.Quantity, 0))
+		e.Units += max(it.Quantity, 0)
+		volume += q * float64(it.W) * float64(it.H) * float64(it.D)
+		rotations += q * float64(len(Rotations(it)))
+	}
+	if e.Units == 0 || len(boxes) == 0 {
+		return e
+	}
+	units := float64(e.Units)
+	rotations /= units
+
+	largest := 0.0
+	for _, b := range boxes {
+		largest = max(largest, float64(b.W)*float64(b.H)*float64(b.D))
+	}
+	perBox := units
+	if volume > 0 {
+		perBox = math.Min(units, math.Max(1, math.Floor(largest/(volume/units))))
+	}
+	e.UnitsPerBox = int(perBox)
+	e.ExtremePoints = 3*e.UnitsPerBox + 1
+
+	// Box i starts with units - i*perBox remaining, and on average half its
+	// points are there when a unit is tried.
+	opened := math.Ceil(units / perBox)
+	remaining := units*opened - perBox*opened*(opened-1)/2
+	if opts.MaxBoxes > 0 {
+		opened = math.Min(opened, float64(opts.MaxBoxes))
+		remaining = math.Min(remaining, units*opened)
+	}
+	points := math.Max(1, float64(e.ExtremePoints)/2)
+	e.Evaluations = saturate(float64(len(boxes)) * remaining * points * rotations)
+	e.MemoryBytes = saturate(units*unitBytes + float64(len(boxes))*perBox*placementBytes)
+	return e
+}
+
+// saturate converts f to an int64, capping it rather than overflowing.
+func saturate(f float64) int64 {
+	if f >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(f)
+}
+
+// MinBoxes is a lower bound on the boxes items need: their volume, fully
+// compressed, over that of the largest box. No packing does better, so it
+// tells an attainable box count from one that is not.
+func MinBoxes(items []InputItem, boxes []InputBox) int {
+	var volume, largest float64
+	for _, it := range items {
+		squeezed := math.Pow(1-it.Compressibility/100, 3)
+		volume += float64(max(it.Quantity, 0)) * float64(it.W) * float64(it.H) * float64(it.D) * squeezed
+	}
+	for _, b := range boxes {
+		largest = max(largest, float64(b.W)*float64(b.H)*float64(b.D))
+	}
+	if volume == 0 || largest == 0 {
+		return 0
+	}
+	return int(math.Ceil(volume / largest))
+}
@@ -0,0 +1,46 @@
+package packing
+
+import (
+	"testing"
+)
+
+func TestEstimateCost(t *testing.T) {
+	items := []InputItem{{ID: "cube", W: 10, H: 10, D: 10, Quantity: 40}}
+	boxes := []InputBox{{ID: "box", W: 20, H: 20, D: 20}}
+
+	e := EstimateCost(items, boxes, Options{})
+	if e.Units != 40 || e.UnitsPerBox != 8 || e.ExtremePoints != 25 {
+		t.Errorf("Expected 40 units, 8 per box and 25 points, got %+v", e)
+	}
+	if e.Evaluations <= 0 || e.MemoryBytes <= 0 {
+		t.Errorf("Expected a positive cost, got %+v", e)
+	}
+
+	// Twice the units cost about four times as much, and every box type
+	// adds to it.
+	items[0].Quantity = 80
+	double := EstimateCost(items, boxes, Options{})
+	if r := float64(double.Evaluations) / float64(e.Evaluations); r < 3.5 || r > 4.5 {
+		t.Errorf("Expected about 4 times the evaluations, got %.2f", r)
+	}
+	boxes = append(boxes, InputBox{ID: "other", W: 20, H: 20, D: 20})
+	if more := EstimateCost(items, boxes, Options{}); more.Evaluations != 2*double.Evaluations {
+		t.Errorf("Expected a second box type to double the evaluations, got %d and %d", more.Evaluations, double.Evaluations)
+	}
+
+	if e := EstimateCost(nil, boxes, Options{}); e.Evaluations != 0 {
+		t.Errorf("Expected no cost without items, got %+v", e)
+	}
+}
+
+func TestMinBoxes(t *testing.T) {
+	items := []InputItem{{ID: "cube", W: 10, H: 10, D: 10, Quantity: 9}}
+	boxes := []InputBox{{ID: "small", W: 10, H: 10, D: 10}, {ID: "large", W: 20, H: 20, D: 20}}
+	if n := MinBoxes(items, boxes); n != 2 {
+		t.Errorf("Expected 2 boxes, got %d", n)
+	}
+	items[0].Compressibility = 50
+	if n := MinBoxes(items, boxes); n != 1 {
+		t.Errorf("Expected 1 box for squeezable cubes, got %d", n)
+	}
+}
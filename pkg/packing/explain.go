@@ -0,0 +1,112 @@
+package packing
+
+import "fmt"
+
+// Rules that decide between two candidate boxes, in the order prefers
+// applies them. RuleOnlyCandidate means no other box type took any item.
+const (
+	RuleOnlyCandidate = "only_candidate"
+	RuleTier          = "tier"
+	RuleCostPerVolume = "cost_per_volume"
+	RuleUtilization   = "utilization"
+	RulePackedVolume  = "packed_volume"
+	RuleSmallerBox    = "smaller_box"
+	RuleBoxOrder      = "box_order"
+)
+
+// BoxDecision records how findBestBox chose the type of a packed box: the
+// box types it tried for the items left at that point and the rule that
+// made the chosen one beat the runner-up.
+type BoxDecision struct {
+	// RemainingItems is the number of units still to pack when the box was
+	// opened.
+	RemainingItems int            `json:"remaining_items"`
+	Candidates     []BoxCandidate `json:"candidates"`
+	// Chosen and RunnerUp index Candidates; RunnerUp is the box that would
+	// have been chosen otherwise, or -1 if there was none.
+	Chosen   int    `json:"chosen"`
+	RunnerUp int    `json:"runner_up"`
+	Rule     string `json:"rule"`
+	// Spillover is set when the spillover policy restricted the choice to
+	// a single box type.
+	Spillover string `json:"spillover,omitempty"`
+	// DownsizedTo is the cheaper box type ObjectiveMinimizeCost moved the
+	// contents to after packing.
+	DownsizedTo string `json:"downsized_to,omitempty"`
+}
+
+// BoxCandidate is one box type tried for a packed box.
+type BoxCandidate struct {
+	BoxID        string  `json:"box_id"`
+	Tier         int     `json:"tier,omitempty"`
+	Cost         float64 `json:"cost,omitempty"`
+	Volume       int     `json:"volume"`
+	Items        int     `json:"items"`
+	PackedVolume int     `json:"packed_volume"`
+	Utilization  float64 `json:"utilization_percent"`
+}
+
+// newBoxDecision records the choice of boxes[best] for items, given the
+// units each box took and their volume.
+func newBoxDecision(items []itemToPack, boxes []InputBox, packed [][]bool, packedVol []int, best int, opts Options) *BoxDecision {
+	d := &BoxDecision{RemainingItems: len(items), Chosen: best, RunnerUp: -1, Rule: RuleOnlyCandidate}
+	for i, b := range boxes {
+		c := BoxCandidate{BoxID: b.ID, Tier: b.Tier, Cost: b.Cost, Volume: b.Volume(), PackedVolume: packedVol[i]}
+		for _, ok := range packed[i] {
+			if ok {
+				c.Items++
+			}
+		}
+		if c.Volume > 0 {
+			c.Utilization = float64(c.PackedVolume) / float64(c.Volume) * 100
+		}
+		d.Candidates = append(d.Candidates, c)
+
+		if i == best || packedVol[i] <= 0 {
+			continue
+		}
+		if d.RunnerUp == -1 || opts.prefers(b, packedVol[i], boxes[d.RunnerUp], packedVol[d.RunnerUp]) {
+			d.RunnerUp = i
+		}
+	}
+	if d.RunnerUp != -1 {
+		_, d.Rule = opts.compareBoxes(boxes[best], packedVol[best], boxes[d.RunnerUp], packedVol[d.RunnerUp])
+	}
+	return d
+}
+
+// Summary explains the decision in a sentence or two.
+func (d BoxDecision) Summary() string {
+	chosen := d.Candidates[d.Chosen]
+	var s string
+	if d.RunnerUp == -1 {
+		s = fmt.Sprintf("%s was the only box type that took any of the %d remaining units.", chosen.BoxID, d.RemainingItems)
+	} else {
+		other := d.Candidates[d.RunnerUp]
+		s = fmt.Sprintf("%s was chosen over %s ", chosen.BoxID, other.BoxID)
+		switch d.Rule {
+		case RuleTier:
+			s += fmt.Sprintf("because its tier %d comes before tier %d.", chosen.Tier, other.Tier)
+		case RuleCostPerVolume:
+			s += fmt.Sprintf("for its lower cost per packed volume (%.4g against %.4g).",
+				chosen.Cost/float64(chosen.PackedVolume), other.Cost/float64(other.PackedVolume))
+		case RuleUtilization:
+			s += fmt.Sprintf("for its higher utilization (%.1f%% against %.1f%%).", chosen.Utilization, other.Utilization)
+		case RulePackedVolume:
+			s += fmt.Sprintf("because it packs more volume (%d against %d, %d of the %d remaining units).",
+				chosen.PackedVolume, other.PackedVolume, chosen.Items, d.RemainingItems)
+		case RuleSmallerBox:
+			s += fmt.Sprintf("because both pack a volume of %d and it is the smaller box (%d against %d).",
+				chosen.PackedVolume, chosen.Volume, other.Volume)
+		default:
+			s += "because the two tie on every rule and it comes first in the catalog sorted by volume."
+		}
+	}
+	if d.Spillover != "" {
+		s = fmt.Sprintf("The %s spillover policy restricted the choice to %s. ", d.Spillover, chosen.BoxID) + s
+	}
+	if d.DownsizedTo != "" {
+		s += fmt.Sprintf(" The contents were then moved to the cheaper %s.", d.DownsizedTo)
+	}
+	return s
+}
@@ -0,0 +1,74 @@
+package packing
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestBoxDecision(t *testing.T) {
+	cubes := []InputItem{{ID: "cube", W: 5, H: 5, D: 5, Quantity: 8}}
+	small := InputBox{ID: "small", W: 10, H: 10, D: 10}
+	large := InputBox{ID: "large", W: 20, H: 20, D: 20}
+
+	tests := []struct {
+		name     string
+		items    []InputItem
+		boxes    []InputBox
+		opts     Options
+		wantBox  string
+		wantRule string
+		wantText string
+	}{
+		{"same volume", cubes, []InputBox{large, small}, Options{}, "small", RuleSmallerBox, "smaller box (1000 against 8000)"},
+		{"tier", cubes, []InputBox{large, {ID: "small", W: 10, H: 10, D: 10, Tier: 1}}, Options{}, "large", RuleTier, "tier 0 comes before tier 1"},
+		{"utilization", []InputItem{{ID: "slab", W: 10, H: 5, D: 10, Quantity: 3}}, []InputBox{small, large}, Options{Objective: ObjectiveMaximizeUtilization}, "small", RuleUtilization, "100.0% against 18.8%"},
+		{"only fit", []InputItem{{ID: "big", W: 15, H: 15, D: 15, Quantity: 1}}, []InputBox{small, large}, Options{}, "large", RuleOnlyCandidate, "only box type"},
+	}
+	for _, tt := range tests {
+		packed, _ := PackWithOptions(tt.items, tt.boxes, tt.opts)
+		d := packed[0].Decision
+		if d == nil {
+			t.Fatalf("%s: expected a decision", tt.name)
+		}
+		if got := d.Candidates[d.Chosen].BoxID; got != tt.wantBox || packed[0].BoxID != tt.wantBox {
+			t.Errorf("%s: expected %s chosen, got %s", tt.name, tt.wantBox, got)
+		}
+		if d.Rule != tt.wantRule {
+			t.Errorf("%s: expected rule %s, got %s", tt.name, tt.wantRule, d.Rule)
+		}
+		if s := d.Summary(); !strings.Contains(s, tt.wantText) {
+			t.Errorf("%s: expected the summary to mention %q, got %q", tt.name, tt.wantText, s)
+		}
+		if len(d.Candidates) != 2 || d.RemainingItems != tt.items[0].Quantity {
+			t.Errorf("%s: expected 2 candidates for %d units, got %+v", tt.name, tt.items[0].Quantity, d)
+		}
+	}
+}
+
+func TestBoxDecisionSpilloverAndDownsize(t *testing.T) {
+	// 12 cubes fill the medium box; the last one goes into another medium.
+	items := []InputItem{{ID: "cube", W: 5, H: 5, D: 5, Quantity: 13}}
+	boxes := []InputBox{{ID: "small", W: 10, H: 10, D: 10}, {ID: "medium", W: 10, H: 10, D: 15}}
+	packed, _ := PackWithOptions(items, boxes, Options{Spillover: SpilloverSameSize})
+	if len(packed) != 2 || packed[1].Decision.Spillover != SpilloverSameSize {
+		t.Fatalf("Expected the second box chosen by the spillover policy, got %+v", packed)
+	}
+	if s := packed[1].Decision.Summary(); !strings.HasPrefix(s, "The same_size spillover policy restricted the choice to medium.") {
+		t.Errorf("Expected the summary to lead with the spillover policy, got %q", s)
+	}
+
+	// downsize keeps the decision of the box it replaces.
+	boxes = []InputBox{{ID: "small", W: 5, H: 5, D: 5, Cost: 1}, {ID: "big", W: 20, H: 20, D: 20, Cost: 2}}
+	cube := expandItems([]InputItem{{ID: "cube", W: 5, H: 5, D: 5, Quantity: 1}})
+	placements, _, _ := packIntoBox(context.Background(), cube, boxes[1], Options{})
+	packed = []PackedBox{newPackedBox("big", placements)}
+	packed[0].Decision = &BoxDecision{Candidates: []BoxCandidate{{BoxID: "big"}}, RunnerUp: -1, Rule: RuleOnlyCandidate}
+	downsize(context.Background(), packed, boxes, newBoxStock(boxes), cube, Options{})
+	if d := packed[0].Decision; packed[0].BoxID != "small" || d.DownsizedTo != "small" {
+		t.Errorf("Expected big to be downsized to small, got %s and %+v", packed[0].BoxID, d)
+	}
+	if s := packed[0].Decision.Summary(); !strings.HasSuffix(s, "moved to the cheaper small.") {
+		t.Errorf("Expected the summary to mention the downsize, got %q", s)
+	}
+}
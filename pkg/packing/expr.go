@@ -0,0 +1,386 @@
+package packing
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A small expression language for user-supplied placement constraints, e.g.
+//
+//	item.weight < 20 || placement.y == 0
+//
+// Expressions combine numbers, 'strings', true/false and the variables listed
+// in constraintVars with the operators || && ! == != < <= > >= + - * / and
+// parentheses. A constraint must evaluate to a boolean; a candidate placement
+// is only accepted when every constraint is true.
+
+// constraintVars lists the variables available to constraint expressions.
+var constraintVars = map[string]func(env *placementEnv) value{
+	"item.id":     func(e *placementEnv) value { return strValue(e.item.ID) },
+	"item.w":      func(e *placementEnv) value { return numValue(e.item.W) },
+	"item.h":      func(e *placementEnv) value { return numValue(e.item.H) },
+	"item.d":      func(e *placementEnv) value { return numValue(e.item.D) },
+	"item.volume": func(e *placementEnv) value { return numValue(e.item.volume) },
+	"item.weight": func(e *placementEnv) value { return numValue(e.item.Weight) },
+	"placement.x": func(e *placementEnv) value { return numValue(e.x) },
+	"placement.y": func(e *placementEnv) value { return numValue(e.y) },
+	"placement.z": func(e *placementEnv) value { return numValue(e.z) },
+	"placement.w": func(e *placementEnv) value { return numValue(e.w) },
+	"placement.h": func(e *placementEnv) value { return numValue(e.h) },
+	"placement.d": func(e *placementEnv) value { return numValue(e.d) },
+	"box.id":      func(e *placementEnv) value { return strValue(e.box.ID) },
+	"box.w":       func(e *placementEnv) value { return numValue(e.box.W) },
+	"box.h":       func(e *placementEnv) value { return numValue(e.box.H) },
+	"box.d":       func(e *placementEnv) value { return numValue(e.box.D) },
+	"box.items":   func(e *placementEnv) value { return numValue(len(e.placements)) },
+}
+
+// placementEnv is the candidate a constraint is evaluated against.
+type placementEnv struct {
+	item       itemToPack
+	box        InputBox
+	placements []Placement
+	x, y, z    int
+	w, h, d    int
+}
+
+type valueKind int
+
+const (
+	kindNum valueKind = iota
+	kindStr
+	kindBool
+)
+
+type value struct {
+	kind valueKind
+	num  float64
+	str  string
+	b    bool
+}
+
+func numValue[T int | float64](n T) value { return value{kind: kindNum, num: float64(n)} }
+func strValue(s string) value             { return value{kind: kindStr, str: s} }
+func boolValue(b bool) value              { return value{kind: kindBool, b: b} }
+
+func (k valueKind) String() string {
+	switch k {
+	case kindNum:
+		return "number"
+	case kindStr:
+		return "string"
+	default:
+		return "bool"
+	}
+}
+
+// expr is a compiled expression node.
+type expr interface {
+	eval(env *placementEnv) (value, error)
+}
+
+type (
+	literalExpr struct{ v value }
+	varExpr     struct{ get func(*placementEnv) value }
+	unaryExpr   struct {
+		op string
+		x  expr
+	}
+	binaryExpr struct {
+		op   string
+		l, r expr
+	}
+)
+
+func (e literalExpr) eval(*placementEnv) (value, error)    { return e.v, nil }
+func (e varExpr) eval(env *placementEnv) (value, error)    { return e.get(env), nil }
+func (e unaryExpr) eval(env *placementEnv) (value, error)  { return evalUnary(e, env) }
+func (e binaryExpr) eval(env *placementEnv) (value, error) { return evalBinary(e, env) }
+
+func evalUnary(e unaryExpr, env *placementEnv) (value, error) {
+	v, err := e.x.eval(env)
+	if err != nil {
+		return value{}, err
+	}
+	switch {
+	case e.op == "!" && v.kind == kindBool:
+		return boolValue(!v.b), nil
+	case e.op == "-" && v.kind == kindNum:
+		return numValue(-v.num), nil
+	}
+	return value{}, fmt.Errorf("operator %s not defined on %s", e.op, v.kind)
+}
+
+func evalBinary(e binaryExpr, env *placementEnv) (value, error) {
+	l, err := e.l.eval(env)
+	if err != nil {
+		return value{}, err
+	}
+
+	// Logical operators short-circuit.
+	if e.op == "&&" || e.op == "||" {
+		if l.kind != kindBool {
+			return value{}, fmt.Errorf("operator %s needs bool operands, got %s", e.op, l.kind)
+		}
+		if (e.op == "&&" && !l.b) || (e.op == "||" && l.b) {
+			return l, nil
+		}
+		r, err := e.r.eval(env)
+		if err != nil {
+			return value{}, err
+		}
+		if r.kind != kindBool {
+			return value{}, fmt.Errorf("operator %s needs bool operands, got %s", e.op, r.kind)
+		}
+		return r, nil
+	}
+
+	r, err := e.r.eval(env)
+	if err != nil {
+		return value{}, err
+	}
+	if l.kind != r.kind {
+		return value{}, fmt.Errorf("operator %s: mismatched types %s and %s", e.op, l.kind, r.kind)
+	}
+
+	switch e.op {
+	case "==":
+		return boolValue(l == r), nil
+	case "!=":
+		return boolValue(l != r), nil
+	}
+
+	if l.kind == kindStr {
+		switch e.op {
+		case "<":
+			return boolValue(l.str < r.str), nil
+		case "<=":
+			return boolValue(l.str <= r.str), nil
+		case ">":
+			return boolValue(l.str > r.str), nil
+		case ">=":
+			return boolValue(l.str >= r.str), nil
+		case "+":
+			return strValue(l.str + r.str), nil
+		}
+	}
+	if l.kind == kindNum {
+		switch e.op {
+		case "<":
+			return boolValue(l.num < r.num), nil
+		case "<=":
+			return boolValue(l.num <= r.num), nil
+		case ">":
+			return boolValue(l.num > r.num), nil
+		case ">=":
+			return boolValue(l.num >= r.num), nil
+		case "+":
+			return numValue(l.num + r.num), nil
+		case "-":
+			return numValue(l.num - r.num), nil
+		case "*":
+			return numValue(l.num * r.num), nil
+		case "/":
+			if r.num == 0 {
+				return value{}, fmt.Errorf("division by zero")
+			}
+			return numValue(l.num / r.num), nil
+		}
+	}
+	return value{}, fmt.Errorf("operator %s not defined on %s", e.op, l.kind)
+}
+
+// constraint is a compiled placement constraint.
+type constraint struct {
+	root expr
+}
+
+// allows reports whether the candidate satisfies the constraint. Evaluation
+// errors (such as a division by zero) count as a violation.
+func (c constraint) allows(env *placementEnv) bool {
+	v, err := c.root.eval(env)
+	return err == nil && v.kind == kindBool && v.b
+}
+
+// ValidateConstraints reports the first of srcs that does not compile.
+func ValidateConstraints(srcs []string) error {
+	_, err := compileConstraints(srcs)
+	return err
+}
+
+func compileConstraints(srcs []string) ([]constraint, error) {
+	out := make([]constraint, 0, len(srcs))
+	for _, src := range srcs {
+		root, err := parseExpr(src)
+		if err != nil {
+			return nil, fmt.Errorf("constraint %q: %w", src, err)
+		}
+		out = append(out, constraint{root: root})
+	}
+	return out, nil
+}
+
+// Parsing
+
+type token struct {
+	kind string // "num", "str", "ident", "op", "eof"
+	text string
+}
+
+func tokenize(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || (c == '.' && i+1 < len(src) && unicode.IsDigit(rune(src[i+1]))):
+			j := i
+			for j < len(src) && (unicode.IsDigit(rune(src[j])) || src[j] == '.') {
+				j++
+			}
+			toks = append(toks, token{"num", src[i:j]})
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(src) && (unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j])) || src[j] == '_' || src[j] == '.') {
+				j++
+			}
+			toks = append(toks, token{"ident", src[i:j]})
+			i = j
+		case c == '\'' || c == '"':
+			j := strings.IndexByte(src[i+1:], src[i])
+			if j < 0 {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			toks = append(toks, token{"str", src[i+1 : i+1+j]})
+			i += j + 2
+		default:
+			op := ""
+			for _, candidate := range []string{"||", "&&", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "(", ")"} {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+			toks = append(toks, token{"op", op})
+			i += len(op)
+		}
+	}
+	return append(toks, token{kind: "eof"}), nil
+}
+
+var binaryPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6,
+}
+
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func parseExpr(src string) (expr, error) {
+	toks, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	e, err := p.binary(1)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != "eof" {
+		return nil, fmt.Errorf("unexpected %q", t.text)
+	}
+	return e, nil
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	p.pos++
+	return t
+}
+
+// binary parses operators of at least the given precedence (precedence climbing).
+func (p *parser) binary(minPrec int) (expr, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		prec, ok := binaryPrecedence[t.text]
+		if t.kind != "op" || !ok || prec < minPrec {
+			return left, nil
+		}
+		p.next()
+		right, err := p.binary(prec + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{op: t.text, l: left, r: right}
+	}
+}
+
+func (p *parser) unary() (expr, error) {
+	if t := p.peek(); t.kind == "op" && (t.text == "!" || t.text == "-") {
+		p.next()
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryExpr{op: t.text, x: x}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (expr, error) {
+	t := p.next()
+	switch t.kind {
+	case "num":
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", t.text)
+		}
+		return literalExpr{numValue(n)}, nil
+	case "str":
+		return literalExpr{strValue(t.text)}, nil
+	case "ident":
+		switch t.text {
+		case "true":
+			return literalExpr{boolValue(true)}, nil
+		case "false":
+			return literalExpr{boolValue(false)}, nil
+		}
+		get, ok := constraintVars[t.text]
+		if !ok {
+			return nil, fmt.Errorf("unknown variable %q", t.text)
+		}
+		return varExpr{get: get}, nil
+	case "op":
+		if t.text == "(" {
+			e, err := p.binary(1)
+			if err != nil {
+				return nil, err
+			}
+			if p.next().text != ")" {
+				return nil, fmt.Errorf("missing closing parenthesis")
+			}
+			return e, nil
+		}
+	case "eof":
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q", t.text)
+}
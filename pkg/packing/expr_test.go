@@ -0,0 +1,92 @@
+package packing
+
+import "testing"
+
+func TestConstraintExpressions(t *testing.T) {
+	env := &placementEnv{
+		item: itemToPack{InputItem: InputItem{ID: "tv", W: 10, H: 20, D: 5, Weight: 25}, volume: 1000},
+		box:  InputBox{ID: "crate", W: 50, H: 50, D: 50},
+		x:    0, y: 20, z: 5,
+		w: 10, h: 20, d: 5,
+	}
+
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{"placement.y == 0", false},
+		{"item.volume < 2000 || placement.y == 0", true},
+		{"item.weight < 20 || placement.y == 0", false},
+		{"item.weight >= 25", true},
+		{"item.id == 'tv' && box.id != \"pallet\"", true},
+		{"!(placement.y + placement.h > box.h / 2)", false},
+		{"-item.w * 2 < 0", true},
+		{"item.w / 0 > 1", false},
+		{"item.id", false},
+	}
+
+	for _, tt := range tests {
+		cs, err := compileConstraints([]string{tt.src})
+		if err != nil {
+			t.Errorf("%s: %v", tt.src, err)
+			continue
+		}
+		if got := cs[0].allows(env); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestConstraintCompileErrors(t *testing.T) {
+	for _, src := range []string{"item.color == 'red'", "placement.y ==", "(item.w > 1", "item.w $ 2", "'open"} {
+		if _, err := compileConstraints([]string{src}); err == nil {
+			t.Errorf("%s: expected a compile error", src)
+		}
+	}
+}
+
+func TestPackWithConstraints(t *testing.T) {
+	items := []InputItem{
+		{ID: "heavy", W: 10, H: 10, D: 10, Quantity: 2},
+		{ID: "light", W: 5, H: 5, D: 5, Quantity: 4},
+	}
+	boxes := []InputBox{{ID: "box", W: 20, H: 20, D: 20}}
+
+	// Large items must stay on the floor.
+	opts := Options{Constraints: []string{"item.volume < 1000 || placement.y == 0"}}
+	packedBoxes, unpackedItems := PackWithOptions(items, boxes, opts)
+
+	if len(unpackedItems) > 0 {
+		t.Errorf("Expected all items packed, got %d unpacked", len(unpackedItems))
+	}
+	for _, pb := range packedBoxes {
+		for _, p := range pb.Contents {
+			if p.ItemID == "heavy" && p.Y != 0 {
+				t.Errorf("Expected heavy items on the floor, got y=%d", p.Y)
+			}
+		}
+	}
+}
+
+func TestPackWithWeightConstraint(t *testing.T) {
+	items := []InputItem{
+		{ID: "anvil", W: 10, H: 10, D: 10, Weight: 30, Quantity: 2},
+		{ID: "pillow", W: 10, H: 10, D: 10, Weight: 1, Quantity: 4},
+	}
+	boxes := []InputBox{{ID: "box", W: 20, H: 20, D: 20}}
+
+	// The example from the constraint docs: heavy items stay on the floor.
+	opts := Options{Constraints: []string{"item.weight < 20 || placement.y == 0"}}
+	packedBoxes, unpackedItems := PackWithOptions(items, boxes, opts)
+
+	if len(unpackedItems) > 0 {
+		t.Errorf("Expected all items packed, got %d unpacked", len(unpackedItems))
+	}
+	for _, pb := range packedBoxes {
+		for _, p := range pb.Contents {
+			if p.ItemID == "anvil" && p.Y != 0 {
+				t.Errorf("Expected anvils on the floor, got y=%d", p.Y)
+			}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package packing
+
+// skuGapPenalty weighs one unit of distance from the item's SKU block like a
+// whole unit of height, so a unit is only placed away from its block when
+// nothing touching it fits.
+const skuGapPenalty = 1000
+
+// skuGap returns the smallest distance between the candidate box and an
+// already placed unit with the same ID, summed over the three axes; 0 when
+// they touch or when no unit of the SKU is placed yet.
+func skuGap(placements []Placement, id string, x, y, z, w, h, d int) int {
+	best := -1
+	for _, p := range placements {
+		if p.ItemID != id {
+			continue
+		}
+		gap := axisGap(p.X, p.W, x, w) + axisGap(p.Y, p.H, y, h) + axisGap(p.Z, p.D, z, d)
+		if best == -1 || gap < best {
+			best = gap
+		}
+	}
+	return max(best, 0)
+}
+
+func axisGap(a, aLen, b, bLen int) int {
+	return max(0, a-(b+bLen), b-(a+aLen))
+}
+
+// slidePositions returns the other floor corners of free space ep that an
+// item of footprint w × d can slide to from (x, z), so a unit can sit next to
+// its SKU block at either end of the space.
+func slidePositions(ep FreeSpace, x, y, z, w, d int) [][3]int {
+	xs, zs := []int{x}, []int{z}
+	if alt := 2*ep.X + ep.W - w - x; ep.W >= w && alt != x {
+		xs = append(xs, alt)
+	}
+	if alt := 2*ep.Z + ep.D - d - z; ep.D >= d && alt != z {
+		zs = append(zs, alt)
+	}
+	var out [][3]int
+	for _, px := range xs {
+		for _, pz := range zs {
+			if px != x || pz != z {
+				out = append(out, [3]int{px, y, pz})
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,73 @@
+package packing
+
+import "testing"
+
+func TestGroupSKUs(t *testing.T) {
+	// Next to the big item there is an L-shaped floor area; the third small
+	// unit would land in the far corner away from the other two.
+	items := []InputItem{
+		{ID: "big", W: 20, H: 10, D: 20, Quantity: 1},
+		{ID: "small", W: 10, H: 10, D: 10, Quantity: 3},
+	}
+	boxes := []InputBox{{ID: "box", W: 30, H: 10, D: 30}}
+
+	packed, _ := PackWithOptions(items, boxes, Options{GroupSKUs: true})
+	if len(packed) != 1 {
+		t.Fatalf("Expected 1 box, got %d", len(packed))
+	}
+	var units []Placement
+	for _, p := range packed[0].Contents {
+		if p.ItemID == "small" {
+			units = append(units, p)
+		}
+	}
+	for i, p := range units {
+		if gap := skuGap(append(units[:i:i], units[i+1:]...), "small", p.X, p.Y, p.Z, p.W, p.H, p.D); gap != 0 {
+			t.Errorf("Expected every unit to touch another, %+v is %d away", p, gap)
+		}
+	}
+}
+
+func TestSkuGap(t *testing.T) {
+	placed := []Placement{{ItemID: "a", X: 0, W: 10, H: 10, D: 10}}
+	if g := skuGap(placed, "a", 15, 0, 0, 10, 10, 10); g != 5 {
+		t.Errorf("Expected a gap of 5, got %d", g)
+	}
+	if g := skuGap(placed, "a", 10, 0, 0, 10, 10, 10); g != 0 {
+		t.Errorf("Expected touching boxes to have no gap, got %d", g)
+	}
+	if g := skuGap(placed, "b", 50, 0, 0, 10, 10, 10); g != 0 {
+		t.Errorf("Expected no gap for a SKU not yet placed, got %d", g)
+	}
+}
+
+func TestKeepGroupsTogether(t *testing.T) {
+	items := []InputItem{
+		{ID: "a", W: 10, H: 10, D: 10, Quantity: 1, GroupID: "order-1"},
+		{ID: "b", W: 10, H: 10, D: 10, Quantity: 2, GroupID: "order-2"},
+		{ID: "c", W: 10, H: 10, D: 10, Quantity: 1, GroupID: "order-3"},
+	}
+	boxes := []InputBox{{ID: "double", W: 20, H: 10, D: 10}}
+
+	// Packed in ID order, the second order would straddle both boxes.
+	packed, _ := Pack(items, boxes)
+	if w := GroupSplitWarnings(packed, items); len(w) != 1 {
+		t.Fatalf("Expected the plain packer to split one order, got %+v", w)
+	}
+
+	packed, unpacked := PackWithOptions(items, boxes, Options{KeepGroupsTogether: true})
+	if len(packed) != 2 || len(unpacked) != 0 {
+		t.Fatalf("Expected 2 boxes and nothing unpacked, got %d and %d", len(packed), len(unpacked))
+	}
+	if w := GroupSplitWarnings(packed, items); len(w) != 0 {
+		t.Errorf("Expected every order in one box, got %+v", w)
+	}
+
+	// An order larger than any box is split rather than left unpacked.
+	items = append(items, InputItem{ID: "d", W: 10, H: 10, D: 10, Quantity: 3, GroupID: "order-4"})
+	packed, unpacked = PackWithOptions(items, boxes, Options{KeepGroupsTogether: true})
+	w := GroupSplitWarnings(packed, items)
+	if len(unpacked) != 0 || len(w) != 1 || w[0].Code != WarnGroupSplit {
+		t.Errorf("Expected only the oversized order to be split, got %d unpacked and %+v", len(unpacked), w)
+	}
+}
@@ -0,0 +1,174 @@
+package packing
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+)
+
+// With keep_groups_together, items sharing a group_id, such as the lines of
+// one order in a batch, go into a box as a whole: a group is only added to a
+// box if every unit of it fits, and otherwise waits for a later box. Groups
+// too large for any empty box are split like ungrouped items, which fill the
+// space the groups leave.
+
+// itemGroup is the units of one group_id among the items still to pack.
+type itemGroup struct {
+	id     string
+	items  []int // indexes into the items being packed
+	volume int
+}
+
+// groupItems splits items into the groups to keep together, largest first,
+// and the loose units: ungrouped ones and those of the groups in split.
+func groupItems(items []itemToPack, split map[string]bool) ([]itemGroup, []int) {
+	byID := make(map[string]*itemGroup)
+	var groups []*itemGroup
+	var loose []int
+	for i, it := range items {
+		if it.GroupID == "" || split[it.GroupID] {
+			loose = append(loose, i)
+			continue
+		}
+		g := byID[it.GroupID]
+		if g == nil {
+			g = &itemGroup{id: it.GroupID}
+			byID[it.GroupID] = g
+			groups = append(groups, g)
+		}
+		g.items = append(g.items, i)
+		g.volume += it.volume
+	}
+	slices.SortStableFunc(groups, func(a, b *itemGroup) int {
+		if c := cmp.Compare(b.volume, a.volume); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.id, b.id)
+	})
+	out := make([]itemGroup, len(groups))
+	for i, g := range groups {
+		out[i] = *g
+	}
+	return out, loose
+}
+
+// oversizedGroups returns the groups that fit no empty box of boxes whole.
+func oversizedGroups(ctx context.Context, items []itemToPack, boxes []InputBox, opts Options) map[string]bool {
+	groups, _ := groupItems(items, nil)
+	split := make(map[string]bool)
+	for _, g := range groups {
+		units := make([]itemToPack, len(g.items))
+		for i, k := range g.items {
+			units[i] = items[k]
+		}
+		fits := false
+		for _, box := range boxes {
+			if _, _, vol := packIntoBox(ctx, units, box, opts); vol == g.volume {
+				fits = true
+				break
+			}
+		}
+		if !fits {
+			split[g.id] = true
+		}
+	}
+	return split
+}
+
+// clone returns a copy of s that can be placed into without changing s.
+func (s *boxState) clone() *boxState {
+	c := *s
+	c.extremePoints = slices.Clone(s.extremePoints)
+	c.spaces = slices.Clone(s.spaces)
+	c.placements = slices.Clone(s.placements)
+	c.items = slices.Clone(s.items)
+	c.grid, c.gridded = nil, 0
+	return &c
+}
+
+// fillGrouped packs items into box whole group by whole group, then the
+// loose units one by one.
+func fillGrouped(ctx context.Context, items []itemToPack, box InputBox, split map[string]bool, opts Options) ([]Placement, []bool, int) {
+	groups, loose := groupItems(items, split)
+	state := newBoxState(ctx, box, opts)
+	packed := make([]bool, len(items))
+	minSide := suffixMinSides(items)[0]
+
+	for _, g := range groups {
+		trial := state.clone()
+		fits := true
+		for _, k := range g.items {
+			if !trial.place(items[k], minSide) {
+				fits = false
+				break
+			}
+		}
+		if fits {
+			state = trial
+			for _, k := range g.items {
+				packed[k] = true
+			}
+		}
+	}
+	for _, k := range loose {
+		if state.place(items[k], minSide) {
+			packed[k] = true
+		}
+	}
+	return state.placements, packed, state.packedVol
+}
+
+// findGroupedBox is findBestBox for keep_groups_together.
+func findGroupedBox(ctx context.Context, items []itemToPack, boxes []InputBox, split map[string]bool, opts Options) (int, []Placement, []bool, *BoxDecision) {
+	bestIdx := -1
+	var bestPlacements []Placement
+	packed := make([][]bool, len(boxes))
+	packedVol := make([]int, len(boxes))
+	for i, box := range boxes {
+		var placements []Placement
+		placements, packed[i], packedVol[i] = fillGrouped(ctx, items, box, split, opts)
+		if packedVol[i] > 0 && (bestIdx == -1 || opts.prefers(box, packedVol[i], boxes[bestIdx], packedVol[bestIdx])) {
+			bestIdx, bestPlacements = i, placements
+		}
+	}
+	if bestIdx == -1 {
+		return -1, nil, nil, nil
+	}
+	return bestIdx, bestPlacements, packed[bestIdx], newBoxDecision(items, boxes, packed, packedVol, bestIdx, opts)
+}
+
+// GroupSplitWarnings flags every group whose units ended up in more than one
+// box.
+func GroupSplitWarnings(packed []PackedBox, items []InputItem) []Warning {
+	groupOf := make(map[string]string)
+	var order []string
+	for _, it := range items {
+		if it.GroupID != "" {
+			groupOf[it.ID] = it.GroupID
+		}
+	}
+	boxes := make(map[string]int)
+	for _, pb := range packed {
+		seen := make(map[string]bool)
+		for _, p := range pb.Contents {
+			if g := groupOf[p.ItemID]; g != "" && !seen[g] {
+				seen[g] = true
+				if boxes[g] == 0 {
+					order = append(order, g)
+				}
+				boxes[g]++
+			}
+		}
+	}
+	var warnings []Warning
+	for _, g := range order {
+		if boxes[g] > 1 {
+			warnings = append(warnings, Warning{
+				Code:    WarnGroupSplit,
+				Message: fmt.Sprintf("group %q does not fit in one box and is split over %d boxes", g, boxes[g]),
+			})
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,18 @@
+package packing
+
+import "slices"
+
+// Items listing each other in incompatible_with, such as chemicals and food,
+// never share a box. Incompatibility goes both ways, so it is enough for one
+// of the two items to list the other. An item that cannot join a box for this
+// reason waits for another box, like one that is too heavy.
+
+// compatible reports whether item may be placed in a box holding placed.
+func compatible(item itemToPack, placed []itemToPack) bool {
+	for _, p := range placed {
+		if slices.Contains(item.IncompatibleWith, p.ID) || slices.Contains(p.IncompatibleWith, item.ID) {
+			return false
+		}
+	}
+	return true
+}
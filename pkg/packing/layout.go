@@ -0,0 +1,25 @@
+package packing
+
+import "fmt"
+
+// CheckLayout verifies that every placement lies inside its box and that no
+// two placements overlap. The packer guarantees both; the check is for
+// layouts that were edited or converted afterwards.
+func CheckLayout(packed []PackedBox, boxes []InputBox) error {
+	byID := BoxesByID(boxes)
+	for i, pb := range packed {
+		box, ok := byID[pb.BoxID]
+		if !ok {
+			return fmt.Errorf("box %d: unknown box id %q", i, pb.BoxID)
+		}
+		for j, p := range pb.Contents {
+			if !FitsInBox(box, p.X, p.Y, p.Z, p.W, p.H, p.D) {
+				return fmt.Errorf("box %d: item %q extends outside the box", i, p.ItemID)
+			}
+			if hasOverlap(pb.Contents[:j], p.X, p.Y, p.Z, p.W, p.H, p.D) {
+				return fmt.Errorf("box %d: item %q overlaps another item", i, p.ItemID)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package packing
+
+import "fmt"
+
+// InnerUnit describes the units inside a multipack case, e.g. 12 bottles in
+// a case.
+type InnerUnit struct {
+	// ID defaults to the case ID with a "-unit" suffix.
+	ID    string `json:"id,omitempty"`
+	W     int    `json:"w"`
+	H     int    `json:"h"`
+	D     int    `json:"d"`
+	Count int    `json:"count"`
+	// Weight of one unit; defaults to the case weight divided by Count.
+	Weight float64 `json:"weight,omitempty"`
+}
+
+// MultipackSplit reports cases that were broken into their inner units.
+type MultipackSplit struct {
+	ItemID string `json:"item_id"`
+	UnitID string `json:"unit_id"`
+	Cases  int    `json:"cases"`
+	Units  int    `json:"units"`
+}
+
+func ValidateMultipacks(items []InputItem) error {
+	for _, it := range items {
+		if it.Inner == nil {
+			continue
+		}
+		in := it.Inner
+		if in.Count < 2 || in.W <= 0 || in.H <= 0 || in.D <= 0 || in.Weight < 0 {
+			return fmt.Errorf("item %q: inner needs positive dimensions and a count of at least 2", it.ID)
+		}
+	}
+	return nil
+}
+
+// units returns the inner units of every case of a multipack as one item.
+// They keep the case's orientation, stacking and customs attributes, with
+// weight and value shared out per unit.
+func (it InputItem) units() InputItem {
+	in := it.Inner
+	u := it
+	u.ID = in.ID
+	if u.ID == "" {
+		u.ID = it.ID + "-unit"
+	}
+	u.W, u.H, u.D = in.W, in.H, in.D
+	u.Quantity = it.Quantity * in.Count
+	u.Weight = in.Weight
+	if u.Weight == 0 {
+		u.Weight = it.Weight / float64(in.Count)
+	}
+	u.Value = it.Value / float64(in.Count)
+	u.Inner, u.Splittable = nil, false
+	return u
+}
+
+// SplitMultipacks breaks splittable cases that fit no box into their inner
+// units. Cases that fit some box are packed whole.
+func SplitMultipacks(items []InputItem, boxes []InputBox) ([]InputItem, []MultipackSplit) {
+	var out []InputItem
+	var splits []MultipackSplit
+	for _, it := range items {
+		if it.Inner == nil || !it.Splittable || it.Quantity == 0 || fitsAnyBox(it, boxes) {
+			out = append(out, it)
+			continue
+		}
+		u := it.units()
+		out = append(out, u)
+		splits = append(splits, MultipackSplit{ItemID: it.ID, UnitID: u.ID, Cases: it.Quantity, Units: u.Quantity})
+	}
+	return out, splits
+}
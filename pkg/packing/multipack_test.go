@@ -0,0 +1,31 @@
+package packing
+
+import "testing"
+
+func TestSplitMultipacks(t *testing.T) {
+	boxes := []InputBox{{ID: "box", W: 20, H: 20, D: 20}}
+	inner := &InnerUnit{W: 10, H: 10, D: 10, Count: 12}
+	items := []InputItem{
+		{ID: "case", W: 30, H: 20, D: 40, Quantity: 2, Weight: 6, Value: 24, Inner: inner, Splittable: true},
+		{ID: "small-case", W: 20, H: 10, D: 20, Quantity: 1, Inner: &InnerUnit{W: 10, H: 10, D: 10, Count: 4}, Splittable: true},
+	}
+
+	out, splits := SplitMultipacks(items, boxes)
+
+	if len(splits) != 1 || splits[0].ItemID != "case" || splits[0].Units != 24 || splits[0].UnitID != "case-unit" {
+		t.Errorf("Expected only the oversized case to be split into 24 units, got %+v", splits)
+	}
+	u := out[0]
+	if u.ID != "case-unit" || u.Quantity != 24 || u.Weight != 0.5 || u.Value != 2 || u.Inner != nil {
+		t.Errorf("Expected units to share out weight and value, got %+v", u)
+	}
+	if out[1].ID != "small-case" {
+		t.Errorf("Expected the fitting case to stay whole, got %+v", out[1])
+	}
+
+	items[0].Splittable = false
+	s := SuggestBoxes(items[:1], boxes)
+	if len(s) != 1 || !s[0].SplitHelps {
+		t.Errorf("Expected the suggestion to say splitting would help, got %+v", s)
+	}
+}
@@ -0,0 +1,111 @@
+package packing
+
+import "context"
+
+// Objectives for filling one box at a time. They differ in which box type
+// findBestBox opens next.
+const (
+	// ObjectiveMinimizeBoxes opens the box that packs the most volume, with
+	// the smaller box winning a tie. It is the default.
+	ObjectiveMinimizeBoxes = "minimize_boxes"
+	// ObjectiveMinimizeCost opens the box with the lowest cost per packed
+	// volume, then swaps each box for a cheaper type that holds the same
+	// contents where there is one. Boxes without a cost count as free.
+	ObjectiveMinimizeCost = "minimize_cost"
+	// ObjectiveMaximizeUtilization opens the box filled to the highest
+	// share of its volume.
+	ObjectiveMaximizeUtilization = "maximize_utilization"
+)
+
+// prefers reports whether box a packing aVol beats box b packing bVol: the
+// lower tier wins, then the box the objective prefers.
+func (o Options) prefers(a InputBox, aVol int, b InputBox, bVol int) bool {
+	better, _ := o.compareBoxes(a, aVol, b, bVol)
+	return better
+}
+
+// compareBoxes is prefers that also returns the rule that decided, or
+// RuleBoxOrder when a and b tie.
+func (o Options) compareBoxes(a InputBox, aVol int, b InputBox, bVol int) (bool, string) {
+	if a.Tier != b.Tier {
+		return a.Tier < b.Tier, RuleTier
+	}
+	switch o.Objective {
+	case ObjectiveMinimizeCost:
+		// a.Cost/aVol < b.Cost/bVol without dividing.
+		if ac, bc := a.Cost*float64(bVol), b.Cost*float64(aVol); ac != bc {
+			return ac < bc, RuleCostPerVolume
+		}
+	case ObjectiveMaximizeUtilization:
+		if au, bu := aVol*b.Volume(), bVol*a.Volume(); au != bu {
+			return au > bu, RuleUtilization
+		}
+	}
+	if aVol != bVol {
+		return aVol > bVol, RulePackedVolume
+	}
+	if a.Volume() != b.Volume() {
+		return a.Volume() < b.Volume(), RuleSmallerBox
+	}
+	return false, RuleBoxOrder
+}
+
+// downsize replaces each packed box with the cheapest type in stock that
+// takes all of its contents. items supplies the item behind each placement.
+func downsize(ctx context.Context, packed []PackedBox, boxes []InputBox, stock boxStock, items []itemToPack, opts Options) {
+	byID := make(map[string]itemToPack, len(items))
+	for _, it := range items {
+		byID[it.ID] = it
+	}
+
+	for i, pb := range packed {
+		cur := -1
+		for j, b := range boxes {
+			if b.ID == pb.BoxID {
+				cur = j
+				break
+			}
+		}
+		if cur == -1 {
+			continue
+		}
+
+		contents := make([]itemToPack, len(pb.Contents))
+		for k, p := range pb.Contents {
+			contents[k] = byID[p.ItemID]
+		}
+
+		best, bestPlacements := cur, pb.Contents
+		for j, b := range boxes {
+			if stock[j] == 0 || b.Tier > boxes[best].Tier || b.Cost >= boxes[best].Cost {
+				continue
+			}
+			placements, ok, _ := packIntoBox(ctx, contents, b, opts)
+			if !allPacked(ok) {
+				continue
+			}
+			best, bestPlacements = j, placements
+		}
+		if best != cur {
+			if stock[cur] >= 0 {
+				stock[cur]++
+			}
+			stock.take(best)
+			packed[i] = newPackedBox(boxes[best].ID, bestPlacements)
+			if pb.Decision != nil {
+				d := *pb.Decision
+				d.DownsizedTo = boxes[best].ID
+				packed[i].Decision = &d
+			}
+		}
+	}
+}
+
+func allPacked(packed []bool) bool {
+	for _, ok := range packed {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
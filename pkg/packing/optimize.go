@@ -0,0 +1,312 @@
+package packing
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"binpacker/geometry"
+)
+
+// Score ranks packing results; lower is better. Unpacked items weigh
+// most, then the number of boxes, then the total volume of the boxes used.
+type Score struct {
+	Unpacked  int `json:"unpacked"`
+	Boxes     int `json:"boxes"`
+	BoxVolume int `json:"box_volume"`
+}
+
+func (a Score) Compare(b Score) int {
+	if c := cmp.Compare(a.Unpacked, b.Unpacked); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Boxes, b.Boxes); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.BoxVolume, b.BoxVolume)
+}
+
+func scorePack(packed []PackedBox, unpacked []InputItem, boxes []InputBox) Score {
+	vol := make(map[string]int, len(boxes))
+	for _, b := range boxes {
+		vol[b.ID] = b.Volume()
+	}
+
+	s := Score{Unpacked: len(unpacked), Boxes: len(packed)}
+	for _, pb := range packed {
+		s.BoxVolume += vol[pb.BoxID]
+	}
+	return s
+}
+
+// Search improves a packing by simulated annealing over the item
+// insertion order and the rotation of each item. The greedy volume-sorted
+// order with free rotations is the starting point; each step perturbs the
+// current order and keeps the result if it is no worse, or with a chance
+// that falls with the temperature if it is worse. At temperature zero, the
+// default, this is plain hill climbing. The best result found is kept
+// apart, and it and the current one are exported through SearchState so a
+// search can be checkpointed and resumed.
+type Search struct {
+	items []itemToPack
+	boxes []InputBox
+	opts  Options
+	rng   *rand.Rand
+	State SearchState
+
+	// choices holds the rotations each item may be pinned to; items with
+	// fewer than two distinct ones have none.
+	choices [][]int
+	// volumeScale turns box volume into energy; see energy.
+	volumeScale float64
+	temperature float64
+}
+
+// SearchState is the resumable part of a Search.
+type SearchState struct {
+	Seed       uint64      `json:"seed"`
+	Iterations int         `json:"iterations"`
+	Order      []int       `json:"order"`
+	Score      Score       `json:"score"`
+	Packed     []PackedBox `json:"packed_boxes"`
+	Unpacked   []InputItem `json:"unpacked_items"`
+	// Rotations pins each item, by index into the sorted items, to one of
+	// rotationNames; -1 leaves it free.
+	Rotations []int `json:"rotations,omitempty"`
+
+	// Current is where the annealing walk stands, when it has moved away
+	// from the best result.
+	Current *searchPoint `json:"current,omitempty"`
+}
+
+// searchPoint is one order and its result.
+type searchPoint struct {
+	Order     []int `json:"order"`
+	Rotations []int `json:"rotations"`
+	Score     Score `json:"score"`
+}
+
+// annealStart is the temperature a search is cooled from: a step that
+// costs one extra box is then accepted about one time in eight.
+const annealStart = 1.0
+
+// NewSearch prepares a search. When resume carries a previous order it
+// continues from there; otherwise it evaluates the greedy order first.
+func NewSearch(inputItems []InputItem, availableBoxes []InputBox, opts Options, seed uint64, resume *SearchState) *Search {
+	items := expandItems(inputItems)
+	sortItemsByVolume(items)
+	if opts.PlacementPolicy == PlacementAxleLoad {
+		sortItemsByWeight(items)
+	}
+
+	s := &Search{
+		items:   items,
+		boxes:   sortBoxesByVolume(availableBoxes),
+		opts:    opts.withCompiledConstraints(),
+		choices: make([][]int, len(items)),
+	}
+	for i, it := range items {
+		s.choices[i] = rotationChoices(it.InputItem)
+	}
+
+	if resume != nil && len(resume.Order) == len(items) {
+		s.State = *resume
+	} else {
+		order := make([]int, len(items))
+		for i := range order {
+			order[i] = i
+		}
+		packed, unpacked := algorithmFor(s.opts).Pack(context.Background(), items, s.boxes, s.opts)
+		s.State = SearchState{
+			Seed:     seed,
+			Order:    order,
+			Score:    scorePack(packed, unpacked, s.boxes),
+			Packed:   packed,
+			Unpacked: unpacked,
+		}
+	}
+	if len(s.State.Rotations) != len(items) {
+		s.State.Rotations = slices.Repeat([]int{-1}, len(items))
+	}
+	if cur := s.State.Current; cur != nil && (len(cur.Order) != len(items) || len(cur.Rotations) != len(items)) {
+		s.State.Current = nil
+	}
+	s.volumeScale = float64(max(1, s.State.Score.BoxVolume))
+
+	// Seeding with the iteration count keeps a resumed search on a fresh
+	// random stream instead of replaying steps already tried.
+	s.rng = rand.New(rand.NewPCG(s.State.Seed, uint64(s.State.Iterations)))
+	return s
+}
+
+// rotationChoices returns the indexes into rotationNames an item may be
+// pinned to, one per distinct orientation, or nil if there is no choice.
+func rotationChoices(item InputItem) []int {
+	var choices []int
+	var seen [][3]int
+	for i, rot := range geometry.RotationsOf(item.W, item.H, item.D) {
+		if item.KeepUpright && rotationNames[i][1] != 'h' {
+			continue
+		}
+		if len(item.AllowedRotations) > 0 && !slices.Contains(item.AllowedRotations, rotationNames[i]) {
+			continue
+		}
+		if slices.Contains(seen, rot) {
+			continue
+		}
+		seen = append(seen, rot)
+		choices = append(choices, i)
+	}
+	if len(choices) < 2 {
+		return nil
+	}
+	return choices
+}
+
+// Cool sets the temperature for a search that has used progress, from 0
+// to 1, of its time budget.
+func (s *Search) Cool(progress float64) {
+	s.temperature = annealStart * max(0, 1-progress)
+}
+
+// energy is a score as one number: an unpacked item costs more than a box,
+// and a box more than a difference in box volume of the size of the
+// greedy result.
+func (s *Search) energy(sc Score) float64 {
+	return float64(sc.Unpacked)*4 + float64(sc.Boxes)*2 + float64(sc.BoxVolume)/s.volumeScale
+}
+
+// current returns where the annealing walk stands.
+func (s *Search) current() searchPoint {
+	if s.State.Current != nil {
+		return *s.State.Current
+	}
+	return searchPoint{Order: s.State.Order, Rotations: s.State.Rotations, Score: s.State.Score}
+}
+
+// Step tries one perturbation and reports whether it strictly improved the
+// best result.
+func (s *Search) Step() bool {
+	s.State.Iterations++
+
+	cur := s.current()
+	order := slices.Clone(cur.Order)
+	rots := slices.Clone(cur.Rotations)
+	if len(order) < 2 {
+		return false
+	}
+
+	// Pin or free the rotation of a random item, or move a random item to
+	// a random position; a few moves per step let the search escape
+	// plateaus where single changes do nothing.
+	moves := 1 + s.rng.IntN(3)
+	for range moves {
+		if k := s.rng.IntN(len(order)); s.rng.IntN(3) == 0 && s.choices[k] != nil {
+			if c := s.rng.IntN(len(s.choices[k]) + 1); c < len(s.choices[k]) {
+				rots[k] = s.choices[k][c]
+			} else {
+				rots[k] = -1
+			}
+			continue
+		}
+		from := s.rng.IntN(len(order))
+		to := s.rng.IntN(len(order))
+		v := order[from]
+		order = slices.Insert(slices.Delete(order, from, from+1), to, v)
+	}
+
+	items := make([]itemToPack, len(order))
+	for i, idx := range order {
+		items[i] = s.items[idx]
+		if r := rots[idx]; r >= 0 {
+			items[i].KeepUpright = false
+			items[i].AllowedRotations = []string{rotationNames[r]}
+		}
+	}
+
+	packed, unpacked := algorithmFor(s.opts).Pack(context.Background(), items, s.boxes, s.opts)
+	s.unpin(unpacked)
+	score := scorePack(packed, unpacked, s.boxes)
+
+	if score.Compare(cur.Score) > 0 {
+		delta := s.energy(score) - s.energy(cur.Score)
+		if s.temperature <= 0 || s.rng.Float64() >= math.Exp(-delta/s.temperature) {
+			return false
+		}
+	}
+	s.State.Current = &searchPoint{Order: order, Rotations: rots, Score: score}
+
+	c := score.Compare(s.State.Score)
+	if c > 0 {
+		return false
+	}
+	s.State.Order, s.State.Rotations, s.State.Score, s.State.Packed, s.State.Unpacked = order, rots, score, packed, unpacked
+	s.State.Current = nil
+	return c < 0
+}
+
+// unpin restores the rotation constraints of the caller's items to unpacked
+// items a step pinned.
+func (s *Search) unpin(unpacked []InputItem) {
+	for i := range unpacked {
+		idx := slices.IndexFunc(s.items, func(it itemToPack) bool { return it.ID == unpacked[i].ID })
+		if idx >= 0 {
+			unpacked[i] = s.items[idx].InputItem
+		}
+	}
+}
+
+// OptimizeRun bounds an optimize search. Without a Seed it stops after
+// Budget, or Steps steps if set. With one it runs exactly Steps steps, with
+// the temperature lowered by step rather than by time, so the same request
+// and seed always give the same result however fast the server is.
+// TargetBoxes, if set, ends the search early once every item is packed in
+// at most that many boxes.
+type OptimizeRun struct {
+	Budget      time.Duration
+	Steps       int
+	Seed        *uint64
+	TargetBoxes int
+}
+
+// OptimizeContext packs like PackContext, then anneals the insertion order
+// and rotations as run allows or until ctx is done, and returns the best
+// packing found with the number of orders tried. The error is set only when
+// ctx cut a seeded search short, as its result is then not reproducible.
+func OptimizeContext(ctx context.Context, inputItems []InputItem, availableBoxes []InputBox, opts Options, run OptimizeRun) ([]PackedBox, []InputItem, int, error) {
+	start := time.Now()
+	seed := uint64(start.UnixNano())
+	if run.Seed != nil {
+		seed = *run.Seed
+	}
+	search := NewSearch(inputItems, availableBoxes, opts, seed, nil)
+	for i := 0; run.Steps == 0 || i < run.Steps; i++ {
+		if ctx.Err() != nil {
+			break
+		}
+		if best := search.State.Score; run.TargetBoxes > 0 && best.Unpacked == 0 && best.Boxes <= run.TargetBoxes {
+			break
+		}
+		if run.Seed != nil {
+			search.Cool(float64(i) / float64(run.Steps))
+		} else {
+			elapsed := time.Since(start)
+			if elapsed >= run.Budget {
+				break
+			}
+			search.Cool(float64(elapsed) / float64(run.Budget))
+		}
+		search.Step()
+	}
+
+	var err error
+	if run.Seed != nil && search.State.Iterations < run.Steps {
+		err = ctx.Err()
+	}
+	packed, unpacked := search.State.Packed, search.State.Unpacked
+	finishPacked(packed, availableBoxes, inputItems, search.opts)
+	return packed, unpacked, search.State.Iterations, err
+}
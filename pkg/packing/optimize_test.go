@@ -0,0 +1,92 @@
+package packing
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestOrderSearchNeverWorsens(t *testing.T) {
+	items := []InputItem{
+		{ID: "square", W: 2, H: 1, D: 2, Quantity: 3},
+		{ID: "rod", W: 1, H: 1, D: 3, Quantity: 3},
+		{ID: "bar", W: 3, H: 1, D: 1, Quantity: 3},
+	}
+	boxes := []InputBox{{ID: "tray", W: 3, H: 1, D: 4}}
+
+	search := NewSearch(items, boxes, Options{}, 1, nil)
+	greedy := search.State.Score
+	for range 200 {
+		search.Step()
+	}
+
+	if search.State.Score.Compare(greedy) > 0 {
+		t.Errorf("Search result %+v is worse than greedy %+v", search.State.Score, greedy)
+	}
+	if search.State.Iterations != 200 {
+		t.Errorf("Expected 200 iterations, got %d", search.State.Iterations)
+	}
+}
+
+func TestAnnealingKeepsBest(t *testing.T) {
+	items := []InputItem{
+		{ID: "square", W: 2, H: 1, D: 2, Quantity: 3},
+		{ID: "rod", W: 1, H: 1, D: 3, Quantity: 3},
+		{ID: "bar", W: 3, H: 1, D: 1, Quantity: 3, AllowedRotations: []string{"whd", "dhw"}},
+	}
+	boxes := []InputBox{{ID: "tray", W: 3, H: 1, D: 4}}
+
+	search := NewSearch(items, boxes, Options{}, 1, nil)
+	greedy := search.State.Score
+	for i := range 300 {
+		search.Cool(float64(i) / 300)
+		search.Step()
+		if search.State.Score.Compare(greedy) > 0 {
+			t.Fatalf("Best result %+v is worse than greedy %+v at step %d", search.State.Score, greedy, i)
+		}
+	}
+	for _, it := range search.State.Unpacked {
+		if it.ID == "bar" && len(it.AllowedRotations) != 2 {
+			t.Errorf("Expected unpacked bar to keep its rotations, got %v", it.AllowedRotations)
+		}
+	}
+}
+
+func TestSeededOptimizeIsRepeatable(t *testing.T) {
+	seed := uint64(42)
+	run := OptimizeRun{Steps: 50, Seed: &seed}
+	for _, sc := range conformanceScenarios() {
+		if sc.cancel {
+			continue
+		}
+		t.Run(sc.name, func(t *testing.T) {
+			boxes := []InputBox{sc.box}
+			packed1, unpacked1, n, err := OptimizeContext(context.Background(), sc.items, boxes, sc.opts, run)
+			if err != nil {
+				t.Fatal(err)
+			}
+			packed2, unpacked2, _, _ := OptimizeContext(context.Background(), sc.items, boxes, sc.opts, run)
+			if !reflect.DeepEqual(packed1, packed2) || !reflect.DeepEqual(unpacked1, unpacked2) {
+				t.Error("Expected the same result for the same seed")
+			}
+			if n != 50 && len(expandItems(sc.items)) > 1 {
+				t.Errorf("Expected 50 steps, got %d", n)
+			}
+		})
+	}
+
+}
+
+func TestOptimizeStopsAtTargetBoxes(t *testing.T) {
+	items := []InputItem{{ID: "cube", W: 5, H: 5, D: 5, Quantity: 8}}
+	boxes := []InputBox{{ID: "box", W: 10, H: 10, D: 10}}
+	seed := uint64(1)
+
+	_, unpacked, n, err := OptimizeContext(context.Background(), items, boxes, Options{}, OptimizeRun{Steps: 50, Seed: &seed, TargetBoxes: 1})
+	if err != nil || len(unpacked) != 0 {
+		t.Fatalf("Expected every cube packed, got %d unpacked (%v)", len(unpacked), err)
+	}
+	if n != 0 {
+		t.Errorf("Expected the search to stop before any step, got %d", n)
+	}
+}
@@ -0,0 +1,1050 @@
+package packing
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"binpacker/geometry"
+)
+
+// InputItem represents an item to be packed.
+type InputItem struct {
+	ID       string `json:"id"`
+	W        int    `json:"w"`
+	H        int    `json:"h"`
+	D        int    `json:"d"`
+	Quantity int    `json:"quantity"`
+
+	// Weight is the weight of one unit, in any unit as long as box limits
+	// use the same one.
+	Weight float64 `json:"weight,omitempty"`
+
+	// Customs data, rolled up per box in the response. See customs.go.
+	HSCode        string  `json:"hs_code,omitempty"`
+	Value         float64 `json:"value,omitempty"`
+	OriginCountry string  `json:"origin_country,omitempty"`
+
+	// KeepUpright keeps the item's H side vertical, for "this side up"
+	// items. AllowedRotations lists the only orientations the item may be
+	// packed in; see rotationNames. Both may be set.
+	KeepUpright      bool     `json:"keep_upright,omitempty"`
+	AllowedRotations []string `json:"allowed_rotations,omitempty"`
+
+	// Stackable set to false keeps anything from being placed on top of
+	// the item. MaxStackWeight caps the weight resting on it, and
+	// MaxStackUnits the units of the item stacked on each other, counting
+	// itself; 0 means no limit. See stacking.go.
+	Stackable      *bool   `json:"stackable,omitempty"`
+	MaxStackWeight float64 `json:"max_stack_weight,omitempty"`
+	MaxStackUnits  int     `json:"max_stack_units,omitempty"`
+
+	// Inner describes the units of a multipack case. With Splittable, a
+	// case that fits no box is packed as its units instead. See
+	// multipack.go.
+	Inner      *InnerUnit `json:"inner,omitempty"`
+	Splittable bool       `json:"splittable,omitempty"`
+
+	// GroupID ties the item to others with the same ID, e.g. the lines of
+	// one order, for Options.KeepGroupsTogether.
+	GroupID string `json:"group_id,omitempty"`
+
+	// IncompatibleWith lists the IDs of items that must not share a box
+	// with this one. See incompatible.go.
+	IncompatibleWith []string `json:"incompatible_with,omitempty"`
+
+	// WallClearance is the least gap kept between the item and the side
+	// walls of its box. See clearance.go.
+	WallClearance int `json:"wall_clearance,omitempty"`
+
+	// Compressibility is the percentage by which the sides of a soft item,
+	// such as apparel or a pillow, may be squeezed to make it fit. See
+	// compress.go.
+	Compressibility float64 `json:"compressibility,omitempty"`
+
+	// Units overrides the request units for this item. The server converts
+	// sides to one grid before packing; the packer itself ignores it.
+	Units string `json:"units,omitempty"`
+	// Size holds the sides as decoded when one has decimals; see
+	// sides.go.
+	Size *[3]float64 `json:"-"`
+}
+
+// InputBox represents an available box type.
+type InputBox struct {
+	ID string `json:"id"`
+	W  int    `json:"w"`
+	H  int    `json:"h"`
+	D  int    `json:"d"`
+
+	// MaxFillPercent caps how much of the box volume may be used, overriding
+	// Options.TargetFillPercent for this box type.
+	MaxFillPercent float64 `json:"max_fill_percent,omitempty"`
+
+	// Cost is the price of one box, used when comparing catalogs.
+	Cost float64 `json:"cost,omitempty"`
+
+	// MaxWeight caps the total weight of a box's contents; 0 means no limit.
+	MaxWeight float64 `json:"max_weight,omitempty"`
+
+	// Quantity is the number of boxes of this type in stock; 0 means
+	// unlimited.
+	Quantity int `json:"quantity,omitempty"`
+
+	// Tier ranks box types by preference, 0 first. A box of a higher tier
+	// is only opened for items no box of a lower tier takes, e.g. for odd
+	// sizes or expensive boxes. See Options.prefers.
+	Tier int `json:"tier,omitempty"`
+
+	// Units overrides the request units for this box, like InputItem.Units.
+	Units string `json:"units,omitempty"`
+	// Size holds the sides as decoded when one has decimals; see
+	// sides.go.
+	Size *[3]float64 `json:"-"`
+}
+
+// PackedBox represents a box with its packed contents.
+type PackedBox struct {
+	BoxID       string      `json:"box_id"`
+	Contents    []Placement `json:"contents"`
+	TotalWeight float64     `json:"total_weight,omitempty"`
+	// ShippingClass is the first matching class of the request's
+	// shipping_classes table. See shipping.go.
+	ShippingClass string `json:"shipping_class,omitempty"`
+
+	// Box size and fill, filled in by Measure.
+	W           int     `json:"w"`
+	H           int     `json:"h"`
+	D           int     `json:"d"`
+	UsedVolume  int     `json:"used_volume"`
+	FreeVolume  int     `json:"free_volume"`
+	Utilization float64 `json:"utilization_percent"`
+
+	// AxleLoads is set under the axle_load placement policy.
+	AxleLoads *AxleLoads `json:"axle_loads,omitempty"`
+
+	// Decision records how the box type was chosen, when the algorithm
+	// compared box types for it; see explain.go.
+	Decision *BoxDecision `json:"-"`
+}
+
+// ValidateWeights rejects negative item weights and box limits, including
+// box stock.
+func ValidateWeights(items []InputItem, boxes []InputBox) error {
+	for _, it := range items {
+		if it.Weight < 0 {
+			return fmt.Errorf("item %q: weight must not be negative", it.ID)
+		}
+		if it.MaxStackWeight < 0 {
+			return fmt.Errorf("item %q: max_stack_weight must not be negative", it.ID)
+		}
+		if it.MaxStackUnits < 0 {
+			return fmt.Errorf("item %q: max_stack_units must not be negative", it.ID)
+		}
+	}
+	for _, b := range boxes {
+		if b.MaxWeight < 0 {
+			return fmt.Errorf("box %q: max_weight must not be negative", b.ID)
+		}
+		if b.Quantity < 0 {
+			return fmt.Errorf("box %q: quantity must not be negative", b.ID)
+		}
+		if b.Tier < 0 {
+			return fmt.Errorf("box %q: tier must not be negative", b.ID)
+		}
+	}
+	return nil
+}
+
+func newPackedBox(boxID string, contents []Placement) PackedBox {
+	pb := PackedBox{BoxID: boxID, Contents: contents}
+	for _, p := range contents {
+		pb.TotalWeight += p.Weight
+	}
+	return pb
+}
+
+// Placement represents an item's position and dimensions in a box.
+type Placement struct {
+	ItemID string  `json:"item_id"`
+	X      int     `json:"x"`
+	Y      int     `json:"y"`
+	Z      int     `json:"z"`
+	W      int     `json:"w"`
+	H      int     `json:"h"`
+	D      int     `json:"d"`
+	Weight float64 `json:"weight,omitempty"`
+	// Compression is the percentage by which a compressible item was
+	// squeezed to fit; W, H and D are its squeezed sides.
+	Compression float64 `json:"compression,omitempty"`
+}
+
+// FreeSpace represents an available region in the box.
+type FreeSpace = geometry.Cuboid
+
+func (p Placement) cuboid() geometry.Cuboid {
+	return geometry.Cuboid{X: p.X, Y: p.Y, Z: p.Z, W: p.W, H: p.H, D: p.D}
+}
+
+func (b InputBox) Volume() int {
+	return b.W * b.H * b.D
+}
+
+// itemToPack is an internal representation for packing (handles quantity expansion).
+type itemToPack struct {
+	InputItem
+	volume int
+	maxDim int
+	// compression is the percentage the sides of InputItem were squeezed
+	// by; see compress.go.
+	compression float64
+}
+
+// Options tunes the packing algorithm. The zero value gives the default
+// behaviour.
+type Options struct {
+	// PlacementPolicy selects the floor corner items are packed from; see
+	// the Placement* constants.
+	PlacementPolicy string `json:"placement_policy,omitempty"`
+
+	// Objective changes what the packer optimizes for; see objective.go.
+	// ObjectiveBalance needs Containers to be set.
+	Objective  string `json:"objective,omitempty"`
+	Containers int    `json:"containers,omitempty"`
+
+	// TargetFillPercent stops adding items to a box once this share of its
+	// volume is used. Spillover selects the box opened next; see the
+	// Spillover* constants.
+	TargetFillPercent float64 `json:"target_fill_percent,omitempty"`
+	Spillover         string  `json:"spillover,omitempty"`
+
+	// Constraints are expressions every candidate placement must satisfy,
+	// e.g. "item.volume < 2000 || placement.y == 0"; see expr.go.
+	Constraints []string `json:"constraints,omitempty"`
+
+	// MinSupportPercent is the share of an item's base that must rest on
+	// the box floor or on items below it; 0 means defaultMinSupportPercent.
+	MinSupportPercent float64 `json:"min_support_percent,omitempty"`
+
+	// GroupSKUs biases placement towards units of the same item ID so each
+	// SKU forms a block; see grouping.go.
+	GroupSKUs bool `json:"group_skus,omitempty"`
+
+	// Aisle is a channel every box must keep clear; see aisle.go.
+	Aisle *Aisle `json:"aisle,omitempty"`
+
+	// AxleLoad configures the axle_load placement policy; see axle.go.
+	AxleLoad *AxleLoad `json:"axle_load,omitempty"`
+
+	// Strategy selects how a single box is filled; see strategy.go.
+	Strategy string `json:"strategy,omitempty"`
+
+	// Algorithm selects how items are distributed over boxes; see
+	// algorithm.go.
+	Algorithm string `json:"algorithm,omitempty"`
+
+	// Compact slides packed items down and towards the anchor walls until
+	// they touch something; see compact.go.
+	Compact bool `json:"compact,omitempty"`
+
+	// MaxBoxes caps the number of boxes in the result; items that don't
+	// fit in that many are unpacked. 0 means no limit.
+	MaxBoxes int `json:"max_boxes,omitempty"`
+
+	// KeepGroupsTogether packs the items of each group_id into one box
+	// where any box can hold them; see groups.go.
+	KeepGroupsTogether bool `json:"keep_groups_together,omitempty"`
+
+	compiled []constraint
+}
+
+// defaultMinSupportPercent keeps items from overhanging by more than 30%,
+// which is stable for ordinary cartons without being so strict that gaps
+// between items can't be bridged.
+const defaultMinSupportPercent = 70
+
+func (o Options) minSupport() float64 {
+	if o.MinSupportPercent == 0 {
+		return defaultMinSupportPercent
+	}
+	return o.MinSupportPercent
+}
+
+// withCompiledConstraints returns a copy of o ready for packing. Constraints
+// that fail to compile are dropped; callers should validate first.
+func (o Options) withCompiledConstraints() Options {
+	if len(o.Constraints) > 0 && o.compiled == nil {
+		o.compiled, _ = compileConstraints(o.Constraints)
+	}
+	return o
+}
+
+// allows reports whether a candidate placement satisfies every constraint.
+func (o Options) allows(env *placementEnv) bool {
+	for _, c := range o.compiled {
+		if !c.allows(env) {
+			return false
+		}
+	}
+	return true
+}
+
+func (o Options) Validate() error {
+	if err := validatePlacementPolicy(o.PlacementPolicy); err != nil {
+		return err
+	}
+	if err := o.validateStrategy(); err != nil {
+		return err
+	}
+	if err := o.validateAlgorithm(); err != nil {
+		return err
+	}
+
+	switch o.Objective {
+	case "", ObjectiveMinimizeBoxes, ObjectiveMinimizeCost, ObjectiveMaximizeUtilization:
+	case ObjectiveBalance:
+		if o.Containers < 1 {
+			return errors.New("objective \"balance\" requires containers >= 1")
+		}
+	default:
+		return fmt.Errorf("unknown objective %q", o.Objective)
+	}
+
+	if o.MaxBoxes < 0 {
+		return errors.New("max_boxes must not be negative")
+	}
+	if o.Objective == ObjectiveBalance && o.MaxBoxes > 0 && o.MaxBoxes < o.Containers {
+		return fmt.Errorf("max_boxes %d is less than containers %d", o.MaxBoxes, o.Containers)
+	}
+	if o.KeepGroupsTogether && (o.Objective == ObjectiveBalance || (o.Strategy != "" && o.Strategy != StrategyExtremePoints)) {
+		return errors.New("keep_groups_together needs the default strategy and cannot be combined with objective \"balance\"")
+	}
+	if o.TargetFillPercent < 0 || o.TargetFillPercent > 100 {
+		return errors.New("target_fill_percent must be between 0 and 100")
+	}
+	if o.MinSupportPercent < 0 || o.MinSupportPercent > 100 {
+		return errors.New("min_support_percent must be between 0 and 100")
+	}
+	if err := o.Aisle.validate(); err != nil {
+		return err
+	}
+	if err := o.AxleLoad.validate(o.PlacementPolicy); err != nil {
+		return err
+	}
+	switch o.Spillover {
+	case "", SpilloverSameSize, SpilloverNextSizeUp:
+	default:
+		return fmt.Errorf("unknown spillover policy %q", o.Spillover)
+	}
+
+	if _, err := compileConstraints(o.Constraints); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Pack distributes items into boxes using the Extreme Points algorithm.
+func Pack(inputItems []InputItem, availableBoxes []InputBox) ([]PackedBox, []InputItem) {
+	return PackWithOptions(inputItems, availableBoxes, Options{})
+}
+
+// PackWithOptions is Pack with tuning options.
+func PackWithOptions(inputItems []InputItem, availableBoxes []InputBox, opts Options) ([]PackedBox, []InputItem) {
+	packed, unpacked, _ := PackContext(context.Background(), inputItems, availableBoxes, opts)
+	return packed, unpacked
+}
+
+// PackContext is PackWithOptions that stops placing items once ctx is done.
+// It then returns the boxes packed so far, with every item not yet placed
+// unpacked, and ctx.Err().
+func PackContext(ctx context.Context, inputItems []InputItem, availableBoxes []InputBox, opts Options) ([]PackedBox, []InputItem, error) {
+	items := expandItems(inputItems)
+	sortItemsByVolume(items)
+	if opts.PlacementPolicy == PlacementAxleLoad {
+		sortItemsByWeight(items)
+	}
+
+	opts = opts.withCompiledConstraints()
+	packed, unpacked := algorithmFor(opts).Pack(ctx, items, sortBoxesByVolume(availableBoxes), opts)
+	if ctx.Err() == nil {
+		finishPacked(packed, availableBoxes, inputItems, opts)
+	} else if opts.PlacementPolicy == PlacementAxleLoad {
+		setAxleLoads(packed, availableBoxes, opts)
+	}
+	return packed, unpacked, ctx.Err()
+}
+
+// finishPacked runs the passes opts asks for after packing: compaction and
+// axle loads.
+func finishPacked(packed []PackedBox, availableBoxes []InputBox, inputItems []InputItem, opts Options) {
+	if opts.Compact {
+		compactPacked(packed, availableBoxes, inputItems, opts)
+	}
+	if opts.PlacementPolicy == PlacementAxleLoad {
+		setAxleLoads(packed, availableBoxes, opts)
+	}
+}
+
+func setAxleLoads(packed []PackedBox, availableBoxes []InputBox, opts Options) {
+	byID := BoxesByID(availableBoxes)
+	for i := range packed {
+		packed[i].AxleLoads = opts.AxleLoad.axleLoads(packed[i], byID[packed[i].BoxID])
+	}
+}
+
+func sortBoxesByVolume(availableBoxes []InputBox) []InputBox {
+	boxes := slices.Clone(availableBoxes)
+	slices.SortFunc(boxes, func(a, b InputBox) int {
+		return cmp.Compare(a.Volume(), b.Volume())
+	})
+	return boxes
+}
+
+// packSorted packs items in the given order into boxes, which must be sorted
+// by volume.
+func packSorted(ctx context.Context, items []itemToPack, boxes []InputBox, opts Options) ([]PackedBox, []InputItem) {
+	if opts.Objective == ObjectiveBalance {
+		return packBalanced(ctx, items, boxes, opts)
+	}
+
+	var packedBoxes []PackedBox
+	var unpackedItems []InputItem
+
+	remaining := items
+	lastIdx := -1
+	stock := newBoxStock(boxes)
+	var split map[string]bool
+	if opts.KeepGroupsTogether {
+		split = oversizedGroups(ctx, items, boxes, opts)
+	}
+	for len(remaining) > 0 {
+		if opts.MaxBoxes > 0 && len(packedBoxes) == opts.MaxBoxes {
+			break
+		}
+		avail, idx := stock.available(boxes)
+		var bestIdx int
+		var bestPlacements []Placement
+		var bestPacked []bool
+		var decision *BoxDecision
+		if opts.KeepGroupsTogether {
+			bestIdx, bestPlacements, bestPacked, decision = findGroupedBox(ctx, remaining, avail, split, opts)
+		} else {
+			bestIdx, bestPlacements, bestPacked, decision = findNextBox(ctx, remaining, avail, slices.Index(idx, lastIdx), opts)
+		}
+		if bestIdx == -1 {
+			break
+		}
+
+		bestIdx = idx[bestIdx]
+		stock.take(bestIdx)
+		pb := newPackedBox(boxes[bestIdx].ID, bestPlacements)
+		pb.Decision = decision
+		packedBoxes = append(packedBoxes, pb)
+
+		remaining = filterUnpacked(remaining, bestPacked)
+		lastIdx = bestIdx
+	}
+	for _, item := range remaining {
+		unpackedItems = append(unpackedItems, item.InputItem)
+	}
+
+	if opts.Objective == ObjectiveMinimizeCost && ctx.Err() == nil {
+		downsize(ctx, packedBoxes, boxes, stock, items, opts)
+	}
+	return packedBoxes, unpackedItems
+}
+
+func expandItems(inputItems []InputItem) []itemToPack {
+	var items []itemToPack
+	for _, item := range inputItems {
+		for range item.Quantity {
+			items = append(items, itemToPack{
+				InputItem: item,
+				volume:    item.W * item.H * item.D,
+				maxDim:    max(item.W, item.H, item.D),
+			})
+		}
+	}
+	return items
+}
+
+// Measure fills in the size and fill of each packed box.
+func Measure(packed []PackedBox, boxes []InputBox) {
+	byID := BoxesByID(boxes)
+	for i := range packed {
+		pb := &packed[i]
+		b := byID[pb.BoxID]
+		pb.W, pb.H, pb.D = b.W, b.H, b.D
+		pb.UsedVolume = 0
+		for _, item := range pb.Contents {
+			pb.UsedVolume += item.W * item.H * item.D
+		}
+		pb.FreeVolume = b.Volume() - pb.UsedVolume
+		pb.Utilization = 0
+		if b.Volume() > 0 {
+			pb.Utilization = float64(pb.UsedVolume) / float64(b.Volume()) * 100
+		}
+	}
+}
+
+// BoxesByID returns the boxes keyed by ID.
+func BoxesByID(boxes []InputBox) map[string]InputBox {
+	m := make(map[string]InputBox, len(boxes))
+	for _, b := range boxes {
+		m[b.ID] = b
+	}
+	return m
+}
+
+func sortItemsByVolume(items []itemToPack) {
+	slices.SortFunc(items, func(a, b itemToPack) int {
+		if c := cmp.Compare(b.volume, a.volume); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.maxDim, a.maxDim); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+}
+
+// findBestBox packs items into every box type and returns the index of the
+// best, its placements, which items it took and how it was chosen, or -1 if
+// none takes any.
+// Box types are tried concurrently, at most GOMAXPROCS at a time; the
+// results are compared in box order, so the choice is the same as trying
+// them one by one.
+func findBestBox(ctx context.Context, items []itemToPack, boxes []InputBox, opts Options) (int, []Placement, []bool, *BoxDecision) {
+	return findBestBoxWorkers(ctx, items, boxes, opts, runtime.GOMAXPROCS(0))
+}
+
+func findBestBoxWorkers(ctx context.Context, items []itemToPack, boxes []InputBox, opts Options, workers int) (int, []Placement, []bool, *BoxDecision) {
+	type result struct {
+		placements []Placement
+		packed     []bool
+		packedVol  int
+	}
+	results := make([]result, len(boxes))
+	fill := func(i int) {
+		r := &results[i]
+		r.placements, r.packed, r.packedVol = packIntoBox(ctx, items, boxes[i], opts)
+	}
+	if workers = min(workers, len(boxes)); workers < 2 {
+		for i := range boxes {
+			fill(i)
+		}
+	} else {
+		var next atomic.Int64
+		var wg sync.WaitGroup
+		for range workers {
+			wg.Go(func() {
+				for i := int(next.Add(1) - 1); i < len(boxes); i = int(next.Add(1) - 1) {
+					fill(i)
+				}
+			})
+		}
+		wg.Wait()
+	}
+
+	bestIdx := -1
+	for i, r := range results {
+		if r.packedVol <= 0 {
+			continue
+		}
+		if bestIdx == -1 || opts.prefers(boxes[i], r.packedVol, boxes[bestIdx], results[bestIdx].packedVol) {
+			bestIdx = i
+		}
+	}
+	if bestIdx == -1 {
+		return -1, nil, nil, nil
+	}
+	packed := make([][]bool, len(results))
+	packedVol := make([]int, len(results))
+	for i, r := range results {
+		packed[i], packedVol[i] = r.packed, r.packedVol
+	}
+	decision := newBoxDecision(items, boxes, packed, packedVol, bestIdx, opts)
+	return bestIdx, results[bestIdx].placements, results[bestIdx].packed, decision
+}
+
+func filterUnpacked(items []itemToPack, packed []bool) []itemToPack {
+	var remaining []itemToPack
+	for i, isPacked := range packed {
+		if !isPacked {
+			remaining = append(remaining, items[i])
+		}
+	}
+	return remaining
+}
+
+// packIntoBox attempts to pack items into a specific box using the strategy
+// selected by opts.
+func packIntoBox(ctx context.Context, items []itemToPack, box InputBox, opts Options) ([]Placement, []bool, int) {
+	return strategyFor(opts).fill(ctx, items, box, opts)
+}
+
+// packExtremePoints packs items into box in order, each at its best extreme
+// point.
+func packExtremePoints(ctx context.Context, items []itemToPack, box InputBox, opts Options) ([]Placement, []bool, int) {
+	state := newBoxState(ctx, box, opts)
+	packed := make([]bool, len(items))
+	packedVol := 0
+	minSides := suffixMinSides(items)
+
+	for i, item := range items {
+		if state.place(item, minSides[i+1]) {
+			packed[i] = true
+			packedVol += item.volume
+		}
+	}
+
+	return state.placements, packed, packedVol
+}
+
+// boxState is the incremental packing state of a single open box. Once ctx
+// is done, no more items fit.
+type boxState struct {
+	ctx           context.Context
+	box           InputBox
+	opts          Options
+	extremePoints []FreeSpace
+	spaces        []FreeSpace
+	placements    []Placement
+	items         []itemToPack   // placed items, parallel to placements
+	keepOut       []Placement    // regions no item may overlap
+	grid          *geometry.Grid // over placements once there are many; see spatial.go
+	gridded       int            // placements in grid
+	wallEnd       int            // when set, items must end in front of this depth
+	packedVol     int
+	capVol        int
+	weight        float64
+}
+
+func newBoxState(ctx context.Context, box InputBox, opts Options) *boxState {
+	whole := FreeSpace{W: box.W, H: box.H, D: box.D}
+	s := &boxState{
+		ctx:           ctx,
+		box:           box,
+		opts:          opts,
+		extremePoints: []FreeSpace{whole},
+		spaces:        []FreeSpace{whole},
+		capVol:        fillCap(box, opts),
+	}
+	// The aisle is cut out of the free space up front so the space on both
+	// sides of it is reachable.
+	if opts.Aisle != nil {
+		if aisle, ok := opts.Aisle.region(box); ok {
+			s.spaces = subtractPlacement(s.spaces, aisle, 1)
+			s.extremePoints = slices.Clone(s.spaces)
+			s.keepOut = []Placement{aisle}
+		}
+	}
+	return s
+}
+
+// place puts item at its best position and reports whether it fitted.
+// minSide is the shortest side of any item still to be placed afterwards and
+// is used to discard points and spaces that have become useless. A
+// compressible item that does not fit is squeezed as little as makes it fit.
+func (s *boxState) place(item itemToPack, minSide int) bool {
+	if s.placeAs(item, minSide) {
+		return true
+	}
+	for _, c := range compressions(item) {
+		if s.placeAs(c, minSide) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *boxState) placeAs(item itemToPack, minSide int) bool {
+	if !s.admits(item) {
+		return false
+	}
+
+	sortByPosition(s.extremePoints)
+
+	// The space beyond the current wall is blocked for this placement only,
+	// so its points survive for the walls to come.
+	blocked := s.occupied()
+	if s.wallEnd > 0 && s.wallEnd < s.box.D {
+		blocked = blocked.withKeepOut(Placement{Z: s.wallEnd, W: s.box.W, H: s.box.H, D: s.box.D - s.wallEnd})
+	}
+	pos, rotIdx := findBestPlacement(s.ctx, s.extremePoints, item, s.box, blocked, s.items, s.opts)
+	if rotIdx == -1 {
+		return false
+	}
+
+	rot := Rotations(item.InputItem)[rotIdx]
+	placement := s.add(item, pos[0], pos[1], pos[2], rot[0], rot[1], rot[2])
+
+	s.spaces = subtractPlacement(s.spaces, placement, minSide)
+
+	// Keep-out regions count as occupied, so no point starts inside one and
+	// none is dropped in favour of a point whose space runs into one.
+	occupied := s.occupied()
+	s.extremePoints = updateExtremePoints(s.extremePoints, placement, s.box, occupied)
+	s.extremePoints = deduplicatePoints(append(s.extremePoints, s.spaces...))
+	s.extremePoints = pruneExtremePoints(s.extremePoints, occupied, minSide)
+	return true
+}
+
+// admits reports whether item stays within the fill, weight and
+// compatibility limits of the box, wherever it goes.
+func (s *boxState) admits(item itemToPack) bool {
+	if s.packedVol+item.volume > s.capVol {
+		return false
+	}
+	if s.box.MaxWeight > 0 && s.weight+item.Weight > s.box.MaxWeight {
+		return false
+	}
+	return compatible(item, s.items)
+}
+
+// allowedAt reports whether item can be placed at (x, y, z) as w × h × d.
+func (s *boxState) allowedAt(item itemToPack, x, y, z, w, h, d int) bool {
+	return placementAllowed(item, s.box, s.occupied(), s.items, s.opts, x, y, z, w, h, d)
+}
+
+// add records item as placed at (x, y, z) as w × h × d. Free space is left
+// to the caller.
+func (s *boxState) add(item itemToPack, x, y, z, w, h, d int) Placement {
+	placement := Placement{
+		ItemID: item.ID,
+		X:      x, Y: y, Z: z,
+		W: w, H: h, D: d,
+		Weight:      item.Weight,
+		Compression: item.compression,
+	}
+	s.placements = append(s.placements, placement)
+	s.items = append(s.items, item)
+	s.packedVol += item.volume
+	s.weight += item.Weight
+	return placement
+}
+
+func sortByPosition(points []FreeSpace) {
+	slices.SortFunc(points, func(a, b FreeSpace) int {
+		if c := cmp.Compare(a.Y, b.Y); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.Z, b.Z); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.X, b.X)
+	})
+}
+
+// findBestPlacement returns the position and rotation index for item, or a
+// rotation index of -1 when it fits nowhere or ctx is done. placed holds the
+// items behind the placements in occ, for their stacking limits.
+func findBestPlacement(ctx context.Context, points []FreeSpace, item itemToPack, box InputBox, occ occupied, placed []itemToPack, opts Options) ([3]int, int) {
+	var bestPos [3]int
+	bestRot := -1
+	bestScore := math.MaxInt
+
+	placements := occ.placements
+	layers := layerBases(placements)
+
+	for _, ep := range points {
+		if ctx.Err() != nil {
+			return [3]int{}, -1
+		}
+		anchor := anchorFor(opts.PlacementPolicy, ep.Y, layers)
+
+		for ri, rot := range Rotations(item.InputItem) {
+			w, h, d := rot[0], rot[1], rot[2]
+
+			// Mirrored anchors slide the item to the far end of the point's
+			// reachable space so it sits against the opposite wall or item.
+			x, y, z := ep.X, ep.Y, ep.Z
+			if anchor.mirrorX {
+				x = ep.X + ep.W - w
+			}
+			if anchor.mirrorZ {
+				z = ep.Z + ep.D - d
+			}
+
+			positions := [][3]int{{x, y, z}}
+			if opts.GroupSKUs {
+				positions = append(positions, slidePositions(ep, x, y, z, w, d)...)
+			}
+			if opts.PlacementPolicy == PlacementAxleLoad {
+				positions = append(positions, opts.AxleLoad.zonePositions(box, ep, x, y, z, d)...)
+			}
+			for _, pos := range positions {
+				x, y, z := pos[0], pos[1], pos[2]
+				x, z = awayFromWalls(item, box, x, z, w, d)
+				if !placementAllowed(item, box, occ, placed, opts, x, y, z, w, h, d) {
+					continue
+				}
+
+				// Score: prefer positions closer to the anchor corner (bottom-left-back by default)
+				ax, az := anchor.distance(box, x, z, w, d)
+				if opts.PlacementPolicy == PlacementAxleLoad {
+					// Height still counts twice as much as a step
+					// towards a better zone, so loads stay low.
+					az = opts.AxleLoad.zoneCost(box, z, d)*5 + az
+				}
+				score := y*1000 + az*100 + ax*10
+				score += (ep.W - w) + (ep.H - h) + (ep.D - d)
+				if opts.GroupSKUs {
+					score += skuGap(placements, item.ID, x, y, z, w, h, d) * skuGapPenalty
+				}
+
+				if score < bestScore {
+					bestScore = score
+					bestPos = [3]int{x, y, z}
+					bestRot = ri
+				}
+			}
+		}
+	}
+
+	return bestPos, bestRot
+}
+
+// placementAllowed reports whether item can go at (x, y, z) as w × h × d:
+// inside box and clear of its walls, clear of everything in occ, supported,
+// within the stacking limits of the items below and allowed by the
+// constraints.
+func placementAllowed(item itemToPack, box InputBox, occ occupied, placed []itemToPack, opts Options, x, y, z, w, h, d int) bool {
+	if !FitsInBox(box, x, y, z, w, h, d) || !clearOfWalls(item, box, x, z, w, d) {
+		return false
+	}
+	if occ.overlaps(x, y, z, w, h, d) {
+		return false
+	}
+	if y > 0 && !supported(occ.near(geometry.Cuboid{X: x, Y: y - 1, Z: z, W: w, H: 1, D: d}), x, y, z, w, d, opts.minSupport()) {
+		return false
+	}
+	if y > 0 && !stackingAllows(item, occ.near(geometry.Cuboid{X: x, Z: z, W: w, H: y, D: d}), occ.placements, placed, x, y, z, w, d) {
+		return false
+	}
+	if !stackRunAllows(item, occ.placements, x, y, z, w, h, d) {
+		return false
+	}
+	if len(opts.compiled) > 0 {
+		env := placementEnv{item: item, box: box, placements: occ.placements, x: x, y: y, z: z, w: w, h: h, d: d}
+		if !opts.allows(&env) {
+			return false
+		}
+	}
+	return true
+}
+
+func updateExtremePoints(eps []FreeSpace, placed Placement, box InputBox, occ occupied) []FreeSpace {
+	newPoints := []FreeSpace{
+		{X: placed.X + placed.W, Y: placed.Y, Z: placed.Z},
+		{X: placed.X, Y: placed.Y + placed.H, Z: placed.Z},
+		{X: placed.X, Y: placed.Y, Z: placed.Z + placed.D},
+	}
+
+	var valid []FreeSpace
+	for _, ep := range newPoints {
+		if ep.X >= box.W || ep.Y >= box.H || ep.Z >= box.D || ep.X < 0 || ep.Y < 0 || ep.Z < 0 {
+			continue
+		}
+		if !occ.contains(ep) {
+			ep.W, ep.H, ep.D = reachableExtents(ep, box, occ)
+			valid = append(valid, ep)
+		}
+	}
+
+	for _, ep := range eps {
+		if !isInsidePlaced(ep, placed) {
+			// Extents only ever shrink, so existing points only need
+			// clipping against the item that was just placed.
+			ep.W, ep.H, ep.D = clipExtents(ep, ep.W, ep.H, ep.D, placed)
+			valid = append(valid, ep)
+		}
+	}
+
+	return deduplicatePoints(valid)
+}
+
+// suffixMinSides returns, for each index i, the shortest side among items[i:],
+// taking compressible items at their fully squeezed size since place may fall
+// back to it. The extra trailing entry is math.MaxInt for "no items left".
+func suffixMinSides(items []itemToPack) []int {
+	sides := make([]int, len(items)+1)
+	sides[len(items)] = math.MaxInt
+	for i := len(items) - 1; i >= 0; i-- {
+		sides[i] = min(sides[i+1], minSqueezedSide(items[i]))
+	}
+	return sides
+}
+
+// pruneExtremePoints drops points that can no longer be used:
+//   - points whose reachable extent on some axis is shorter than minSide,
+//     since no remaining item can start there in any orientation;
+//   - points whose free space lies entirely inside the empty free space of
+//     another point closer to the origin, since anything that fits at the
+//     dominated point also fits at the dominating one.
+func pruneExtremePoints(points []FreeSpace, occ occupied, minSide int) []FreeSpace {
+	live := make([]FreeSpace, 0, len(points))
+	for _, ep := range points {
+		if min(ep.W, ep.H, ep.D) >= minSide {
+			live = append(live, ep)
+		}
+	}
+
+	// Emptiness of a dominating point's space is only checked on demand,
+	// since most pairs fail the cheaper containment test first.
+	const unknown, empty, occupied = 0, 1, 2
+	state := make([]int, len(live))
+	isEmpty := func(j int) bool {
+		if state[j] == unknown {
+			state[j] = occupied
+			ep := live[j]
+			if !occ.overlaps(ep.X, ep.Y, ep.Z, ep.W, ep.H, ep.D) {
+				state[j] = empty
+			}
+		}
+		return state[j] == empty
+	}
+
+	result := make([]FreeSpace, 0, len(live))
+	for i, ep := range live {
+		dominated := false
+		for j, other := range live {
+			if i != j && dominates(other, ep) && isEmpty(j) {
+				dominated = true
+				break
+			}
+		}
+		if !dominated {
+			result = append(result, ep)
+		}
+	}
+	return result
+}
+
+// dominates reports whether a's free space contains b's. Identical spaces
+// never dominate each other, deduplicatePoints handles those.
+func dominates(a, b FreeSpace) bool {
+	return a != b && geometry.Contains(a, b)
+}
+
+// reachableExtents measures how far the point can extend along each axis
+// before running into a placed item, a keep-out region or the box wall.
+func reachableExtents(ep FreeSpace, box InputBox, occ occupied) (int, int, int) {
+	w, h, d := box.W-ep.X, box.H-ep.Y, box.D-ep.Z
+	for _, p := range occ.keepOut {
+		w, h, d = clipExtents(ep, w, h, d, p)
+	}
+	for _, ray := range []geometry.Cuboid{
+		{X: ep.X, Y: ep.Y, Z: ep.Z, W: w, H: 1, D: 1},
+		{X: ep.X, Y: ep.Y, Z: ep.Z, W: 1, H: h, D: 1},
+		{X: ep.X, Y: ep.Y, Z: ep.Z, W: 1, H: 1, D: d},
+	} {
+		for _, p := range occ.near(ray) {
+			w, h, d = clipExtents(ep, w, h, d, p)
+		}
+	}
+	return w, h, d
+}
+
+// clipExtents shortens the axis rays starting at ep that run into p.
+func clipExtents(ep FreeSpace, w, h, d int, p Placement) (int, int, int) {
+	inX := ep.X >= p.X && ep.X < p.X+p.W
+	inY := ep.Y >= p.Y && ep.Y < p.Y+p.H
+	inZ := ep.Z >= p.Z && ep.Z < p.Z+p.D
+
+	if inY && inZ && p.X >= ep.X {
+		w = min(w, p.X-ep.X)
+	}
+	if inX && inZ && p.Y >= ep.Y {
+		h = min(h, p.Y-ep.Y)
+	}
+	if inX && inY && p.Z >= ep.Z {
+		d = min(d, p.Z-ep.Z)
+	}
+	return w, h, d
+}
+
+func isInsidePlaced(ep FreeSpace, placed Placement) bool {
+	return geometry.ContainsPoint(placed.cuboid(), ep.X, ep.Y, ep.Z)
+}
+
+// deduplicatePoints removes repeated points. Points sharing a corner but with
+// different extents are kept, since mirrored anchors place items relative to
+// the far end of the extent.
+func deduplicatePoints(points []FreeSpace) []FreeSpace {
+	seen := make(map[FreeSpace]bool)
+	var result []FreeSpace
+	for _, p := range points {
+		if !seen[p] {
+			seen[p] = true
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// rotationNames name the six orientations in the order rotations yields
+// them; see geometry.RotationNames.
+var rotationNames = geometry.RotationNames
+
+// Rotations returns the orientations item may be packed in, as W, H, D
+// extents. The unrotated orientation comes first when it is allowed.
+func Rotations(item InputItem) [][3]int {
+	orientations := geometry.RotationsOf(item.W, item.H, item.D)
+	all := orientations[:]
+	if !item.KeepUpright && len(item.AllowedRotations) == 0 {
+		return all
+	}
+	allowed := all[:0]
+	for i, rot := range all {
+		if item.KeepUpright && rotationNames[i][1] != 'h' {
+			continue
+		}
+		if len(item.AllowedRotations) > 0 && !slices.Contains(item.AllowedRotations, rotationNames[i]) {
+			continue
+		}
+		allowed = append(allowed, rot)
+	}
+	return allowed
+}
+
+// ValidateOrientations rejects unknown rotation names and items whose
+// constraints leave no orientation at all.
+func ValidateOrientations(items []InputItem) error {
+	for _, it := range items {
+		for _, r := range it.AllowedRotations {
+			if !slices.Contains(rotationNames[:], r) {
+				return fmt.Errorf("item %q: unknown rotation %q: use one of %s", it.ID, r, strings.Join(rotationNames[:], ", "))
+			}
+		}
+		if len(Rotations(it)) == 0 {
+			return fmt.Errorf("item %q: allowed_rotations leaves no upright orientation", it.ID)
+		}
+	}
+	return nil
+}
+
+func FitsInBox(box InputBox, x, y, z, w, h, d int) bool {
+	return x >= 0 && y >= 0 && z >= 0 &&
+		x+w <= box.W && y+h <= box.H && z+d <= box.D
+}
+
+func hasOverlap(placements []Placement, x, y, z, w, h, d int) bool {
+	for _, p := range placements {
+		if boxesOverlap(p, x, y, z, w, h, d) {
+			return true
+		}
+	}
+	return false
+}
+
+func boxesOverlap(p Placement, x, y, z, w, h, d int) bool {
+	return geometry.Overlaps(p.cuboid(), geometry.Cuboid{X: x, Y: y, Z: z, W: w, H: h, D: d})
+}
@@ -0,0 +1,1002 @@
+package packing
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestPack(t *testing.T) {
+	items := []InputItem{
+		{ID: "item-b", W: 20, H: 20, D: 20, Quantity: 1},
+		{ID: "item-a", W: 10, H: 10, D: 10, Quantity: 2},
+		{ID: "item-c", W: 5, H: 5, D: 5, Quantity: 5},
+	}
+
+	boxes := []InputBox{
+		{ID: "box-small", W: 15, H: 15, D: 15},
+		{ID: "box-large", W: 30, H: 30, D: 30},
+	}
+
+	packedBoxes, unpackedItems := Pack(items, boxes)
+
+	if len(unpackedItems) > 0 {
+		t.Errorf("Expected all items to be packed, but got %d unpacked items", len(unpackedItems))
+	}
+
+	if len(packedBoxes) == 0 {
+		t.Errorf("Expected at least one packed box, but got 0")
+	}
+
+	// Verify no overlaps in placements
+	for _, box := range packedBoxes {
+		if !verifyNoOverlaps(box.Contents) {
+			t.Errorf("Detected overlapping items in box %s", box.BoxID)
+		}
+	}
+
+	if len(packedBoxes) != 1 {
+		t.Errorf("Expected 1 box to contain everything, got %d", len(packedBoxes))
+	}
+
+	if packedBoxes[0].BoxID != "box-large" {
+		t.Errorf("Expected box-large, got %s", packedBoxes[0].BoxID)
+	}
+}
+
+func TestPackSplit(t *testing.T) {
+	// Test where items MUST be split across boxes
+	items := []InputItem{
+		{ID: "item-big-1", W: 20, H: 20, D: 20, Quantity: 1},
+		{ID: "item-big-2", W: 20, H: 20, D: 20, Quantity: 1},
+	}
+
+	boxes := []InputBox{
+		{ID: "box-medium", W: 25, H: 25, D: 25}, // Can only hold one 20x20x20 item
+	}
+
+	packedBoxes, unpackedItems := Pack(items, boxes)
+
+	if len(unpackedItems) > 0 {
+		t.Errorf("Expected all items to be packed, but got %d unpacked items", len(unpackedItems))
+	}
+
+	if len(packedBoxes) != 2 {
+		t.Errorf("Expected 2 boxes, got %d", len(packedBoxes))
+	}
+
+	// Verify no overlaps
+	for _, box := range packedBoxes {
+		if !verifyNoOverlaps(box.Contents) {
+			t.Errorf("Detected overlapping items in box %s", box.BoxID)
+		}
+	}
+}
+
+func TestPackMaxWeight(t *testing.T) {
+	// Volume allows all four items in one box, weight only allows two
+	items := []InputItem{
+		{ID: "brick", W: 5, H: 5, D: 5, Quantity: 4, Weight: 3},
+		{ID: "anvil", W: 5, H: 5, D: 5, Quantity: 1, Weight: 20},
+	}
+	boxes := []InputBox{{ID: "crate", W: 20, H: 20, D: 20, MaxWeight: 7}}
+
+	packedBoxes, unpackedItems := Pack(items, boxes)
+
+	if len(unpackedItems) != 1 || unpackedItems[0].ID != "anvil" {
+		t.Errorf("Expected only the anvil to be unpacked, got %+v", unpackedItems)
+	}
+	if len(packedBoxes) != 2 {
+		t.Fatalf("Expected 2 boxes, got %d", len(packedBoxes))
+	}
+	for _, box := range packedBoxes {
+		if box.TotalWeight != 6 {
+			t.Errorf("Expected total weight 6, got %g", box.TotalWeight)
+		}
+	}
+
+	if err := ValidateWeights(nil, []InputBox{{ID: "crate", MaxWeight: -1}}); err == nil {
+		t.Error("Expected a negative max_weight to be rejected")
+	}
+}
+
+func TestOrientationConstraints(t *testing.T) {
+	// The box is too low for the bottle standing up
+	boxes := []InputBox{{ID: "flat", W: 40, H: 10, D: 40}}
+	bottle := InputItem{ID: "bottle", W: 10, H: 30, D: 10, Quantity: 1}
+
+	if _, unpacked := Pack([]InputItem{bottle}, boxes); len(unpacked) != 0 {
+		t.Errorf("Expected the bottle to be packed lying down, got %d unpacked", len(unpacked))
+	}
+
+	bottle.KeepUpright = true
+	if _, unpacked := Pack([]InputItem{bottle}, boxes); len(unpacked) != 1 {
+		t.Errorf("Expected the upright bottle not to fit, got %d unpacked", len(unpacked))
+	}
+
+	bottle.KeepUpright = false
+	bottle.AllowedRotations = []string{"wdh"}
+	packed, _ := Pack([]InputItem{bottle}, boxes)
+	if len(packed) != 1 || packed[0].Contents[0].D != 30 {
+		t.Errorf("Expected the bottle to lie along the depth, got %+v", packed)
+	}
+
+	if err := ValidateOrientations([]InputItem{{ID: "x", KeepUpright: true, AllowedRotations: []string{"hwd"}}}); err == nil {
+		t.Error("Expected constraints leaving no orientation to be rejected")
+	}
+	if err := ValidateOrientations([]InputItem{{ID: "x", AllowedRotations: []string{"xyz"}}}); err == nil {
+		t.Error("Expected an unknown rotation to be rejected")
+	}
+}
+
+func TestStackingLimits(t *testing.T) {
+	// A box only wide enough for one column, so items must stack
+	boxes := []InputBox{{ID: "tube", W: 10, H: 30, D: 10}}
+	noStack := false
+	eggs := InputItem{ID: "eggs", W: 10, H: 10, D: 10, Quantity: 1, Weight: 1, Stackable: &noStack}
+	can := InputItem{ID: "can", W: 10, H: 10, D: 10, Quantity: 1, Weight: 2}
+
+	packed, _ := Pack([]InputItem{eggs, can}, boxes)
+	for _, pb := range packed {
+		if len(pb.Contents) > 1 && pb.Contents[0].ItemID == "eggs" {
+			t.Errorf("Expected nothing on top of the eggs, got %+v", pb.Contents)
+		}
+	}
+
+	eggs.Quantity = 2
+	packed, _ = Pack([]InputItem{eggs}, boxes)
+	if len(packed) != 2 {
+		t.Errorf("Expected unstackable items to need a box each, got %d boxes", len(packed))
+	}
+
+	// The base carries at most 3: one can on it is fine, two are not
+	base := InputItem{ID: "base", W: 10, H: 10, D: 10, Quantity: 1, Weight: 1, MaxStackWeight: 3}
+	can.Quantity = 2
+	packed, _ = Pack([]InputItem{base, can}, []InputBox{{ID: "tube", W: 10, H: 30, D: 10}})
+	for _, pb := range packed {
+		for _, p := range pb.Contents {
+			if p.ItemID == "base" && len(pb.Contents) > 2 {
+				t.Errorf("Expected at most one can on the base, got %+v", pb.Contents)
+			}
+		}
+	}
+}
+
+func TestMaxStackUnits(t *testing.T) {
+	// The tube fits five crates on each other but they take at most two
+	boxes := []InputBox{{ID: "tube", W: 10, H: 50, D: 10}}
+	crates := InputItem{ID: "crate", W: 10, H: 10, D: 10, Quantity: 5, MaxStackUnits: 2}
+
+	for _, strategy := range []string{"", StrategyColumnStacking} {
+		packed, _ := PackWithOptions([]InputItem{crates}, boxes, Options{Strategy: strategy})
+		if len(packed) != 3 {
+			t.Errorf("%q: Expected 3 tubes for stacks of at most 2, got %d", strategy, len(packed))
+		}
+	}
+
+	// Another item between them starts a new run
+	crate := itemToPack{InputItem: crates}
+	stack := []Placement{
+		{ItemID: "crate", Y: 0, W: 10, H: 10, D: 10},
+		{ItemID: "crate", Y: 10, W: 10, H: 10, D: 10},
+		{ItemID: "lid", Y: 20, W: 10, H: 2, D: 10},
+	}
+	if stackRunAllows(crate, stack[:2], 0, 20, 0, 10, 10, 10) {
+		t.Error("Expected a third crate on two to be refused")
+	}
+	if !stackRunAllows(crate, stack, 0, 22, 0, 10, 10, 10) {
+		t.Error("Expected a crate on the lid to be allowed")
+	}
+	// A crate slid under two counts the run above it
+	above := []Placement{stack[1], {ItemID: "crate", Y: 20, W: 10, H: 10, D: 10}}
+	if stackRunAllows(crate, above, 0, 0, 0, 10, 10, 10) {
+		t.Error("Expected a crate under another to count as stacked")
+	}
+
+	if err := ValidateWeights([]InputItem{{ID: "x", W: 1, H: 1, D: 1, Quantity: 1, MaxStackUnits: -1}}, boxes); err == nil {
+		t.Error("Expected a negative max_stack_units to be rejected")
+	}
+}
+
+func TestSupportThreshold(t *testing.T) {
+	// The slab can only lie on top of the cube, covering 36% of its base
+	items := []InputItem{
+		{ID: "cube", W: 12, H: 12, D: 12, Quantity: 1},
+		{ID: "slab", W: 20, H: 4, D: 20, Quantity: 1},
+	}
+	boxes := []InputBox{{ID: "box", W: 20, H: 16, D: 20}}
+
+	if packed, _ := Pack(items, boxes); len(packed) != 2 {
+		t.Errorf("Expected the unsupported slab to need a second box, got %d boxes", len(packed))
+	}
+	packed, _ := PackWithOptions(items, boxes, Options{MinSupportPercent: 30})
+	if len(packed) != 1 {
+		t.Errorf("Expected a 30%% threshold to allow the overhang, got %d boxes", len(packed))
+	}
+}
+
+func TestBoxStock(t *testing.T) {
+	items := []InputItem{{ID: "cube", W: 10, H: 10, D: 10, Quantity: 4}}
+	boxes := []InputBox{
+		{ID: "single", W: 10, H: 10, D: 10, Quantity: 2},
+		{ID: "double", W: 20, H: 10, D: 10, Quantity: 1},
+	}
+
+	packed, unpacked := Pack(items, boxes)
+	used := map[string]int{}
+	for _, pb := range packed {
+		used[pb.BoxID]++
+	}
+	if used["single"] > 2 || used["double"] > 1 {
+		t.Errorf("Expected stock to be respected, got %v", used)
+	}
+	if len(unpacked) != 0 {
+		t.Errorf("Expected stock to cover all items, got %d unpacked", len(unpacked))
+	}
+
+	items[0].Quantity = 6
+	packed, unpacked = Pack(items, boxes)
+	if len(packed) != 3 || len(unpacked) != 2 {
+		t.Errorf("Expected 3 boxes and 2 unpacked items once stock runs out, got %d and %d", len(packed), len(unpacked))
+	}
+	if w := StockWarnings(packed, unpacked, boxes); len(w) != 2 || w[0].Code != WarnBoxStockExhausted {
+		t.Errorf("Expected a stock warning per exhausted type, got %+v", w)
+	}
+}
+
+func TestMaxBoxes(t *testing.T) {
+	items := []InputItem{
+		{ID: "cube", W: 10, H: 10, D: 10, Quantity: 5},
+		{ID: "giant", W: 50, H: 50, D: 50, Quantity: 1},
+	}
+	boxes := []InputBox{{ID: "double", W: 20, H: 10, D: 10}}
+
+	packed, unpacked := PackWithOptions(items, boxes, Options{MaxBoxes: 2})
+	if len(packed) != 2 || len(unpacked) != 2 {
+		t.Fatalf("Expected 2 boxes and 2 unpacked units, got %d and %d", len(packed), len(unpacked))
+	}
+	w := MaxBoxesWarnings(packed, unpacked, boxes, Options{MaxBoxes: 2})
+	if len(w) != 1 || w[0].Code != WarnMaxBoxesReached || w[0].ItemID != "cube" {
+		t.Errorf("Expected a max_boxes warning for the cube only, got %+v", w)
+	}
+
+	packed, unpacked = PackWithOptions(items, boxes, Options{MaxBoxes: 3})
+	if w := MaxBoxesWarnings(packed, unpacked, boxes, Options{MaxBoxes: 3}); len(unpacked) != 1 || len(w) != 0 {
+		t.Errorf("Expected no warning when only an oversized item is unpacked, got %+v", w)
+	}
+
+	if err := (Options{MaxBoxes: -1}).Validate(); err == nil {
+		t.Error("Expected negative max_boxes to be rejected")
+	}
+}
+
+func TestIncompatibleItems(t *testing.T) {
+	items := []InputItem{
+		{ID: "bleach", W: 10, H: 10, D: 10, Quantity: 2, IncompatibleWith: []string{"bread"}},
+		{ID: "bread", W: 10, H: 10, D: 10, Quantity: 1},
+		{ID: "towel", W: 10, H: 10, D: 10, Quantity: 1},
+	}
+	boxes := []InputBox{{ID: "quad", W: 40, H: 10, D: 10}}
+
+	packed, unpacked := Pack(items, boxes)
+	if len(packed) != 2 || len(unpacked) != 0 {
+		t.Fatalf("Expected 2 boxes with everything packed, got %d and %d unpacked", len(packed), len(unpacked))
+	}
+	for _, pb := range packed {
+		ids := make(map[string]bool)
+		for _, p := range pb.Contents {
+			ids[p.ItemID] = true
+		}
+		if ids["bleach"] && ids["bread"] {
+			t.Errorf("Expected bleach and bread in separate boxes, got %+v", pb.Contents)
+		}
+	}
+}
+
+func TestWallClearance(t *testing.T) {
+	// The box is 40 × 40: a 10-wide clearance leaves the middle 20 × 20 for
+	// the vaccines, and the boxes of tape fill the ring along the walls.
+	items := []InputItem{
+		{ID: "vaccine", W: 20, H: 10, D: 20, Quantity: 1, WallClearance: 10},
+		{ID: "tape", W: 10, H: 10, D: 10, Quantity: 12},
+	}
+	boxes := []InputBox{{ID: "reefer", W: 40, H: 10, D: 40}}
+
+	for _, name := range []string{AlgorithmExtremePoints, AlgorithmFFDShelf, AlgorithmBestFit, AlgorithmGuillotine} {
+		for _, compact := range []bool{false, true} {
+			opts := Options{Algorithm: name, Compact: compact}
+			packed, unpacked, _ := PackContext(context.Background(), items, boxes, opts)
+			vaccines := 0
+			for _, pb := range packed {
+				for _, p := range pb.Contents {
+					if p.ItemID != "vaccine" {
+						continue
+					}
+					vaccines++
+					if p.X < 10 || p.Z < 10 || p.X+p.W > 30 || p.Z+p.D > 30 {
+						t.Errorf("%s compact=%t: vaccine at (%d, %d) is too close to a wall", name, compact, p.X, p.Z)
+					}
+				}
+			}
+			if vaccines != 1 {
+				t.Errorf("%s compact=%t: expected the vaccines packed, got %d (%d unpacked)", name, compact, vaccines, len(unpacked))
+			}
+			if name == AlgorithmExtremePoints && (len(packed) != 1 || len(unpacked) != 0) {
+				t.Errorf("compact=%t: expected everything in one box, got %d boxes and %d unpacked", compact, len(packed), len(unpacked))
+			}
+		}
+	}
+}
+
+func TestPackObjectives(t *testing.T) {
+	items := []InputItem{{ID: "cube", W: 10, H: 10, D: 10, Quantity: 2}}
+	boxes := []InputBox{
+		{ID: "double", W: 20, H: 10, D: 10, Cost: 10},
+		{ID: "single", W: 10, H: 10, D: 10, Cost: 3},
+		{ID: "roomy", W: 30, H: 10, D: 10, Cost: 4},
+	}
+
+	cases := map[string][]string{
+		"":                           {"double"},
+		ObjectiveMinimizeBoxes:       {"double"},
+		ObjectiveMinimizeCost:        {"roomy"},
+		ObjectiveMaximizeUtilization: {"double"},
+	}
+	for objective, want := range cases {
+		packed, _ := PackWithOptions(items, boxes, Options{Objective: objective})
+		var got []string
+		for _, pb := range packed {
+			got = append(got, pb.BoxID)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("Objective %q: expected boxes %v, got %v", objective, want, got)
+		}
+	}
+
+	// Without the roomy box two singles are cheaper than one double
+	packed, _ := PackWithOptions(items, boxes[:2], Options{Objective: ObjectiveMinimizeCost})
+	if len(packed) != 2 || packed[0].BoxID != "single" {
+		t.Errorf("Expected two single boxes, got %+v", packed)
+	}
+}
+
+func TestBoxTiers(t *testing.T) {
+	items := []InputItem{
+		{ID: "cube", W: 10, H: 10, D: 10, Quantity: 2},
+		{ID: "pole", W: 5, H: 5, D: 40, Quantity: 1},
+	}
+	boxes := []InputBox{
+		{ID: "long", W: 20, H: 10, D: 40, Tier: 1},
+		{ID: "double", W: 20, H: 10, D: 10},
+	}
+
+	// The long box would take everything, but only the pole needs it
+	for _, objective := range []string{"", ObjectiveMinimizeCost, ObjectiveMaximizeUtilization} {
+		packed, unpacked := PackWithOptions(items, boxes, Options{Objective: objective})
+		var got []string
+		for _, pb := range packed {
+			got = append(got, pb.BoxID)
+		}
+		if want := []string{"double", "long"}; len(unpacked) != 0 || !slices.Equal(slices.Sorted(slices.Values(got)), want) {
+			t.Errorf("Objective %q: expected boxes %v, got %v", objective, want, got)
+		}
+	}
+
+	boxes[0].Tier = 0
+	if packed, _ := Pack(items, boxes); len(packed) != 1 {
+		t.Errorf("Expected one long box when it is not de-prioritized, got %d boxes", len(packed))
+	}
+
+	boxes[0].Tier = -1
+	if err := ValidateWeights(items, boxes); err == nil {
+		t.Error("Expected a negative tier to be rejected")
+	}
+}
+
+func TestNoOverlap(t *testing.T) {
+	// Test that items are placed without overlapping
+	items := []InputItem{
+		{ID: "cube", W: 10, H: 10, D: 10, Quantity: 8},
+	}
+
+	boxes := []InputBox{
+		{ID: "box", W: 20, H: 20, D: 20}, // Should fit exactly 8 cubes
+	}
+
+	packedBoxes, unpackedItems := Pack(items, boxes)
+
+	if len(unpackedItems) > 0 {
+		t.Errorf("Expected all 8 cubes to be packed, but got %d unpacked", len(unpackedItems))
+	}
+
+	if len(packedBoxes) != 1 {
+		t.Errorf("Expected 1 box, got %d", len(packedBoxes))
+	}
+
+	// Verify each box has 8 items
+	if len(packedBoxes[0].Contents) != 8 {
+		t.Errorf("Expected 8 items in box, got %d", len(packedBoxes[0].Contents))
+	}
+
+	// Verify no overlaps
+	if !verifyNoOverlaps(packedBoxes[0].Contents) {
+		t.Error("Detected overlapping items!")
+	}
+}
+
+func TestRotation(t *testing.T) {
+	// Test that rotation is used to fit items
+	items := []InputItem{
+		{ID: "long-item", W: 50, H: 5, D: 5, Quantity: 1},
+	}
+
+	boxes := []InputBox{
+		{ID: "tall-box", W: 10, H: 60, D: 10}, // Item needs rotation to fit
+	}
+
+	packedBoxes, unpackedItems := Pack(items, boxes)
+
+	if len(unpackedItems) > 0 {
+		t.Errorf("Expected long item to be packed (rotated), but it wasn't")
+	}
+
+	if len(packedBoxes) != 1 {
+		t.Errorf("Expected 1 box, got %d", len(packedBoxes))
+	}
+
+	// The item should be rotated to fit
+	if len(packedBoxes[0].Contents) != 1 {
+		t.Errorf("Expected 1 item, got %d", len(packedBoxes[0].Contents))
+	}
+
+	item := packedBoxes[0].Contents[0]
+	// Check that item fits in box dimensions after rotation
+	if item.W > 10 || item.H > 60 || item.D > 10 {
+		t.Errorf("Item dimensions %dx%dx%d don't fit in 10x60x10 box", item.W, item.H, item.D)
+	}
+}
+
+func TestItemsWithinBounds(t *testing.T) {
+	// Test that all items stay within box bounds
+	items := []InputItem{
+		{ID: "item-1", W: 10, H: 10, D: 10, Quantity: 5},
+		{ID: "item-2", W: 8, H: 8, D: 8, Quantity: 3},
+	}
+
+	boxes := []InputBox{
+		{ID: "box", W: 30, H: 30, D: 30},
+	}
+
+	packedBoxes, _ := Pack(items, boxes)
+
+	box := boxes[0]
+	for _, pb := range packedBoxes {
+		for _, item := range pb.Contents {
+			if item.X < 0 || item.Y < 0 || item.Z < 0 {
+				t.Errorf("Item %s has negative position: (%d,%d,%d)", item.ItemID, item.X, item.Y, item.Z)
+			}
+			if item.X+item.W > box.W || item.Y+item.H > box.H || item.Z+item.D > box.D {
+				t.Errorf("Item %s extends outside box bounds: pos(%d,%d,%d) size(%d,%d,%d)",
+					item.ItemID, item.X, item.Y, item.Z, item.W, item.H, item.D)
+			}
+		}
+	}
+}
+
+func TestTightPacking(t *testing.T) {
+	// Test tight packing scenario
+	items := []InputItem{
+		{ID: "item", W: 10, H: 10, D: 10, Quantity: 3},
+	}
+
+	boxes := []InputBox{
+		{ID: "box", W: 20, H: 10, D: 20}, // Volume 4000, can fit 3 items (3000) but spatially only 4 positions
+	}
+
+	packedBoxes, unpackedItems := Pack(items, boxes)
+
+	// Should pack all 3 items in various positions
+	totalPacked := 0
+	for _, pb := range packedBoxes {
+		totalPacked += len(pb.Contents)
+		if !verifyNoOverlaps(pb.Contents) {
+			t.Error("Detected overlapping items in tight packing")
+		}
+	}
+
+	if len(unpackedItems) > 0 {
+		t.Errorf("Expected all 3 items packed, got %d unpacked", len(unpackedItems))
+	}
+}
+
+func TestBottomLeftBackPreference(t *testing.T) {
+	// Test that items are packed with bottom-left-back preference
+	items := []InputItem{
+		{ID: "item", W: 5, H: 5, D: 5, Quantity: 1},
+	}
+
+	boxes := []InputBox{
+		{ID: "box", W: 20, H: 20, D: 20},
+	}
+
+	packedBoxes, _ := Pack(items, boxes)
+
+	if len(packedBoxes) == 0 || len(packedBoxes[0].Contents) == 0 {
+		t.Fatal("Expected item to be packed")
+	}
+
+	item := packedBoxes[0].Contents[0]
+	// Item should be at origin (0,0,0) due to bottom-left-back preference
+	if item.X != 0 || item.Y != 0 || item.Z != 0 {
+		t.Errorf("Expected item at origin (0,0,0), got (%d,%d,%d)", item.X, item.Y, item.Z)
+	}
+}
+
+// Helper function to verify no items overlap
+func verifyNoOverlaps(placements []Placement) bool {
+	for i := 0; i < len(placements); i++ {
+		for j := i + 1; j < len(placements); j++ {
+			p1 := placements[i]
+			p2 := placements[j]
+
+			// Check overlap on all three axes
+			overlapX := p1.X < p2.X+p2.W && p1.X+p1.W > p2.X
+			overlapY := p1.Y < p2.Y+p2.H && p1.Y+p1.H > p2.Y
+			overlapZ := p1.Z < p2.Z+p2.D && p1.Z+p1.D > p2.Z
+
+			if overlapX && overlapY && overlapZ {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestPruneExtremePointsReducesPointCount(t *testing.T) {
+	items := []InputItem{
+		{ID: "a", W: 7, H: 5, D: 3, Quantity: 60},
+		{ID: "b", W: 4, H: 4, D: 4, Quantity: 60},
+		{ID: "c", W: 9, H: 2, D: 6, Quantity: 60},
+	}
+	box := InputBox{ID: "box", W: 60, H: 60, D: 60}
+
+	packedBoxes, _ := Pack(items, []InputBox{box})
+	if len(packedBoxes) == 0 {
+		t.Fatal("Expected at least one packed box")
+	}
+	placements := packedBoxes[0].Contents
+
+	// Replay the placements with and without pruning and compare how many
+	// extreme points are tracked along the way.
+	raw := []FreeSpace{{W: box.W, H: box.H, D: box.D}}
+	pruned := []FreeSpace{{W: box.W, H: box.H, D: box.D}}
+	rawTotal, prunedTotal := 0, 0
+	for i, p := range placements {
+		raw = updateExtremePoints(raw, p, box, occupied{placements: placements[:i+1]})
+		pruned = updateExtremePoints(pruned, p, box, occupied{placements: placements[:i+1]})
+		pruned = pruneExtremePoints(pruned, occupied{placements: placements[:i+1]}, 2)
+		rawTotal += len(raw)
+		prunedTotal += len(pruned)
+	}
+
+	if prunedTotal >= rawTotal {
+		t.Errorf("Expected pruning to reduce tracked points, got %d pruned vs %d raw", prunedTotal, rawTotal)
+	}
+	t.Logf("extreme points tracked: %d raw, %d pruned", rawTotal, prunedTotal)
+}
+
+func TestPruneExtremePointsKeepsUsablePoints(t *testing.T) {
+	box := InputBox{ID: "box", W: 20, H: 20, D: 20}
+	placements := []Placement{{ItemID: "a", W: 10, H: 10, D: 10}}
+
+	points := updateExtremePoints([]FreeSpace{{W: 20, H: 20, D: 20}}, placements[0], box, occupied{placements: placements})
+	points = pruneExtremePoints(points, occupied{placements: placements}, 10)
+
+	if len(points) != 3 {
+		t.Fatalf("Expected 3 usable points next to the cube, got %d: %+v", len(points), points)
+	}
+	for _, ep := range points {
+		if ep.W < 10 || ep.H < 10 || ep.D < 10 {
+			t.Errorf("Point %+v cannot hold a 10-unit cube and should have been pruned", ep)
+		}
+	}
+
+	// Nothing of side 11 fits next to the cube, so every point is dead.
+	if got := pruneExtremePoints(points, occupied{placements: placements}, 11); len(got) != 0 {
+		t.Errorf("Expected all points pruned for side 11, got %+v", got)
+	}
+}
+
+func BenchmarkPackLarge(b *testing.B) {
+	items := []InputItem{
+		{ID: "a", W: 12, H: 8, D: 6, Quantity: 200},
+		{ID: "b", W: 5, H: 5, D: 5, Quantity: 200},
+		{ID: "c", W: 20, H: 3, D: 9, Quantity: 100},
+	}
+	boxes := []InputBox{
+		{ID: "small", W: 40, H: 40, D: 40},
+		{ID: "large", W: 100, H: 80, D: 80},
+	}
+
+	for b.Loop() {
+		Pack(items, boxes)
+	}
+}
+
+func TestFindBestBoxParallelMatchesSerial(t *testing.T) {
+	items := expandItems([]InputItem{
+		{ID: "a", W: 12, H: 8, D: 6, Quantity: 20},
+		{ID: "b", W: 5, H: 5, D: 5, Quantity: 30},
+		{ID: "c", W: 20, H: 3, D: 9, Quantity: 10},
+	})
+	sortItemsByVolume(items)
+	var boxes []InputBox
+	for i := range 60 {
+		side := 20 + i
+		boxes = append(boxes, InputBox{ID: fmt.Sprintf("box-%d", i), W: side, H: side - i%7, D: side + i%5})
+	}
+	boxes = sortBoxesByVolume(boxes)
+
+	for _, opts := range []Options{{}, {TargetFillPercent: 60}, {Objective: ObjectiveMaximizeUtilization}} {
+		wantIdx, wantPlacements, wantPacked, _ := findBestBoxWorkers(context.Background(), items, boxes, opts, 1)
+		gotIdx, gotPlacements, gotPacked, _ := findBestBoxWorkers(context.Background(), items, boxes, opts, 8)
+		if gotIdx != wantIdx || !slices.Equal(gotPlacements, wantPlacements) || !slices.Equal(gotPacked, wantPacked) {
+			t.Errorf("%+v: expected box %d as when run serially, got box %d", opts, wantIdx, gotIdx)
+		}
+	}
+}
+
+func BenchmarkPackManyBoxTypes(b *testing.B) {
+	items := []InputItem{
+		{ID: "a", W: 12, H: 8, D: 6, Quantity: 40},
+		{ID: "b", W: 5, H: 5, D: 5, Quantity: 40},
+		{ID: "c", W: 20, H: 3, D: 9, Quantity: 20},
+	}
+	var boxes []InputBox
+	for i := range 50 {
+		boxes = append(boxes, InputBox{ID: fmt.Sprintf("box-%d", i), W: 30 + i, H: 30 + i/2, D: 30 + i})
+	}
+
+	for b.Loop() {
+		Pack(items, boxes)
+	}
+}
+
+func TestResidualSpaceMerging(t *testing.T) {
+	// After the first two items the free floor area is split into pieces that
+	// no single extreme point sees in full; the 3-wide bar only fits once the
+	// adjacent gaps are treated as one residual space.
+	items := []InputItem{
+		{ID: "square", W: 2, H: 1, D: 2, Quantity: 1},
+		{ID: "rod", W: 1, H: 1, D: 3, Quantity: 1},
+		{ID: "bar", W: 3, H: 1, D: 1, Quantity: 1},
+	}
+	boxes := []InputBox{
+		{ID: "tray", W: 3, H: 1, D: 4},
+	}
+
+	packedBoxes, unpackedItems := Pack(items, boxes)
+
+	if len(unpackedItems) > 0 {
+		t.Errorf("Expected all items to be packed, got %d unpacked", len(unpackedItems))
+	}
+	if len(packedBoxes) != 1 {
+		t.Fatalf("Expected 1 box, got %d", len(packedBoxes))
+	}
+	if !verifyNoOverlaps(packedBoxes[0].Contents) {
+		t.Error("Detected overlapping items")
+	}
+}
+
+func TestSubtractPlacementKeepsMaximalSpaces(t *testing.T) {
+	spaces := []FreeSpace{{W: 10, H: 10, D: 10}}
+	spaces = subtractPlacement(spaces, Placement{X: 0, Y: 0, Z: 0, W: 4, H: 10, D: 4}, 1)
+
+	// The L-shaped remainder is covered by two overlapping maximal slabs.
+	want := map[FreeSpace]bool{
+		{X: 4, Y: 0, Z: 0, W: 6, H: 10, D: 10}: true,
+		{X: 0, Y: 0, Z: 4, W: 10, H: 10, D: 6}: true,
+	}
+	if len(spaces) != len(want) {
+		t.Fatalf("Expected %d spaces, got %+v", len(want), spaces)
+	}
+	for _, s := range spaces {
+		if !want[s] {
+			t.Errorf("Unexpected space %+v", s)
+		}
+	}
+}
+
+func TestAnchorCornerPolicies(t *testing.T) {
+	items := []InputItem{
+		{ID: "item", W: 5, H: 5, D: 5, Quantity: 1},
+	}
+	boxes := []InputBox{
+		{ID: "box", W: 20, H: 20, D: 20},
+	}
+
+	tests := []struct {
+		policy string
+		x, z   int
+	}{
+		{PlacementBackLeft, 0, 0},
+		{PlacementBackRight, 15, 0},
+		{PlacementFrontLeft, 0, 15},
+		{PlacementFrontRight, 15, 15},
+	}
+
+	for _, tt := range tests {
+		packedBoxes, _ := PackWithOptions(items, boxes, Options{PlacementPolicy: tt.policy})
+		if len(packedBoxes) != 1 || len(packedBoxes[0].Contents) != 1 {
+			t.Fatalf("%s: expected a single packed item", tt.policy)
+		}
+		got := packedBoxes[0].Contents[0]
+		if got.X != tt.x || got.Y != 0 || got.Z != tt.z {
+			t.Errorf("%s: expected item at (%d,0,%d), got (%d,%d,%d)", tt.policy, tt.x, tt.z, got.X, got.Y, got.Z)
+		}
+	}
+}
+
+func TestAlternatingPolicyPacksAllItems(t *testing.T) {
+	items := []InputItem{
+		{ID: "carton", W: 10, H: 10, D: 10, Quantity: 8},
+	}
+	boxes := []InputBox{
+		{ID: "pallet", W: 20, H: 20, D: 20},
+	}
+
+	packedBoxes, unpackedItems := PackWithOptions(items, boxes, Options{PlacementPolicy: PlacementAlternating})
+
+	if len(unpackedItems) > 0 || len(packedBoxes) != 1 {
+		t.Fatalf("Expected all cartons in one box, got %d boxes and %d unpacked", len(packedBoxes), len(unpackedItems))
+	}
+	if !verifyNoOverlaps(packedBoxes[0].Contents) {
+		t.Error("Detected overlapping items")
+	}
+
+	// The second layer starts from the opposite corner.
+	for _, p := range packedBoxes[0].Contents {
+		if p.Y == 10 {
+			if p.X != 10 || p.Z != 10 {
+				t.Errorf("Expected first item of layer 2 at the front-right corner, got (%d,%d,%d)", p.X, p.Y, p.Z)
+			}
+			break
+		}
+	}
+}
+
+func TestBalanceObjectiveSplitsEvenly(t *testing.T) {
+	items := []InputItem{
+		{ID: "crate", W: 10, H: 10, D: 10, Quantity: 6},
+		{ID: "case", W: 10, H: 10, D: 5, Quantity: 4},
+	}
+	boxes := []InputBox{
+		{ID: "truck", W: 40, H: 20, D: 20},
+	}
+
+	packedBoxes, unpackedItems := PackWithOptions(items, boxes, Options{Objective: ObjectiveBalance, Containers: 2})
+
+	if len(unpackedItems) > 0 {
+		t.Errorf("Expected all items to be packed, got %d unpacked", len(unpackedItems))
+	}
+	if len(packedBoxes) != 2 {
+		t.Fatalf("Expected 2 containers, got %d", len(packedBoxes))
+	}
+
+	var loads []int
+	for _, pb := range packedBoxes {
+		if !verifyNoOverlaps(pb.Contents) {
+			t.Errorf("Detected overlapping items in %s", pb.BoxID)
+		}
+		load := 0
+		for _, p := range pb.Contents {
+			load += p.W * p.H * p.D
+		}
+		loads = append(loads, load)
+	}
+	if loads[0] != loads[1] {
+		t.Errorf("Expected equal loads, got %v", loads)
+	}
+}
+
+func TestBalanceObjectiveSpreadsWeight(t *testing.T) {
+	// By volume alone both ingots would follow the foam into the second
+	// truck, since the foam fills twice their volume.
+	items := []InputItem{
+		{ID: "foam", W: 10, H: 10, D: 20, Quantity: 1, Weight: 1},
+		{ID: "ingot", W: 10, H: 10, D: 10, Quantity: 2, Weight: 40},
+	}
+	boxes := []InputBox{{ID: "truck", W: 40, H: 20, D: 20, MaxWeight: 100}}
+
+	packedBoxes, unpackedItems := PackWithOptions(items, boxes, Options{Objective: ObjectiveBalance, Containers: 2})
+	if len(unpackedItems) > 0 || len(packedBoxes) != 2 {
+		t.Fatalf("Expected everything in 2 containers, got %d boxes and %d unpacked", len(packedBoxes), len(unpackedItems))
+	}
+	for _, pb := range packedBoxes {
+		if !slices.ContainsFunc(pb.Contents, func(p Placement) bool { return p.ItemID == "ingot" }) {
+			t.Errorf("Expected an ingot in each container, got %+v", pb.Contents)
+		}
+	}
+}
+
+func TestTargetFillAndSpillover(t *testing.T) {
+	items := []InputItem{
+		{ID: "cube", W: 10, H: 10, D: 10, Quantity: 6},
+	}
+	boxes := []InputBox{
+		{ID: "small", W: 20, H: 20, D: 20},
+		{ID: "large", W: 30, H: 30, D: 30},
+	}
+
+	// Half of the small box holds 4 cubes; the remaining 2 spill over.
+	opts := Options{TargetFillPercent: 50, Spillover: SpilloverSameSize}
+	packedBoxes, unpackedItems := PackWithOptions(items, boxes, opts)
+
+	if len(unpackedItems) > 0 {
+		t.Fatalf("Expected all items to be packed, got %d unpacked", len(unpackedItems))
+	}
+	for _, pb := range packedBoxes {
+		vol := 0
+		for _, p := range pb.Contents {
+			vol += p.W * p.H * p.D
+		}
+		limit := 27000 / 2
+		if pb.BoxID == "small" {
+			limit = 8000 / 2
+		}
+		if vol > limit {
+			t.Errorf("Box %s filled to %d, above its target of %d", pb.BoxID, vol, limit)
+		}
+	}
+
+	first := packedBoxes[0].BoxID
+	for _, pb := range packedBoxes[1:] {
+		if pb.BoxID != first {
+			t.Errorf("Expected same_size spillover to reuse %s, got %s", first, pb.BoxID)
+		}
+	}
+}
+
+func TestSpilloverNextSizeUp(t *testing.T) {
+	items := []InputItem{
+		{ID: "cube", W: 10, H: 10, D: 10, Quantity: 12},
+	}
+	boxes := []InputBox{
+		{ID: "small", W: 20, H: 20, D: 20},
+		{ID: "large", W: 30, H: 30, D: 30, MaxFillPercent: 15},
+	}
+
+	packedBoxes, _ := PackWithOptions(items, boxes, Options{Spillover: SpilloverNextSizeUp})
+
+	// The small box takes 8 cubes; without a policy the remaining 4 would
+	// go into another small box as that is the tighter fit.
+	if len(packedBoxes) != 2 {
+		t.Fatalf("Expected 2 boxes, got %d", len(packedBoxes))
+	}
+	if packedBoxes[0].BoxID != "small" || packedBoxes[1].BoxID != "large" {
+		t.Errorf("Expected small then large, got %s then %s", packedBoxes[0].BoxID, packedBoxes[1].BoxID)
+	}
+}
+
+func TestAxleLoadPolicy(t *testing.T) {
+	// A trailer 100 long: the heavy crate should go to the centre-rear
+	// zones rather than against the rear doors, the light ones around it.
+	boxes := []InputBox{{ID: "trailer", W: 10, H: 10, D: 100}}
+	items := []InputItem{
+		{ID: "light", W: 10, H: 10, D: 10, Quantity: 3, Weight: 1},
+		{ID: "heavy", W: 10, H: 10, D: 10, Quantity: 1, Weight: 100},
+	}
+	opts := Options{PlacementPolicy: PlacementAxleLoad}
+
+	packed, unpacked := PackWithOptions(items, boxes, opts)
+	if len(packed) != 1 || len(unpacked) != 0 {
+		t.Fatalf("Expected one trailer and nothing unpacked, got %d and %d", len(packed), len(unpacked))
+	}
+	for _, p := range packed[0].Contents {
+		if p.ItemID == "heavy" && (p.Z < 20 || p.Z+p.D > 60) {
+			t.Errorf("Expected the heavy crate in the centre-rear zones, got z=%d", p.Z)
+		}
+	}
+
+	loads := packed[0].AxleLoads
+	if loads == nil {
+		t.Fatal("Expected axle loads to be reported")
+	}
+	if got := loads.Rear + loads.Front; got < 102.9 || got > 103.1 {
+		t.Errorf("Expected axle loads to add up to the total weight 103, got %v", got)
+	}
+	if loads.Rear <= loads.Front {
+		t.Errorf("Expected the rear axle to carry more, got %+v", loads)
+	}
+
+	if err := (Options{AxleLoad: &AxleLoad{}}).Validate(); err == nil {
+		t.Error("Expected axle_load without its placement policy to be rejected")
+	}
+}
+
+func TestPackContextStopsWhenDone(t *testing.T) {
+	items := []InputItem{{ID: "cube", W: 1, H: 1, D: 1, Quantity: 10}}
+	boxes := []InputBox{{ID: "box", W: 10, H: 10, D: 10}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	packed, unpacked, err := PackContext(ctx, items, boxes, Options{})
+	if err != context.Canceled || len(packed) != 0 || len(unpacked) != 10 {
+		t.Errorf("Expected nothing packed and context.Canceled, got %d boxes, %d unpacked, %v", len(packed), len(unpacked), err)
+	}
+
+	packed, unpacked, err = PackContext(context.Background(), items, boxes, Options{})
+	if err != nil || len(packed) != 1 || len(unpacked) != 0 {
+		t.Errorf("Expected everything packed, got %d boxes, %d unpacked, %v", len(packed), len(unpacked), err)
+	}
+}
+
+// withoutGrid makes every box use a plain scan until the returned function
+// is called.
+func withoutGrid() (restore func()) {
+	n := gridMinPlacements
+	gridMinPlacements = math.MaxInt
+	return func() { gridMinPlacements = n }
+}
+
+func TestGridMatchesScan(t *testing.T) {
+	items := []InputItem{
+		{ID: "a", W: 12, H: 8, D: 6, Quantity: 120},
+		{ID: "b", W: 5, H: 5, D: 5, Quantity: 120},
+		{ID: "c", W: 20, H: 3, D: 9, Quantity: 60, Stackable: new(bool)},
+	}
+	boxes := []InputBox{{ID: "crate", W: 60, H: 50, D: 60}}
+
+	for alg := range algorithms {
+		opts := Options{Algorithm: alg}
+		gridPacked, gridUnpacked := PackWithOptions(items, boxes, opts)
+		restore := withoutGrid()
+		scanPacked, scanUnpacked := PackWithOptions(items, boxes, opts)
+		restore()
+
+		if !reflect.DeepEqual(gridPacked, scanPacked) || !reflect.DeepEqual(gridUnpacked, scanUnpacked) {
+			t.Errorf("%s: Expected the grid to give the same packing as a scan", alg)
+		}
+	}
+}
+
+// A compressible item still to come must not lose the space it only fits
+// once squeezed: 11 squeezed by 10% is 10, the room left beside the rigid item.
+func TestPruningKeepsSpaceForSqueezedItems(t *testing.T) {
+	items := []InputItem{
+		{ID: "rigid", W: 15, H: 10, D: 10, Quantity: 1},
+		{ID: "soft", W: 11, H: 11, D: 11, Quantity: 1, Compressibility: 10},
+	}
+	boxes := []InputBox{{ID: "box", W: 25, H: 10, D: 10}}
+
+	packed, unpacked := PackWithOptions(items, boxes, Options{})
+	if len(packed) != 1 || len(unpacked) != 0 {
+		t.Errorf("Expected both items in 1 box, got %d boxes and %d unpacked", len(packed), len(unpacked))
+	}
+}
+
+// BenchmarkPack5kItems packs 5000 items, about 1000 to a box. Without the
+// grid each candidate position is tested against every item in the box.
+func BenchmarkPack5kItems(b *testing.B) {
+	items := []InputItem{
+		{ID: "a", W: 12, H: 8, D: 6, Quantity: 2000},
+		{ID: "b", W: 5, H: 5, D: 5, Quantity: 2000},
+		{ID: "c", W: 20, H: 3, D: 9, Quantity: 1000},
+	}
+	boxes := []InputBox{{ID: "crate", W: 80, H: 80, D: 80}}
+
+	b.Run("grid", func(b *testing.B) {
+		for b.Loop() {
+			Pack(items, boxes)
+		}
+	})
+	b.Run("scan", func(b *testing.B) {
+		defer withoutGrid()()
+		for b.Loop() {
+			Pack(items, boxes)
+		}
+	})
+}
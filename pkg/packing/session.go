@@ -0,0 +1,220 @@
+package packing
+
+import (
+	"context"
+	"slices"
+)
+
+// Session is a packing kept open between edits, so an added item is placed
+// into the existing layout instead of repacking everything.
+type Session struct {
+	boxes    []InputBox
+	opts     Options
+	open     []*boxState
+	unpacked []itemToPack
+	count    int // items in the session, packed or not
+	changed  map[int]bool
+}
+
+// NewSession returns an empty session packing into boxes.
+func NewSession(boxes []InputBox, opts Options) *Session {
+	return &Session{
+		boxes:   sortBoxesByVolume(boxes),
+		opts:    opts.withCompiledConstraints(),
+		changed: make(map[int]bool),
+	}
+}
+
+// Len returns the number of items in the session, packed or not.
+func (s *Session) Len() int {
+	return s.count
+}
+
+// Add places items, largest first. Items that ctx leaves no time to place
+// are left unpacked, and Add returns ctx.Err().
+func (s *Session) Add(ctx context.Context, inputItems []InputItem) error {
+	if err := ValidateOrientations(inputItems); err != nil {
+		return err
+	}
+	s.bind(ctx)
+	items := expandItems(inputItems)
+	sortItemsByVolume(items)
+	for _, it := range items {
+		s.add(ctx, it)
+	}
+	return ctx.Err()
+}
+
+// bind makes the open boxes place under ctx, the context of the current
+// edit.
+func (s *Session) bind(ctx context.Context) {
+	for _, st := range s.open {
+		st.ctx = ctx
+	}
+}
+
+// add places one item: into the first open box with room, else into the
+// smallest new box in stock that takes it.
+func (s *Session) add(ctx context.Context, item itemToPack) {
+	s.count++
+	for i, st := range s.open {
+		if st.place(item, 1) {
+			s.changed[i] = true
+			return
+		}
+	}
+	stock := newBoxStock(s.boxes)
+	for _, st := range s.open {
+		if i := slices.IndexFunc(s.boxes, func(b InputBox) bool { return b.ID == st.box.ID }); i != -1 {
+			stock.take(i)
+		}
+	}
+	avail, availIdx := stock.available(s.boxes)
+	idx, _, _, _ := findBestBox(ctx, []itemToPack{item}, avail, s.opts)
+	if idx == -1 {
+		s.unpacked = append(s.unpacked, item)
+		return
+	}
+	st := newBoxState(ctx, s.boxes[availIdx[idx]], s.opts)
+	st.place(item, 1)
+	s.open = append(s.open, st)
+	s.changed[len(s.open)-1] = true
+}
+
+// Remove takes up to n instances of an item out, unpacked ones first, then
+// from the most recently opened boxes, and returns how many it took. Boxes
+// that lose items are rebuilt under ctx.
+func (s *Session) Remove(ctx context.Context, id string, n int) int {
+	s.bind(ctx)
+	removed := 0
+	for i := len(s.unpacked) - 1; i >= 0 && removed < n; i-- {
+		if s.unpacked[i].ID == id {
+			s.unpacked = slices.Delete(s.unpacked, i, i+1)
+			removed++
+		}
+	}
+
+	for b := len(s.open) - 1; b >= 0 && removed < n; b-- {
+		st := s.open[b]
+		kept := st.items[:0:0]
+		for i := len(st.items) - 1; i >= 0; i-- {
+			if removed < n && st.items[i].ID == id {
+				removed++
+				continue
+			}
+			kept = append(kept, st.items[i])
+		}
+		if len(kept) != len(st.items) {
+			slices.Reverse(kept)
+			s.rebuild(ctx, b, kept)
+		}
+	}
+	s.count -= removed
+
+	// Drop boxes that became empty.
+	for b := len(s.open) - 1; b >= 0; b-- {
+		if len(s.open[b].placements) == 0 {
+			s.open = slices.Delete(s.open, b, b+1)
+			s.markFrom(b)
+		}
+	}
+	return removed
+}
+
+// rebuild repacks the remaining contents of box b from scratch. Items that
+// no longer fit go through add.
+func (s *Session) rebuild(ctx context.Context, b int, items []itemToPack) {
+	sortItemsByVolume(items)
+
+	st := newBoxState(ctx, s.open[b].box, s.opts)
+	s.open[b] = st
+	s.changed[b] = true
+	for _, it := range items {
+		if !st.place(it, 1) {
+			s.count--
+			s.add(ctx, it)
+		}
+	}
+}
+
+// Repack replaces the session with a full pack of all its items. If ctx
+// ends before the pack does, the session is left as it was and Repack
+// returns ctx.Err().
+func (s *Session) Repack(ctx context.Context) error {
+	items := slices.Clone(s.unpacked)
+	for _, st := range s.open {
+		items = append(items, st.items...)
+	}
+	sortItemsByVolume(items)
+
+	packed, _ := packSorted(ctx, items, s.boxes, s.opts)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.open, s.unpacked, s.count = nil, nil, 0
+
+	// Replay the plan box by box to get live states back, taking the
+	// original items (with their orientation constraints) by ID.
+	byID := BoxesByID(s.boxes)
+	pending := make(map[string][]itemToPack)
+	for _, it := range items {
+		pending[it.ID] = append(pending[it.ID], it)
+	}
+	for _, pb := range packed {
+		st := newBoxState(ctx, byID[pb.BoxID], s.opts)
+		for _, p := range pb.Contents {
+			queue := pending[p.ItemID]
+			if len(queue) == 0 {
+				continue
+			}
+			if st.place(queue[0], 1) {
+				s.count++
+				pending[p.ItemID] = queue[1:]
+			}
+		}
+		s.open = append(s.open, st)
+	}
+	for _, it := range items {
+		if queue := pending[it.ID]; len(queue) > 0 {
+			pending[it.ID] = queue[1:]
+			s.add(ctx, it)
+		}
+	}
+	s.markFrom(0)
+	return nil
+}
+
+// markFrom marks every box from index b on as changed, e.g. after boxes
+// shift position.
+func (s *Session) markFrom(b int) {
+	for i := b; i < len(s.open); i++ {
+		s.changed[i] = true
+	}
+}
+
+// Result returns the boxes of the session and the items left unpacked.
+func (s *Session) Result() ([]PackedBox, []InputItem) {
+	packed := make([]PackedBox, len(s.open))
+	for i, st := range s.open {
+		packed[i] = newPackedBox(st.box.ID, slices.Clone(st.placements))
+	}
+	unpacked := make([]InputItem, len(s.unpacked))
+	for i, it := range s.unpacked {
+		unpacked[i] = it.InputItem
+	}
+	return packed, unpacked
+}
+
+// Changed returns, in order, the indexes of the boxes whose contents changed
+// since the last call.
+func (s *Session) Changed() []int {
+	changed := []int{}
+	for i := range s.changed {
+		if i < len(s.open) {
+			changed = append(changed, i)
+		}
+	}
+	slices.Sort(changed)
+	clear(s.changed)
+	return changed
+}
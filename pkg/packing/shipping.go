@@ -0,0 +1,67 @@
+package packing
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ShippingClass is one tier of a carrier's rate table. A box belongs to the
+// class when it stays within every limit that is set; 0 means no limit.
+// Dimensions are the outer box sizes, in the same unit as the boxes.
+type ShippingClass struct {
+	Name      string  `json:"name"`
+	MaxWeight float64 `json:"max_weight,omitempty"`
+	// MaxLength limits the longest side.
+	MaxLength int `json:"max_length,omitempty"`
+	// MaxLengthPlusGirth limits the longest side plus twice the sum of the
+	// other two, as most parcel carriers measure size.
+	MaxLengthPlusGirth int `json:"max_length_plus_girth,omitempty"`
+}
+
+func ValidateShippingClasses(classes []ShippingClass) error {
+	for i, c := range classes {
+		if c.Name == "" {
+			return fmt.Errorf("shipping_classes[%d]: name is required", i)
+		}
+		if c.MaxWeight < 0 || c.MaxLength < 0 || c.MaxLengthPlusGirth < 0 {
+			return fmt.Errorf("shipping class %q: limits must not be negative", c.Name)
+		}
+	}
+	return nil
+}
+
+func (c ShippingClass) fits(box InputBox, weight float64) bool {
+	dims := sortedDims(box.W, box.H, box.D)
+	length := dims[2]
+	girth := 2 * (dims[0] + dims[1])
+	return (c.MaxWeight == 0 || weight <= c.MaxWeight) &&
+		(c.MaxLength == 0 || length <= c.MaxLength) &&
+		(c.MaxLengthPlusGirth == 0 || length+girth <= c.MaxLengthPlusGirth)
+}
+
+// AssignShippingClasses sets the shipping class of every packed box to the
+// first class in the table it fits, so tables are listed cheapest first.
+// Boxes that fit no class are left without one and reported in warnings.
+func AssignShippingClasses(packed []PackedBox, boxes []InputBox, classes []ShippingClass) []Warning {
+	if len(classes) == 0 {
+		return nil
+	}
+	byID := BoxesByID(boxes)
+	var warnings []Warning
+	for i := range packed {
+		pb := &packed[i]
+		j := slices.IndexFunc(classes, func(c ShippingClass) bool {
+			return c.fits(byID[pb.BoxID], pb.TotalWeight)
+		})
+		if j == -1 {
+			warnings = append(warnings, Warning{
+				Code:    WarnNoShippingClass,
+				Message: fmt.Sprintf("box %d fits none of the shipping classes", i+1),
+				BoxID:   pb.BoxID,
+			})
+			continue
+		}
+		pb.ShippingClass = classes[j].Name
+	}
+	return warnings
+}
@@ -0,0 +1,30 @@
+package packing
+
+import "testing"
+
+func TestAssignShippingClasses(t *testing.T) {
+	boxes := []InputBox{{ID: "small", W: 20, H: 10, D: 15}, {ID: "long", W: 120, H: 10, D: 10}}
+	classes := []ShippingClass{
+		{Name: "letter", MaxWeight: 0.5, MaxLength: 30},
+		{Name: "parcel", MaxWeight: 20, MaxLengthPlusGirth: 100},
+	}
+	packed := []PackedBox{
+		{BoxID: "small", TotalWeight: 0.2},
+		{BoxID: "small", TotalWeight: 3},
+		{BoxID: "long", TotalWeight: 1},
+	}
+
+	warnings := AssignShippingClasses(packed, boxes, classes)
+
+	for i, want := range []string{"letter", "parcel", ""} {
+		if packed[i].ShippingClass != want {
+			t.Errorf("Box %d: expected class %q, got %q", i+1, want, packed[i].ShippingClass)
+		}
+	}
+	if len(warnings) != 1 || warnings[0].Code != WarnNoShippingClass || warnings[0].BoxID != "long" {
+		t.Errorf("Expected one no_shipping_class warning for the long box, got %+v", warnings)
+	}
+	if err := ValidateShippingClasses([]ShippingClass{{MaxWeight: 1}}); err == nil {
+		t.Error("Expected a class without a name to be rejected")
+	}
+}
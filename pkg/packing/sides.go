@@ -0,0 +1,73 @@
+package packing
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// Sides are decoded from any JSON number. Whole numbers are packed as
+// given; an item or box with a decimal side keeps its sides as decoded in
+// Size, and is only packed once a caller has scaled it onto a whole-number
+// grid.
+
+// maxExactSide is the largest side a float64 holds exactly.
+const maxExactSide = 1 << 52
+
+// decodeSides parses the w, h and d of an item or box as ints. If any side
+// has decimals, all three are also returned as floats, for callers that
+// convert them to a whole-number grid.
+func decodeSides(w, h, d json.Number) (int, int, int, *[3]float64, error) {
+	var ints [3]int
+	var floats [3]float64
+	whole := true
+	for i, n := range []json.Number{w, h, d} {
+		if n == "" {
+			continue
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return 0, 0, 0, nil, err
+		}
+		floats[i] = f
+		if f != math.Trunc(f) || math.Abs(f) > maxExactSide {
+			whole = false
+		}
+		ints[i] = int(f)
+	}
+	if !whole {
+		return ints[0], ints[1], ints[2], &floats, nil
+	}
+	return ints[0], ints[1], ints[2], nil, nil
+}
+
+func (it *InputItem) UnmarshalJSON(b []byte) error {
+	type plain InputItem
+	aux := struct {
+		*plain
+		W json.Number `json:"w"`
+		H json.Number `json:"h"`
+		D json.Number `json:"d"`
+	}{plain: (*plain)(it)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	var err error
+	it.W, it.H, it.D, it.Size, err = decodeSides(aux.W, aux.H, aux.D)
+	return err
+}
+
+func (b *InputBox) UnmarshalJSON(data []byte) error {
+	type plain InputBox
+	aux := struct {
+		*plain
+		W json.Number `json:"w"`
+		H json.Number `json:"h"`
+		D json.Number `json:"d"`
+	}{plain: (*plain)(b)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	b.W, b.H, b.D, b.Size, err = decodeSides(aux.W, aux.H, aux.D)
+	return err
+}
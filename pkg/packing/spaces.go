@@ -0,0 +1,25 @@
+package packing
+
+import "binpacker/geometry"
+
+// Residual free space is tracked as a set of maximal empty cuboids: every
+// space extends as far as it can along each axis, so two regions that touch
+// are represented by one space covering both rather than two fragments.
+// Extreme points only see the box through axis-aligned rays, which hides
+// free volume that spans neighbouring gaps; the corners of the maximal
+// spaces are fed back into the extreme point list to expose it.
+
+// subtractPlacement removes the volume occupied by p from every space and
+// returns the maximal spaces that remain. Spaces narrower than minSide on any
+// axis cannot hold a remaining item and are dropped.
+func subtractPlacement(spaces []FreeSpace, p Placement, minSide int) []FreeSpace {
+	var result []FreeSpace
+	for _, s := range spaces {
+		for _, piece := range geometry.Subtract(s, p.cuboid()) {
+			if piece == s || min(piece.W, piece.H, piece.D) >= max(minSide, 1) {
+				result = append(result, piece)
+			}
+		}
+	}
+	return geometry.RemoveContained(result)
+}
@@ -0,0 +1,98 @@
+package packing
+
+import (
+	"iter"
+	"slices"
+
+	"binpacker/geometry"
+)
+
+// Every candidate position is checked against what is already in the box,
+// so with a plain scan a box of n items costs about n² overlap tests per
+// item. Once a box holds gridMinPlacements items its placements are also
+// kept in a geometry.Grid, and queries only look at the placements in the
+// cells they touch.
+
+// gridMinPlacements is the number of placements from which a box is
+// indexed; below it a scan is faster. It is a variable so benchmarks can
+// compare the two.
+var gridMinPlacements = 32
+
+// occupied is what a candidate placement must stay clear of: the
+// placements in a box, with a grid over them or nil, and keep-out regions,
+// which count as occupied but support nothing.
+type occupied struct {
+	placements []Placement
+	keepOut    []Placement
+	grid       *geometry.Grid
+}
+
+// occupied returns the placements and keep-out regions of s, bringing the
+// grid up to date first.
+func (s *boxState) occupied() occupied {
+	if s.grid == nil && len(s.placements) >= gridMinPlacements {
+		s.grid = newPlacementGrid(s.box, s.placements)
+		s.gridded = 0
+	}
+	if s.grid != nil {
+		// Placements are only ever appended, except that placeColumn
+		// swaps the last one for the units of the column, whose cells it
+		// covered.
+		for ; s.gridded < len(s.placements); s.gridded++ {
+			s.grid.Insert(s.gridded, s.placements[s.gridded].cuboid())
+		}
+	}
+	return occupied{placements: s.placements, keepOut: s.keepOut, grid: s.grid}
+}
+
+// newPlacementGrid returns an empty grid over box with cells the size of
+// the average placement, which keeps a few placements in each cell.
+func newPlacementGrid(box InputBox, placements []Placement) *geometry.Grid {
+	var sum [3]int
+	for _, p := range placements {
+		sum[0], sum[1], sum[2] = sum[0]+p.W, sum[1]+p.H, sum[2]+p.D
+	}
+	n := max(1, len(placements))
+	return geometry.NewGrid(geometry.Cuboid{W: box.W, H: box.H, D: box.D}, [3]int{sum[0] / n, sum[1] / n, sum[2] / n})
+}
+
+// withKeepOut returns o with extra regions kept out.
+func (o occupied) withKeepOut(extra ...Placement) occupied {
+	o.keepOut = append(slices.Clip(o.keepOut), extra...)
+	return o
+}
+
+// near yields the placements that may overlap c, and possibly others, with
+// their indexes.
+func (o occupied) near(c geometry.Cuboid) iter.Seq2[int, Placement] {
+	if o.grid == nil {
+		return slices.All(o.placements)
+	}
+	return func(yield func(int, Placement) bool) {
+		for i := range o.grid.Near(c) {
+			if i < len(o.placements) && !yield(i, o.placements[i]) {
+				return
+			}
+		}
+	}
+}
+
+// overlaps reports whether the region at (x, y, z) of w × h × d overlaps a
+// placement or keep-out region.
+func (o occupied) overlaps(x, y, z, w, h, d int) bool {
+	if hasOverlap(o.keepOut, x, y, z, w, h, d) {
+		return true
+	}
+	for _, p := range o.near(geometry.Cuboid{X: x, Y: y, Z: z, W: w, H: h, D: d}) {
+		if boxesOverlap(p, x, y, z, w, h, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports whether the point lies inside a placement or keep-out
+// region.
+func (o occupied) contains(ep FreeSpace) bool {
+	return o.overlaps(ep.X, ep.Y, ep.Z, 1, 1, 1)
+}
@@ -0,0 +1,48 @@
+package packing
+
+import "context"
+
+// Spillover policies decide which box is opened once the current one has
+// reached its fill target and items remain.
+const (
+	SpilloverSameSize   = "same_size"
+	SpilloverNextSizeUp = "next_size_up"
+)
+
+// fillCap returns the packed volume box may hold under its fill target.
+// MaxFillPercent on the box wins over the request-wide TargetFillPercent;
+// without either the whole box volume is available.
+func fillCap(box InputBox, opts Options) int {
+	percent := box.MaxFillPercent
+	if percent <= 0 {
+		percent = opts.TargetFillPercent
+	}
+	if percent <= 0 || percent >= 100 {
+		return box.Volume()
+	}
+	return int(float64(box.Volume()) * percent / 100)
+}
+
+// findNextBox picks the box to open after boxes[lastIdx] (or the first box
+// when lastIdx is -1). With a spillover policy the choice is restricted to
+// the same or next larger type; boxes must be sorted by volume. When the
+// restricted type cannot take any item, every type is considered again.
+func findNextBox(ctx context.Context, items []itemToPack, boxes []InputBox, lastIdx int, opts Options) (int, []Placement, []bool, *BoxDecision) {
+	if lastIdx >= 0 {
+		lo := -1
+		switch opts.Spillover {
+		case SpilloverSameSize:
+			lo = lastIdx
+		case SpilloverNextSizeUp:
+			lo = min(lastIdx+1, len(boxes)-1)
+		}
+		if lo >= 0 {
+			idx, placements, packed, decision := findBestBox(ctx, items, boxes[lo:lo+1], opts)
+			if idx != -1 {
+				decision.Spillover = opts.Spillover
+				return lo + idx, placements, packed, decision
+			}
+		}
+	}
+	return findBestBox(ctx, items, boxes, opts)
+}
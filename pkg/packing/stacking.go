@@ -0,0 +1,119 @@
+package packing
+
+import "iter"
+
+// Support and load-bearing limits. Weight is assumed to press straight
+// down, so an item carries everything placed above it whose footprint
+// overlaps its own, not just what touches it. That overestimates the load on
+// items that only partly support what is above them, which errs on the safe
+// side.
+
+func (it InputItem) stackable() bool {
+	return it.Stackable == nil || *it.Stackable
+}
+
+// stackingAllows reports whether item can go at (x, y, z) with footprint
+// w × d without being placed on an unstackable item or pushing any item
+// below it past its MaxStackWeight. under yields at least the placements
+// below the footprint, with their indexes into placements and placed.
+func stackingAllows(item itemToPack, under iter.Seq2[int, Placement], placements []Placement, placed []itemToPack, x, y, z, w, d int) bool {
+	for i, below := range under {
+		if below.Y+below.H > y || !footprintsOverlap(below, x, z, w, d) {
+			continue
+		}
+		spec := placed[i]
+		if !spec.stackable() {
+			return false
+		}
+		if spec.MaxStackWeight <= 0 {
+			continue
+		}
+		load := item.Weight
+		for j, above := range placements {
+			if j != i && above.Y >= below.Y+below.H && footprintsOverlap(below, above.X, above.Z, above.W, above.D) {
+				load += above.Weight
+			}
+		}
+		if load > spec.MaxStackWeight {
+			return false
+		}
+	}
+	return true
+}
+
+// stackRunAllows reports whether item can go at (x, y, z) as w × h × d
+// without making a run of more than its MaxStackUnits units: units of the
+// item each resting on the one below, counted through whichever units touch
+// above and below.
+func stackRunAllows(item itemToPack, placements []Placement, x, y, z, w, h, d int) bool {
+	if item.MaxStackUnits <= 0 {
+		return true
+	}
+	r := stackRuns{id: item.ID, placements: placements, memo: make(map[[2]int]int)}
+	below := r.longest(x, z, w, d, y, -1)
+	above := r.longest(x, z, w, d, y+h, 1)
+	return below+1+above <= item.MaxStackUnits
+}
+
+// stackRuns measures runs of units of one item among placements.
+type stackRuns struct {
+	id         string
+	placements []Placement
+	memo       map[[2]int]int
+}
+
+// longest returns the longest run going down (dir -1) or up (dir 1) from
+// the units whose top or bottom is at height face and whose footprint
+// overlaps x, z, w × d.
+func (r *stackRuns) longest(x, z, w, d, face, dir int) int {
+	n := 0
+	for i, p := range r.placements {
+		if p.ItemID != r.id || !footprintsOverlap(p, x, z, w, d) {
+			continue
+		}
+		if dir < 0 && p.Y+p.H == face || dir > 0 && p.Y == face {
+			n = max(n, r.run(i, dir))
+		}
+	}
+	return n
+}
+
+// run returns the length of the run from placement i in direction dir,
+// counting i.
+func (r *stackRuns) run(i, dir int) int {
+	key := [2]int{i, dir}
+	if n, ok := r.memo[key]; ok {
+		return n
+	}
+	p := r.placements[i]
+	face := p.Y + p.H
+	if dir < 0 {
+		face = p.Y
+	}
+	n := 1 + r.longest(p.X, p.Z, p.W, p.D, face, dir)
+	r.memo[key] = n
+	return n
+}
+
+func footprintsOverlap(p Placement, x, z, w, d int) bool {
+	return p.X < x+w && p.X+p.W > x &&
+		p.Z < z+d && p.Z+p.D > z
+}
+
+// supported reports whether at least minPercent of the w × d base at height
+// y rests on the tops of placements. Placements never overlap, so their
+// contact areas can simply be added up.
+func supported(placements iter.Seq2[int, Placement], x, y, z, w, d int, minPercent float64) bool {
+	area := 0
+	for _, p := range placements {
+		if p.Y+p.H != y {
+			continue
+		}
+		ow := min(p.X+p.W, x+w) - max(p.X, x)
+		od := min(p.Z+p.D, z+d) - max(p.Z, z)
+		if ow > 0 && od > 0 {
+			area += ow * od
+		}
+	}
+	return float64(area)*100 >= minPercent*float64(w*d)
+}
@@ -0,0 +1,90 @@
+package packing
+
+import "fmt"
+
+// boxStock tracks how many boxes of each type are left, parallel to a box
+// list. Types without a Quantity are unlimited (-1).
+type boxStock []int
+
+func newBoxStock(boxes []InputBox) boxStock {
+	s := make(boxStock, len(boxes))
+	for i, b := range boxes {
+		s[i] = -1
+		if b.Quantity > 0 {
+			s[i] = b.Quantity
+		}
+	}
+	return s
+}
+
+// available returns the box types still in stock and their indexes in the
+// full list.
+func (s boxStock) available(boxes []InputBox) ([]InputBox, []int) {
+	avail := make([]InputBox, 0, len(boxes))
+	idx := make([]int, 0, len(boxes))
+	for i, b := range boxes {
+		if s[i] != 0 {
+			avail = append(avail, b)
+			idx = append(idx, i)
+		}
+	}
+	return avail, idx
+}
+
+func (s boxStock) take(i int) {
+	if s[i] > 0 {
+		s[i]--
+	}
+}
+
+// StockWarnings flags box types whose whole stock was used when items were
+// left unpacked, since more stock is the likely fix.
+func StockWarnings(packed []PackedBox, unpacked []InputItem, boxes []InputBox) []Warning {
+	if len(unpacked) == 0 {
+		return nil
+	}
+	used := make(map[string]int)
+	for _, pb := range packed {
+		used[pb.BoxID]++
+	}
+	var warnings []Warning
+	for _, b := range boxes {
+		if b.Quantity > 0 && used[b.ID] >= b.Quantity {
+			warnings = append(warnings, Warning{
+				Code:    WarnBoxStockExhausted,
+				Message: fmt.Sprintf("all %d boxes of type %q are used", b.Quantity, b.ID),
+				BoxID:   b.ID,
+			})
+		}
+	}
+	return warnings
+}
+
+// MaxBoxesWarnings names the items left unpacked because the result reached
+// opts.MaxBoxes boxes. Items too large for every box are left out: another
+// box would not have helped them.
+func MaxBoxesWarnings(packed []PackedBox, unpacked []InputItem, boxes []InputBox, opts Options) []Warning {
+	if opts.MaxBoxes == 0 || len(packed) < opts.MaxBoxes {
+		return nil
+	}
+	units := make(map[string]int)
+	var ids []string
+	for _, it := range unpacked {
+		if !fitsAnyBox(it, boxes) {
+			continue
+		}
+		if units[it.ID] == 0 {
+			ids = append(ids, it.ID)
+		}
+		units[it.ID]++
+	}
+	var warnings []Warning
+	for _, id := range ids {
+		warnings = append(warnings, Warning{
+			Code:    WarnMaxBoxesReached,
+			Message: fmt.Sprintf("%d units of item %q are unpacked: the max_boxes limit of %d was reached", units[id], id, opts.MaxBoxes),
+			ItemID:  id,
+		})
+	}
+	return warnings
+}
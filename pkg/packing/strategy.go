@@ -0,0 +1,148 @@
+package packing
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Strategies for filling a single box, selected by Options.Strategy. The
+// balance objective and /pack/live add items one at a time and always use
+// extreme points.
+const (
+	// StrategyExtremePoints places every item at its best extreme point.
+	// It is the default.
+	StrategyExtremePoints = "extreme_points"
+	// StrategyWallBuilding loads the box in walls: vertical slices across
+	// the full width and height, filled from the back wall forward. Each
+	// wall is as deep as the most common item side, which suits loads of
+	// a few large item types such as furniture and appliances.
+	StrategyWallBuilding = "wall_building"
+	// StrategyColumnStacking stacks the dominant carton types into
+	// full-height towers first and fills the rest at extreme points,
+	// which suits large loads of a few uniform cartons.
+	StrategyColumnStacking = "column_stacking"
+)
+
+// strategy fills a single box. fill returns the placements, which of items
+// it placed and their total volume; check, if set, rejects options the
+// strategy cannot honour. Every strategy registered in strategies must pass
+// the conformance suite in strategy_conformance_test.go.
+type strategy struct {
+	fill  func(ctx context.Context, items []itemToPack, box InputBox, opts Options) ([]Placement, []bool, int)
+	check func(o Options) error
+}
+
+var strategies = map[string]strategy{
+	StrategyExtremePoints: {fill: packExtremePoints},
+	StrategyWallBuilding: {fill: packWalls, check: func(o Options) error {
+		if o.PlacementPolicy != "" && o.PlacementPolicy != PlacementBackLeft {
+			return errors.New("walls are built from the back wall; use placement_policy \"back_left\"")
+		}
+		return nil
+	}},
+	StrategyColumnStacking: {fill: packColumns, check: func(o Options) error {
+		if len(o.Constraints) > 0 {
+			// Constraints would see a whole tower as one item.
+			return fmt.Errorf("strategy %q cannot be combined with constraints", o.Strategy)
+		}
+		return nil
+	}},
+}
+
+// strategyFor returns the strategy selected by opts, extreme points by
+// default.
+func strategyFor(opts Options) strategy {
+	if st, ok := strategies[opts.Strategy]; ok {
+		return st
+	}
+	return strategies[StrategyExtremePoints]
+}
+
+func (o Options) validateStrategy() error {
+	if o.Strategy == "" || o.Strategy == StrategyExtremePoints {
+		return nil
+	}
+	st, ok := strategies[o.Strategy]
+	if !ok {
+		return fmt.Errorf("unknown strategy %q", o.Strategy)
+	}
+	if o.Objective == ObjectiveBalance {
+		return fmt.Errorf("strategy %q cannot be combined with objective \"balance\"", o.Strategy)
+	}
+	if st.check != nil {
+		return st.check(o)
+	}
+	return nil
+}
+
+// packWalls fills box wall by wall. Items that fit no wall get a last try
+// anywhere in the space left, including gaps in earlier walls.
+func packWalls(ctx context.Context, items []itemToPack, box InputBox, opts Options) ([]Placement, []bool, int) {
+	state := newBoxState(ctx, box, opts)
+	packed := make([]bool, len(items))
+	packedVol := 0
+	// Unlike a single pass, any unpacked item may still be tried later, so
+	// only spaces too small for all of them are useless.
+	minSide := suffixMinSides(items)[0]
+
+	fill := func() int {
+		n := 0
+		for i, item := range items {
+			if !packed[i] && state.place(item, minSide) {
+				packed[i] = true
+				packedVol += item.volume
+				n++
+			}
+		}
+		return n
+	}
+
+	for z := 0; z < box.D; {
+		depth := wallDepth(items, packed, box, box.D-z)
+		if depth == 0 {
+			break
+		}
+		state.wallEnd = z + depth
+		if fill() == 0 {
+			break
+		}
+		z += depth
+	}
+	state.wallEnd = 0
+	fill()
+	return state.placements, packed, packedVol
+}
+
+// wallDepth picks the depth of the next wall from the sides of the unpacked
+// items that fit in a wall up to room deep: the side that the most item
+// volume can stand on, preferring the deeper of equals.
+func wallDepth(items []itemToPack, packed []bool, box InputBox, room int) int {
+	score := make(map[int]int)
+	for i, item := range items {
+		if packed[i] {
+			continue
+		}
+		sides := make(map[int]bool, 3)
+		for _, r := range Rotations(item.InputItem) {
+			if r[0] <= box.W && r[1] <= box.H && r[2] <= room {
+				sides[r[2]] = true
+			}
+		}
+		for d := range sides {
+			score[d] += item.volume
+		}
+	}
+	if len(score) == 0 {
+		return 0
+	}
+	return slices.MaxFunc(slices.Collect(maps.Keys(score)), func(a, b int) int {
+		if c := cmp.Compare(score[a], score[b]); c != 0 {
+			return c
+		}
+		return cmp.Compare(a, b)
+	})
+}
@@ -0,0 +1,231 @@
+package packing
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+// conformanceScenario is one box filled by every registered strategy. want
+// is the number of units that must be packed, or -1 when only the
+// invariants are checked.
+type conformanceScenario struct {
+	name   string
+	box    InputBox
+	items  []InputItem
+	opts   Options
+	cancel bool
+	want   int
+}
+
+func conformanceScenarios() []conformanceScenario {
+	unstackable := false
+	return []conformanceScenario{
+		{
+			name:  "exact fit",
+			box:   InputBox{ID: "box", W: 10, H: 10, D: 10},
+			items: []InputItem{{ID: "cube", W: 5, H: 5, D: 5, Quantity: 8}},
+			want:  8,
+		},
+		{
+			name:  "oversized item",
+			box:   InputBox{ID: "box", W: 10, H: 10, D: 10},
+			items: []InputItem{{ID: "pole", W: 1, H: 1, D: 11, Quantity: 1}, {ID: "cube", W: 5, H: 5, D: 5, Quantity: 1}},
+			want:  1,
+		},
+		{
+			name:  "rotation needed",
+			box:   InputBox{ID: "box", W: 10, H: 4, D: 4},
+			items: []InputItem{{ID: "plank", W: 2, H: 4, D: 10, Quantity: 2}},
+			want:  2,
+		},
+		{
+			name: "mixed load",
+			box:  InputBox{ID: "container", W: 60, H: 40, D: 90},
+			items: []InputItem{
+				{ID: "fridge", W: 20, H: 35, D: 20, Quantity: 4, Weight: 60, KeepUpright: true},
+				{ID: "carton", W: 10, H: 10, D: 10, Quantity: 40, Weight: 2, MaxStackWeight: 8, MaxStackUnits: 3},
+				{ID: "tv", W: 25, H: 15, D: 4, Quantity: 6, Weight: 8, Stackable: &unstackable},
+				{ID: "rug", W: 30, H: 3, D: 3, Quantity: 5, Weight: 4, AllowedRotations: []string{"whd", "dhw"}},
+			},
+			want: -1,
+		},
+		{
+			name:  "weight limit",
+			box:   InputBox{ID: "box", W: 10, H: 10, D: 10, MaxWeight: 10},
+			items: []InputItem{{ID: "brick", W: 2, H: 2, D: 2, Quantity: 5, Weight: 3}},
+			want:  3,
+		},
+		{
+			name: "incompatible items",
+			box:  InputBox{ID: "box", W: 10, H: 10, D: 10},
+			items: []InputItem{
+				{ID: "bleach", W: 5, H: 5, D: 5, Quantity: 2, IncompatibleWith: []string{"bread"}},
+				{ID: "bread", W: 5, H: 5, D: 5, Quantity: 2},
+			},
+			want: 2,
+		},
+		{
+			name:  "fill target",
+			box:   InputBox{ID: "box", W: 10, H: 10, D: 10},
+			items: []InputItem{{ID: "cube", W: 5, H: 5, D: 5, Quantity: 8}},
+			opts:  Options{TargetFillPercent: 50},
+			want:  4,
+		},
+		{
+			name:  "aisle",
+			box:   InputBox{ID: "truck", W: 30, H: 10, D: 20},
+			items: []InputItem{{ID: "crate", W: 10, H: 10, D: 10, Quantity: 6}},
+			opts:  Options{Aisle: &Aisle{Axis: AisleAlongDepth, Width: 10, Height: 10}},
+			want:  4,
+		},
+		{
+			name:   "cancelled",
+			box:    InputBox{ID: "box", W: 10, H: 10, D: 10},
+			items:  []InputItem{{ID: "cube", W: 5, H: 5, D: 5, Quantity: 8}},
+			cancel: true,
+			want:   0,
+		},
+	}
+}
+
+func TestStrategyConformance(t *testing.T) {
+	for name, st := range strategies {
+		for _, sc := range conformanceScenarios() {
+			t.Run(name+"/"+sc.name, func(t *testing.T) {
+				opts := sc.opts
+				opts.Strategy = name
+				if err := opts.Validate(); err != nil {
+					t.Skipf("strategy does not support the scenario: %v", err)
+				}
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				if sc.cancel {
+					cancel()
+				}
+
+				items := expandItems(sc.items)
+				sortItemsByVolume(items)
+				placements, packed, vol := st.fill(ctx, items, sc.box, opts.withCompiledConstraints())
+
+				checkConformance(t, sc.box, items, placements, packed, vol, opts)
+				if n := len(placements); sc.want >= 0 && n != sc.want {
+					t.Errorf("Expected %d units packed, got %d", sc.want, n)
+				}
+			})
+		}
+	}
+}
+
+// checkConformance checks the guarantees every strategy gives for one box.
+func checkConformance(t *testing.T, box InputBox, items []itemToPack, placements []Placement, packed []bool, vol int, opts Options) {
+	t.Helper()
+
+	if len(packed) != len(items) {
+		t.Fatalf("Expected a packed flag per item, got %d for %d items", len(packed), len(items))
+	}
+	var packedIDs []string
+	wantVol := 0
+	specs := make(map[string]itemToPack)
+	for i, it := range items {
+		specs[it.ID] = it
+		if packed[i] {
+			packedIDs = append(packedIDs, it.ID)
+			wantVol += it.volume
+		}
+	}
+	var placedIDs []string
+	for _, p := range placements {
+		placedIDs = append(placedIDs, p.ItemID)
+	}
+	slices.Sort(packedIDs)
+	slices.Sort(placedIDs)
+	if !slices.Equal(packedIDs, placedIDs) {
+		t.Errorf("Expected placements for the packed items %v, got %v", packedIDs, placedIDs)
+	}
+	if vol != wantVol {
+		t.Errorf("Expected a packed volume of %d, got %d", wantVol, vol)
+	}
+	if vol > fillCap(box, opts) {
+		t.Errorf("Expected at most %d packed volume, got %d", fillCap(box, opts), vol)
+	}
+
+	if err := CheckLayout([]PackedBox{{BoxID: box.ID, Contents: placements}}, []InputBox{box}); err != nil {
+		t.Error(err)
+	}
+	var aisle []Placement
+	if opts.Aisle != nil {
+		if region, ok := opts.Aisle.region(box); ok {
+			aisle = append(aisle, region)
+		}
+	}
+
+	var weight float64
+	for i, p := range placements {
+		spec := specs[p.ItemID]
+		weight += p.Weight
+		if !slices.Contains(Rotations(spec.InputItem), [3]int{p.W, p.H, p.D}) {
+			t.Errorf("%s: %dx%dx%d is not an allowed orientation", p.ItemID, p.W, p.H, p.D)
+		}
+		if hasOverlap(aisle, p.X, p.Y, p.Z, p.W, p.H, p.D) {
+			t.Errorf("%s: placed in the aisle at %+v", p.ItemID, p)
+		}
+		others := slices.Delete(slices.Clone(placements), i, i+1)
+		if p.Y > 0 && !supported(slices.All(others), p.X, p.Y, p.Z, p.W, p.D, opts.minSupport()) {
+			t.Errorf("%s: not supported at %+v", p.ItemID, p)
+		}
+
+		var load float64
+		for _, o := range others {
+			if o.Y >= p.Y+p.H && footprintsOverlap(p, o.X, o.Z, o.W, o.D) {
+				load += o.Weight
+			}
+		}
+		if load > 0 && !spec.stackable() {
+			t.Errorf("%s: is not stackable but carries %v", p.ItemID, load)
+		}
+		if spec.MaxStackWeight > 0 && load > spec.MaxStackWeight {
+			t.Errorf("%s: carries %v, more than its max_stack_weight %v", p.ItemID, load, spec.MaxStackWeight)
+		}
+		if !stackRunAllows(spec, others, p.X, p.Y, p.Z, p.W, p.H, p.D) {
+			t.Errorf("%s: stacked higher than its max_stack_units %d", p.ItemID, spec.MaxStackUnits)
+		}
+	}
+	if box.MaxWeight > 0 && weight > box.MaxWeight {
+		t.Errorf("Expected at most %v weight, got %v", box.MaxWeight, weight)
+	}
+	for i, a := range placements {
+		for _, b := range placements[i+1:] {
+			if !compatible(specs[a.ItemID], []itemToPack{specs[b.ItemID]}) {
+				t.Errorf("Expected %s and %s in separate boxes", a.ItemID, b.ItemID)
+			}
+		}
+	}
+}
+
+// TestStrategiesThroughPack runs every strategy end to end over several
+// boxes, where the unpacked items must make up the rest of the input.
+func TestStrategiesThroughPack(t *testing.T) {
+	boxes := []InputBox{{ID: "small", W: 20, H: 20, D: 20}, {ID: "large", W: 40, H: 30, D: 40, Quantity: 2}}
+	items := []InputItem{
+		{ID: "carton", W: 10, H: 10, D: 10, Quantity: 70},
+		{ID: "long", W: 35, H: 5, D: 5, Quantity: 4},
+		{ID: "giant", W: 50, H: 50, D: 50, Quantity: 1},
+	}
+	for name := range strategies {
+		packed, unpacked := PackWithOptions(items, boxes, Options{Strategy: name})
+		if err := CheckLayout(packed, boxes); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		units := len(unpacked)
+		for _, pb := range packed {
+			units += len(pb.Contents)
+		}
+		if units != 75 {
+			t.Errorf("%s: expected all 75 units accounted for, got %d", name, units)
+		}
+		if !slices.ContainsFunc(unpacked, func(it InputItem) bool { return it.ID == "giant" }) {
+			t.Errorf("%s: expected the giant item unpacked, got %v", name, unpacked)
+		}
+	}
+}
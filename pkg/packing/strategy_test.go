@@ -0,0 +1,94 @@
+package packing
+
+import "testing"
+
+func TestWallDepth(t *testing.T) {
+	items := expandItems([]InputItem{
+		{ID: "sofa", W: 20, H: 10, D: 8, Quantity: 1},
+		{ID: "chair", W: 5, H: 5, D: 5, Quantity: 4},
+	})
+	box := InputBox{ID: "truck", W: 30, H: 30, D: 30}
+	packed := make([]bool, len(items))
+
+	if got := wallDepth(items, packed, box, 30); got != 20 {
+		t.Errorf("Expected the deepest side the sofa can stand on, got %d", got)
+	}
+	if got := wallDepth(items, packed, box, 9); got != 8 {
+		t.Errorf("Expected the sofa on its shortest side with 9 left, got %d", got)
+	}
+	packed[0] = true
+	if got := wallDepth(items, packed, box, 30); got != 5 {
+		t.Errorf("Expected the chair depth once the sofa is packed, got %d", got)
+	}
+}
+
+func TestWallBuilding(t *testing.T) {
+	boxes := []InputBox{{ID: "truck", W: 20, H: 10, D: 30}}
+	items := []InputItem{
+		{ID: "fridge", W: 10, H: 10, D: 10, Quantity: 4},
+		{ID: "tv", W: 10, H: 5, D: 2, Quantity: 6},
+	}
+	packed, unpacked := PackWithOptions(items, boxes, Options{Strategy: StrategyWallBuilding})
+	if len(packed) != 1 || len(unpacked) != 0 {
+		t.Fatalf("Expected everything in one box, got %d boxes and %d unpacked", len(packed), len(unpacked))
+	}
+	if err := CheckLayout(packed, boxes); err != nil {
+		t.Fatal(err)
+	}
+	// The fridges make up the first two walls; the TVs go in the third.
+	for _, p := range packed[0].Contents {
+		if (p.ItemID == "fridge") != (p.Z < 20) {
+			t.Errorf("Expected fridges in the first two walls and TVs behind them, got %+v", p)
+		}
+	}
+
+	for _, opts := range []Options{
+		{Strategy: "shelf"},
+		{Strategy: StrategyWallBuilding, Objective: ObjectiveBalance, Containers: 2},
+		{Strategy: StrategyWallBuilding, PlacementPolicy: PlacementFrontLeft},
+	} {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("Expected %+v to be rejected", opts)
+		}
+	}
+}
+
+func TestColumnStacking(t *testing.T) {
+	boxes := []InputBox{{ID: "pallet", W: 40, H: 30, D: 40}}
+	items := []InputItem{
+		{ID: "carton", W: 10, H: 10, D: 10, Quantity: 20, Weight: 1, MaxStackWeight: 5},
+		{ID: "tube", W: 30, H: 5, D: 5, Quantity: 2},
+	}
+	packed, unpacked := PackWithOptions(items, boxes, Options{Strategy: StrategyColumnStacking})
+	if len(packed) != 1 || len(unpacked) != 0 {
+		t.Fatalf("Expected everything in one box, got %d boxes and %d unpacked", len(packed), len(unpacked))
+	}
+	if err := CheckLayout(packed, boxes); err != nil {
+		t.Fatal(err)
+	}
+
+	// 20 cartons make six towers of three and two left over for the
+	// extreme points; every tower carton sits squarely on another.
+	bases := make(map[[2]int]int)
+	for _, p := range packed[0].Contents {
+		if p.ItemID == "carton" {
+			bases[[2]int{p.X, p.Z}]++
+		}
+	}
+	towers := 0
+	for _, n := range bases {
+		if n == 3 {
+			towers++
+		}
+	}
+	if towers != 6 {
+		t.Errorf("Expected 6 towers of 3 cartons, got %v", bases)
+	}
+
+	if got := columnGroups(expandItems(items[1:]), boxes[0]); len(got) != 0 {
+		t.Errorf("Expected no towers for 2 tubes, got %+v", got)
+	}
+	if err := (Options{Strategy: StrategyColumnStacking, Constraints: []string{"placement.y == 0"}}).Validate(); err == nil {
+		t.Error("Expected column_stacking with constraints to be rejected")
+	}
+}
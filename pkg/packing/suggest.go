@@ -0,0 +1,66 @@
+package packing
+
+import "slices"
+
+// BoxSuggestion tells the caller what box would take an item that is larger
+// than every box in the request: the smallest box made by growing a catalog
+// box just enough on each side.
+type BoxSuggestion struct {
+	ItemID string `json:"item_id"`
+	// BasedOn is the catalog box the suggestion enlarges.
+	BasedOn string `json:"based_on"`
+	W       int    `json:"w"`
+	H       int    `json:"h"`
+	D       int    `json:"d"`
+	// RotationHelps is set when the item would fit an existing box if its
+	// keep_upright or allowed_rotations constraint were lifted.
+	RotationHelps bool `json:"rotation_helps,omitempty"`
+	// SplitHelps is set for a multipack case that is not splittable but
+	// whose inner units would fit an existing box.
+	SplitHelps bool `json:"split_helps,omitempty"`
+}
+
+// fitsAnyBox reports whether item fits an empty box of the catalog in one
+// of its allowed orientations.
+func fitsAnyBox(item InputItem, boxes []InputBox) bool {
+	for _, box := range boxes {
+		for _, rot := range Rotations(item) {
+			if FitsInBox(box, 0, 0, 0, rot[0], rot[1], rot[2]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SuggestBoxes returns a suggestion for each distinct unpacked item that no
+// box can hold. Items left over for other reasons, such as weight limits or
+// running out of room, get none.
+func SuggestBoxes(unpacked []InputItem, boxes []InputBox) []BoxSuggestion {
+	var suggestions []BoxSuggestion
+	var seen []string
+	for _, item := range unpacked {
+		if slices.Contains(seen, item.ID) || fitsAnyBox(item, boxes) {
+			continue
+		}
+		seen = append(seen, item.ID)
+
+		best := BoxSuggestion{ItemID: item.ID}
+		bestVol := -1
+		for _, box := range boxes {
+			for _, rot := range Rotations(item) {
+				w, h, d := max(box.W, rot[0]), max(box.H, rot[1]), max(box.D, rot[2])
+				if vol := w * h * d; bestVol == -1 || vol < bestVol {
+					bestVol = vol
+					best.BasedOn, best.W, best.H, best.D = box.ID, w, h, d
+				}
+			}
+		}
+		free := item
+		free.KeepUpright, free.AllowedRotations = false, nil
+		best.RotationHelps = fitsAnyBox(free, boxes)
+		best.SplitHelps = item.Inner != nil && fitsAnyBox(item.units(), boxes)
+		suggestions = append(suggestions, best)
+	}
+	return suggestions
+}
@@ -0,0 +1,27 @@
+package packing
+
+import "testing"
+
+func TestSuggestBoxes(t *testing.T) {
+	boxes := []InputBox{{ID: "small", W: 10, H: 10, D: 10}, {ID: "tall", W: 20, H: 40, D: 20}}
+	items := []InputItem{
+		{ID: "rod", W: 5, H: 5, D: 50, Quantity: 2},
+		{ID: "bottle", W: 10, H: 30, D: 10, Quantity: 1, KeepUpright: true},
+	}
+	_, unpacked := Pack(items, boxes)
+
+	s := SuggestBoxes(unpacked, boxes)
+	if len(s) != 1 || s[0].ItemID != "rod" {
+		t.Fatalf("Expected one suggestion for the rod, got %+v", s)
+	}
+	if s[0].BasedOn != "small" || s[0].W != 10 || s[0].H != 10 || s[0].D != 50 {
+		t.Errorf("Expected the small box grown to 10x10x50, got %+v", s[0])
+	}
+
+	// Lying down, the bottle would fit the flat box
+	flat := []InputBox{{ID: "flat", W: 40, H: 10, D: 40}}
+	s = SuggestBoxes([]InputItem{items[1]}, flat)
+	if len(s) != 1 || !s[0].RotationHelps || s[0].H != 30 {
+		t.Errorf("Expected an upright suggestion noting rotation would help, got %+v", s)
+	}
+}
@@ -0,0 +1,164 @@
+package packing
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// Warning codes, reported with the result of a pack.
+const (
+	WarnVisualizationFailed = "visualization_failed"
+	WarnZeroClearance       = "zero_clearance"
+	WarnDuplicateBoxID      = "duplicate_box_id"
+	WarnItemNearlyFillsBox  = "item_nearly_fills_box"
+	WarnDegenerateItem      = "degenerate_item"
+	WarnPostPackHookFailed  = "post_pack_hook_failed"
+	WarnLayoutInconsistent  = "layout_inconsistent"
+	WarnNoShippingClass     = "no_shipping_class"
+	WarnBoxStockExhausted   = "box_stock_exhausted"
+	WarnOverhang            = "overhang"
+	WarnCubeOut             = "cube_out"
+	WarnMaxBoxesReached     = "max_boxes_reached"
+	WarnGroupSplit          = "group_split"
+	WarnItemsMerged         = "items_merged"
+)
+
+// largeItemRatio is the share of the largest box volume above which a single
+// item is flagged as suspicious.
+const largeItemRatio = 0.8
+
+// Warning is a non-fatal problem encountered while handling a request. The
+// response is still usable, but clients can tell a missing field apart from
+// one that was never requested.
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	ItemID  string `json:"item_id,omitempty"`
+	BoxID   string `json:"box_id,omitempty"`
+}
+
+// InputWarnings flags inputs that are valid but often point at data errors
+// upstream, such as a box catalog exported twice or dimensions in the wrong unit.
+func InputWarnings(items []InputItem, boxes []InputBox) []Warning {
+	var warnings []Warning
+
+	seen := make(map[string]bool, len(boxes))
+	largestVol := 0
+	for _, b := range boxes {
+		if seen[b.ID] {
+			warnings = append(warnings, Warning{
+				Code:    WarnDuplicateBoxID,
+				Message: fmt.Sprintf("box id %q appears more than once", b.ID),
+				BoxID:   b.ID,
+			})
+		}
+		seen[b.ID] = true
+		largestVol = max(largestVol, b.Volume())
+	}
+
+	for _, item := range items {
+		itemDims := sortedDims(item.W, item.H, item.D)
+		for _, b := range boxes {
+			if itemDims == sortedDims(b.W, b.H, b.D) {
+				warnings = append(warnings, Warning{
+					Code:    WarnZeroClearance,
+					Message: fmt.Sprintf("item %q has the same dimensions as box %q and leaves no clearance", item.ID, b.ID),
+					ItemID:  item.ID,
+					BoxID:   b.ID,
+				})
+			}
+		}
+
+		if largestVol > 0 && float64(item.W*item.H*item.D) > largeItemRatio*float64(largestVol) {
+			warnings = append(warnings, Warning{
+				Code:    WarnItemNearlyFillsBox,
+				Message: fmt.Sprintf("item %q uses more than %.0f%% of the largest box volume", item.ID, largeItemRatio*100),
+				ItemID:  item.ID,
+			})
+		}
+	}
+
+	return warnings
+}
+
+// OverhangWarnings flags stacked items whose edge sticks out more than
+// tolerance past the items they rest on, for example a carton hanging over
+// the tier below it on a pallet.
+func OverhangWarnings(packed []PackedBox, tolerance int) []Warning {
+	var warnings []Warning
+	for i, pb := range packed {
+		for _, p := range pb.Contents {
+			if p.Y == 0 {
+				continue
+			}
+			minX, minZ := math.MaxInt, math.MaxInt
+			maxX, maxZ := math.MinInt, math.MinInt
+			for _, below := range pb.Contents {
+				if below.Y+below.H != p.Y || !footprintsOverlap(below, p.X, p.Z, p.W, p.D) {
+					continue
+				}
+				minX, maxX = min(minX, below.X), max(maxX, below.X+below.W)
+				minZ, maxZ = min(minZ, below.Z), max(maxZ, below.Z+below.D)
+			}
+			if minX == math.MaxInt {
+				continue
+			}
+			over := max(minX-p.X, p.X+p.W-maxX, minZ-p.Z, p.Z+p.D-maxZ)
+			if over > tolerance {
+				warnings = append(warnings, Warning{
+					Code:    WarnOverhang,
+					Message: fmt.Sprintf("item %q in box %d overhangs the items below it by %d", p.ItemID, i+1, over),
+					ItemID:  p.ItemID,
+					BoxID:   pb.BoxID,
+				})
+			}
+		}
+	}
+	return warnings
+}
+
+// CubeOutWarnings flags boxes that ran out of space while their max_weight
+// would still have taken an item that went to a later box or was left
+// unpacked. The lightest such item is named.
+func CubeOutWarnings(packed []PackedBox, unpacked []InputItem, boxes []InputBox) []Warning {
+	byID := BoxesByID(boxes)
+	var warnings []Warning
+	for i, pb := range packed {
+		box := byID[pb.BoxID]
+		if box.MaxWeight <= 0 {
+			continue
+		}
+		lightest := Placement{Weight: -1}
+		for _, later := range packed[i+1:] {
+			for _, p := range later.Contents {
+				if lightest.Weight < 0 || p.Weight < lightest.Weight {
+					lightest = p
+				}
+			}
+		}
+		for _, it := range unpacked {
+			if lightest.Weight < 0 || it.Weight < lightest.Weight {
+				lightest = Placement{ItemID: it.ID, Weight: it.Weight}
+			}
+		}
+		if lightest.Weight < 0 || pb.TotalWeight+lightest.Weight > box.MaxWeight {
+			continue
+		}
+		warnings = append(warnings, Warning{
+			Code: WarnCubeOut,
+			Message: fmt.Sprintf("box %d is full by volume at %s of %s max weight; item %q would still fit by weight",
+				i+1, strconv.FormatFloat(round1(pb.TotalWeight), 'f', -1, 64), strconv.FormatFloat(box.MaxWeight, 'f', -1, 64), lightest.ItemID),
+			ItemID: lightest.ItemID,
+			BoxID:  pb.BoxID,
+		})
+	}
+	return warnings
+}
+
+func sortedDims(w, h, d int) [3]int {
+	dims := []int{w, h, d}
+	slices.Sort(dims)
+	return [3]int{dims[0], dims[1], dims[2]}
+}
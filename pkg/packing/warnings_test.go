@@ -0,0 +1,69 @@
+package packing
+
+import (
+	"testing"
+)
+
+func TestInputWarnings(t *testing.T) {
+	items := []InputItem{
+		{ID: "exact", W: 10, H: 20, D: 30, Quantity: 1},
+		{ID: "huge", W: 29, H: 29, D: 29, Quantity: 1},
+		{ID: "small", W: 5, H: 5, D: 5, Quantity: 1},
+	}
+	boxes := []InputBox{
+		{ID: "box-a", W: 30, H: 20, D: 10},
+		{ID: "box-b", W: 30, H: 30, D: 30},
+		{ID: "box-b", W: 30, H: 30, D: 30},
+	}
+
+	got := map[string]int{}
+	for _, w := range InputWarnings(items, boxes) {
+		got[w.Code]++
+		if w.ItemID == "small" {
+			t.Errorf("Unexpected warning for item small: %+v", w)
+		}
+	}
+
+	if got[WarnZeroClearance] != 1 {
+		t.Errorf("Expected 1 zero clearance warning, got %d", got[WarnZeroClearance])
+	}
+	if got[WarnDuplicateBoxID] != 1 {
+		t.Errorf("Expected 1 duplicate box warning, got %d", got[WarnDuplicateBoxID])
+	}
+	if got[WarnItemNearlyFillsBox] != 1 {
+		t.Errorf("Expected 1 large item warning, got %d", got[WarnItemNearlyFillsBox])
+	}
+}
+
+func TestOverhangWarnings(t *testing.T) {
+	packed := []PackedBox{{BoxID: "pallet", Contents: []Placement{
+		{ItemID: "base", X: 0, Y: 0, Z: 0, W: 10, H: 5, D: 10},
+		{ItemID: "flush", X: 0, Y: 5, Z: 0, W: 10, H: 5, D: 8},
+		{ItemID: "wide", X: 0, Y: 10, Z: 0, W: 10, H: 5, D: 12},
+	}}}
+
+	got := OverhangWarnings(packed, 2)
+	if len(got) != 1 || got[0].ItemID != "wide" || got[0].Code != WarnOverhang {
+		t.Errorf("Expected one overhang warning for item wide, got %+v", got)
+	}
+	if got := OverhangWarnings(packed, 4); len(got) != 0 {
+		t.Errorf("Expected no warnings within tolerance, got %+v", got)
+	}
+}
+
+func TestCubeOutWarnings(t *testing.T) {
+	boxes := []InputBox{{ID: "crate", W: 10, H: 10, D: 10, MaxWeight: 100}}
+	items := []InputItem{{ID: "foam", W: 10, H: 10, D: 10, Quantity: 2, Weight: 5}}
+
+	packed, unpacked := PackWithOptions(items, boxes, Options{})
+	got := CubeOutWarnings(packed, unpacked, boxes)
+	if len(got) != 1 || got[0].ItemID != "foam" || got[0].BoxID != "crate" {
+		t.Errorf("Expected the first crate to cube out with foam left over, got %+v", got)
+	}
+
+	boxes[0].MaxWeight = 8
+	packed, unpacked = PackWithOptions(items, boxes, Options{})
+	if got := CubeOutWarnings(packed, unpacked, boxes); len(got) != 0 {
+		t.Errorf("Expected no warning when weight is the limit, got %+v", got)
+	}
+}
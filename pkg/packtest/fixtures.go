@@ -0,0 +1,31 @@
+package packtest
+
+import (
+	_ "embed"
+	"encoding/json"
+)
+
+//go:embed fixtures/default.json
+var defaultFixtures []byte
+
+// Default IDs of the built-in fixtures.
+const (
+	PackID          = "pk_local_wevh3185yva6xgcqr0xanvq14pa2"
+	VisualizationID = "vz_local_nje86kwxgaj3tcrt9mrew16v21yq"
+	JobID           = "jb_local_h6vctt3zy2c738xm72txn7gq5b6t"
+)
+
+// Default returns the built-in fixtures, recorded from the service:
+//
+//   - POST /pack packs two mugs and a plate into one box
+//   - GET /packs/{PackID} returns the same result
+//   - GET /visualize/{VisualizationID} returns its page
+//   - POST /pack/async queues JobID
+//   - GET /jobs/{JobID} reports it running, then done with the result
+func Default() []Fixture {
+	var fixtures []Fixture
+	if err := json.Unmarshal(defaultFixtures, &fixtures); err != nil {
+		panic("packtest: invalid built-in fixtures: " + err.Error())
+	}
+	return fixtures
+}
@@ -0,0 +1,125 @@
+// Package packtest fakes the packing API for the tests of its clients. A
+// Server replays recorded responses, the built-in ones from Default or
+// those a Recorder captured from a live service, so a client can be tested
+// without network access or an API key.
+package packtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+)
+
+// Fixture is one recorded exchange. A JSON response body is kept as Body,
+// any other as Text.
+type Fixture struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// Request is the body sent, kept for reference; replay does not match
+	// on it.
+	Request json.RawMessage   `json:"request,omitempty"`
+	Status  int               `json:"status"`
+	Header  map[string]string `json:"header,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+	Text    string            `json:"text,omitempty"`
+}
+
+// Request is a request a Server received.
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// Server is an httptest.Server that answers with fixtures. Requests are
+// matched by method and path. The fixtures of a route are served in order
+// and the last repeats, so a job can be polled from queued to done.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	routes   map[string][]Fixture
+	served   map[string]int
+	requests []Request
+}
+
+// NewServer starts a Server replaying fixtures. Close it when done.
+func NewServer(fixtures ...Fixture) *Server {
+	s := &Server{routes: map[string][]Fixture{}, served: map[string]int{}}
+	for _, f := range fixtures {
+		key := f.Method + " " + f.Path
+		s.routes[key] = append(s.routes[key], f)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.replay))
+	return s
+}
+
+func (s *Server) replay(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	key := r.Method + " " + r.URL.Path
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Header: r.Header.Clone(), Body: body})
+	route := s.routes[key]
+	var f Fixture
+	if len(route) > 0 {
+		f = route[min(s.served[key], len(route)-1)]
+		s.served[key]++
+	}
+	s.mu.Unlock()
+
+	if len(route) == 0 {
+		http.Error(w, fmt.Sprintf("packtest: no fixture for %s", key), http.StatusNotFound)
+		return
+	}
+	f.write(w)
+}
+
+func (f Fixture) write(w http.ResponseWriter) {
+	for k, v := range f.Header {
+		w.Header().Set(k, v)
+	}
+	if len(f.Body) > 0 && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(f.Status)
+	if len(f.Body) > 0 {
+		_, _ = w.Write(f.Body)
+	} else {
+		_, _ = io.WriteString(w, f.Text)
+	}
+}
+
+// Requests returns the requests received so far, oldest first.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Load reads fixtures saved by Save.
+func Load(path string) ([]Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixtures []Fixture
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("packtest: %s: %w", path, err)
+	}
+	return fixtures, nil
+}
+
+// Save writes fixtures to path as an indented JSON array.
+func Save(path string, fixtures []Fixture) error {
+	data, err := json.MarshalIndent(fixtures, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
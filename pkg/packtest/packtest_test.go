@@ -0,0 +1,106 @@
+package packtest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServerReplaysDefault(t *testing.T) {
+	s := NewServer(Default()...)
+	defer s.Close()
+
+	resp, err := http.Post(s.URL+"/pack", "application/json", strings.NewReader(`{"items": []}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var packed struct {
+		PackID string `json:"pack_id"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&packed)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || packed.PackID != PackID {
+		t.Errorf("Expected the recorded pack, got %d %q", resp.StatusCode, packed.PackID)
+	}
+
+	// A polled job goes from running to done and stays done.
+	var statuses []string
+	for range 3 {
+		resp, err := http.Get(s.URL + "/jobs/" + JobID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var job struct {
+			Status string `json:"status"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&job)
+		resp.Body.Close()
+		statuses = append(statuses, job.Status)
+	}
+	if strings.Join(statuses, ",") != "running,done,done" {
+		t.Errorf("Expected running, done, done, got %v", statuses)
+	}
+
+	resp, err = http.Get(s.URL + "/visualize/" + VisualizationID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") || !strings.Contains(string(page), VisualizationID) {
+		t.Errorf("Expected the visualization page, got %q", page)
+	}
+
+	resp, err = http.Get(s.URL + "/jobs/other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 without a fixture, got %d", resp.StatusCode)
+	}
+	if got := s.Requests(); len(got) != 6 || string(got[0].Body) != `{"items": []}` {
+		t.Errorf("Expected the requests recorded, got %d", len(got))
+	}
+}
+
+func TestRecorderRoundTrip(t *testing.T) {
+	live := NewServer(Default()...)
+	defer live.Close()
+	rec := NewRecorder(live.URL)
+	defer rec.Close()
+
+	for _, path := range []string{"/packs/" + PackID, "/visualize/" + VisualizationID} {
+		resp, err := http.Get(rec.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	if err := Save(path, rec.Fixtures()); err != nil {
+		t.Fatal(err)
+	}
+	fixtures, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) != 2 || len(fixtures[0].Body) == 0 || fixtures[1].Text == "" {
+		t.Fatalf("Expected a JSON and an HTML fixture, got %+v", fixtures)
+	}
+
+	replay := NewServer(fixtures...)
+	defer replay.Close()
+	resp, err := http.Get(replay.URL + "/packs/" + PackID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), PackID) || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected the recorded result replayed, got %s", body)
+	}
+}
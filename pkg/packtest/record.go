@@ -0,0 +1,84 @@
+package packtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// recordedHeaders are the response headers a Recorder keeps; the others
+// change from run to run or are set by the Server.
+var recordedHeaders = []string{"Content-Type", "Location", "Retry-After", "Content-Disposition"}
+
+// Recorder is an httptest.Server that forwards requests to a live service
+// and records the exchanges as fixtures. Point a client at it once, save
+// Fixtures, and replay them with NewServer from then on.
+type Recorder struct {
+	*httptest.Server
+
+	target   string
+	client   *http.Client
+	mu       sync.Mutex
+	fixtures []Fixture
+}
+
+// NewRecorder starts a Recorder forwarding to the service at target, e.g.
+// "https://example.p.rapidapi.com". Requests keep their headers, so the
+// client's credentials reach the service; they are not recorded.
+func NewRecorder(target string) *Recorder {
+	rec := &Recorder{target: strings.TrimSuffix(target, "/"), client: &http.Client{}}
+	rec.Server = httptest.NewServer(http.HandlerFunc(rec.forward))
+	return rec
+}
+
+func (rec *Recorder) forward(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, rec.target+r.URL.RequestURI(), bytes.NewReader(body))
+	if err != nil {
+		http.Error(w, "packtest: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	req.Header = r.Header.Clone()
+	resp, err := rec.client.Do(req)
+	if err != nil {
+		http.Error(w, "packtest: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		http.Error(w, "packtest: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	f := Fixture{Method: r.Method, Path: r.URL.Path, Status: resp.StatusCode, Header: map[string]string{}}
+	if json.Valid(body) {
+		f.Request = body
+	}
+	for _, k := range recordedHeaders {
+		if v := resp.Header.Get(k); v != "" {
+			f.Header[k] = v
+		}
+	}
+	if json.Valid(respBody) {
+		f.Body = respBody
+	} else {
+		f.Text = string(respBody)
+	}
+	rec.mu.Lock()
+	rec.fixtures = append(rec.fixtures, f)
+	rec.mu.Unlock()
+
+	f.write(w)
+}
+
+// Fixtures returns the exchanges recorded so far, oldest first.
+func (rec *Recorder) Fixtures() []Fixture {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]Fixture(nil), rec.fixtures...)
+}
@@ -0,0 +1,108 @@
+package visualize
+
+import "binpacker/pkg/packing"
+
+// heatmapCells is the number of voxels along the longest side of a box in
+// the free space heatmap. Voxels are cubes, so shorter sides get fewer.
+const heatmapCells = 12
+
+// FreeVoxel is a voxel of a box with some free space in it.
+type FreeVoxel struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	Z int `json:"z"`
+	W int `json:"w"`
+	H int `json:"h"`
+	D int `json:"d"`
+	// Free is the free fraction of the voxel's volume.
+	Free float64 `json:"free"`
+	// Trapped marks free space below a mostly occupied voxel, which can no
+	// longer be filled from the top.
+	Trapped bool `json:"trapped,omitempty"`
+}
+
+// BoxHeatmap is the voxelized free space of one packed box.
+type BoxHeatmap struct {
+	Voxels         []FreeVoxel `json:"voxels"`
+	FreePercent    float64     `json:"free_percent"`
+	TrappedPercent float64     `json:"trapped_percent"`
+}
+
+// freeSpaceHeatmap voxelizes the box and measures how much of each voxel is
+// left empty by the placements.
+func freeSpaceHeatmap(pb packing.PackedBox, box packing.InputBox) BoxHeatmap {
+	longest := max(box.W, box.H, box.D)
+	if longest <= 0 {
+		return BoxHeatmap{}
+	}
+	step := max(1, (longest+heatmapCells-1)/heatmapCells)
+	nx, ny, nz := (box.W+step-1)/step, (box.H+step-1)/step, (box.D+step-1)/step
+
+	occupied := make([]int, nx*ny*nz)
+	idx := func(i, j, k int) int { return (i*ny+j)*nz + k }
+
+	for _, p := range pb.Contents {
+		for i := p.X / step; i < nx && i*step < p.X+p.W; i++ {
+			ox := overlap(p.X, p.X+p.W, i*step, min((i+1)*step, box.W))
+			for j := p.Y / step; j < ny && j*step < p.Y+p.H; j++ {
+				oy := overlap(p.Y, p.Y+p.H, j*step, min((j+1)*step, box.H))
+				for k := p.Z / step; k < nz && k*step < p.Z+p.D; k++ {
+					oz := overlap(p.Z, p.Z+p.D, k*step, min((k+1)*step, box.D))
+					occupied[idx(i, j, k)] += ox * oy * oz
+				}
+			}
+		}
+	}
+
+	var hm BoxHeatmap
+	var freeVol, trappedVol float64
+	for i := range nx {
+		for k := range nz {
+			// Walk each column top-down so trapped space is known on the way.
+			covered := false
+			for j := ny - 1; j >= 0; j-- {
+				v := FreeVoxel{X: i * step, Y: j * step, Z: k * step}
+				v.W = min(step, box.W-v.X)
+				v.H = min(step, box.H-v.Y)
+				v.D = min(step, box.D-v.Z)
+				vol := v.W * v.H * v.D
+
+				free := vol - occupied[idx(i, j, k)]
+				v.Free = float64(free) / float64(vol)
+				if v.Free < 0.5 {
+					covered = true
+				}
+				if free <= 0 {
+					continue
+				}
+				v.Trapped = covered && v.Free >= 0.5
+				freeVol += float64(free)
+				if v.Trapped {
+					trappedVol += float64(free)
+				}
+				hm.Voxels = append(hm.Voxels, v)
+			}
+		}
+	}
+
+	total := float64(box.Volume())
+	hm.FreePercent = freeVol / total * 100
+	hm.TrappedPercent = trappedVol / total * 100
+	return hm
+}
+
+func overlap(a0, a1, b0, b1 int) int {
+	return max(0, min(a1, b1)-max(a0, b0))
+}
+
+// heatmapsFor returns the heatmap of each packed box, in order.
+func heatmapsFor(packed []packing.PackedBox, boxes []packing.InputBox) []BoxHeatmap {
+	byID := packing.BoxesByID(boxes)
+	out := make([]BoxHeatmap, len(packed))
+	for i, pb := range packed {
+		if b, ok := byID[pb.BoxID]; ok {
+			out[i] = freeSpaceHeatmap(pb, b)
+		}
+	}
+	return out
+}
@@ -0,0 +1,41 @@
+package visualize
+
+import (
+	"testing"
+
+	"binpacker/pkg/packing"
+)
+
+func TestFreeSpaceHeatmapMarksTrappedPockets(t *testing.T) {
+	box := packing.InputBox{ID: "b", W: 2, H: 2, D: 2}
+	shelf := packing.PackedBox{BoxID: "b", Contents: []packing.Placement{{ItemID: "shelf", X: 0, Y: 1, Z: 0, W: 2, H: 1, D: 2}}}
+
+	hm := freeSpaceHeatmap(shelf, box)
+
+	if len(hm.Voxels) != 4 {
+		t.Fatalf("Expected 4 free voxels, got %d", len(hm.Voxels))
+	}
+	for _, v := range hm.Voxels {
+		if v.Y != 0 || !v.Trapped || v.Free != 1 {
+			t.Errorf("Expected a fully free trapped voxel on the floor, got %+v", v)
+		}
+	}
+	if hm.FreePercent != 50 || hm.TrappedPercent != 50 {
+		t.Errorf("Expected 50%% free and trapped, got %v and %v", hm.FreePercent, hm.TrappedPercent)
+	}
+}
+
+func TestFreeSpaceHeatmapPartialVoxels(t *testing.T) {
+	// 24 units long gives voxels of 2; the item fills half of the first one.
+	box := packing.InputBox{ID: "b", W: 24, H: 2, D: 2}
+	pb := packing.PackedBox{BoxID: "b", Contents: []packing.Placement{{ItemID: "a", W: 1, H: 2, D: 2}}}
+
+	hm := freeSpaceHeatmap(pb, box)
+
+	if len(hm.Voxels) != 12 {
+		t.Fatalf("Expected 12 voxels, got %d", len(hm.Voxels))
+	}
+	if v := hm.Voxels[0]; v.X != 0 || v.Free != 0.5 || v.Trapped {
+		t.Errorf("Expected the first voxel half free and open, got %+v", v)
+	}
+}
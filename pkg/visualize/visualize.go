@@ -0,0 +1,563 @@
+// Package visualize renders packing results as an interactive three.js
+// page, with a free space heatmap for each box.
+package visualize
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+
+	"binpacker/pkg/packing"
+)
+
+//go:embed assets/three.min.js
+var threeJS string
+
+//go:embed assets/OrbitControls.js
+var orbitControlsJS string
+
+// Data contains all data needed to render the 3D visualization.
+type Data struct {
+	PackedBoxes []packing.PackedBox
+	Boxes       []packing.InputBox
+	RequestID   string
+	// TableURL links to the accessible table view when the visualization
+	// is served by the API.
+	TableURL string
+	// Heatmaps is computed from PackedBoxes when left empty.
+	Heatmaps []BoxHeatmap
+	// Standalone inlines the scripts instead of loading them from a CDN,
+	// so the page works offline.
+	Standalone bool
+	// Frame marks the axes of an output frame on each box, so the
+	// visualization can be read against the returned coordinates.
+	Frame *Frame
+}
+
+// Frame tells the visualization where to draw the output axes: the origin
+// corner as 0/1 fractions of the box size and each axis direction in the
+// canonical frame.
+type Frame struct {
+	Label  string    `json:"label"`
+	Origin [3]int    `json:"origin"`
+	Axes   [3][3]int `json:"axes"`
+}
+
+// HTML creates an interactive 3D HTML visualization.
+func HTML(data Data) (string, error) {
+	if data.Heatmaps == nil {
+		data.Heatmaps = heatmapsFor(data.PackedBoxes, data.Boxes)
+	}
+
+	t, err := template.New("visualization").Funcs(template.FuncMap{
+		"jsonMarshal":     MarshalJS,
+		"threeJS":         func() template.JS { return template.JS(threeJS) },
+		"orbitControlsJS": func() template.JS { return template.JS(orbitControlsJS) },
+	}).Parse(visualizationTemplate)
+	if err != nil {
+		return "", fmt.Errorf("parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("execute template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// MarshalJS embeds a value in a template script block.
+func MarshalJS(v any) template.JS {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "[]"
+	}
+	return template.JS(b)
+}
+
+const visualizationTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>3D Packing Result - {{.RequestID}}</title>
+    <style>
+        :root {
+            --bg-primary: #0f0f1a;
+            --bg-secondary: #1a1a2e;
+            --bg-tertiary: #252542;
+            --text-primary: #e8e8f0;
+            --text-secondary: #a0a0b8;
+            --accent-primary: #6366f1;
+            --accent-secondary: #818cf8;
+            --success: #22c55e;
+            --border-color: #3a3a5c;
+        }
+        * { margin: 0; padding: 0; box-sizing: border-box; }
+        body {
+            font-family: 'Inter', 'Segoe UI', system-ui, sans-serif;
+            background: var(--bg-primary);
+            overflow: hidden;
+            color: var(--text-primary);
+        }
+        #container { width: 100vw; height: 100vh; position: relative; touch-action: none; }
+        
+        #info {
+            position: absolute;
+            top: 20px;
+            left: 20px;
+            background: var(--bg-secondary);
+            padding: 20px;
+            border-radius: 16px;
+            box-shadow: 0 10px 40px rgba(0, 0, 0, 0.4);
+            max-width: 280px;
+            z-index: 100;
+            border: 1px solid var(--border-color);
+            backdrop-filter: blur(10px);
+        }
+        #info h2 {
+            color: var(--accent-secondary);
+            margin-bottom: 16px;
+            font-size: 18px;
+            font-weight: 600;
+            display: flex;
+            align-items: center;
+            gap: 10px;
+        }
+        .stat {
+            display: flex;
+            justify-content: space-between;
+            padding: 10px 0;
+            border-bottom: 1px solid var(--border-color);
+            font-size: 13px;
+        }
+        .stat:last-child { border-bottom: none; }
+        .stat-label { color: var(--text-secondary); }
+        .stat-value { font-weight: 600; color: var(--text-primary); }
+        .stat-value.highlight { color: var(--success); }
+        
+        #controls {
+            position: absolute;
+            bottom: 20px;
+            left: 20px;
+            background: var(--bg-secondary);
+            padding: 16px;
+            border-radius: 12px;
+            z-index: 100;
+            border: 1px solid var(--border-color);
+        }
+        #controls h4 {
+            font-size: 12px;
+            color: var(--text-secondary);
+            margin-bottom: 10px;
+            text-transform: uppercase;
+            letter-spacing: 1px;
+        }
+        #controls p {
+            margin: 6px 0;
+            color: var(--text-secondary);
+            font-size: 12px;
+            display: flex;
+            align-items: center;
+            gap: 8px;
+        }
+        .kbd {
+            background: var(--bg-tertiary);
+            padding: 3px 8px;
+            border-radius: 4px;
+            font-family: monospace;
+            font-size: 11px;
+            color: var(--text-primary);
+        }
+        
+        .legend {
+            position: absolute;
+            top: 20px;
+            right: 20px;
+            background: var(--bg-secondary);
+            padding: 16px;
+            border-radius: 12px;
+            z-index: 100;
+            border: 1px solid var(--border-color);
+            max-width: 220px;
+        }
+        .legend h3 {
+            color: var(--accent-secondary);
+            margin-bottom: 12px;
+            font-size: 14px;
+        }
+        .legend-item {
+            display: flex;
+            align-items: center;
+            margin: 8px 0;
+            font-size: 12px;
+        }
+        .legend-color {
+            width: 16px;
+            height: 16px;
+            border-radius: 4px;
+            margin-right: 10px;
+            border: 1px solid rgba(255,255,255,0.1);
+        }
+        .toggle {
+            margin-top: 12px;
+            width: 100%;
+            padding: 8px;
+            background: var(--bg-tertiary);
+            color: var(--text-primary);
+            border: 1px solid var(--border-color);
+            border-radius: 8px;
+            font-size: 12px;
+            cursor: pointer;
+        }
+        .toggle[aria-pressed="true"] { border-color: var(--accent-primary); }
+        .touch-only { display: none; }
+        #panelToggle {
+            display: none;
+            position: absolute;
+            top: 12px;
+            right: 12px;
+            z-index: 200;
+            width: 44px;
+            height: 44px;
+            border-radius: 12px;
+            background: var(--bg-secondary);
+            color: var(--text-primary);
+            border: 1px solid var(--border-color);
+            font-size: 20px;
+        }
+        select.toggle { appearance: auto; }
+        @media (pointer: coarse) {
+            .mouse-only { display: none; }
+            .touch-only { display: flex; }
+        }
+        /* Phones and handhelds: smaller panels that can be hidden entirely. */
+        @media (max-width: 640px) {
+            #panelToggle { display: block; }
+            #info { top: 12px; left: 12px; padding: 12px; max-width: 180px; border-radius: 12px; }
+            #info h2 { font-size: 14px; margin-bottom: 8px; }
+            .stat { padding: 6px 0; font-size: 11px; }
+            .legend { top: auto; bottom: 12px; right: 12px; padding: 10px; max-width: 160px; }
+            .legend h3 { display: none; }
+            .legend-item { margin: 4px 0; font-size: 11px; }
+            #controls { display: none; }
+            body.panels-hidden #info, body.panels-hidden .legend { display: none; }
+        }
+    </style>
+</head>
+<body>
+    <div id="container"></div>
+    
+    <div id="info">
+        <h2>📦 Packing Results</h2>
+        <div class="stat">
+            <span class="stat-label">Boxes Used</span>
+            <span class="stat-value">{{len .PackedBoxes}}</span>
+        </div>
+        <div class="stat">
+            <span class="stat-label">Total Items</span>
+            <span class="stat-value highlight" id="totalItems">0</span>
+        </div>
+        <div class="stat">
+            <span class="stat-label">Trapped Space</span>
+            <span class="stat-value" id="trappedSpace">0%</span>
+        </div>
+        {{- if .Frame}}
+        <div class="stat">
+            <span class="stat-label">Coordinates</span>
+            <span class="stat-value" style="font-size: 11px;">{{.Frame.Label}}</span>
+        </div>
+        {{- end}}
+        <div class="stat">
+            <span class="stat-label">Request ID</span>
+            <span class="stat-value" style="font-size: 10px; word-break: break-all;">{{.RequestID}}</span>
+        </div>
+        {{- if .TableURL}}
+        <a class="toggle" href="{{.TableURL}}" style="display: block; text-align: center; text-decoration: none;">Accessible table view</a>
+        {{- end}}
+    </div>
+
+    <div class="legend">
+        <h3>🎨 Legend</h3>
+        <div class="legend-item">
+            <div class="legend-color" style="background: rgba(99, 102, 241, 0.7);"></div>
+            <span>Box Container</span>
+        </div>
+        <div class="legend-item">
+            <div class="legend-color" style="background: linear-gradient(135deg, #6366f1, #ec4899);"></div>
+            <span>Packed Items</span>
+        </div>
+        <div class="legend-item">
+            <div class="legend-color" style="background: linear-gradient(135deg, #38bdf8, #facc15);"></div>
+            <span>Free Space (open)</span>
+        </div>
+        <div class="legend-item">
+            <div class="legend-color" style="background: #ef4444;"></div>
+            <span>Trapped Pockets</span>
+        </div>
+        {{- if .Frame}}
+        <div class="legend-item">
+            <div class="legend-color" style="background: linear-gradient(90deg, #ef4444 33%, #22c55e 33% 66%, #3b82f6 66%);"></div>
+            <span>X / Y / Z Axes</span>
+        </div>
+        {{- end}}
+        <button class="toggle" id="heatmapToggle" aria-pressed="false">Show free space heatmap</button>
+        <label for="quality" style="display: block; margin-top: 10px; font-size: 12px; color: var(--text-secondary);">Quality</label>
+        <select class="toggle" id="quality">
+            <option value="low">Low (handhelds)</option>
+            <option value="medium">Medium</option>
+            <option value="high">High</option>
+        </select>
+    </div>
+
+    <div id="controls">
+        <h4>🖱️ Controls</h4>
+        <p class="mouse-only"><span class="kbd">Left Drag</span> Rotate</p>
+        <p class="mouse-only"><span class="kbd">Right Drag</span> Pan</p>
+        <p class="mouse-only"><span class="kbd">Scroll</span> Zoom</p>
+        <p class="mouse-only"><span class="kbd">H</span> Heatmap</p>
+        <p class="touch-only"><span class="kbd">1 Finger</span> Rotate</p>
+        <p class="touch-only"><span class="kbd">Pinch</span> Zoom</p>
+        <p class="touch-only"><span class="kbd">2 Fingers</span> Pan</p>
+    </div>
+
+    <button id="panelToggle" aria-label="Show or hide panels" aria-pressed="true">☰</button>
+
+    {{- if .Standalone}}
+    <script>{{threeJS}}</script>
+    <script>{{orbitControlsJS}}</script>
+    {{- else}}
+    <script src="https://cdnjs.cloudflare.com/ajax/libs/three.js/r128/three.min.js"></script>
+    <script src="https://cdn.jsdelivr.net/npm/three@0.128.0/examples/js/controls/OrbitControls.js"></script>
+    {{- end}}
+    
+    <script>
+        // Quality presets trade looks for frame rate. Touch devices and small
+        // screens start on low; ?quality= or the selector overrides it.
+        const qualityPresets = {
+            low: { antialias: false, shadows: false, pixelRatio: 1, glass: false },
+            medium: { antialias: true, shadows: false, pixelRatio: Math.min(window.devicePixelRatio, 1.5), glass: false },
+            high: { antialias: true, shadows: true, pixelRatio: Math.min(window.devicePixelRatio, 2), glass: true }
+        };
+        const handheld = window.matchMedia('(pointer: coarse)').matches || window.innerWidth <= 640;
+        let qualityName = new URLSearchParams(window.location.search).get('quality');
+        if (!qualityPresets[qualityName]) qualityName = handheld ? 'low' : 'high';
+        const quality = qualityPresets[qualityName];
+        
+        const scene = new THREE.Scene();
+        scene.background = new THREE.Color(0x0f0f1a);
+        scene.fog = new THREE.Fog(0x0f0f1a, 80, 300);
+        
+        const camera = new THREE.PerspectiveCamera(50, window.innerWidth / window.innerHeight, 0.1, 10000);
+        
+        const renderer = new THREE.WebGLRenderer({ antialias: quality.antialias });
+        renderer.setPixelRatio(quality.pixelRatio);
+        renderer.setSize(window.innerWidth, window.innerHeight);
+        renderer.shadowMap.enabled = quality.shadows;
+        renderer.shadowMap.type = THREE.PCFSoftShadowMap;
+        renderer.toneMapping = THREE.ACESFilmicToneMapping;
+        document.getElementById('container').appendChild(renderer.domElement);
+        
+        // Lighting
+        const ambientLight = new THREE.AmbientLight(0xffffff, 0.4);
+        scene.add(ambientLight);
+        
+        const mainLight = new THREE.DirectionalLight(0xffffff, 1);
+        mainLight.position.set(50, 100, 50);
+        mainLight.castShadow = quality.shadows;
+        mainLight.shadow.mapSize.width = 2048;
+        mainLight.shadow.mapSize.height = 2048;
+        scene.add(mainLight);
+        
+        const fillLight = new THREE.DirectionalLight(0x6366f1, 0.3);
+        fillLight.position.set(-50, 30, -50);
+        scene.add(fillLight);
+        
+        const controls = new THREE.OrbitControls(camera, renderer.domElement);
+        controls.enableDamping = true;
+        controls.dampingFactor = 0.05;
+        // One finger rotates; two fingers pinch to zoom and drag to pan.
+        controls.touches = { ONE: THREE.TOUCH.ROTATE, TWO: THREE.TOUCH.DOLLY_PAN };
+        
+        // Grid
+        const gridHelper = new THREE.GridHelper(200, 40, 0x2a2a4a, 0x1a1a2e);
+        scene.add(gridHelper);
+        
+        // Data
+        const packedBoxes = {{.PackedBoxes | jsonMarshal}};
+        const boxes = {{.Boxes | jsonMarshal}};
+        const heatmaps = {{.Heatmaps | jsonMarshal}};
+        const frame = {{.Frame | jsonMarshal}};
+        const heatmapGroup = new THREE.Group();
+        heatmapGroup.visible = false;
+        scene.add(heatmapGroup);
+        
+        let totalItems = 0;
+        let maxDimension = 0;
+        let trappedVolume = 0;
+        let totalVolume = 0;
+        
+        const boxMap = {};
+        boxes.forEach(box => { boxMap[box.id] = box; });
+        
+        const colorPalette = [
+            0x6366f1, 0xec4899, 0x14b8a6, 0xf59e0b, 
+            0x8b5cf6, 0x06b6d4, 0xf43f5e, 0x22c55e
+        ];
+        
+        packedBoxes.forEach((packedBox, boxIndex) => {
+            const boxDef = boxMap[packedBox.box_id];
+            if (!boxDef) return;
+            
+            maxDimension = Math.max(maxDimension, boxDef.w, boxDef.h, boxDef.d);
+            
+            const offsetX = boxIndex * (boxDef.w + 30);
+            
+            // Glass box
+            const boxGeometry = new THREE.BoxGeometry(boxDef.w, boxDef.h, boxDef.d);
+            const boxMaterial = quality.glass
+                ? new THREE.MeshPhysicalMaterial({
+                    color: 0xffffff,
+                    metalness: 0,
+                    roughness: 0,
+                    transmission: 0.9,
+                    transparent: true,
+                    opacity: 0.15,
+                    side: THREE.DoubleSide,
+                    depthWrite: false
+                })
+                : new THREE.MeshBasicMaterial({ color: 0xffffff, transparent: true, opacity: 0.08, depthWrite: false });
+            const boxMesh = new THREE.Mesh(boxGeometry, boxMaterial);
+            boxMesh.position.set(offsetX + boxDef.w / 2, boxDef.h / 2, boxDef.d / 2);
+            scene.add(boxMesh);
+            
+            // Box edges
+            const boxEdges = new THREE.EdgesGeometry(boxGeometry);
+            const boxLine = new THREE.LineSegments(
+                boxEdges,
+                new THREE.LineBasicMaterial({ color: 0x6366f1, linewidth: 2 })
+            );
+            boxLine.position.copy(boxMesh.position);
+            scene.add(boxLine);
+            
+            // Output frame axes from the origin corner, X red, Y green, Z blue.
+            if (frame) {
+                const origin = new THREE.Vector3(
+                    offsetX + frame.origin[0] * boxDef.w,
+                    frame.origin[1] * boxDef.h,
+                    frame.origin[2] * boxDef.d
+                );
+                const length = Math.min(boxDef.w, boxDef.h, boxDef.d) * 0.4;
+                [0xef4444, 0x22c55e, 0x3b82f6].forEach((color, i) => {
+                    const dir = new THREE.Vector3(...frame.axes[i]);
+                    scene.add(new THREE.ArrowHelper(dir, origin, length, color, length * 0.2, length * 0.12));
+                });
+            }
+            
+            // Items
+            packedBox.contents.forEach((item, itemIndex) => {
+                totalItems++;
+                
+                const itemGeometry = new THREE.BoxGeometry(item.w * 0.98, item.h * 0.98, item.d * 0.98);
+                const itemMaterial = new THREE.MeshStandardMaterial({
+                    color: colorPalette[itemIndex % colorPalette.length],
+                    roughness: 0.3,
+                    metalness: 0.1
+                });
+                
+                const itemMesh = new THREE.Mesh(itemGeometry, itemMaterial);
+                itemMesh.position.set(
+                    offsetX + item.x + item.w / 2,
+                    item.y + item.h / 2,
+                    item.z + item.d / 2
+                );
+                itemMesh.castShadow = quality.shadows;
+                itemMesh.receiveShadow = quality.shadows;
+                scene.add(itemMesh);
+                
+                // Item edges
+                const itemEdges = new THREE.EdgesGeometry(itemGeometry);
+                const itemLine = new THREE.LineSegments(
+                    itemEdges,
+                    new THREE.LineBasicMaterial({ color: 0x000000, opacity: 0.2, transparent: true })
+                );
+                itemLine.position.copy(itemMesh.position);
+                scene.add(itemLine);
+            });
+            
+            // Free space heatmap: open space shades from blue (mostly
+            // free) to yellow, trapped pockets are red.
+            const heatmap = heatmaps[boxIndex];
+            if (heatmap && heatmap.voxels && heatmap.voxels.length) {
+                const voxelMesh = new THREE.InstancedMesh(
+                    new THREE.BoxGeometry(1, 1, 1),
+                    new THREE.MeshBasicMaterial({ transparent: true, opacity: 0.35, depthWrite: false }),
+                    heatmap.voxels.length
+                );
+                const matrix = new THREE.Matrix4();
+                const open = new THREE.Color(0x38bdf8);
+                const partial = new THREE.Color(0xfacc15);
+                const trapped = new THREE.Color(0xef4444);
+                heatmap.voxels.forEach((v, i) => {
+                    matrix.makeScale(v.w * 0.9, v.h * 0.9, v.d * 0.9);
+                    matrix.setPosition(offsetX + v.x + v.w / 2, v.y + v.h / 2, v.z + v.d / 2);
+                    voxelMesh.setMatrixAt(i, matrix);
+                    voxelMesh.setColorAt(i, v.trapped ? trapped : partial.clone().lerp(open, v.free));
+                });
+                heatmapGroup.add(voxelMesh);
+                trappedVolume += heatmap.trapped_percent * boxDef.w * boxDef.h * boxDef.d;
+                totalVolume += boxDef.w * boxDef.h * boxDef.d;
+            }
+        });
+        
+        document.getElementById('totalItems').textContent = totalItems;
+        if (totalVolume > 0) {
+            document.getElementById('trappedSpace').textContent = (trappedVolume / totalVolume).toFixed(1) + '%';
+        }
+        
+        const heatmapToggle = document.getElementById('heatmapToggle');
+        function toggleHeatmap() {
+            heatmapGroup.visible = !heatmapGroup.visible;
+            heatmapToggle.setAttribute('aria-pressed', heatmapGroup.visible);
+            heatmapToggle.textContent = (heatmapGroup.visible ? 'Hide' : 'Show') + ' free space heatmap';
+        }
+        heatmapToggle.addEventListener('click', toggleHeatmap);
+        
+        const qualitySelect = document.getElementById('quality');
+        qualitySelect.value = qualityName;
+        qualitySelect.addEventListener('change', () => {
+            const params = new URLSearchParams(window.location.search);
+            params.set('quality', qualitySelect.value);
+            window.location.search = params.toString();
+        });
+        
+        const panelToggle = document.getElementById('panelToggle');
+        panelToggle.addEventListener('click', () => {
+            const hidden = document.body.classList.toggle('panels-hidden');
+            panelToggle.setAttribute('aria-pressed', !hidden);
+        });
+        window.addEventListener('keydown', e => {
+            if (e.key === 'h' || e.key === 'H') toggleHeatmap();
+        });
+        
+        const cameraDistance = maxDimension * 2.5;
+        camera.position.set(cameraDistance, cameraDistance * 0.8, cameraDistance);
+        camera.lookAt(maxDimension / 2, 0, maxDimension / 2);
+        
+        function animate() {
+            requestAnimationFrame(animate);
+            controls.update();
+            renderer.render(scene, camera);
+        }
+        animate();
+        
+        window.addEventListener('resize', () => {
+            camera.aspect = window.innerWidth / window.innerHeight;
+            camera.updateProjectionMatrix();
+            renderer.setSize(window.innerWidth, window.innerHeight);
+        });
+    </script>
+</body>
+</html>`
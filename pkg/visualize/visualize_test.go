@@ -0,0 +1,35 @@
+package visualize
+
+import (
+	"strings"
+	"testing"
+
+	"binpacker/pkg/packing"
+)
+
+func TestStandaloneInlinesScripts(t *testing.T) {
+	data := Data{
+		PackedBoxes: []packing.PackedBox{{BoxID: "box", Contents: []packing.Placement{{ItemID: "cube", W: 5, H: 5, D: 5}}}},
+		Boxes:       []packing.InputBox{{ID: "box", W: 10, H: 10, D: 10}},
+		RequestID:   "offline",
+		Standalone:  true,
+	}
+	html, err := HTML(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html, threeJS[len(threeJS)-200:]) || !strings.Contains(html, orbitControlsJS[len(orbitControlsJS)-200:]) {
+		t.Error("Expected three.js and OrbitControls to be inlined verbatim")
+	}
+	if strings.Contains(html, "cdnjs.cloudflare.com") {
+		t.Error("Expected no CDN scripts in standalone HTML")
+	}
+
+	data.Standalone = false
+	if html, err = HTML(data); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html, "cdnjs.cloudflare.com") || strings.Contains(html, threeJS[len(threeJS)-200:]) {
+		t.Error("Expected scripts loaded from the CDN")
+	}
+}